@@ -0,0 +1,200 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// readJSON returns arg, or the content of the file it names when it starts
+// with file:// as with the AWS CLI.
+func readJSON(arg string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(arg, "file://"); ok {
+		return os.ReadFile(path)
+	}
+	return []byte(arg), nil
+}
+
+// parseItem parses an item in the DynamoDB JSON format of the AWS CLI,
+// e.g. {"pk": {"S": "a"}, "n": {"N": "1"}}.
+func parseItem(arg string) (map[string]types.AttributeValue, error) {
+	if arg == "" {
+		return nil, nil
+	}
+	b, err := readJSON(arg)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	item := make(map[string]types.AttributeValue, len(raw))
+	for name, v := range raw {
+		av, err := parseAttributeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		item[name] = av
+	}
+	return item, nil
+}
+
+func parseAttributeValue(b json.RawMessage) (types.AttributeValue, error) {
+	var typed map[string]json.RawMessage
+	if err := json.Unmarshal(b, &typed); err != nil {
+		return nil, err
+	}
+	if len(typed) != 1 {
+		return nil, fmt.Errorf("expected a single type, got %d", len(typed))
+	}
+	var typ string
+	var v json.RawMessage
+	for typ, v = range typed {
+	}
+	switch typ {
+	case "S":
+		var s string
+		err := json.Unmarshal(v, &s)
+		return &types.AttributeValueMemberS{Value: s}, err
+	case "N":
+		var n string
+		err := json.Unmarshal(v, &n)
+		return &types.AttributeValueMemberN{Value: n}, err
+	case "B":
+		var b []byte
+		err := json.Unmarshal(v, &b)
+		return &types.AttributeValueMemberB{Value: b}, err
+	case "BOOL":
+		var t bool
+		err := json.Unmarshal(v, &t)
+		return &types.AttributeValueMemberBOOL{Value: t}, err
+	case "NULL":
+		var t bool
+		err := json.Unmarshal(v, &t)
+		return &types.AttributeValueMemberNULL{Value: t}, err
+	case "SS":
+		var ss []string
+		err := json.Unmarshal(v, &ss)
+		return &types.AttributeValueMemberSS{Value: ss}, err
+	case "NS":
+		var ns []string
+		err := json.Unmarshal(v, &ns)
+		return &types.AttributeValueMemberNS{Value: ns}, err
+	case "BS":
+		var bs [][]byte
+		err := json.Unmarshal(v, &bs)
+		return &types.AttributeValueMemberBS{Value: bs}, err
+	case "L":
+		var raw []json.RawMessage
+		if err := json.Unmarshal(v, &raw); err != nil {
+			return nil, err
+		}
+		l := make([]types.AttributeValue, len(raw))
+		for i, e := range raw {
+			av, err := parseAttributeValue(e)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = av
+		}
+		return &types.AttributeValueMemberL{Value: l}, nil
+	case "M":
+		m, err := parseItem(string(v))
+		return &types.AttributeValueMemberM{Value: m}, err
+	default:
+		return nil, fmt.Errorf("unknown type %s", typ)
+	}
+}
+
+// formatItem formats an item in the DynamoDB JSON format, attributes sorted by name.
+func formatItem(item map[string]types.AttributeValue) []byte {
+	var buf bytes.Buffer
+	writeItem(&buf, item)
+	return buf.Bytes()
+}
+
+func writeItem(buf *bytes.Buffer, item map[string]types.AttributeValue) {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJSON(buf, name)
+		buf.WriteByte(':')
+		writeAttributeValue(buf, item[name])
+	}
+	buf.WriteByte('}')
+}
+
+func writeAttributeValue(buf *bytes.Buffer, av types.AttributeValue) {
+	typed := func(typ string, v any) {
+		buf.WriteString(`{"` + typ + `":`)
+		writeJSON(buf, v)
+		buf.WriteByte('}')
+	}
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		typed("S", v.Value)
+	case *types.AttributeValueMemberN:
+		typed("N", v.Value)
+	case *types.AttributeValueMemberB:
+		typed("B", base64.StdEncoding.EncodeToString(v.Value))
+	case *types.AttributeValueMemberBOOL:
+		typed("BOOL", v.Value)
+	case *types.AttributeValueMemberNULL:
+		typed("NULL", v.Value)
+	case *types.AttributeValueMemberSS:
+		typed("SS", v.Value)
+	case *types.AttributeValueMemberNS:
+		typed("NS", v.Value)
+	case *types.AttributeValueMemberBS:
+		typed("BS", v.Value)
+	case *types.AttributeValueMemberL:
+		buf.WriteString(`{"L":[`)
+		for i, e := range v.Value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeAttributeValue(buf, e)
+		}
+		buf.WriteString(`]}`)
+	case *types.AttributeValueMemberM:
+		buf.WriteString(`{"M":`)
+		writeItem(buf, v.Value)
+		buf.WriteByte('}')
+	default:
+		buf.WriteString("null")
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, v any) {
+	b, _ := json.Marshal(v)
+	buf.Write(b)
+}
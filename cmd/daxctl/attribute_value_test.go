@@ -0,0 +1,81 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseItem(t *testing.T) {
+	in := `{"s":{"S":"a"},"n":{"N":"1.5"},"b":{"B":"AQI="},"t":{"BOOL":true},"z":{"NULL":true},` +
+		`"ss":{"SS":["a","b"]},"ns":{"NS":["1"]},"bs":{"BS":["AQ=="]},` +
+		`"l":{"L":[{"S":"x"},{"N":"2"}]},"m":{"M":{"k":{"S":"v"}}}}`
+	item, err := parseItem(in)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.AttributeValue{
+		"s":  &types.AttributeValueMemberS{Value: "a"},
+		"n":  &types.AttributeValueMemberN{Value: "1.5"},
+		"b":  &types.AttributeValueMemberB{Value: []byte{1, 2}},
+		"t":  &types.AttributeValueMemberBOOL{Value: true},
+		"z":  &types.AttributeValueMemberNULL{Value: true},
+		"ss": &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"ns": &types.AttributeValueMemberNS{Value: []string{"1"}},
+		"bs": &types.AttributeValueMemberBS{Value: [][]byte{{1}}},
+		"l":  &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "x"}, &types.AttributeValueMemberN{Value: "2"}}},
+		"m":  &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"k": &types.AttributeValueMemberS{Value: "v"}}},
+	}, item)
+
+	out, err := parseItem(string(formatItem(item)))
+	require.NoError(t, err)
+	assert.Equal(t, item, out)
+}
+
+func TestParseItem_errors(t *testing.T) {
+	for _, in := range []string{
+		`[]`,
+		`{"a":"S"}`,
+		`{"a":{"X":"1"}}`,
+		`{"a":{"S":"1","N":"1"}}`,
+		`{"a":{"N":1}}`,
+	} {
+		if _, err := parseItem(in); err == nil {
+			t.Errorf("expected an error for %s", in)
+		}
+	}
+}
+
+func TestParseItem_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"pk":{"S":"a"}}`), 0o600))
+	item, err := parseItem("file://" + path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}}, item)
+}
+
+func TestFormatItem(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"b":  &types.AttributeValueMemberN{Value: "1"},
+		"a":  &types.AttributeValueMemberS{Value: "x\"y"},
+		"bs": &types.AttributeValueMemberB{Value: []byte{1, 2}},
+	}
+	assert.Equal(t, `{"a":{"S":"x\"y"},"b":{"N":"1"},"bs":{"B":"AQI="}}`, string(formatItem(item)))
+}
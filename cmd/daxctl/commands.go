@@ -0,0 +1,279 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func runCheck(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	if err := flag.NewFlagSet("check", flag.ContinueOnError).Parse(args); err != nil {
+		return err
+	}
+	res, err := client.HealthCheck(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "discovery fresh: %t, last refresh: %s\n", res.DiscoveryFresh, res.LastRefresh.Format(time.RFC3339))
+	if res.RefreshError != nil {
+		fmt.Fprintf(out, "refresh error: %v\n", res.RefreshError)
+	}
+	for _, n := range res.Nodes {
+		status := "ok"
+		if !n.Healthy {
+			status = fmt.Sprintf("failed: %v", n.Err)
+		}
+		fmt.Fprintf(out, "%s (%s): %s in %s\n", n.Address, n.Hostname, status, n.Latency)
+	}
+	if !res.Healthy {
+		fmt.Fprintln(out, "unhealthy")
+		return errUnhealthy
+	}
+	fmt.Fprintln(out, "healthy")
+	return nil
+}
+
+// nodeView is the JSON form of a node in the topology output.
+type nodeView struct {
+	NodeID             int64  `json:"nodeId"`
+	Address            string `json:"address"`
+	Hostname           string `json:"hostname"`
+	Role               string `json:"role"`
+	AvailabilityZone   string `json:"availabilityZone,omitempty"`
+	Healthy            bool   `json:"healthy"`
+	IdleConnections    int    `json:"idleConnections"`
+	PendingConnections int    `json:"pendingConnections"`
+	InFlightRequests   int    `json:"inFlightRequests"`
+}
+
+func runTopology(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	if err := flag.NewFlagSet("topology", flag.ContinueOnError).Parse(args); err != nil {
+		return err
+	}
+	state, err := client.ClusterState(ctx)
+	if err != nil {
+		return err
+	}
+	view := struct {
+		LastRefresh  time.Time  `json:"lastRefresh"`
+		RefreshError string     `json:"refreshError,omitempty"`
+		Nodes        []nodeView `json:"nodes"`
+	}{LastRefresh: state.LastRefresh, Nodes: []nodeView{}}
+	if state.RefreshError != nil {
+		view.RefreshError = state.RefreshError.Error()
+	}
+	for _, n := range state.Nodes {
+		view.Nodes = append(view.Nodes, nodeView{
+			NodeID:             n.NodeID,
+			Address:            n.Address,
+			Hostname:           n.Hostname,
+			Role:               string(n.Role),
+			AvailabilityZone:   n.AvailabilityZone,
+			Healthy:            n.Healthy,
+			IdleConnections:    n.IdleConnections,
+			PendingConnections: n.PendingConnections,
+			InFlightRequests:   n.InFlightRequests,
+		})
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(view)
+}
+
+func runGet(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	table := flags.String("table", "", "table name")
+	key := flags.String("key", "", "key of the item")
+	consistent := flags.Bool("consistent", false, "strongly consistent read")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	input, err := getItemInput(*table, *key, *consistent)
+	if err != nil {
+		return err
+	}
+	res, err := client.GetItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	if res.Item == nil {
+		return errors.New("item not found")
+	}
+	return printItems(out, res.Item)
+}
+
+func getItemInput(table, key string, consistent bool) (*dynamodb.GetItemInput, error) {
+	if table == "" || key == "" {
+		return nil, errors.New("-table and -key are required")
+	}
+	k, err := parseItem(key)
+	if err != nil {
+		return nil, fmt.Errorf("-key: %w", err)
+	}
+	return &dynamodb.GetItemInput{TableName: aws.String(table), Key: k, ConsistentRead: aws.Bool(consistent)}, nil
+}
+
+func runQuery(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	table := flags.String("table", "", "table name")
+	index := flags.String("index", "", "index name")
+	condition := flags.String("condition", "", "key condition expression")
+	filter := flags.String("filter", "", "filter expression")
+	names := flags.String("names", "", `expression attribute names, e.g. {"#n":"name"}`)
+	values := flags.String("values", "", "expression attribute values")
+	limit := flags.Int("limit", 0, "maximum number of items evaluated")
+	consistent := flags.Bool("consistent", false, "strongly consistent read")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" || *condition == "" {
+		return errors.New("-table and -condition are required")
+	}
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(*table),
+		KeyConditionExpression: aws.String(*condition),
+		ConsistentRead:         aws.Bool(*consistent),
+	}
+	if *index != "" {
+		input.IndexName = index
+	}
+	if *filter != "" {
+		input.FilterExpression = filter
+	}
+	if *limit > 0 {
+		input.Limit = aws.Int32(int32(*limit))
+	}
+	if *names != "" {
+		b, err := readJSON(*names)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &input.ExpressionAttributeNames); err != nil {
+			return fmt.Errorf("-names: %w", err)
+		}
+	}
+	var err error
+	if input.ExpressionAttributeValues, err = parseItem(*values); err != nil {
+		return fmt.Errorf("-values: %w", err)
+	}
+	res, err := client.Query(ctx, input)
+	if err != nil {
+		return err
+	}
+	if err := printItems(out, res.Items...); err != nil {
+		return err
+	}
+	if res.LastEvaluatedKey != nil {
+		fmt.Fprintf(out, "last evaluated key: %s\n", formatItem(res.LastEvaluatedKey))
+	}
+	return nil
+}
+
+func runPut(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("put", flag.ContinueOnError)
+	table := flags.String("table", "", "table name")
+	item := flags.String("item", "", "item to write")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *table == "" || *item == "" {
+		return errors.New("-table and -item are required")
+	}
+	it, err := parseItem(*item)
+	if err != nil {
+		return fmt.Errorf("-item: %w", err)
+	}
+	if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(*table), Item: it}); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "ok")
+	return nil
+}
+
+func runProbe(ctx context.Context, client daxClient, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("probe", flag.ContinueOnError)
+	table := flags.String("table", "", "table name")
+	key := flags.String("key", "", "key of the item to read")
+	consistent := flags.Bool("consistent", false, "strongly consistent reads")
+	count := flags.Int("n", 100, "number of requests")
+	interval := flags.Duration("interval", 0, "pause between requests")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	input, err := getItemInput(*table, *key, *consistent)
+	if err != nil {
+		return err
+	}
+	if *count <= 0 {
+		return errors.New("-n must be positive")
+	}
+
+	latencies := make([]time.Duration, 0, *count)
+	failures := 0
+	var lastErr error
+	for i := 0; i < *count && ctx.Err() == nil; i++ {
+		if i > 0 && *interval > 0 {
+			time.Sleep(*interval)
+		}
+		start := time.Now()
+		if _, err := client.GetItem(ctx, input); err != nil {
+			failures++
+			lastErr = err
+			continue
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	fmt.Fprintf(out, "requests: %d, failures: %d\n", len(latencies)+failures, failures)
+	if lastErr != nil {
+		fmt.Fprintf(out, "last error: %v\n", lastErr)
+	}
+	if len(latencies) == 0 {
+		return errUnhealthy
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	fmt.Fprintf(out, "min: %s, p50: %s, p90: %s, p99: %s, max: %s\n",
+		latencies[0], percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), latencies[len(latencies)-1])
+	return nil
+}
+
+// percentile returns the p-th percentile of the sorted latencies, nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func printItems(out io.Writer, items ...map[string]types.AttributeValue) error {
+	for _, item := range items {
+		if _, err := fmt.Fprintf(out, "%s\n", formatItem(item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	daxtypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	health  daxtypes.HealthCheckResult
+	state   daxtypes.ClusterState
+	item    map[string]types.AttributeValue
+	getErr  error
+	gets    []*dynamodb.GetItemInput
+	queries []*dynamodb.QueryInput
+	puts    []*dynamodb.PutItemInput
+}
+
+func (c *testClient) HealthCheck(context.Context) (daxtypes.HealthCheckResult, error) {
+	return c.health, nil
+}
+
+func (c *testClient) ClusterState(context.Context) (daxtypes.ClusterState, error) {
+	return c.state, nil
+}
+
+func (c *testClient) GetItem(_ context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.gets = append(c.gets, input)
+	if c.getErr != nil {
+		return nil, c.getErr
+	}
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
+
+func (c *testClient) Query(_ context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, input)
+	return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{c.item}}, nil
+}
+
+func (c *testClient) PutItem(_ context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.puts = append(c.puts, input)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestRunCheck(t *testing.T) {
+	client := &testClient{health: daxtypes.HealthCheckResult{
+		DiscoveryFresh: true,
+		Nodes: []daxtypes.NodeHealth{
+			{Address: "10.0.0.1:8111", Hostname: "node-1", Healthy: true, Latency: time.Millisecond},
+			{Address: "10.0.0.2:8111", Hostname: "node-2", Err: errors.New("refused")},
+		},
+	}}
+	var out bytes.Buffer
+	assert.ErrorIs(t, runCheck(context.Background(), client, nil, &out), errUnhealthy)
+	assert.Contains(t, out.String(), "10.0.0.2:8111 (node-2): failed: refused")
+
+	client.health.Healthy = true
+	out.Reset()
+	assert.NoError(t, runCheck(context.Background(), client, nil, &out))
+	assert.True(t, strings.HasSuffix(out.String(), "healthy\n"))
+}
+
+func TestRunTopology(t *testing.T) {
+	client := &testClient{state: daxtypes.ClusterState{
+		RefreshError: errors.New("refresh failed"),
+		Nodes:        []daxtypes.NodeState{{NodeID: 1, Address: "10.0.0.1:8111", Role: daxtypes.NodeRoleLeader, Healthy: true}},
+	}}
+	var out bytes.Buffer
+	require.NoError(t, runTopology(context.Background(), client, nil, &out))
+	assert.Contains(t, out.String(), `"refreshError": "refresh failed"`)
+	assert.Contains(t, out.String(), `"role": "leader"`)
+}
+
+func TestRunGetAndPut(t *testing.T) {
+	client := &testClient{item: map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}}}
+	var out bytes.Buffer
+	require.NoError(t, runGet(context.Background(), client, []string{"-table", "t", "-key", `{"pk":{"S":"a"}}`, "-consistent"}, &out))
+	assert.Equal(t, "{\"pk\":{\"S\":\"a\"}}\n", out.String())
+	require.Len(t, client.gets, 1)
+	assert.Equal(t, "t", aws.ToString(client.gets[0].TableName))
+	assert.True(t, aws.ToBool(client.gets[0].ConsistentRead))
+
+	require.NoError(t, runPut(context.Background(), client, []string{"-table", "t", "-item", `{"pk":{"S":"b"}}`}, &out))
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "b"}, client.puts[0].Item["pk"])
+
+	assert.Error(t, runGet(context.Background(), client, []string{"-table", "t"}, &out))
+}
+
+func TestRunQuery(t *testing.T) {
+	client := &testClient{item: map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}}}
+	var out bytes.Buffer
+	args := []string{"-table", "t", "-condition", "#k = :v", "-names", `{"#k":"pk"}`, "-values", `{":v":{"S":"a"}}`, "-limit", "5"}
+	require.NoError(t, runQuery(context.Background(), client, args, &out))
+	require.Len(t, client.queries, 1)
+	q := client.queries[0]
+	assert.Equal(t, map[string]string{"#k": "pk"}, q.ExpressionAttributeNames)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "a"}, q.ExpressionAttributeValues[":v"])
+	assert.Equal(t, int32(5), aws.ToInt32(q.Limit))
+	assert.Nil(t, q.IndexName)
+}
+
+func TestRunProbe(t *testing.T) {
+	client := &testClient{}
+	var out bytes.Buffer
+	require.NoError(t, runProbe(context.Background(), client, []string{"-table", "t", "-key", `{"pk":{"S":"a"}}`, "-n", "10"}, &out))
+	assert.Len(t, client.gets, 10)
+	assert.Contains(t, out.String(), "requests: 10, failures: 0")
+	assert.Contains(t, out.String(), "p99:")
+
+	client.getErr = errors.New("boom")
+	out.Reset()
+	assert.ErrorIs(t, runProbe(context.Background(), client, []string{"-table", "t", "-key", `{"pk":{"S":"a"}}`, "-n", "3"}, &out), errUnhealthy)
+	assert.Contains(t, out.String(), "failures: 3")
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+	assert.Equal(t, time.Duration(50), percentile(sorted, 50))
+	assert.Equal(t, time.Duration(99), percentile(sorted, 99))
+	assert.Equal(t, time.Duration(1), percentile(sorted[:1], 99))
+}
+
+func TestRun_usage(t *testing.T) {
+	t.Setenv("DAX_ENDPOINT", "")
+	var out, errOut bytes.Buffer
+	assert.Equal(t, 2, run([]string{"-endpoint", "dax://localhost:8111", "unknown"}, nil, &out, &errOut))
+	assert.Contains(t, errOut.String(), "commands:")
+	errOut.Reset()
+	assert.Equal(t, 2, run([]string{"check"}, nil, &out, &errOut))
+}
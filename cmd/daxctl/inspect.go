@@ -0,0 +1,109 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-dax-go-v2/dax/wire"
+)
+
+func runInspect(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	response := flags.Bool("response", false, "the frame is a response, not a request")
+	redact := flags.Bool("redact", false, "replace strings and byte strings with their length")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("expected at most one file, reads stdin by default")
+	}
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	input, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	frame := parseFrame(input)
+
+	var res any
+	if *response {
+		res, err = wire.DecodeResponse(frame, *redact)
+	} else {
+		res, err = wire.DecodeRequest(frame, *redact)
+	}
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(res)
+}
+
+// parseFrame accepts a frame as raw bytes, as hex digits or as the hex dump
+// of an unredacted wire dump, e.g.
+//
+//	00000000  01 1a 0f b0 cc 6a  |.....j|
+func parseFrame(input []byte) []byte {
+	text := strings.TrimSpace(string(input))
+	if b, ok := parseHexDump(text); ok {
+		return b
+	}
+	if b, err := hex.DecodeString(strings.Join(strings.Fields(text), "")); err == nil {
+		return b
+	}
+	return input
+}
+
+func parseHexDump(text string) ([]byte, bool) {
+	var buf bytes.Buffer
+	lines := strings.Split(text, "\n")
+	for _, line := range lines {
+		// the offset, then up to 16 bytes in two groups, then the characters
+		if i := strings.IndexByte(line, '|'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields[0]) != 8 || len(fields) > 17 {
+			return nil, false
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil {
+			return nil, false
+		}
+		for _, f := range fields[1:] {
+			b, err := hex.DecodeString(f)
+			if err != nil || len(b) != 1 {
+				return nil, false
+			}
+			buf.Write(b)
+		}
+	}
+	return buf.Bytes(), true
+}
@@ -0,0 +1,52 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrame(t *testing.T) {
+	frame := []byte{0x01, 0x1a, 0x0f, 0xb0, 0xcc, 0x6a, 0x61, 't'}
+	assert.Equal(t, frame, parseFrame(frame))
+	assert.Equal(t, frame, parseFrame([]byte("011a0fb0 cc6a6174\n")))
+	assert.Equal(t, frame, parseFrame([]byte(hex.Dump(frame))))
+	long := bytes.Repeat(frame, 5)
+	assert.Equal(t, long, parseFrame([]byte(hex.Dump(long))))
+}
+
+func TestRun_inspect(t *testing.T) {
+	t.Setenv("DAX_ENDPOINT", "")
+	var out, errOut bytes.Buffer
+	in := strings.NewReader(hex.Dump([]byte{0x01, 0x1a, 0x0f, 0xb0, 0xcc, 0x6a, 0x61, 't'}))
+	require.Equal(t, 0, run([]string{"inspect", "-redact"}, in, &out, &errOut), errOut.String())
+	assert.JSONEq(t, `{"service":1,"methodId":263244906,"method":"GetItem","arguments":["<redacted 1 bytes>"]}`, out.String())
+
+	out.Reset()
+	in = strings.NewReader("8101")
+	assert.Equal(t, 0, run([]string{"inspect", "-response"}, in, &out, &errOut))
+	assert.JSONEq(t, `{"error":{"codes":[1],"message":""}}`, out.String())
+
+	in = strings.NewReader("01")
+	assert.Equal(t, 1, run([]string{"inspect"}, in, &out, &errOut))
+	assert.Contains(t, errOut.String(), "daxctl inspect:")
+}
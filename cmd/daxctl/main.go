@@ -0,0 +1,158 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+// Command daxctl runs operational diagnostics against a DAX cluster through
+// the same client code paths applications use.
+//
+// Usage:
+//
+//	daxctl -endpoint dax://mycluster.frfx8h.clustercfg.dax.usw2.amazonaws.com [-region us-west-2] <command> [flags]
+//
+// The commands are:
+//
+//	check     check connectivity and credentials for each node, exits with 1 when unhealthy
+//	topology  print the cluster nodes known to the client as JSON
+//	get       run a GetItem, e.g. get -table t -key '{"pk":{"S":"a"}}'
+//	query     run a Query, e.g. query -table t -condition 'pk = :pk' -values '{":pk":{"S":"a"}}'
+//	put       run a PutItem, e.g. put -table t -item file://item.json
+//	probe     run GetItem repeatedly and print latency percentiles
+//	inspect   decode a captured request or response frame to JSON, needs no endpoint
+//
+// Items, keys and values are in the DynamoDB JSON format of the AWS CLI and
+// may be read from a file with the file:// prefix. Credentials are loaded
+// the same way as the AWS CLI does.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	daxtypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// errUnhealthy makes daxctl exit with 1 without printing an error, the
+// command already reported why.
+var errUnhealthy = errors.New("unhealthy")
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, client daxClient, args []string, out io.Writer) error
+	// offline commands run without a client, e.g. to inspect captured frames.
+	offline func(args []string, in io.Reader, out io.Writer) error
+}
+
+// daxClient is the part of *dax.Dax the commands use.
+type daxClient interface {
+	HealthCheck(ctx context.Context) (daxtypes.HealthCheckResult, error)
+	ClusterState(ctx context.Context) (daxtypes.ClusterState, error)
+	GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+var commands = []command{
+	{"check", "check connectivity and credentials for each node", runCheck, nil},
+	{"topology", "print the cluster nodes known to the client as JSON", runTopology, nil},
+	{"get", "run a GetItem", runGet, nil},
+	{"query", "run a Query", runQuery, nil},
+	{"put", "run a PutItem", runPut, nil},
+	{"probe", "run GetItem repeatedly and print latency percentiles", runProbe, nil},
+	{"inspect", "decode a captured request or response frame to JSON", nil, runInspect},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, in io.Reader, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("daxctl", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	endpoint := flags.String("endpoint", os.Getenv("DAX_ENDPOINT"), "cluster endpoint, defaults to $DAX_ENDPOINT")
+	region := flags.String("region", "", "region of the cluster, defaults to the AWS configuration")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the command")
+	flags.Usage = func() {
+		fmt.Fprintln(errOut, "usage: daxctl -endpoint <endpoint> [-region <region>] <command> [flags]")
+		fmt.Fprintln(errOut, "\ncommands:")
+		for _, c := range commands {
+			fmt.Fprintf(errOut, "  %-9s %s\n", c.name, c.usage)
+		}
+		fmt.Fprintln(errOut, "\nflags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	cmd := findCommand(flags.Arg(0))
+	if cmd != nil && cmd.offline != nil {
+		return exitCode(cmd, cmd.offline(flags.Args()[1:], in, out), errOut)
+	}
+	if cmd == nil || *endpoint == "" {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	client, err := newClient(ctx, *endpoint, *region)
+	if err != nil {
+		fmt.Fprintf(errOut, "daxctl: %v\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	return exitCode(cmd, cmd.run(ctx, client, flags.Args()[1:], out), errOut)
+}
+
+func exitCode(cmd *command, err error, errOut io.Writer) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case !errors.Is(err, errUnhealthy):
+		fmt.Fprintf(errOut, "daxctl %s: %v\n", cmd.name, err)
+	}
+	return 1
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func newClient(ctx context.Context, endpoint, region string) (*dax.Dax, error) {
+	var optFns []func(*config.LoadOptions) error
+	if region != "" {
+		optFns = append(optFns, config.WithRegion(region))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	return dax.NewFromConfig(awsCfg, endpoint)
+}
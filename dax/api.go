@@ -21,10 +21,13 @@ import (
 	"io"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/types"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-// DynamoDBAPI is compatible to aws-sdk-go-v2/service/dynamodb.Client
+// DynamoDBAPI is compatible to aws-sdk-go-v2/service/dynamodb.Client, which
+// implements it as well as Dax, so code written against it runs with either.
 type DynamoDBAPI interface {
 	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
@@ -86,7 +89,50 @@ type DynamoDBAPI interface {
 	UpdateKinesisStreamingDestination(ctx context.Context, params *dynamodb.UpdateKinesisStreamingDestinationInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateKinesisStreamingDestinationOutput, error)
 }
 
+// DaxAPI covers every method of Dax, the DynamoDB operations as well as the
+// DAX specific ones, so applications using the latter can mock the client,
+// e.g. with mockgen, without maintaining an interface of their own.
+type DaxAPI interface {
+	DynamoDBAPI
+	io.Closer
+
+	QueryEach(ctx context.Context, input *dynamodb.QueryInput, fn func(item map[string]ddbtypes.AttributeValue) error, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	ScanEach(ctx context.Context, input *dynamodb.ScanInput, fn func(item map[string]ddbtypes.AttributeValue) error, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	ScanAllSegments(ctx context.Context, input *dynamodb.ScanInput, parallelism int, fn func(segment int32, page *dynamodb.ScanOutput) error, optFns ...func(*dynamodb.Options)) error
+
+	ExtractKey(ctx context.Context, table string, item map[string]ddbtypes.AttributeValue) (types.ItemKey, error)
+	PrewarmTables(ctx context.Context, tables ...string) error
+	InvalidateCaches(scope types.CacheScope) error
+
+	ClusterState(ctx context.Context) (types.ClusterState, error)
+	DiscoveryStatus(ctx context.Context) (types.DiscoveryStatus, error)
+	HealthCheck(ctx context.Context) (types.HealthCheckResult, error)
+	RefreshCluster(ctx context.Context) error
+	UpdateTopology(nodes []types.Node) error
+	FlushTelemetry(ctx context.Context) error
+
+	SupportedOperations() map[string]types.SupportLevel
+	OperationSupport(op string) types.SupportLevel
+}
+
+var (
+	_ DaxAPI      = (*Dax)(nil)
+	_ DynamoDBAPI = (*dynamodb.Client)(nil)
+)
+
 func (d *Dax) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("PutItem", input); err != nil {
+		return nil, err
+	}
+	if err := d.checkParameters("PutItem", input); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("PutItem", input); err != nil {
+		return nil, err
+	}
 	o, cfn, err := d.config.requestOptions(false, ctx, optFns...)
 	if err != nil {
 		return nil, err
@@ -98,6 +144,18 @@ func (d *Dax) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns
 }
 
 func (d *Dax) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("DeleteItem", input); err != nil {
+		return nil, err
+	}
+	if err := d.checkParameters("DeleteItem", input); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("DeleteItem", input); err != nil {
+		return nil, err
+	}
 	o, cfn, err := d.config.requestOptions(false, ctx, optFns...)
 	if err != nil {
 		return nil, err
@@ -109,6 +167,18 @@ func (d *Dax) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, o
 }
 
 func (d *Dax) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("UpdateItem", input); err != nil {
+		return nil, err
+	}
+	if err := d.checkParameters("UpdateItem", input); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("UpdateItem", input); err != nil {
+		return nil, err
+	}
 	o, cfn, err := d.config.requestOptions(false, ctx, optFns...)
 	if err != nil {
 		return nil, err
@@ -120,39 +190,77 @@ func (d *Dax) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, o
 }
 
 func (d *Dax) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
-	o, cfn, err := d.config.requestOptions(true, ctx, optFns...)
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("GetItem", input); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("GetItem", input); err != nil {
+		return nil, err
+	}
+	o, cfn, err := d.config.readOptions(consistentRead(getItemConsistentRead(input)), ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
 	if cfn != nil {
 		defer cfn()
 	}
-	return d.client.GetItemWithOptions(ctx, input, &dynamodb.GetItemOutput{}, o)
+	return d.client.GetItemWithOptions(ctx, getItemWithConsistentRead(input, &o.Options), &dynamodb.GetItemOutput{}, o)
 }
 
 func (d *Dax) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
-	o, cfn, err := d.config.requestOptions(true, ctx, optFns...)
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("Scan", input); err != nil {
+		return nil, err
+	}
+	if err := d.checkParameters("Scan", input); err != nil {
+		return nil, err
+	}
+	o, cfn, err := d.config.readOptions(consistentRead(scanConsistentRead(input)), ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
 	if cfn != nil {
 		defer cfn()
 	}
-	return d.client.ScanWithOptions(ctx, input, &dynamodb.ScanOutput{}, o)
+	done, err := d.beginScan(o.Context, input, &o.Options)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return d.client.ScanWithOptions(ctx, scanWithConsistentRead(input, &o.Options), &dynamodb.ScanOutput{}, o)
 }
 
 func (d *Dax) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
-	o, cfn, err := d.config.requestOptions(true, ctx, optFns...)
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("Query", input); err != nil {
+		return nil, err
+	}
+	if err := d.checkParameters("Query", input); err != nil {
+		return nil, err
+	}
+	o, cfn, err := d.config.readOptions(consistentRead(queryConsistentRead(input)), ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
 	if cfn != nil {
 		defer cfn()
 	}
-	return d.client.QueryWithOptions(ctx, input, &dynamodb.QueryOutput{}, o)
+	return d.client.QueryWithOptions(ctx, queryWithConsistentRead(input, &o.Options), &dynamodb.QueryOutput{}, o)
 }
 
 func (d *Dax) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("BatchWriteItem", input); err != nil {
+		return nil, err
+	}
 	o, cfn, err := d.config.requestOptions(false, ctx, optFns...)
 	if err != nil {
 		return nil, err
@@ -160,21 +268,63 @@ func (d *Dax) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItem
 	if cfn != nil {
 		defer cfn()
 	}
+	if input != nil && batchWriteItemRequestCount(input) > maxBatchWriteItemRequests {
+		return d.batchWriteItemChunks(o.Context, splitBatchWriteItemInput(input, maxBatchWriteItemRequests), o)
+	}
 	return d.client.BatchWriteItemWithOptions(ctx, input, &dynamodb.BatchWriteItemOutput{}, o)
 }
 
 func (d *Dax) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
-	o, cfn, err := d.config.requestOptions(true, ctx, optFns...)
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := d.checkLegacyParameters("BatchGetItem", input); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("BatchGetItem", input); err != nil {
+		return nil, err
+	}
+	o, cfn, err := d.config.readOptions(consistentRead(batchGetItemConsistentRead(input)), ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
 	if cfn != nil {
 		defer cfn()
 	}
-	return d.client.BatchGetItemWithOptions(ctx, input, &dynamodb.BatchGetItemOutput{}, o)
+	input = batchGetItemWithConsistentRead(input, &o.Options)
+	if input == nil {
+		return d.client.BatchGetItemWithOptions(ctx, input, &dynamodb.BatchGetItemOutput{}, o)
+	}
+	consistent, eventual := splitBatchGetItemConsistency(input)
+	if consistent != nil && eventual != nil {
+		chunks := append(splitBatchGetItemInput(consistent, maxBatchGetItemKeys), splitBatchGetItemInput(eventual, maxBatchGetItemKeys)...)
+		return d.batchGetItemChunks(o.Context, chunks, o)
+	}
+	if batchGetItemKeyCount(input) > maxBatchGetItemKeys {
+		return d.batchGetItemChunks(o.Context, splitBatchGetItemInput(input, maxBatchGetItemKeys), o)
+	}
+	return d.client.BatchGetItemWithOptions(ctx, input, &dynamodb.BatchGetItemOutput{}, d.config.batchGetItemOptions(input, o))
+}
+
+// batchGetItemOptions returns the options of a request reading the tables of
+// input. With the replica ReadPreference, strongly consistent reads are sent
+// to the leader, so that eventually consistent ones are left to the replicas.
+func (c *Config) batchGetItemOptions(input *dynamodb.BatchGetItemInput, o client.RequestOptions) client.RequestOptions {
+	consistent := batchGetItemConsistentRead(input) != nil
+	o.Passthrough = consistent
+	if consistent && c.ReadPreference.IsReplica() {
+		o.ReadPreference = types.ReadPreferenceLeader
+	}
+	return o
 }
 
 func (d *Dax) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("TransactWriteItems", input); err != nil {
+		return nil, err
+	}
 	o, cfn, err := d.config.requestOptions(false, ctx, optFns...)
 	if err != nil {
 		return nil, err
@@ -186,7 +336,13 @@ func (d *Dax) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWr
 }
 
 func (d *Dax) TransactGetItems(ctx context.Context, input *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
-	o, cfn, err := d.config.requestOptions(true, ctx, optFns...)
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	if err := checkRequestLimits("TransactGetItems", input); err != nil {
+		return nil, err
+	}
+	o, cfn, err := d.config.readOptions(alwaysPassthrough, ctx, optFns...)
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +544,146 @@ func (d *Dax) unImpl() error {
 	return errors.New(client.ErrCodeNotImplemented)
 }
 
+// InvalidateCaches drops the client-local cache entries selected by scope,
+// e.g. after an emergency schema or data fix:
+//
+//	err := svc.InvalidateCaches(types.TableCaches("mytable"))
+func (d *Dax) InvalidateCaches(scope types.CacheScope) error {
+	if !d.initialized() {
+		return nil
+	}
+	if c, ok := d.client.(client.CacheInvalidator); ok {
+		return c.InvalidateCaches(scope)
+	}
+	return nil
+}
+
+// PrewarmTables fetches the key schemas of tables from every node of the
+// cluster up front, so the first request for each table does not pay an
+// extra round trip, e.g. during a Lambda cold start. Attribute lists are
+// defined per set of attribute names written, and are still fetched on
+// first use. The client is initialized if it has not been yet.
+//
+//	err := svc.PrewarmTables(ctx, "orders", "customers")
+func (d *Dax) PrewarmTables(ctx context.Context, tables ...string) error {
+	if err := d.init(ctx); err != nil {
+		return err
+	}
+	if c, ok := d.client.(client.TablePrewarmer); ok {
+		return c.PrewarmTables(ctx, tables...)
+	}
+	return errors.New("prewarming tables is not supported by the client")
+}
+
+// ExtractKey returns the key of item in table the way the client encodes it
+// for DAX, so applications building cache keys, shard routers or dedup
+// layers use the same canonical form. The key schema of the table is cached
+// and fetched from the cluster on first use. Items lacking a key attribute,
+// or holding one of the wrong type, are rejected.
+//
+//	key, err := svc.ExtractKey(ctx, "mytable", item)
+//	seen[string(key.Encoded)] = true
+func (d *Dax) ExtractKey(ctx context.Context, table string, item map[string]ddbtypes.AttributeValue) (types.ItemKey, error) {
+	if err := d.init(ctx); err != nil {
+		return types.ItemKey{}, err
+	}
+	if c, ok := d.client.(client.KeyExtractor); ok {
+		return c.ExtractKey(ctx, table, item)
+	}
+	return types.ItemKey{}, errors.New("key extraction is not supported by the client")
+}
+
+// HealthCheck verifies that cluster discovery is fresh and that the client
+// can connect and authenticate to the cluster nodes, for example to back a
+// readiness probe. The returned error is only set when the check could not
+// be performed, the outcome is reported by the result.
+func (d *Dax) HealthCheck(ctx context.Context) (types.HealthCheckResult, error) {
+	if err := d.init(ctx); err != nil {
+		return types.HealthCheckResult{}, err
+	}
+	if c, ok := d.client.(client.HealthChecker); ok {
+		return c.HealthCheck(ctx)
+	}
+	return types.HealthCheckResult{}, errors.New("health check is not supported by the client")
+}
+
+// RefreshCluster re-discovers the cluster nodes immediately, e.g. after a
+// known scaling event, instead of waiting for the periodic refresh to notice
+// new or removed nodes.
+func (d *Dax) RefreshCluster(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := d.init(ctx); err != nil {
+		return err
+	}
+	if c, ok := d.client.(client.ClusterRefresher); ok {
+		return c.RefreshCluster(ctx)
+	}
+	return errors.New("cluster refresh is not supported by the client")
+}
+
+// UpdateTopology replaces the nodes of a client created with NewFromTopology.
+// Connections to nodes which are kept are reused.
+func (d *Dax) UpdateTopology(nodes []types.Node) error {
+	if err := d.init(context.Background()); err != nil {
+		return err
+	}
+	if c, ok := d.client.(client.TopologyUpdater); ok {
+		return c.UpdateTopology(nodes)
+	}
+	return errors.New("topology updates are not supported by the client")
+}
+
+// ClusterState describes the cluster nodes known to the client, their roles
+// and availability zones, whether requests are routed to them, and the state
+// of the client's connection pools. Unlike HealthCheck it does not contact
+// the nodes.
+func (d *Dax) ClusterState(ctx context.Context) (types.ClusterState, error) {
+	if err := d.init(ctx); err != nil {
+		return types.ClusterState{}, err
+	}
+	if c, ok := d.client.(client.ClusterStateReporter); ok {
+		return c.ClusterState(), nil
+	}
+	return types.ClusterState{}, errors.New("cluster state is not supported by the client")
+}
+
+// DiscoveryStatus reports when the client last refreshed the cluster
+// membership, successfully or not, the error of the last refresh, and the
+// seed and node the membership was discovered from, which tells a stale
+// view of the cluster apart from nodes which are down.
+func (d *Dax) DiscoveryStatus(ctx context.Context) (types.DiscoveryStatus, error) {
+	if err := d.init(ctx); err != nil {
+		return types.DiscoveryStatus{}, err
+	}
+	if c, ok := d.client.(client.DiscoveryStatusReporter); ok {
+		return c.DiscoveryStatus(), nil
+	}
+	return types.DiscoveryStatus{}, errors.New("discovery status is not supported by the client")
+}
+
+// FlushTelemetry logs a snapshot of the cluster topology and connection
+// pools, when debug logging is enabled, and flushes the meter provider and
+// logger if they buffer output. Close does the same, call FlushTelemetry
+// directly when the process may exit without closing the client, e.g. at the
+// end of a Lambda invocation.
+func (d *Dax) FlushTelemetry(ctx context.Context) error {
+	if !d.initialized() {
+		return nil
+	}
+	if c, ok := d.client.(client.TelemetryFlusher); ok {
+		return c.FlushTelemetry(ctx)
+	}
+	return nil
+}
+
+// Close stops accepting new requests, waits up to CloseDrainTimeout for
+// in-flight requests to complete and then closes all connections.
 func (d *Dax) Close() error {
+	if !d.closeLazy() {
+		return nil
+	}
 	if c, ok := d.client.(io.Closer); ok {
 		return c.Close()
 	}
@@ -17,9 +17,11 @@ package dax
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 )
 
 func TestUnimplementedBehavior(t *testing.T) {
@@ -36,6 +38,29 @@ func TestUnimplementedBehavior(t *testing.T) {
 	}
 }
 
+func TestDaxAPI_coversClient(t *testing.T) {
+	api := reflect.TypeOf((*DaxAPI)(nil)).Elem()
+	dax := reflect.TypeOf((*Dax)(nil))
+	for i := 0; i < dax.NumMethod(); i++ {
+		if name := dax.Method(i).Name; !hasMethod(api, name) {
+			t.Errorf("expect DaxAPI to declare Dax.%s", name)
+		}
+	}
+
+	api = reflect.TypeOf((*DynamoDBAPI)(nil)).Elem()
+	ddb := reflect.TypeOf((*dynamodb.Client)(nil))
+	for i := 0; i < ddb.NumMethod(); i++ {
+		if name := ddb.Method(i).Name; name != "Options" && !hasMethod(api, name) {
+			t.Errorf("expect DynamoDBAPI to declare dynamodb.Client.%s", name)
+		}
+	}
+}
+
+func hasMethod(t reflect.Type, name string) bool {
+	_, ok := t.MethodByName(name)
+	return ok
+}
+
 func createClient(t *testing.T) *Dax {
 	cfg := DefaultConfig()
 	cfg.HostPorts = []string{"127.0.0.1:8111"}
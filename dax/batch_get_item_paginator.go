@@ -33,6 +33,7 @@ type BatchGetItemPaginator struct {
 	firstPage    bool
 	requestItems map[string]types.KeysAndAttributes
 	isTruncated  bool
+	guard        pageGuard
 }
 
 // NewBatchGetItemPaginator returns a new BatchGetItemPaginator
@@ -53,9 +54,18 @@ func NewBatchGetItemPaginator(client dynamodb.BatchGetItemAPIClient, params *dyn
 		params:       params,
 		firstPage:    true,
 		requestItems: params.RequestItems,
+		guard:        pageGuard{op: "BatchGetItem"},
 	}
 }
 
+// SetMaxPages makes NextPage fail with a PaginationLoopError instead of
+// retrieving more than n pages. Zero, the default, sets no ceiling. Repeated
+// UnprocessedKeys are expected while throttled and are only detected with
+// StopOnDuplicateToken.
+func (p *BatchGetItemPaginator) SetMaxPages(n int) {
+	p.guard.maxPages = n
+}
+
 // HasMorePages returns a boolean indicating whether more pages are available
 func (p *BatchGetItemPaginator) HasMorePages() bool {
 	return p.firstPage || p.isTruncated
@@ -66,6 +76,9 @@ func (p *BatchGetItemPaginator) NextPage(ctx context.Context, optFns ...func(*dy
 	if !p.HasMorePages() {
 		return nil, fmt.Errorf("no more pages available")
 	}
+	if err := p.guard.before(); err != nil {
+		return nil, err
+	}
 
 	params := *p.params
 	params.RequestItems = p.requestItems
@@ -75,6 +88,7 @@ func (p *BatchGetItemPaginator) NextPage(ctx context.Context, optFns ...func(*dy
 		return nil, err
 	}
 	p.firstPage = false
+	p.guard.pages++
 
 	prevToken := p.requestItems
 	p.isTruncated = len(result.UnprocessedKeys) != 0
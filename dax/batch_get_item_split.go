@@ -0,0 +1,278 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchGetItemKeys is the number of keys a single BatchGetItem request may contain.
+const maxBatchGetItemKeys = 100
+
+// BatchGetItemError is returned by BatchGetItem when some of the requests it
+// split a large batch into failed. The output returned with it merges the
+// results of the requests which succeeded; the keys of the failed requests
+// are in Failed, not in UnprocessedKeys.
+type BatchGetItemError struct {
+	// Requests is the number of requests the batch was split into.
+	Requests int
+	// Failed holds the failed requests.
+	Failed []BatchGetItemFailure
+}
+
+// BatchGetItemFailure describes a failed request of a split batch.
+type BatchGetItemFailure struct {
+	RequestItems map[string]types.KeysAndAttributes
+	Err          error
+}
+
+// Error names the tables of the failed request and the number of keys for
+// each, followed by the error.
+func (f BatchGetItemFailure) Error() string {
+	counts := make(map[string]int, len(f.RequestItems))
+	for table, kaas := range f.RequestItems {
+		counts[table] = len(kaas.Keys)
+	}
+	return describeBatchFailure(counts, "keys", f.Err)
+}
+
+func (f BatchGetItemFailure) Unwrap() error {
+	return f.Err
+}
+
+// Error lists the failed requests, one per line.
+func (e *BatchGetItemError) Error() string {
+	return fmt.Sprintf("%d of %d batch get requests failed:\n%v", len(e.Failed), e.Requests, errors.Join(e.Unwrap()...))
+}
+
+// Unwrap returns the failed requests, which unwrap to their errors.
+func (e *BatchGetItemError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+func batchGetItemKeyCount(input *dynamodb.BatchGetItemInput) int {
+	n := 0
+	for _, kaas := range input.RequestItems {
+		n += len(kaas.Keys)
+	}
+	return n
+}
+
+// splitBatchGetItemInput splits the keys of input, in table name order, into
+// requests of at most size keys.
+func splitBatchGetItemInput(input *dynamodb.BatchGetItemInput, size int) []*dynamodb.BatchGetItemInput {
+	tables := make([]string, 0, len(input.RequestItems))
+	for table := range input.RequestItems {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var chunks []*dynamodb.BatchGetItemInput
+	var cur *dynamodb.BatchGetItemInput
+	n := 0
+	for _, table := range tables {
+		kaas := input.RequestItems[table]
+		keys := kaas.Keys
+		for len(keys) > 0 {
+			if cur == nil || n == size {
+				in := *input
+				in.RequestItems = make(map[string]types.KeysAndAttributes)
+				cur, n = &in, 0
+				chunks = append(chunks, cur)
+			}
+			k := min(size-n, len(keys))
+			part := kaas
+			part.Keys = keys[:k:k]
+			cur.RequestItems[table] = part
+			keys, n = keys[k:], n+k
+		}
+	}
+	return chunks
+}
+
+// splitBatchGetItemConsistency splits the tables of input into those read
+// with strong consistency and those read with eventual consistency, nil if
+// there is none.
+func splitBatchGetItemConsistency(input *dynamodb.BatchGetItemInput) (consistent, eventual *dynamodb.BatchGetItemInput) {
+	for table, kaas := range input.RequestItems {
+		part := &eventual
+		if aws.ToBool(kaas.ConsistentRead) {
+			part = &consistent
+		}
+		if *part == nil {
+			in := *input
+			in.RequestItems = make(map[string]types.KeysAndAttributes)
+			*part = &in
+		}
+		(*part).RequestItems[table] = kaas
+	}
+	return consistent, eventual
+}
+
+// batchGetItemChunks executes chunks with up to BatchGetItemConcurrency
+// requests in flight. Failed chunks do not stop the others, they are
+// reported by a BatchGetItemError returned with the merged output of the
+// chunks which succeeded.
+func (d *Dax) batchGetItemChunks(ctx context.Context, chunks []*dynamodb.BatchGetItemInput, o client.RequestOptions) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{}
+	errs := make([]error, len(chunks))
+	started := make([]bool, len(chunks))
+	var mu sync.Mutex
+	forEachChunk(ctx, len(chunks), d.config.BatchGetItemConcurrency, func(i int) {
+		res, err := d.client.BatchGetItemWithOptions(ctx, chunks[i], &dynamodb.BatchGetItemOutput{}, d.config.batchGetItemOptions(chunks[i], o))
+		mu.Lock()
+		defer mu.Unlock()
+		started[i], errs[i] = true, err
+		if err == nil {
+			mergeBatchGetItemOutput(out, res)
+		}
+	})
+
+	var failed []BatchGetItemFailure
+	for i, chunk := range chunks {
+		err := errs[i]
+		if !started[i] {
+			err = ctx.Err()
+		}
+		if err != nil {
+			failed = append(failed, BatchGetItemFailure{RequestItems: chunk.RequestItems, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return out, &BatchGetItemError{Requests: len(chunks), Failed: failed}
+	}
+	return out, nil
+}
+
+// forEachChunk calls fn for the chunk indexes 0 to n-1 with up to limit calls
+// in flight. Once ctx is done the remaining chunks are not started.
+func forEachChunk(ctx context.Context, n, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(limit, 1))
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func mergeBatchGetItemOutput(dst, src *dynamodb.BatchGetItemOutput) {
+	if src == nil {
+		return
+	}
+	client.MergeResultMetadata(&dst.ResultMetadata, src.ResultMetadata)
+	for table, items := range src.Responses {
+		if dst.Responses == nil {
+			dst.Responses = make(map[string][]map[string]types.AttributeValue)
+		}
+		dst.Responses[table] = append(dst.Responses[table], items...)
+	}
+	for table, kaas := range src.UnprocessedKeys {
+		if dst.UnprocessedKeys == nil {
+			dst.UnprocessedKeys = make(map[string]types.KeysAndAttributes)
+		}
+		if prev, ok := dst.UnprocessedKeys[table]; ok {
+			// Limit the capacity so that appending never writes into a slice of src.
+			kaas.Keys = append(prev.Keys[:len(prev.Keys):len(prev.Keys)], kaas.Keys...)
+		}
+		dst.UnprocessedKeys[table] = kaas
+	}
+	for _, cc := range src.ConsumedCapacity {
+		dst.ConsumedCapacity = mergeConsumedCapacity(dst.ConsumedCapacity, cc)
+	}
+}
+
+// mergeConsumedCapacity adds cc to the entry of the same table in ccs.
+func mergeConsumedCapacity(ccs []types.ConsumedCapacity, cc types.ConsumedCapacity) []types.ConsumedCapacity {
+	for i := range ccs {
+		if aws.ToString(ccs[i].TableName) != aws.ToString(cc.TableName) {
+			continue
+		}
+		dst := &ccs[i]
+		dst.CapacityUnits = addUnits(dst.CapacityUnits, cc.CapacityUnits)
+		dst.ReadCapacityUnits = addUnits(dst.ReadCapacityUnits, cc.ReadCapacityUnits)
+		dst.WriteCapacityUnits = addUnits(dst.WriteCapacityUnits, cc.WriteCapacityUnits)
+		dst.Table = addCapacity(dst.Table, cc.Table)
+		dst.GlobalSecondaryIndexes = addIndexCapacity(dst.GlobalSecondaryIndexes, cc.GlobalSecondaryIndexes)
+		dst.LocalSecondaryIndexes = addIndexCapacity(dst.LocalSecondaryIndexes, cc.LocalSecondaryIndexes)
+		return ccs
+	}
+	return append(ccs, cc)
+}
+
+func addUnits(a, b *float64) *float64 {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return aws.Float64(*a + *b)
+}
+
+func addCapacity(a, b *types.Capacity) *types.Capacity {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &types.Capacity{
+		CapacityUnits:      addUnits(a.CapacityUnits, b.CapacityUnits),
+		ReadCapacityUnits:  addUnits(a.ReadCapacityUnits, b.ReadCapacityUnits),
+		WriteCapacityUnits: addUnits(a.WriteCapacityUnits, b.WriteCapacityUnits),
+	}
+}
+
+func addIndexCapacity(a, b map[string]types.Capacity) map[string]types.Capacity {
+	if len(b) == 0 {
+		return a
+	}
+	res := make(map[string]types.Capacity, len(a)+len(b))
+	for k, v := range a {
+		res[k] = v
+	}
+	for k, v := range b {
+		if prev, ok := res[k]; ok {
+			v = *addCapacity(&prev, &v)
+		}
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,282 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchGetClient returns the requested keys as items, except the first key
+// of each table which is left unprocessed.
+type batchGetClient struct {
+	client.DaxAPI
+	mu       sync.Mutex
+	requests []*dynamodb.BatchGetItemInput
+	options  []client.RequestOptions
+	err      error
+}
+
+func (c *batchGetClient) BatchGetItemWithOptions(ctx context.Context, input *dynamodb.BatchGetItemInput, output *dynamodb.BatchGetItemOutput, opt client.RequestOptions) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, input)
+	c.options = append(c.options, opt)
+	c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	output.Responses = map[string][]map[string]types.AttributeValue{}
+	output.UnprocessedKeys = map[string]types.KeysAndAttributes{}
+	for table, kaas := range input.RequestItems {
+		output.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: kaas.Keys[:1], ProjectionExpression: kaas.ProjectionExpression}
+		output.Responses[table] = kaas.Keys[1:]
+		output.ConsumedCapacity = append(output.ConsumedCapacity, types.ConsumedCapacity{
+			TableName:     aws.String(table),
+			CapacityUnits: aws.Float64(float64(len(kaas.Keys))),
+		})
+	}
+	return output, nil
+}
+
+func batchGetKeys(n int) []map[string]types.AttributeValue {
+	keys := make([]map[string]types.AttributeValue, n)
+	for i := range keys {
+		keys[i] = map[string]types.AttributeValue{"id": &types.AttributeValueMemberN{Value: strconv.Itoa(i)}}
+	}
+	return keys
+}
+
+func TestSplitBatchGetItemInput(t *testing.T) {
+	input := &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"b": {Keys: batchGetKeys(70)},
+			"a": {Keys: batchGetKeys(150), ProjectionExpression: aws.String("id")},
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}
+
+	chunks := splitBatchGetItemInput(input, maxBatchGetItemKeys)
+	require.Len(t, chunks, 3)
+	counts := make([]map[string]int, len(chunks))
+	for i, c := range chunks {
+		counts[i] = map[string]int{}
+		for table, kaas := range c.RequestItems {
+			counts[i][table] = len(kaas.Keys)
+		}
+		assert.Equal(t, types.ReturnConsumedCapacityTotal, c.ReturnConsumedCapacity)
+	}
+	assert.Equal(t, []map[string]int{{"a": 100}, {"a": 50, "b": 50}, {"b": 20}}, counts)
+	assert.Equal(t, "id", *chunks[1].RequestItems["a"].ProjectionExpression)
+	assert.Equal(t, input.RequestItems["a"].Keys[100], chunks[1].RequestItems["a"].Keys[0])
+	assert.Len(t, input.RequestItems["a"].Keys, 150, "expected input to be unchanged")
+}
+
+func TestBatchGetItem_splitsLargeRequests(t *testing.T) {
+	for _, concurrency := range []int{0, 3} {
+		fake := &batchGetClient{}
+		cfg := DefaultConfig()
+		cfg.BatchGetItemConcurrency = concurrency
+		d := &Dax{client: fake, config: cfg}
+
+		out, err := d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				"a": {Keys: batchGetKeys(150), ProjectionExpression: aws.String("id")},
+				"b": {Keys: batchGetKeys(70)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, fake.requests, 3)
+
+		// Two chunks read table a and two read table b, each leaves one key unprocessed.
+		assert.Len(t, out.Responses["a"], 148)
+		assert.Len(t, out.Responses["b"], 68)
+		assert.Len(t, out.UnprocessedKeys["a"].Keys, 2)
+		assert.Equal(t, "id", *out.UnprocessedKeys["a"].ProjectionExpression)
+		assert.Len(t, out.UnprocessedKeys["b"].Keys, 2)
+		require.Len(t, out.ConsumedCapacity, 2)
+		for _, cc := range out.ConsumedCapacity {
+			expected := map[string]float64{"a": 150, "b": 70}[*cc.TableName]
+			assert.Equal(t, expected, *cc.CapacityUnits, *cc.TableName)
+		}
+	}
+}
+
+func TestBatchGetItem_smallRequestsAreNotSplit(t *testing.T) {
+	fake := &batchGetClient{}
+	d := &Dax{client: fake, config: DefaultConfig()}
+	input := &dynamodb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{"a": {Keys: batchGetKeys(100)}}}
+
+	_, err := d.BatchGetItem(context.Background(), input)
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Same(t, input, fake.requests[0])
+}
+
+func TestBatchGetItem_mixedConsistency(t *testing.T) {
+	fake := &batchGetClient{}
+	cfg := DefaultConfig()
+	cfg.ReadPreference = daxTypes.ReadPreferenceReplica
+	d := &Dax{client: fake, config: cfg}
+
+	out, err := d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"strong":   {Keys: batchGetKeys(3), ConsistentRead: aws.Bool(true)},
+			"eventual": {Keys: batchGetKeys(3), ConsistentRead: aws.Bool(false)},
+			"default":  {Keys: batchGetKeys(3)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 2)
+	for i, req := range fake.requests {
+		if _, ok := req.RequestItems["strong"]; ok {
+			assert.Len(t, req.RequestItems, 1)
+			assert.True(t, fake.options[i].Passthrough)
+			assert.Equal(t, daxTypes.ReadPreferenceLeader, fake.options[i].ReadPreference)
+		} else {
+			assert.Len(t, req.RequestItems, 2)
+			assert.False(t, fake.options[i].Passthrough)
+			assert.Empty(t, fake.options[i].ReadPreference)
+		}
+	}
+	assert.Len(t, out.Responses, 3)
+	assert.Len(t, out.UnprocessedKeys, 3)
+
+	// Batches of a single consistency are not split.
+	fake = &batchGetClient{}
+	d.client = fake
+	_, err = d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"a": {Keys: batchGetKeys(3), ConsistentRead: aws.Bool(true)},
+			"b": {Keys: batchGetKeys(3), ConsistentRead: aws.Bool(true)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, daxTypes.ReadPreferenceLeader, fake.options[0].ReadPreference)
+
+	// Without the replica preference, consistent reads are routed like others.
+	fake = &batchGetClient{}
+	d.client, d.config.ReadPreference = fake, daxTypes.ReadPreferenceAny
+	_, err = d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{"a": {Keys: batchGetKeys(3), ConsistentRead: aws.Bool(true)}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, fake.options[0].ReadPreference)
+}
+
+func TestBatchGetItem_splitError(t *testing.T) {
+	fake := &batchGetClient{err: errors.New("throttled")}
+	d := &Dax{client: fake, config: DefaultConfig()}
+
+	out, err := d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{"a": {Keys: batchGetKeys(350)}},
+	})
+	assert.ErrorIs(t, err, fake.err)
+	var bge *BatchGetItemError
+	require.ErrorAs(t, err, &bge)
+	assert.Equal(t, 4, bge.Requests)
+	require.Len(t, bge.Failed, 4)
+	assert.Len(t, bge.Failed[3].RequestItems["a"].Keys, 50)
+	assert.Contains(t, err.Error(), "4 of 4 batch get requests failed")
+	assert.Contains(t, err.Error(), "table a (50 keys): throttled")
+	require.NotNil(t, out)
+	assert.Empty(t, out.Responses)
+	assert.Len(t, fake.requests, 4)
+}
+
+func TestBatchGetItem_splitPartialFailure(t *testing.T) {
+	fake := &batchGetFailingClient{fail: map[string]error{"b": errors.New("node down")}}
+	d := &Dax{client: fake, config: DefaultConfig()}
+
+	out, err := d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"a": {Keys: batchGetKeys(100)},
+			"b": {Keys: batchGetKeys(30)},
+		},
+	})
+	var bge *BatchGetItemError
+	require.ErrorAs(t, err, &bge)
+	assert.ErrorIs(t, err, fake.fail["b"])
+	require.Len(t, bge.Failed, 1)
+	assert.Len(t, bge.Failed[0].RequestItems["b"].Keys, 30)
+	assert.EqualError(t, bge.Failed[0], "table b (30 keys): node down")
+	assert.Len(t, out.Responses["a"], 99)
+}
+
+func TestBatchGetItem_splitRequestTimeout(t *testing.T) {
+	fake := &batchGetClient{}
+	d := &Dax{client: fake, config: DefaultConfig()}
+
+	_, err := d.BatchGetItem(context.Background(), &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{"a": {Keys: batchGetKeys(350)}},
+	}, WithRequestTimeout(time.Nanosecond))
+	var bge *BatchGetItemError
+	require.ErrorAs(t, err, &bge)
+	assert.Len(t, bge.Failed, 4)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, fake.requests)
+}
+
+// batchGetFailingClient fails the requests for the tables in fail.
+type batchGetFailingClient struct {
+	batchGetClient
+	fail map[string]error
+}
+
+func (c *batchGetFailingClient) BatchGetItemWithOptions(ctx context.Context, input *dynamodb.BatchGetItemInput, output *dynamodb.BatchGetItemOutput, opt client.RequestOptions) (*dynamodb.BatchGetItemOutput, error) {
+	for table := range input.RequestItems {
+		if err := c.fail[table]; err != nil {
+			return nil, err
+		}
+	}
+	return c.batchGetClient.BatchGetItemWithOptions(ctx, input, output, opt)
+}
+
+func TestMergeConsumedCapacity(t *testing.T) {
+	ccs := mergeConsumedCapacity(nil, types.ConsumedCapacity{
+		TableName:              aws.String("a"),
+		CapacityUnits:          aws.Float64(1),
+		Table:                  &types.Capacity{CapacityUnits: aws.Float64(1)},
+		GlobalSecondaryIndexes: map[string]types.Capacity{"gsi": {CapacityUnits: aws.Float64(2)}},
+	})
+	ccs = mergeConsumedCapacity(ccs, types.ConsumedCapacity{
+		TableName:              aws.String("a"),
+		CapacityUnits:          aws.Float64(3),
+		ReadCapacityUnits:      aws.Float64(3),
+		Table:                  &types.Capacity{CapacityUnits: aws.Float64(1)},
+		GlobalSecondaryIndexes: map[string]types.Capacity{"gsi": {CapacityUnits: aws.Float64(2)}},
+	})
+	ccs = mergeConsumedCapacity(ccs, types.ConsumedCapacity{TableName: aws.String("b"), CapacityUnits: aws.Float64(5)})
+
+	require.Len(t, ccs, 2)
+	assert.Equal(t, 4.0, *ccs[0].CapacityUnits)
+	assert.Equal(t, 3.0, *ccs[0].ReadCapacityUnits)
+	assert.Equal(t, 2.0, *ccs[0].Table.CapacityUnits)
+	assert.Equal(t, 4.0, *ccs[0].GlobalSecondaryIndexes["gsi"].CapacityUnits)
+	assert.Equal(t, 5.0, *ccs[1].CapacityUnits)
+}
@@ -0,0 +1,191 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchWriteItemRequests is the number of write requests a single BatchWriteItem request may contain.
+const maxBatchWriteItemRequests = 25
+
+// BatchWriteItemError is returned by BatchWriteItem when some of the requests
+// it split a large batch into failed. The output returned with it merges the
+// results of the requests which succeeded, their items were written apart
+// from the UnprocessedItems.
+type BatchWriteItemError struct {
+	// Requests is the number of requests the batch was split into.
+	Requests int
+	// Failed holds the failed requests.
+	Failed []BatchWriteItemFailure
+}
+
+// BatchWriteItemFailure describes a failed request of a split batch. None,
+// some or all of its write requests may have been applied.
+type BatchWriteItemFailure struct {
+	RequestItems map[string][]types.WriteRequest
+	Err          error
+}
+
+// Error names the tables of the failed request and the number of write
+// requests for each, followed by the error.
+func (f BatchWriteItemFailure) Error() string {
+	counts := make(map[string]int, len(f.RequestItems))
+	for table, wrs := range f.RequestItems {
+		counts[table] = len(wrs)
+	}
+	return describeBatchFailure(counts, "write requests", f.Err)
+}
+
+func (f BatchWriteItemFailure) Unwrap() error {
+	return f.Err
+}
+
+// Error lists the failed requests, one per line.
+func (e *BatchWriteItemError) Error() string {
+	return fmt.Sprintf("%d of %d batch write requests failed:\n%v", len(e.Failed), e.Requests, errors.Join(e.Unwrap()...))
+}
+
+// Unwrap returns the failed requests, which unwrap to their errors.
+func (e *BatchWriteItemError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f
+	}
+	return errs
+}
+
+// describeBatchFailure formats the tables of a failed request of a split
+// batch, in name order, e.g. "table a (20 keys), table b (5 keys): throttled".
+func describeBatchFailure(counts map[string]int, unit string, err error) string {
+	tables := make([]string, 0, len(counts))
+	for table := range counts {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	var sb strings.Builder
+	for i, table := range tables {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "table %s (%d %s)", table, counts[table], unit)
+	}
+	fmt.Fprintf(&sb, ": %v", err)
+	return sb.String()
+}
+
+func batchWriteItemRequestCount(input *dynamodb.BatchWriteItemInput) int {
+	n := 0
+	for _, wrs := range input.RequestItems {
+		n += len(wrs)
+	}
+	return n
+}
+
+// splitBatchWriteItemInput splits the write requests of input, in table name
+// order, into requests of at most size write requests.
+func splitBatchWriteItemInput(input *dynamodb.BatchWriteItemInput, size int) []*dynamodb.BatchWriteItemInput {
+	tables := make([]string, 0, len(input.RequestItems))
+	for table := range input.RequestItems {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var chunks []*dynamodb.BatchWriteItemInput
+	var cur *dynamodb.BatchWriteItemInput
+	n := 0
+	for _, table := range tables {
+		wrs := input.RequestItems[table]
+		for len(wrs) > 0 {
+			if cur == nil || n == size {
+				in := *input
+				in.RequestItems = make(map[string][]types.WriteRequest)
+				cur, n = &in, 0
+				chunks = append(chunks, cur)
+			}
+			k := min(size-n, len(wrs))
+			cur.RequestItems[table] = wrs[:k:k]
+			wrs, n = wrs[k:], n+k
+		}
+	}
+	return chunks
+}
+
+// batchWriteItemChunks executes chunks with up to BatchWriteItemConcurrency
+// requests in flight. Failed chunks do not stop the others, they are
+// reported by a BatchWriteItemError returned with the merged output of the
+// chunks which succeeded.
+func (d *Dax) batchWriteItemChunks(ctx context.Context, chunks []*dynamodb.BatchWriteItemInput, o client.RequestOptions) (*dynamodb.BatchWriteItemOutput, error) {
+	out := &dynamodb.BatchWriteItemOutput{}
+	errs := make([]error, len(chunks))
+	started := make([]bool, len(chunks))
+	var mu sync.Mutex
+	forEachChunk(ctx, len(chunks), d.config.BatchWriteItemConcurrency, func(i int) {
+		res, err := d.client.BatchWriteItemWithOptions(ctx, chunks[i], &dynamodb.BatchWriteItemOutput{}, o)
+		mu.Lock()
+		defer mu.Unlock()
+		started[i], errs[i] = true, err
+		if err == nil {
+			mergeBatchWriteItemOutput(out, res)
+		}
+	})
+
+	var failed []BatchWriteItemFailure
+	for i, chunk := range chunks {
+		err := errs[i]
+		if !started[i] {
+			err = ctx.Err()
+		}
+		if err != nil {
+			failed = append(failed, BatchWriteItemFailure{RequestItems: chunk.RequestItems, Err: err})
+		}
+	}
+	if len(failed) > 0 {
+		return out, &BatchWriteItemError{Requests: len(chunks), Failed: failed}
+	}
+	return out, nil
+}
+
+func mergeBatchWriteItemOutput(dst, src *dynamodb.BatchWriteItemOutput) {
+	if src == nil {
+		return
+	}
+	client.MergeResultMetadata(&dst.ResultMetadata, src.ResultMetadata)
+	for table, wrs := range src.UnprocessedItems {
+		if dst.UnprocessedItems == nil {
+			dst.UnprocessedItems = make(map[string][]types.WriteRequest)
+		}
+		dst.UnprocessedItems[table] = append(dst.UnprocessedItems[table], wrs...)
+	}
+	for table, icms := range src.ItemCollectionMetrics {
+		if dst.ItemCollectionMetrics == nil {
+			dst.ItemCollectionMetrics = make(map[string][]types.ItemCollectionMetrics)
+		}
+		dst.ItemCollectionMetrics[table] = append(dst.ItemCollectionMetrics[table], icms...)
+	}
+	for _, cc := range src.ConsumedCapacity {
+		dst.ConsumedCapacity = mergeConsumedCapacity(dst.ConsumedCapacity, cc)
+	}
+}
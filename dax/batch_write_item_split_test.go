@@ -0,0 +1,166 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchWriteClient leaves the first write request of each table unprocessed
+// and fails the requests for the tables in fail.
+type batchWriteClient struct {
+	client.DaxAPI
+	mu       sync.Mutex
+	requests []*dynamodb.BatchWriteItemInput
+	fail     map[string]error
+}
+
+func (c *batchWriteClient) BatchWriteItemWithOptions(ctx context.Context, input *dynamodb.BatchWriteItemInput, output *dynamodb.BatchWriteItemOutput, opt client.RequestOptions) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, input)
+	c.mu.Unlock()
+	output.UnprocessedItems = map[string][]types.WriteRequest{}
+	for table, wrs := range input.RequestItems {
+		if err := c.fail[table]; err != nil {
+			return nil, err
+		}
+		output.UnprocessedItems[table] = wrs[:1]
+		output.ConsumedCapacity = append(output.ConsumedCapacity, types.ConsumedCapacity{
+			TableName:     aws.String(table),
+			CapacityUnits: aws.Float64(float64(len(wrs))),
+		})
+	}
+	return output, nil
+}
+
+func batchWriteRequests(n int) []types.WriteRequest {
+	keys := batchGetKeys(n)
+	wrs := make([]types.WriteRequest, n)
+	for i, key := range keys {
+		wrs[i] = types.WriteRequest{PutRequest: &types.PutRequest{Item: key}}
+	}
+	return wrs
+}
+
+func TestSplitBatchWriteItemInput(t *testing.T) {
+	input := &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]types.WriteRequest{
+			"b": batchWriteRequests(10),
+			"a": batchWriteRequests(40),
+		},
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+	}
+
+	chunks := splitBatchWriteItemInput(input, maxBatchWriteItemRequests)
+	require.Len(t, chunks, 2)
+	assert.Len(t, chunks[0].RequestItems["a"], 25)
+	assert.NotContains(t, chunks[0].RequestItems, "b")
+	assert.Len(t, chunks[1].RequestItems["a"], 15)
+	assert.Len(t, chunks[1].RequestItems["b"], 10)
+	for _, c := range chunks {
+		assert.Equal(t, types.ReturnConsumedCapacityTotal, c.ReturnConsumedCapacity)
+		assert.LessOrEqual(t, batchWriteItemRequestCount(c), maxBatchWriteItemRequests)
+	}
+	assert.Equal(t, 50, batchWriteItemRequestCount(input))
+}
+
+func TestDax_BatchWriteItemSplit(t *testing.T) {
+	for _, concurrency := range []int{0, 3} {
+		c := &batchWriteClient{}
+		d := &Dax{client: c, config: Config{BatchWriteItemConcurrency: concurrency}}
+		out, err := d.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				"a": batchWriteRequests(60),
+				"b": batchWriteRequests(5),
+			},
+		})
+		require.NoError(t, err)
+		assert.Len(t, c.requests, 3)
+		assert.Len(t, out.UnprocessedItems["a"], 3)
+		assert.Len(t, out.UnprocessedItems["b"], 1)
+		units := map[string]float64{}
+		for _, cc := range out.ConsumedCapacity {
+			units[*cc.TableName] = *cc.CapacityUnits
+		}
+		assert.Equal(t, map[string]float64{"a": 60, "b": 5}, units)
+	}
+}
+
+func TestDax_BatchWriteItemSplitPartialFailure(t *testing.T) {
+	failure := errors.New("throttled")
+	c := &batchWriteClient{fail: map[string]error{"b": failure}}
+	d := &Dax{client: c}
+	out, err := d.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]types.WriteRequest{
+			"a": batchWriteRequests(30),
+			"b": batchWriteRequests(30),
+		},
+	})
+
+	var bwe *BatchWriteItemError
+	require.ErrorAs(t, err, &bwe)
+	assert.ErrorIs(t, err, failure)
+	assert.Len(t, c.requests, 3)
+	assert.Equal(t, 3, bwe.Requests)
+	require.Len(t, bwe.Failed, 2)
+	assert.Len(t, bwe.Failed[0].RequestItems["b"], 20)
+	assert.Len(t, bwe.Failed[1].RequestItems["b"], 10)
+	assert.Equal(t, "2 of 3 batch write requests failed:\n"+
+		"table a (5 write requests), table b (20 write requests): throttled\n"+
+		"table b (10 write requests): throttled", err.Error())
+	require.NotNil(t, out)
+	assert.Len(t, out.UnprocessedItems["a"], 1)
+	assert.NotContains(t, out.UnprocessedItems, "b")
+}
+
+func TestDax_BatchWriteItemSplitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := &Dax{client: &batchWriteClient{}}
+	_, err := d.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]types.WriteRequest{"a": batchWriteRequests(30)},
+	})
+
+	var bwe *BatchWriteItemError
+	require.ErrorAs(t, err, &bwe)
+	assert.Len(t, bwe.Failed, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDax_BatchWriteItemSplitRequestTimeout(t *testing.T) {
+	c := &batchWriteClient{}
+	d := &Dax{client: c}
+	_, err := d.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]types.WriteRequest{"a": batchWriteRequests(30)},
+	}, WithRequestTimeout(time.Nanosecond))
+
+	var bwe *BatchWriteItemError
+	require.ErrorAs(t, err, &bwe)
+	assert.Len(t, bwe.Failed, 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, c.requests)
+}
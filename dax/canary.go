@@ -0,0 +1,83 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+)
+
+// Canary is a client for migrating between clusters without an external
+// proxy. It sends a percentage of the requests to the canary cluster and the
+// rest to the stable one, and the percentage can be changed while in use.
+//
+// Requests are assigned by a hash of their tables and keys, so requests for
+// an item keep going to the same cluster while the percentage is unchanged
+// and raising it only moves items to the canary cluster. The number of
+// requests and failures per cluster is reported to the stable MeterProvider
+// as dax.canary.stable.* and dax.canary.canary.* metrics, each cluster
+// reports its other metrics to its own MeterProvider.
+//
+// The embedded Dax uses the stable Config for request settings.
+// InvalidateCaches, PrewarmTables, RefreshCluster and FlushTelemetry apply to
+// both clusters, and HealthCheck is healthy only if both are. Keys are
+// extracted with the key schemas of the stable cluster. Use Stable and Canary
+// for ClusterState, DiscoveryStatus and UpdateTopology.
+type Canary struct {
+	*Dax
+	stable, canary *Dax
+	router         *client.CanaryClient
+}
+
+// NewCanary creates clients for the stable and canary clusters and sends
+// percent, from 0 to 100, of the requests to the canary cluster.
+func NewCanary(stable, canary Config, percent float64) (*Canary, error) {
+	s, err := New(stable)
+	if err != nil {
+		return nil, err
+	}
+	c, err := New(canary)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	r, err := client.NewCanaryClient(s.client, c.client, percent, stable.MeterProvider)
+	if err != nil {
+		s.Close()
+		c.Close()
+		return nil, err
+	}
+	return &Canary{Dax: &Dax{client: r, config: stable, scans: s.scans}, stable: s, canary: c, router: r}, nil
+}
+
+// SetPercent changes the percentage of requests sent to the canary cluster.
+func (c *Canary) SetPercent(percent float64) error {
+	return c.router.SetPercent(percent)
+}
+
+// Percent returns the percentage of requests sent to the canary cluster.
+func (c *Canary) Percent() float64 {
+	return c.router.Percent()
+}
+
+// Stable returns the client of the stable cluster.
+func (c *Canary) Stable() *Dax {
+	return c.stable
+}
+
+// Canary returns the client of the canary cluster.
+func (c *Canary) Canary() *Dax {
+	return c.canary
+}
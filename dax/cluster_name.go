@@ -0,0 +1,221 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// NewFromClusterName creates a new instance of the DAX client for the
+// cluster named clusterName. Its discovery endpoint, and whether its
+// connections are encrypted, are looked up with the DescribeClusters API of
+// the DAX control plane, signed with Config.Credentials for Config.Region,
+// sent with Config.ControlPlaneHTTPClient and retried by
+// Config.ControlPlaneRetryer; Config.HostPorts is replaced by the endpoint.
+// Callers need the dax:DescribeClusters permission.
+//
+//	cfg := dax.NewConfig(awsCfg, "")
+//	client, err := dax.NewFromClusterName(ctx, cfg, "mycluster")
+func NewFromClusterName(ctx context.Context, cfg Config, clusterName string) (*Dax, error) {
+	endpoint, err := describeClusterEndpoint(ctx, cfg, clusterName)
+	if err != nil {
+		if cfg.Logger != nil {
+			cfg.Logger.Logf("ERROR", "Exception in initialisation of DAX Client : %s", err)
+		}
+		return nil, err
+	}
+	cfg.HostPorts = []string{endpoint}
+	return New(cfg)
+}
+
+type describeClustersOutput struct {
+	Clusters []struct {
+		ClusterName              string
+		Status                   string
+		ClusterDiscoveryEndpoint *struct {
+			Address string
+			Port    int
+			URL     string
+		}
+		ClusterEndpointEncryptionType string
+	}
+}
+
+// describeClusterEndpoint returns the discovery endpoint URL of the cluster
+// named clusterName, built from its address with the daxs scheme if its
+// connections are encrypted when the control plane does not return one.
+func describeClusterEndpoint(ctx context.Context, cfg Config, clusterName string) (string, error) {
+	if clusterName == "" {
+		return "", smithy.NewErrParamRequired("clusterName")
+	}
+	if cfg.Region == "" {
+		return "", smithy.NewErrParamRequired("config.Region")
+	}
+	if cfg.Credentials == nil {
+		return "", smithy.NewErrParamRequired("config.Credentials")
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	url := cfg.ControlPlaneEndpoint
+	if url == "" {
+		url = "https://dax." + cfg.Region + ".amazonaws.com"
+		if strings.HasPrefix(cfg.Region, "cn-") {
+			url += ".cn"
+		}
+	}
+	body, err := json.Marshal(map[string][]string{"ClusterNames": {clusterName}})
+	if err != nil {
+		return "", err
+	}
+	httpClient := cfg.ControlPlaneHTTPClient
+	if httpClient == nil {
+		httpClient = awshttp.NewBuildableClient()
+	}
+	var retryer aws.Retryer
+	if cfg.ControlPlaneRetryer != nil {
+		retryer = cfg.ControlPlaneRetryer()
+	} else {
+		retryer = retry.NewStandard()
+	}
+
+	var payload []byte
+	for attempt := 1; ; attempt++ {
+		payload, err = describeClusters(ctx, httpClient, creds, url, cfg.Region, body)
+		if err == nil {
+			break
+		}
+		if attempt >= retryer.MaxAttempts() || !retryer.IsErrorRetryable(err) {
+			return "", err
+		}
+		delay, derr := retryer.RetryDelay(attempt, err)
+		if derr != nil {
+			return "", err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return "", ctx.Err()
+		}
+	}
+
+	var out describeClustersOutput
+	if err := json.Unmarshal(payload, &out); err != nil {
+		return "", fmt.Errorf("DescribeClusters: invalid response: %w", err)
+	}
+	for _, c := range out.Clusters {
+		if c.ClusterName != clusterName {
+			continue
+		}
+		e := c.ClusterDiscoveryEndpoint
+		if e == nil || e.Address == "" {
+			return "", fmt.Errorf("DescribeClusters: cluster %s has no discovery endpoint yet, its status is %s", clusterName, c.Status)
+		}
+		if e.URL != "" {
+			return e.URL, nil
+		}
+		scheme := "dax://"
+		if strings.EqualFold(c.ClusterEndpointEncryptionType, "TLS") {
+			scheme = "daxs://"
+		}
+		if e.Port == 0 {
+			return scheme + e.Address, nil
+		}
+		return scheme + e.Address + ":" + strconv.Itoa(e.Port), nil
+	}
+	return "", &smithy.GenericAPIError{
+		Code:    "ClusterNotFoundFault",
+		Message: fmt.Sprintf("DescribeClusters: cluster %s not found", clusterName),
+		Fault:   smithy.FaultClient,
+	}
+}
+
+// describeClusters sends a signed DescribeClusters request with body to url
+// and returns the payload of its response.
+func describeClusters(ctx context.Context, httpClient aws.HTTPClient, creds aws.Credentials, url, region string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonDAXV3.DescribeClusters")
+	hash := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "dax", region, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// The status and error codes tell the retryer what to retry.
+		return nil, &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: resp},
+				Err:      describeClustersError(resp.StatusCode, payload),
+			},
+			RequestID: resp.Header.Get("X-Amzn-Requestid"),
+		}
+	}
+	return payload, nil
+}
+
+// describeClustersError converts an error response of the control plane.
+func describeClustersError(status int, payload []byte) error {
+	var e struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload, &e); err != nil || e.Type == "" {
+		return fmt.Errorf("DescribeClusters: unexpected status %d: %s", status, payload)
+	}
+	code := e.Type
+	if i := strings.LastIndexByte(code, '#'); i >= 0 {
+		code = code[i+1:]
+	}
+	fault := smithy.FaultClient
+	if status >= 500 {
+		fault = smithy.FaultServer
+	}
+	return &smithy.GenericAPIError{Code: code, Message: "DescribeClusters: " + e.Message, Fault: fault}
+}
@@ -0,0 +1,155 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCredentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+})
+
+func TestDescribeClusterEndpoint(t *testing.T) {
+	var requests []map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "AmazonDAXV3.DescribeClusters", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"), "the request is signed")
+		var in map[string][]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		requests = append(requests, in)
+		switch in["ClusterNames"][0] {
+		case "plain":
+			w.Write([]byte(`{"Clusters":[{"ClusterName":"plain","Status":"available","ClusterEndpointEncryptionType":"NONE",
+				"ClusterDiscoveryEndpoint":{"Address":"plain.abc.dax-clusters.us-west-2.amazonaws.com","Port":8111}}]}`))
+		case "secure":
+			w.Write([]byte(`{"Clusters":[{"ClusterName":"secure","Status":"available","ClusterEndpointEncryptionType":"TLS",
+				"ClusterDiscoveryEndpoint":{"Address":"secure.abc.dax-clusters.us-west-2.amazonaws.com","Port":9111}}]}`))
+		case "url":
+			w.Write([]byte(`{"Clusters":[{"ClusterName":"url","Status":"available","ClusterEndpointEncryptionType":"TLS",
+				"ClusterDiscoveryEndpoint":{"Address":"url.abc.dax-clusters.us-west-2.amazonaws.com","Port":9111,
+				"URL":"daxs://url.abc.dax-clusters.us-west-2.amazonaws.com"}}]}`))
+		case "creating":
+			w.Write([]byte(`{"Clusters":[{"ClusterName":"creating","Status":"creating"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dax.v20170419#ClusterNotFoundFault","message":"Cluster not found."}`))
+		}
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Region = "us-west-2"
+	cfg.Credentials = testCredentials
+	cfg.ControlPlaneEndpoint = server.URL
+
+	cases := map[string]string{
+		"plain":  "dax://plain.abc.dax-clusters.us-west-2.amazonaws.com:8111",
+		"secure": "daxs://secure.abc.dax-clusters.us-west-2.amazonaws.com:9111",
+		"url":    "daxs://url.abc.dax-clusters.us-west-2.amazonaws.com",
+	}
+	for name, expected := range cases {
+		endpoint, err := describeClusterEndpoint(context.Background(), cfg, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, endpoint)
+	}
+	assert.Len(t, requests, len(cases))
+
+	_, err := describeClusterEndpoint(context.Background(), cfg, "creating")
+	assert.ErrorContains(t, err, "status is creating")
+
+	_, err = describeClusterEndpoint(context.Background(), cfg, "missing")
+	var apiErr smithy.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ClusterNotFoundFault", apiErr.ErrorCode())
+
+	_, err = describeClusterEndpoint(context.Background(), cfg, "")
+	assert.Error(t, err)
+	cfg.Credentials = nil
+	_, err = describeClusterEndpoint(context.Background(), cfg, "plain")
+	assert.Error(t, err)
+}
+
+func TestDescribeClusterEndpoint_retries(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests++; requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"__type":"ServiceUnavailableFault","message":"try again"}`))
+			return
+		}
+		w.Write([]byte(`{"Clusters":[{"ClusterName":"c","ClusterEndpointEncryptionType":"NONE",
+			"ClusterDiscoveryEndpoint":{"Address":"127.0.0.1","Port":8111}}]}`))
+	}))
+	defer server.Close()
+
+	var sent int
+	cfg := NewConfig(aws.Config{
+		Region:      "us-west-2",
+		Credentials: testCredentials,
+		HTTPClient: httpClientFunc(func(r *http.Request) (*http.Response, error) {
+			sent++
+			return http.DefaultClient.Do(r)
+		}),
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})
+		},
+	}, "")
+	cfg.ControlPlaneEndpoint = server.URL
+
+	endpoint, err := describeClusterEndpoint(context.Background(), cfg, "c")
+	require.NoError(t, err)
+	assert.Equal(t, "dax://127.0.0.1:8111", endpoint)
+	assert.Equal(t, 2, requests, "the unavailable control plane is retried")
+	assert.Equal(t, 2, sent, "requests are sent with the configured HTTP client")
+
+	cfg.ControlPlaneRetryer = func() aws.Retryer { return aws.NopRetryer{} }
+	requests = 0
+	_, err = describeClusterEndpoint(context.Background(), cfg, "c")
+	var apiErr smithy.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "ServiceUnavailableFault", apiErr.ErrorCode())
+	assert.Equal(t, 1, requests)
+}
+
+func TestNewFromClusterName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Clusters":[{"ClusterName":"c","ClusterEndpointEncryptionType":"NONE",
+			"ClusterDiscoveryEndpoint":{"Address":"127.0.0.1","Port":8111}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := NewConfig(aws.Config{Region: "us-west-2", Credentials: testCredentials}, "")
+	cfg.ControlPlaneEndpoint = server.URL
+	d, err := NewFromClusterName(context.Background(), cfg, "c")
+	require.NoError(t, err)
+	defer d.Close()
+	assert.Equal(t, []string{"dax://127.0.0.1:8111"}, d.config.HostPorts)
+}
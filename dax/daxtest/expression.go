@@ -0,0 +1,441 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Function codes of encoded expressions, as written by the expression
+// encoder of the client.
+const (
+	opEqual = iota
+	opNotEqual
+	opLessThan
+	opGreaterEqual
+	opGreaterThan
+	opLessEqual
+	opAnd
+	opOr
+	opNot
+	opBetween
+	opIn
+	opAttributeExists
+	opAttributeNotExists
+	opAttributeType
+	opBeginsWith
+	opContains
+	opSize
+	opVariable
+	opDocumentPath
+)
+
+// expression is a decoded condition or projection expression: nested
+// []any of function codes, int64, and path elements, string, with the
+// values its variables refer to.
+type expression struct {
+	root   any
+	values []types.AttributeValue
+}
+
+func decodeExpression(b []byte) (*expression, error) {
+	r := cbor.NewSliceReader(b)
+	n, err := r.ReadArrayLength()
+	if err != nil {
+		return nil, err
+	}
+	if n != 2 && n != 3 {
+		return nil, fmt.Errorf("expected 2 or 3 expression elements, got %d", n)
+	}
+	if _, err := r.ReadInt(); err != nil {
+		return nil, err
+	}
+	e := &expression{}
+	if e.root, err = decodeSExpr(r); err != nil {
+		return nil, err
+	}
+	if n == 3 {
+		l, err := r.ReadArrayLength()
+		if err != nil {
+			return nil, err
+		}
+		e.values = make([]types.AttributeValue, l)
+		for i := range e.values {
+			if e.values[i], err = cbor.DecodeAttributeValue(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e, nil
+}
+
+func decodeSExpr(r *cbor.Reader) (any, error) {
+	hdr, err := r.PeekHeader()
+	if err != nil {
+		return nil, err
+	}
+	switch hdr & cbor.MajorTypeMask {
+	case cbor.PosInt, cbor.NegInt:
+		return r.ReadInt64()
+	case cbor.Utf:
+		return r.ReadString()
+	case cbor.Array:
+		n, err := r.ReadArrayLength()
+		if err != nil {
+			return nil, err
+		}
+		l := make([]any, n)
+		for i := range l {
+			if l[i], err = decodeSExpr(r); err != nil {
+				return nil, err
+			}
+		}
+		return l, nil
+	}
+	return nil, unsupported("list element paths")
+}
+
+// paths returns the document paths of a projection expression.
+func (e *expression) paths() ([][]string, error) {
+	l, ok := e.root.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a list of paths")
+	}
+	paths := make([][]string, len(l))
+	for i, p := range l {
+		var err error
+		if paths[i], err = documentPath(p); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// matches evaluates a condition expression against item.
+func (e *expression) matches(item map[string]types.AttributeValue) (bool, error) {
+	return e.condition(e.root, item)
+}
+
+func (e *expression) condition(node any, item map[string]types.AttributeValue) (bool, error) {
+	fn, args, err := function(node)
+	if err != nil {
+		return false, err
+	}
+	switch fn {
+	case opAnd, opOr:
+		if len(args) != 2 {
+			return false, fmt.Errorf("expected 2 operands, got %d", len(args))
+		}
+		a, err := e.condition(args[0], item)
+		if err != nil || a == (fn == opOr) {
+			return a, err
+		}
+		return e.condition(args[1], item)
+	case opNot:
+		if len(args) != 1 {
+			return false, fmt.Errorf("expected 1 operand, got %d", len(args))
+		}
+		a, err := e.condition(args[0], item)
+		return !a, err
+	case opAttributeExists, opAttributeNotExists:
+		v, err := e.operand(args, 0, item)
+		return (v != nil) == (fn == opAttributeExists), err
+	}
+
+	ops, err := e.operands(args, item)
+	if err != nil || ops[0] == nil {
+		return false, err
+	}
+	switch fn {
+	case opEqual, opNotEqual, opLessThan, opGreaterEqual, opGreaterThan, opLessEqual:
+		if len(ops) != 2 {
+			return false, fmt.Errorf("expected 2 operands, got %d", len(ops))
+		}
+		if fn == opEqual || fn == opNotEqual {
+			return equal(ops[0], ops[1]) == (fn == opEqual), nil
+		}
+		c, ok := compare(ops[0], ops[1])
+		if !ok {
+			return false, nil
+		}
+		switch fn {
+		case opLessThan:
+			return c < 0, nil
+		case opLessEqual:
+			return c <= 0, nil
+		case opGreaterThan:
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case opBetween:
+		if len(ops) != 3 {
+			return false, fmt.Errorf("expected 3 operands, got %d", len(ops))
+		}
+		lo, ok1 := compare(ops[0], ops[1])
+		hi, ok2 := compare(ops[0], ops[2])
+		return ok1 && ok2 && lo >= 0 && hi <= 0, nil
+	case opIn:
+		for _, v := range ops[1:] {
+			if equal(ops[0], v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case opBeginsWith:
+		switch v := ops[0].(type) {
+		case *types.AttributeValueMemberS:
+			p, ok := ops[1].(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(v.Value, p.Value), nil
+		case *types.AttributeValueMemberB:
+			p, ok := ops[1].(*types.AttributeValueMemberB)
+			return ok && bytes.HasPrefix(v.Value, p.Value), nil
+		}
+		return false, nil
+	case opContains:
+		return contains(ops[0], ops[1]), nil
+	case opAttributeType:
+		t, ok := ops[1].(*types.AttributeValueMemberS)
+		return ok && typeName(ops[0]) == t.Value, nil
+	}
+	return false, unsupported(fmt.Sprintf("expression function %d", fn))
+}
+
+// operands evaluates args, which the in operator passes as a nested list.
+func (e *expression) operands(args []any, item map[string]types.AttributeValue) ([]types.AttributeValue, error) {
+	if len(args) == 2 {
+		if l, ok := args[1].([]any); ok && len(l) > 0 {
+			if _, nested := l[0].([]any); nested {
+				args = append(args[:1:1], l...)
+			}
+		}
+	}
+	ops := make([]types.AttributeValue, len(args))
+	for i := range args {
+		var err error
+		if ops[i], err = e.operand(args, i, item); err != nil {
+			return nil, err
+		}
+	}
+	return ops, nil
+}
+
+// operand evaluates args[i] to a value, nil for a missing attribute.
+func (e *expression) operand(args []any, i int, item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	if i >= len(args) {
+		return nil, fmt.Errorf("expected at least %d operands, got %d", i+1, len(args))
+	}
+	fn, fargs, err := function(args[i])
+	if err != nil {
+		return nil, err
+	}
+	switch fn {
+	case opVariable:
+		if len(fargs) != 1 {
+			return nil, fmt.Errorf("expected a variable ID")
+		}
+		id, ok := fargs[0].(int64)
+		if !ok || id < 0 || id >= int64(len(e.values)) {
+			return nil, fmt.Errorf("unknown variable %v", fargs[0])
+		}
+		return e.values[id], nil
+	case opDocumentPath:
+		path, err := documentPath(args[i])
+		if err != nil {
+			return nil, err
+		}
+		return lookup(item, path), nil
+	case opSize:
+		v, err := e.operand(fargs, 0, item)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		return size(v), nil
+	}
+	return nil, unsupported(fmt.Sprintf("expression operand %d", fn))
+}
+
+func function(node any) (int64, []any, error) {
+	l, ok := node.([]any)
+	if !ok || len(l) == 0 {
+		return 0, nil, fmt.Errorf("expected a function, got %v", node)
+	}
+	fn, ok := l[0].(int64)
+	if !ok {
+		return 0, nil, fmt.Errorf("expected a function code, got %v", l[0])
+	}
+	return fn, l[1:], nil
+}
+
+func documentPath(node any) ([]string, error) {
+	fn, args, err := function(node)
+	if err != nil {
+		return nil, err
+	}
+	if fn != opDocumentPath || len(args) == 0 {
+		return nil, fmt.Errorf("expected a document path, got %v", node)
+	}
+	path := make([]string, len(args))
+	for i, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			return nil, unsupported("list element paths")
+		}
+		path[i] = s
+	}
+	return path, nil
+}
+
+// lookup returns the value at path in item, nil if there is none.
+func lookup(item map[string]types.AttributeValue, path []string) types.AttributeValue {
+	v, ok := item[path[0]]
+	if !ok {
+		return nil
+	}
+	for _, name := range path[1:] {
+		m, ok := v.(*types.AttributeValueMemberM)
+		if !ok {
+			return nil
+		}
+		if v, ok = m.Value[name]; !ok {
+			return nil
+		}
+	}
+	return v
+}
+
+// compare orders two strings, numbers or binaries of the same type.
+func compare(a, b types.AttributeValue) (int, bool) {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		if y, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(x.Value, y.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		if y, ok := b.(*types.AttributeValueMemberN); ok {
+			nx, ok1 := new(big.Rat).SetString(x.Value)
+			ny, ok2 := new(big.Rat).SetString(y.Value)
+			if ok1 && ok2 {
+				return nx.Cmp(ny), true
+			}
+		}
+	case *types.AttributeValueMemberB:
+		if y, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(x.Value, y.Value), true
+		}
+	}
+	return 0, false
+}
+
+func equal(a, b types.AttributeValue) bool {
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func contains(v, elem types.AttributeValue) bool {
+	switch x := v.(type) {
+	case *types.AttributeValueMemberS:
+		s, ok := elem.(*types.AttributeValueMemberS)
+		return ok && strings.Contains(x.Value, s.Value)
+	case *types.AttributeValueMemberB:
+		b, ok := elem.(*types.AttributeValueMemberB)
+		return ok && bytes.Contains(x.Value, b.Value)
+	case *types.AttributeValueMemberSS:
+		for _, s := range x.Value {
+			if equal(&types.AttributeValueMemberS{Value: s}, elem) {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberNS:
+		for _, n := range x.Value {
+			if equal(&types.AttributeValueMemberN{Value: n}, elem) {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range x.Value {
+			if equal(&types.AttributeValueMemberB{Value: b}, elem) {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberL:
+		for _, e := range x.Value {
+			if equal(e, elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func size(v types.AttributeValue) types.AttributeValue {
+	n := 0
+	switch x := v.(type) {
+	case *types.AttributeValueMemberS:
+		n = len(x.Value)
+	case *types.AttributeValueMemberB:
+		n = len(x.Value)
+	case *types.AttributeValueMemberSS:
+		n = len(x.Value)
+	case *types.AttributeValueMemberNS:
+		n = len(x.Value)
+	case *types.AttributeValueMemberBS:
+		n = len(x.Value)
+	case *types.AttributeValueMemberL:
+		n = len(x.Value)
+	case *types.AttributeValueMemberM:
+		n = len(x.Value)
+	default:
+		return nil
+	}
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(n)}
+}
+
+func typeName(v types.AttributeValue) string {
+	switch v.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	}
+	return ""
+}
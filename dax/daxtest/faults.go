@@ -0,0 +1,252 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+)
+
+// FaultInjector injects faults into the connections of a client to DAX
+// nodes, to exercise the retry and fallback logic of an application. Its
+// DialContext is set as the DialContext of the client configuration:
+//
+//	inj := daxtest.NewFaultInjector(nil)
+//	inj.Fail("GetItem", daxtest.ThrottlingFault("slow down"))
+//	inj.Delay("Query", 2*time.Second)
+//	cfg.DialContext = inj.DialContext
+//
+// Faults are set per operation, such as "GetItem", or for every operation
+// with the empty name. They apply to the nodes of a Server and of a cluster
+// alike.
+type FaultInjector struct {
+	dial func(ctx context.Context, network string, address string) (net.Conn, error)
+
+	mu     sync.Mutex
+	delays map[string]time.Duration
+	drops  map[string][]int
+	faults map[string][]Fault
+}
+
+// NewFaultInjector returns a FaultInjector connecting with dial, a
+// net.Dialer if nil. Use dax.SecureDialContext for encrypted clusters.
+func NewFaultInjector(dial func(ctx context.Context, network string, address string) (net.Conn, error)) *FaultInjector {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &FaultInjector{
+		dial:   dial,
+		delays: make(map[string]time.Duration),
+		drops:  make(map[string][]int),
+		faults: make(map[string][]Fault),
+	}
+}
+
+// Delay delays every response to op by d, until Reset. The delay counts
+// towards the timeout of the request.
+func (inj *FaultInjector) Delay(op string, d time.Duration) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.delays[op] = d
+}
+
+// DropAfter closes the connection of the next request of op after n bytes
+// of its response were read.
+func (inj *FaultInjector) DropAfter(op string, n int) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.drops[op] = append(inj.drops[op], n)
+}
+
+// Fail answers the next request of op with f instead of sending it to the
+// node. Faults injected for the same operation answer successive requests
+// in order.
+func (inj *FaultInjector) Fail(op string, f Fault) {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.faults[op] = append(inj.faults[op], f)
+}
+
+// Reset removes all faults.
+func (inj *FaultInjector) Reset() {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	clear(inj.delays)
+	clear(inj.drops)
+	clear(inj.faults)
+}
+
+// DialContext connects to address through a connection injecting faults.
+func (inj *FaultInjector) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	conn, err := inj.dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &faultConn{Conn: conn, injector: inj, drop: -1, wake: make(chan struct{})}, nil
+}
+
+// plan is the faults of a request.
+type plan struct {
+	delay time.Duration
+	drop  int // -1 for none
+	fault *Fault
+}
+
+// next returns the faults of the next request of op, those for op first.
+func (inj *FaultInjector) next(op string) plan {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	p := plan{drop: -1}
+	for _, o := range []string{op, ""} {
+		if d, ok := inj.delays[o]; ok && p.delay == 0 {
+			p.delay = d
+		}
+		if q := inj.drops[o]; len(q) > 0 && p.drop < 0 {
+			p.drop, inj.drops[o] = q[0], q[1:]
+		}
+		if q := inj.faults[o]; len(q) > 0 && p.fault == nil {
+			p.fault, inj.faults[o] = &q[0], q[1:]
+		}
+	}
+	return p
+}
+
+// faultConn holds the requests written to it until the response is read,
+// to answer them with a fault instead of sending them.
+type faultConn struct {
+	net.Conn
+	injector *FaultInjector
+
+	request  []byte
+	response *bytes.Reader // answer of an injected fault
+	drop     int           // bytes to read before closing, -1 for none
+
+	mu       sync.Mutex
+	deadline time.Time
+	wake     chan struct{} // closed when the deadline changes
+}
+
+func (c *faultConn) Write(p []byte) (int, error) {
+	c.request = append(c.request, p...)
+	return len(p), nil
+}
+
+func (c *faultConn) Read(p []byte) (int, error) {
+	if len(c.request) > 0 {
+		if err := c.send(); err != nil {
+			return 0, err
+		}
+	}
+	if c.response != nil && c.response.Len() > 0 {
+		return c.response.Read(p)
+	}
+	c.response = nil
+	if c.drop == 0 {
+		c.Conn.Close()
+		return 0, io.EOF
+	}
+	if c.drop > 0 && len(p) > c.drop {
+		p = p[:c.drop]
+	}
+	n, err := c.Conn.Read(p)
+	if c.drop > 0 {
+		c.drop -= n
+	}
+	return n, err
+}
+
+// send sends the request written, or answers it with a fault, and waits
+// for the delay of its operation.
+func (c *faultConn) send() error {
+	op, request, err := requestKey(c.request)
+	if err != nil {
+		op = ""
+	}
+	p := c.injector.next(op)
+	out := c.request
+	if p.fault != nil {
+		// The connection preamble and authorization still reach the node.
+		out = c.request[:len(c.request)-len(request)]
+		var buf bytes.Buffer
+		w := cbor.NewWriter(&buf)
+		writeError(w, p.fault)
+		w.Flush()
+		w.Close()
+		c.response = bytes.NewReader(buf.Bytes())
+	}
+	c.request = nil
+	if len(out) > 0 {
+		if _, err := c.Conn.Write(out); err != nil {
+			return err
+		}
+	}
+	c.drop = p.drop
+	if p.delay > 0 {
+		return c.wait(p.delay)
+	}
+	return nil
+}
+
+// wait waits for d, or until the read deadline.
+func (c *faultConn) wait(d time.Duration) error {
+	until := time.Now().Add(d)
+	for {
+		c.mu.Lock()
+		deadline, wake := c.deadline, c.wake
+		c.mu.Unlock()
+		if !deadline.IsZero() && !deadline.After(time.Now()) {
+			return os.ErrDeadlineExceeded
+		}
+		wait := time.Until(until)
+		if wait <= 0 {
+			return nil
+		}
+		if !deadline.IsZero() && time.Until(deadline) < wait {
+			wait = time.Until(deadline)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-wake:
+			t.Stop()
+		}
+	}
+}
+
+func (c *faultConn) SetDeadline(t time.Time) error {
+	c.setReadDeadline(t)
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *faultConn) SetReadDeadline(t time.Time) error {
+	c.setReadDeadline(t)
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *faultConn) setReadDeadline(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	close(c.wake)
+	c.wake = make(chan struct{})
+}
@@ -0,0 +1,88 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	require.NoError(t, srv.CreateTable("orders",
+		types.AttributeDefinition{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS},
+		types.AttributeDefinition{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeN},
+	))
+	require.NoError(t, srv.PutItem("orders", order("alice", 1, "open")))
+
+	inj := NewFaultInjector(nil)
+	cfg := srv.Config()
+	cfg.DialContext = inj.DialContext
+	client, err := dax.New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	get := &dynamodb.GetItemInput{
+		TableName: aws.String("orders"),
+		Key: map[string]types.AttributeValue{
+			"customer": &types.AttributeValueMemberS{Value: "alice"},
+			"order":    &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+	noRetries := dax.WithRetryMaxAttempts(0)
+
+	inj.Fail("GetItem", ConditionalCheckFailedFault("injected"))
+	_, err = client.GetItem(ctx, get, noRetries)
+	var ccf *types.ConditionalCheckFailedException
+	assert.True(t, errors.As(err, &ccf), "got %v", err)
+	out, err := client.GetItem(ctx, get, noRetries)
+	require.NoError(t, err)
+	assert.Equal(t, order("alice", 1, "open"), out.Item)
+
+	inj.Fail("", ConditionalCheckFailedFault("injected"))
+	_, err = client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("orders")}, noRetries)
+	assert.True(t, errors.As(err, &ccf), "got %v", err)
+
+	inj.DropAfter("GetItem", 2)
+	_, err = client.GetItem(ctx, get, noRetries)
+	assert.Error(t, err)
+	out, err = client.GetItem(ctx, get, noRetries)
+	require.NoError(t, err)
+	assert.Equal(t, order("alice", 1, "open"), out.Item)
+
+	inj.Delay("GetItem", time.Minute)
+	tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.GetItem(tctx, get, noRetries)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	inj.Reset()
+	_, err = client.GetItem(ctx, get, noRetries)
+	require.NoError(t, err)
+}
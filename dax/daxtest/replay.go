@@ -0,0 +1,326 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// authorizeConnectionArgs is the number of arguments of AuthorizeConnection.
+const authorizeConnectionArgs = 5
+
+// recording is the file format of a Recorder.
+type recording struct {
+	Exchanges []exchange `json:"exchanges"`
+}
+
+// exchange is a request, without the connection preamble and authorization,
+// and the response the node answered it with.
+type exchange struct {
+	Method   string `json:"method"`
+	Request  []byte `json:"request"`
+	Response []byte `json:"response"`
+}
+
+// requestKey returns the method of a request frame and the request without
+// the connection preamble and the authorization it may start with.
+func requestKey(frame []byte) (string, []byte, error) {
+	var err error
+	if client.HasPreamble(frame) {
+		if frame, err = cbor.SkipItems(frame, client.PreambleItems); err != nil {
+			return "", nil, err
+		}
+	}
+	for {
+		r := cbor.NewSliceReader(frame)
+		if _, err := r.ReadInt64(); err != nil { // service
+			return "", nil, err
+		}
+		id, err := r.ReadInt64()
+		if err != nil {
+			return "", nil, err
+		}
+		method := client.MethodName(id)
+		if method != "AuthorizeConnection" {
+			return method, frame, nil
+		}
+		if frame, err = cbor.SkipItems(frame, 2+authorizeConnectionArgs); err != nil {
+			return "", nil, err
+		}
+	}
+}
+
+// Recorder captures the requests of a client and the responses of the DAX
+// nodes it connects to, to serve them back with a Replayer. Its DialContext
+// is set as the DialContext of the client configuration:
+//
+//	rec := daxtest.NewRecorder(nil)
+//	cfg.DialContext = rec.DialContext
+//	client, err := dax.New(cfg)
+//	...
+//	err = rec.Save("testdata/orders.json")
+//
+// The capture holds the items read and written in clear text, but not the
+// credentials, of which only the signature is sent.
+type Recorder struct {
+	dial func(ctx context.Context, network string, address string) (net.Conn, error)
+
+	mu        sync.Mutex
+	exchanges []exchange
+	conns     map[*recordingConn]struct{}
+}
+
+// NewRecorder returns a Recorder connecting with dial, a net.Dialer if nil.
+// Use dax.SecureDialContext for encrypted clusters.
+func NewRecorder(dial func(ctx context.Context, network string, address string) (net.Conn, error)) *Recorder {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return &Recorder{dial: dial, conns: make(map[*recordingConn]struct{})}
+}
+
+// DialContext connects to address and records the traffic of the connection.
+func (r *Recorder) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	conn, err := r.dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	c := &recordingConn{Conn: conn, rec: r}
+	r.mu.Lock()
+	r.conns[c] = struct{}{}
+	r.mu.Unlock()
+	return c, nil
+}
+
+// Save writes the exchanges recorded so far to the file at path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	rec := recording{Exchanges: append([]exchange(nil), r.exchanges...)}
+	for c := range r.conns {
+		if e, ok := c.pending(); ok {
+			rec.Exchanges = append(rec.Exchanges, e)
+		}
+	}
+	r.mu.Unlock()
+
+	b, err := json.MarshalIndent(&rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// recordingConn records the requests written to a connection, each up to
+// the first read following it, and the responses read, each up to the next
+// write: the client waits for the response to a request before sending the
+// next one on a connection.
+type recordingConn struct {
+	net.Conn
+	rec *Recorder
+
+	request, response []byte // guarded by rec.mu
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.rec.mu.Lock()
+	if e, ok := c.pending(); ok {
+		c.rec.exchanges = append(c.rec.exchanges, e)
+		c.request, c.response = nil, nil
+	}
+	c.request = append(c.request, p[:n]...)
+	c.rec.mu.Unlock()
+	return n, err
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.rec.mu.Lock()
+	c.response = append(c.response, p[:n]...)
+	c.rec.mu.Unlock()
+	return n, err
+}
+
+func (c *recordingConn) Close() error {
+	c.rec.mu.Lock()
+	if e, ok := c.pending(); ok {
+		c.rec.exchanges = append(c.rec.exchanges, e)
+	}
+	c.request, c.response = nil, nil
+	delete(c.rec.conns, c)
+	c.rec.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// pending returns the exchange in progress, if it has been answered.
+// c.rec.mu must be held.
+func (c *recordingConn) pending() (exchange, bool) {
+	if len(c.response) == 0 {
+		return exchange{}, false
+	}
+	method, request, err := requestKey(c.request)
+	if err != nil {
+		return exchange{}, false
+	}
+	return exchange{
+		Method:   method,
+		Request:  bytes.Clone(request),
+		Response: bytes.Clone(c.response),
+	}, true
+}
+
+// Replayer answers the requests of a client with the responses saved by a
+// Recorder, without connecting to any node. Requests are matched by their
+// encoding, so the client must send the requests recorded, in the same
+// order for requests sent more than once; the last response recorded for a
+// request answers it again after the others were used. Requests which were
+// not recorded fail with a ValidationException.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][][]byte
+}
+
+// NewReplayer returns a Replayer of the exchanges saved in the file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec recording
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("daxtest: invalid recording %s: %w", path, err)
+	}
+	r := &Replayer{responses: make(map[string][][]byte)}
+	for _, e := range rec.Exchanges {
+		key := string(e.Request)
+		r.responses[key] = append(r.responses[key], e.Response)
+	}
+	return r, nil
+}
+
+// Config returns the configuration of a client replaying the recording,
+// with static credentials. Its host port is never connected to.
+func (r *Replayer) Config() dax.Config {
+	cfg := dax.DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.DialContext = r.DialContext
+	cfg.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "daxtest", SecretAccessKey: "daxtest", Source: "daxtest"}, nil
+	})
+	return cfg
+}
+
+// DialContext returns a connection to a replayed node, whatever the address.
+func (r *Replayer) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &replayConn{replayer: r, remote: replayAddr(address)}, nil
+}
+
+// respond returns the response to a request frame.
+func (r *Replayer) respond(frame []byte) []byte {
+	method, request, err := requestKey(frame)
+	if err == nil {
+		r.mu.Lock()
+		q := r.responses[string(request)]
+		if len(q) > 1 {
+			r.responses[string(request)] = q[1:]
+		}
+		r.mu.Unlock()
+		if len(q) > 0 {
+			return q[0]
+		}
+		err = unsupported("replaying a request of " + method + " which was not recorded")
+	}
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	defer w.Close()
+	writeError(w, err)
+	w.Flush()
+	return buf.Bytes()
+}
+
+// replayConn answers the request written to it since the last read with the
+// recorded response.
+type replayConn struct {
+	replayer *Replayer
+	remote   replayAddr
+
+	mu                sync.Mutex
+	closed            bool
+	request, response []byte
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.request = append(c.request, p...)
+	return len(p), nil
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if len(c.response) == 0 && len(c.request) > 0 {
+		c.response = c.replayer.respond(c.request)
+		c.request = nil
+	}
+	if len(c.response) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.response)
+	c.response = c.response[n:]
+	return n, nil
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *replayConn) LocalAddr() net.Addr                { return replayAddr("127.0.0.1:0") }
+func (c *replayConn) RemoteAddr() net.Addr               { return c.remote }
+func (c *replayConn) SetDeadline(t time.Time) error      { return nil }
+func (c *replayConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *replayConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type replayAddr string
+
+func (a replayAddr) Network() string { return "tcp" }
+func (a replayAddr) String() string  { return string(a) }
@@ -0,0 +1,99 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderReplayer(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.json")
+	key := map[string]types.AttributeValue{
+		"customer": &types.AttributeValueMemberS{Value: "alice"},
+		"order":    &types.AttributeValueMemberN{Value: "1"},
+	}
+	get := &dynamodb.GetItemInput{TableName: aws.String("orders"), Key: key}
+	put := &dynamodb.PutItemInput{TableName: aws.String("orders"), Item: order("alice", 1, "open")}
+	conditionalPut := &dynamodb.PutItemInput{
+		TableName:           aws.String("orders"),
+		Item:                order("alice", 1, "closed"),
+		ConditionExpression: aws.String("attribute_not_exists(customer)"),
+	}
+
+	srv := NewServer()
+	require.NoError(t, srv.CreateTable("orders",
+		types.AttributeDefinition{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS},
+		types.AttributeDefinition{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeN},
+	))
+	rec := NewRecorder(nil)
+	cfg := srv.Config()
+	cfg.DialContext = rec.DialContext
+	client, err := dax.New(cfg)
+	require.NoError(t, err)
+	out, err := client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Nil(t, out.Item)
+	_, err = client.PutItem(ctx, put)
+	require.NoError(t, err)
+	out, err = client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Equal(t, put.Item, out.Item)
+	_, err = client.PutItem(ctx, conditionalPut)
+	require.Error(t, err)
+	require.NoError(t, client.Close())
+	srv.Close()
+	require.NoError(t, rec.Save(path))
+
+	rep, err := NewReplayer(path)
+	require.NoError(t, err)
+	client, err = dax.New(rep.Config())
+	require.NoError(t, err)
+	defer client.Close()
+	out, err = client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Nil(t, out.Item)
+	_, err = client.PutItem(ctx, put)
+	require.NoError(t, err)
+	out, err = client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Equal(t, put.Item, out.Item)
+	out, err = client.GetItem(ctx, get)
+	require.NoError(t, err, "the last response is replayed again")
+	assert.Equal(t, put.Item, out.Item)
+	_, err = client.PutItem(ctx, conditionalPut)
+	var ccf *types.ConditionalCheckFailedException
+	assert.True(t, errors.As(err, &ccf), "got %v", err)
+
+	_, err = client.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String("orders"), Key: key})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not recorded")
+}
+
+func TestNewReplayer_invalid(t *testing.T) {
+	_, err := NewReplayer(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
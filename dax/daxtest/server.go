@@ -0,0 +1,1012 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+// Package daxtest provides an in-process DAX node for hermetic tests of code
+// using the dax client, in the manner of net/http/httptest.
+//
+// The server speaks the part of the DAX protocol the client uses for
+// GetItem, PutItem, DeleteItem, Query and Scan on the tables created with
+// CreateTable, whose items it keeps in memory. Key condition, condition and
+// filter expressions are evaluated for top-level and nested map attributes.
+// Other operations, and features such as secondary indexes, update
+// expressions or list element paths, fail with a ValidationException.
+// Any credentials are accepted.
+//
+// Recorder and Replayer capture the traffic of a client with a cluster and
+// serve it back, to test code against the recorded behavior of real nodes.
+// FaultInjector delays, drops or fails requests of a client, to test how
+// code copes with them.
+//
+//	srv := daxtest.NewServer()
+//	defer srv.Close()
+//	srv.CreateTable("orders", types.AttributeDefinition{
+//		AttributeName: aws.String("id"),
+//		AttributeType: types.ScalarAttributeTypeS,
+//	})
+//	client, err := dax.New(srv.Config())
+package daxtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Parameters of requests and responses, as numbered by the client.
+const (
+	paramProjectionExpression = 0
+	paramConditionExpression  = 4
+	paramReturnValues         = 7
+	paramUpdateExpression     = 8
+	paramExclusiveStartKey    = 9
+	paramFilterExpression     = 10
+	paramIndexName            = 11
+	paramLimit                = 13
+	paramScanIndexForward     = 14
+	paramSelect               = 15
+	paramSegment              = 16
+	paramTotalSegments        = 17
+
+	responseItem             = 0
+	responseAttributes       = 2
+	responseItems            = 7
+	responseCount            = 8
+	responseLastEvaluatedKey = 9
+	responseScannedCount     = 10
+
+	returnValueNone   = 1
+	returnValueAllOld = 2
+	selectCount       = 3
+	roleLeader        = 1
+)
+
+// Fault is an error the server answers a request with.
+type Fault struct {
+	// Codes is the DAX error code sequence, which selects the error the
+	// client returns, see dax.DaxError.
+	Codes   []int
+	Message string
+	// ErrorCode and StatusCode are the DynamoDB error code and HTTP status
+	// code reported along, if set.
+	ErrorCode  string
+	StatusCode int
+}
+
+func (f *Fault) Error() string {
+	return f.Message
+}
+
+// ValidationFault returns a fault the client reports as a ValidationException.
+func ValidationFault(msg string) Fault {
+	return Fault{Codes: []int{4, 37, 38, 39, 46}, Message: msg, ErrorCode: "ValidationException", StatusCode: 400}
+}
+
+// ResourceNotFoundFault returns a fault the client reports as a ResourceNotFoundException.
+func ResourceNotFoundFault(msg string) Fault {
+	return Fault{Codes: []int{4, 37, 38, 39, 41}, Message: msg, ErrorCode: "ResourceNotFoundException", StatusCode: 400}
+}
+
+// ConditionalCheckFailedFault returns a fault the client reports as a ConditionalCheckFailedException.
+func ConditionalCheckFailedFault(msg string) Fault {
+	return Fault{Codes: []int{4, 37, 38, 39, 43}, Message: msg, ErrorCode: "ConditionalCheckFailedException", StatusCode: 400}
+}
+
+// ThrottlingFault returns a fault the client reports as a ThrottlingException.
+func ThrottlingFault(msg string) Fault {
+	return Fault{Codes: []int{4, 37, 38, 39, 50}, Message: msg, ErrorCode: "ThrottlingException", StatusCode: 400}
+}
+
+// InternalServerErrorFault returns a fault the client reports as an InternalServerError.
+func InternalServerErrorFault(msg string) Fault {
+	return Fault{Codes: []int{4, 37, 38, 39, 47}, Message: msg, ErrorCode: "InternalServerError", StatusCode: 500}
+}
+
+func unsupported(what string) error {
+	f := ValidationFault("daxtest: " + what + " is not supported")
+	return &f
+}
+
+// errClose ends a connection whose input cannot be parsed any further.
+var errClose = errors.New("daxtest: closing connection")
+
+// Server is a DAX node listening on a loopback address.
+type Server struct {
+	// Addr is the host:port the server listens on, to add to the HostPorts
+	// of clients.
+	Addr string
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+	conns  map[net.Conn]struct{}
+	tables map[string]*table
+	faults map[string][]Fault
+
+	listMu    sync.Mutex
+	lists     [][]string
+	listIds   map[lru.StringsKey]int64
+	namesToId *lru.Lru[lru.StringsKey, int64]
+	idToNames *lru.Lru[int64, []string]
+}
+
+// NewServer starts a server on a loopback address. It panics if it cannot
+// listen. The server must be closed with Close.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("daxtest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Addr:     l.Addr().String(),
+		listener: l,
+		conns:    make(map[net.Conn]struct{}),
+		tables:   make(map[string]*table),
+		faults:   make(map[string][]Fault),
+		listIds:  make(map[lru.StringsKey]int64),
+	}
+	s.namesToId = &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(_ context.Context, names lru.StringsKey) (int64, error) {
+			return s.attributeListId(names), nil
+		},
+	}
+	s.idToNames = &lru.Lru[int64, []string]{
+		LoadFunc: func(_ context.Context, id int64) ([]string, error) {
+			return s.attributeList(id)
+		},
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s
+}
+
+// Config returns the configuration of a client connecting to the server,
+// with static credentials.
+func (s *Server) Config() dax.Config {
+	cfg := dax.DefaultConfig()
+	cfg.HostPorts = []string{s.Addr}
+	cfg.Region = "us-west-2"
+	cfg.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "daxtest", SecretAccessKey: "daxtest", Source: "daxtest"}, nil
+	})
+	return cfg
+}
+
+// Close stops the server and closes its connections.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		s.listener.Close()
+		for c := range s.conns {
+			c.Close()
+		}
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// CreateTable creates an empty table with the given key attributes, the
+// hash key first and the range key, if any, second.
+func (s *Server) CreateTable(name string, keySchema ...types.AttributeDefinition) error {
+	if name == "" {
+		return errors.New("daxtest: table name is required")
+	}
+	if len(keySchema) < 1 || len(keySchema) > 2 {
+		return fmt.Errorf("daxtest: expected 1 or 2 key attributes, got %d", len(keySchema))
+	}
+	for _, k := range keySchema {
+		switch k.AttributeType {
+		case types.ScalarAttributeTypeS, types.ScalarAttributeTypeN, types.ScalarAttributeTypeB:
+		default:
+			return fmt.Errorf("daxtest: key attribute %s has invalid type %q", aws.ToString(k.AttributeName), k.AttributeType)
+		}
+		if aws.ToString(k.AttributeName) == "" {
+			return errors.New("daxtest: key attribute name is required")
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tables[name]; ok {
+		return fmt.Errorf("daxtest: table %s already exists", name)
+	}
+	s.tables[name] = &table{keys: keySchema, items: make(map[string]map[string]types.AttributeValue)}
+	return nil
+}
+
+// PutItem stores item in a table, replacing the item with the same key.
+func (s *Server) PutItem(tableName string, item map[string]types.AttributeValue) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName)
+	if err != nil {
+		return err
+	}
+	key, err := cbor.GetEncodedItemKey(item, t.keys)
+	if err != nil {
+		return err
+	}
+	t.items[string(key)] = copyItem(item)
+	return nil
+}
+
+// Item returns the item of a table with the given key, nil if there is none.
+func (s *Server) Item(tableName string, key map[string]types.AttributeValue) map[string]types.AttributeValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.table(tableName)
+	if err != nil {
+		return nil
+	}
+	encoded, err := cbor.GetEncodedItemKey(key, t.keys)
+	if err != nil {
+		return nil
+	}
+	if item, ok := t.items[string(encoded)]; ok {
+		return copyItem(item)
+	}
+	return nil
+}
+
+// InjectFault makes the next request of the operation op, e.g. "GetItem",
+// fail with f. Faults injected for the same operation fail successive
+// requests in order.
+func (s *Server) InjectFault(op string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[op] = append(s.faults[op], f)
+}
+
+func (s *Server) nextFault(op string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := s.faults[op]
+	if len(q) == 0 {
+		return nil
+	}
+	s.faults[op] = q[1:]
+	return &q[0]
+}
+
+// table returns the table called name, s.mu must be held.
+func (s *Server) table(name string) (*table, error) {
+	t, ok := s.tables[name]
+	if !ok {
+		f := ResourceNotFoundFault("Requested resource not found: Table: " + name + " not found")
+		return nil, &f
+	}
+	return t, nil
+}
+
+func (s *Server) attributeListId(names lru.StringsKey) int64 {
+	s.listMu.Lock()
+	defer s.listMu.Unlock()
+	if id, ok := s.listIds[names]; ok {
+		return id
+	}
+	id := int64(len(s.lists))
+	s.lists = append(s.lists, names.Strings())
+	s.listIds[names] = id
+	return id
+}
+
+func (s *Server) attributeList(id int64) ([]string, error) {
+	s.listMu.Lock()
+	defer s.listMu.Unlock()
+	if id < 0 || id >= int64(len(s.lists)) {
+		return nil, unsupported(fmt.Sprintf("attribute list %d", id))
+	}
+	return s.lists[id], nil
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	r := cbor.NewReader(conn)
+	defer r.Close()
+	w := cbor.NewWriter(bufio.NewWriter(conn))
+	defer w.Close()
+
+	for i := 0; i < client.PreambleItems; i++ {
+		if err := r.Skip(); err != nil {
+			return
+		}
+	}
+	for {
+		err := s.serveRequest(r, w)
+		if ferr := w.Flush(); err != nil || ferr != nil {
+			return
+		}
+	}
+}
+
+// handler reads the arguments of a request and writes its response. The
+// returned error ends the connection.
+type handler func(s *Server, op string, r *cbor.Reader, w *cbor.Writer) error
+
+var handlers = map[string]handler{
+	"AuthorizeConnection":       skipping(5, nil),
+	"Endpoints":                 (*Server).endpoints,
+	"DefineKeySchema":           (*Server).defineKeySchema,
+	"DefineAttributeListId":     (*Server).defineAttributeListId,
+	"DefineAttributeList":       (*Server).defineAttributeList,
+	client.OpGetItem:            (*Server).getItem,
+	client.OpPutItem:            (*Server).putItem,
+	client.OpDeleteItem:         (*Server).deleteItem,
+	client.OpQuery:              (*Server).query,
+	client.OpScan:               (*Server).scan,
+	client.OpUpdateItem:         skipping(3, unsupported("UpdateItem")),
+	client.OpBatchGetItem:       skipping(2, unsupported("BatchGetItem")),
+	client.OpBatchWriteItem:     skipping(2, unsupported("BatchWriteItem")),
+	client.OpTransactGetItems:   skipping(4, unsupported("TransactGetItems")),
+	client.OpTransactWriteItems: skipping(9, unsupported("TransactWriteItems")),
+}
+
+func (s *Server) serveRequest(r *cbor.Reader, w *cbor.Writer) error {
+	if _, err := r.ReadInt64(); err != nil { // service
+		return err
+	}
+	id, err := r.ReadInt64()
+	if err != nil {
+		return err
+	}
+	op := client.MethodName(id)
+	h, ok := handlers[op]
+	if !ok {
+		// The arguments of other methods cannot be told apart from the
+		// next request, the connection is closed after answering.
+		if op == "" {
+			op = "method " + strconv.FormatInt(id, 10)
+		}
+		if err := writeError(w, unsupported(op)); err != nil {
+			return err
+		}
+		return errClose
+	}
+	return h(s, op, r, w)
+}
+
+// skipping returns a handler which skips n arguments and answers with
+// err, nothing if it is nil.
+func skipping(n int, err error) handler {
+	return func(s *Server, op string, r *cbor.Reader, w *cbor.Writer) error {
+		for i := 0; i < n; i++ {
+			if err := r.Skip(); err != nil {
+				return err
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		return s.respond(w, op, func(*cbor.Writer) error { return err })
+	}
+}
+
+// respond writes the result of the operation op, written by exec with s.mu
+// held, or the error it failed with, or a fault injected for op.
+func (s *Server) respond(w *cbor.Writer, op string, exec func(w *cbor.Writer) error) error {
+	if f := s.nextFault(op); f != nil {
+		return writeError(w, f)
+	}
+	var buf bytes.Buffer
+	bw := cbor.NewWriter(&buf)
+	defer bw.Close()
+	s.mu.Lock()
+	err := exec(bw)
+	s.mu.Unlock()
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return writeError(w, err)
+	}
+	if err := w.WriteArrayHeader(0); err != nil {
+		return err
+	}
+	return w.Write(buf.Bytes())
+}
+
+func writeError(w *cbor.Writer, err error) error {
+	var f *Fault
+	if !errors.As(err, &f) {
+		vf := ValidationFault(err.Error())
+		f = &vf
+	}
+	if err := w.WriteArrayHeader(len(f.Codes)); err != nil {
+		return err
+	}
+	for _, c := range f.Codes {
+		if err := w.WriteInt(c); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteString(f.Message); err != nil {
+		return err
+	}
+	if err := w.WriteArrayHeader(3); err != nil {
+		return err
+	}
+	if err := w.WriteNull(); err != nil { // request ID
+		return err
+	}
+	if f.ErrorCode == "" {
+		if err := w.WriteNull(); err != nil {
+			return err
+		}
+	} else if err := w.WriteString(f.ErrorCode); err != nil {
+		return err
+	}
+	if f.StatusCode == 0 {
+		return w.WriteNull()
+	}
+	return w.WriteInt(f.StatusCode)
+}
+
+func (s *Server) endpoints(op string, r *cbor.Reader, w *cbor.Writer) error {
+	host, port, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		w.WriteArrayHeader(1)
+		w.WriteMapHeader(5)
+		w.WriteInt(0) // node ID
+		w.WriteInt(1)
+		w.WriteInt(1) // hostname
+		w.WriteString("localhost")
+		w.WriteInt(2) // address
+		w.WriteBytes(net.ParseIP(host).To4())
+		w.WriteInt(3) // port
+		w.WriteInt(p)
+		w.WriteInt(4) // role
+		return w.WriteInt(roleLeader)
+	})
+}
+
+func (s *Server) defineKeySchema(op string, r *cbor.Reader, w *cbor.Writer) error {
+	name, err := r.ReadBytes()
+	if err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		t, err := s.table(string(name))
+		if err != nil {
+			return err
+		}
+		if err := w.WriteMapHeader(len(t.keys)); err != nil {
+			return err
+		}
+		for _, k := range t.keys {
+			if err := w.WriteString(aws.ToString(k.AttributeName)); err != nil {
+				return err
+			}
+			if err := w.WriteString(string(k.AttributeType)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Server) defineAttributeListId(op string, r *cbor.Reader, w *cbor.Writer) error {
+	n, err := r.ReadArrayLength()
+	if err != nil {
+		return err
+	}
+	names := make([]string, n)
+	for i := range names {
+		if names[i], err = r.ReadString(); err != nil {
+			return err
+		}
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		return w.WriteInt64(s.attributeListId(lru.NewStringsKey(names)))
+	})
+}
+
+func (s *Server) defineAttributeList(op string, r *cbor.Reader, w *cbor.Writer) error {
+	id, err := r.ReadInt64()
+	if err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		names, err := s.attributeList(id)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteArrayHeader(len(names)); err != nil {
+			return err
+		}
+		for _, n := range names {
+			if err := w.WriteString(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// request holds the arguments of a data operation.
+type request struct {
+	table                    string
+	key, item, keyCondition  []byte
+	projection, condition    []byte
+	filter, update, startKey []byte
+	indexName                string
+	returnValues             int
+	selection, limit         int
+	segment, totalSegments   int
+	backward                 bool
+}
+
+// readTable reads the table name argument.
+func (req *request) readTable(r *cbor.Reader) error {
+	b, err := r.ReadBytes()
+	req.table = string(b)
+	return err
+}
+
+// readParams reads the optional parameters, the last argument.
+func (req *request) readParams(r *cbor.Reader) error {
+	hdr, err := r.PeekHeader()
+	if err != nil {
+		return err
+	}
+	n, err := r.ReadMapLength()
+	if err != nil {
+		return err
+	}
+	stream := hdr == cbor.MapStream
+	for i := 0; stream || i < n; i++ {
+		if stream {
+			if hdr, err := r.PeekHeader(); err != nil {
+				return err
+			} else if hdr == cbor.Break {
+				return r.ReadBreak()
+			}
+		}
+		key, err := r.ReadInt()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case paramProjectionExpression:
+			req.projection, err = r.ReadBytes()
+		case paramConditionExpression:
+			req.condition, err = r.ReadBytes()
+		case paramFilterExpression:
+			req.filter, err = r.ReadBytes()
+		case paramUpdateExpression:
+			req.update, err = r.ReadBytes()
+		case paramExclusiveStartKey:
+			req.startKey, err = r.ReadBytes()
+		case paramIndexName:
+			var b []byte
+			b, err = r.ReadBytes()
+			req.indexName = string(b)
+		case paramReturnValues:
+			req.returnValues, err = r.ReadInt()
+		case paramSelect:
+			req.selection, err = r.ReadInt()
+		case paramLimit:
+			req.limit, err = r.ReadInt()
+		case paramSegment:
+			req.segment, err = r.ReadInt()
+		case paramTotalSegments:
+			req.totalSegments, err = r.ReadInt()
+		case paramScanIndexForward:
+			var forward int
+			forward, err = r.ReadInt()
+			req.backward = forward == 0
+		default:
+			err = r.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) getItem(op string, r *cbor.Reader, w *cbor.Writer) error {
+	var req request
+	if err := req.readTable(r); err != nil {
+		return err
+	}
+	var err error
+	if req.key, err = r.ReadBytes(); err != nil {
+		return err
+	}
+	if err := req.readParams(r); err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		t, err := s.table(req.table)
+		if err != nil {
+			return err
+		}
+		item, ok := t.items[string(req.key)]
+		if !ok {
+			return w.WriteMapHeader(0)
+		}
+		if err := w.WriteMapHeader(1); err != nil {
+			return err
+		}
+		if err := w.WriteInt(responseItem); err != nil {
+			return err
+		}
+		if req.projection != nil {
+			return writeProjection(w, item, req.projection)
+		}
+		return s.writeNonKeyAttributes(w, t, item)
+	})
+}
+
+func (s *Server) putItem(op string, r *cbor.Reader, w *cbor.Writer) error {
+	var req request
+	if err := req.readTable(r); err != nil {
+		return err
+	}
+	var err error
+	if req.key, err = r.ReadBytes(); err != nil {
+		return err
+	}
+	if req.item, err = r.ReadBytes(); err != nil {
+		return err
+	}
+	if err := req.readParams(r); err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		t, err := s.table(req.table)
+		if err != nil {
+			return err
+		}
+		key, err := t.decodeKey(req.key)
+		if err != nil {
+			return err
+		}
+		item, err := cbor.DecodeItemNonKeyAttributes(context.Background(), cbor.NewSliceReader(req.item), s.idToNames)
+		if err != nil {
+			return err
+		}
+		for k, v := range key {
+			item[k] = v
+		}
+		old := t.items[string(req.key)]
+		if err := checkCondition(req.condition, old); err != nil {
+			return err
+		}
+		t.items[string(req.key)] = item
+		return s.writeOldItem(w, t, &req, old)
+	})
+}
+
+func (s *Server) deleteItem(op string, r *cbor.Reader, w *cbor.Writer) error {
+	var req request
+	if err := req.readTable(r); err != nil {
+		return err
+	}
+	var err error
+	if req.key, err = r.ReadBytes(); err != nil {
+		return err
+	}
+	if err := req.readParams(r); err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		t, err := s.table(req.table)
+		if err != nil {
+			return err
+		}
+		old := t.items[string(req.key)]
+		if err := checkCondition(req.condition, old); err != nil {
+			return err
+		}
+		delete(t.items, string(req.key))
+		return s.writeOldItem(w, t, &req, old)
+	})
+}
+
+// checkCondition fails with a ConditionalCheckFailedException unless item,
+// nil if there is none, meets the encoded condition, if any.
+func checkCondition(condition []byte, item map[string]types.AttributeValue) error {
+	if condition == nil {
+		return nil
+	}
+	e, err := decodeExpression(condition)
+	if err != nil {
+		return err
+	}
+	ok, err := e.matches(item)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		f := ConditionalCheckFailedFault("The conditional request failed")
+		return &f
+	}
+	return nil
+}
+
+// writeOldItem writes the response of a write which replaced or deleted old.
+func (s *Server) writeOldItem(w *cbor.Writer, t *table, req *request, old map[string]types.AttributeValue) error {
+	switch req.returnValues {
+	case 0, returnValueNone:
+		return w.WriteNull()
+	case returnValueAllOld:
+		if old == nil {
+			return w.WriteNull()
+		}
+		if err := w.WriteMapHeader(1); err != nil {
+			return err
+		}
+		if err := w.WriteInt(responseAttributes); err != nil {
+			return err
+		}
+		return s.writeNonKeyAttributes(w, t, old)
+	}
+	return unsupported("ReturnValues other than ALL_OLD")
+}
+
+func (s *Server) query(op string, r *cbor.Reader, w *cbor.Writer) error {
+	var req request
+	if err := req.readTable(r); err != nil {
+		return err
+	}
+	var err error
+	if req.keyCondition, err = r.ReadBytes(); err != nil {
+		return err
+	}
+	if err := req.readParams(r); err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		return s.scanItems(w, &req)
+	})
+}
+
+func (s *Server) scan(op string, r *cbor.Reader, w *cbor.Writer) error {
+	var req request
+	if err := req.readTable(r); err != nil {
+		return err
+	}
+	if err := req.readParams(r); err != nil {
+		return err
+	}
+	return s.respond(w, op, func(w *cbor.Writer) error {
+		return s.scanItems(w, &req)
+	})
+}
+
+// scanItems writes the page of the items of a Query or Scan starting after
+// its exclusive start key.
+func (s *Server) scanItems(w *cbor.Writer, req *request) error {
+	t, err := s.table(req.table)
+	if err != nil {
+		return err
+	}
+	if req.indexName != "" {
+		return unsupported("secondary indexes")
+	}
+
+	var items []map[string]types.AttributeValue
+	keyCondition, err := optionalExpression(req.keyCondition)
+	if err != nil {
+		return err
+	}
+	for i, item := range t.sorted() {
+		if req.totalSegments > 1 && i%req.totalSegments != req.segment {
+			continue
+		}
+		if keyCondition != nil {
+			if ok, err := keyCondition.matches(item); err != nil {
+				return err
+			} else if !ok {
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	if req.backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if req.startKey != nil {
+		start, err := t.decodeKey(req.startKey)
+		if err != nil {
+			return err
+		}
+		for len(items) > 0 {
+			c := t.compareKeys(items[0], start)
+			if req.backward && c < 0 || !req.backward && c > 0 {
+				break
+			}
+			items = items[1:]
+		}
+	}
+
+	filter, err := optionalExpression(req.filter)
+	if err != nil {
+		return err
+	}
+	var matched []map[string]types.AttributeValue
+	var last map[string]types.AttributeValue
+	scanned := 0
+	for i, item := range items {
+		scanned++
+		ok := true
+		if filter != nil {
+			if ok, err = filter.matches(item); err != nil {
+				return err
+			}
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+		if req.limit > 0 && scanned == req.limit {
+			if i < len(items)-1 {
+				last = t.key(item)
+			}
+			break
+		}
+	}
+
+	n := 2
+	if req.selection != selectCount {
+		n++
+	}
+	if last != nil {
+		n++
+	}
+	if err := w.WriteMapHeader(n); err != nil {
+		return err
+	}
+	if req.selection != selectCount {
+		w.WriteInt(responseItems)
+		if err := w.WriteArrayHeader(len(matched)); err != nil {
+			return err
+		}
+		for _, item := range matched {
+			if req.projection != nil {
+				err = writeProjection(w, item, req.projection)
+			} else {
+				err = s.writeKeyAndAttributes(w, t, item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	w.WriteInt(responseCount)
+	w.WriteInt(len(matched))
+	w.WriteInt(responseScannedCount)
+	if err := w.WriteInt(scanned); err != nil {
+		return err
+	}
+	if last != nil {
+		w.WriteInt(responseLastEvaluatedKey)
+		return cbor.EncodeItemKey(last, t.keys, w)
+	}
+	return nil
+}
+
+func optionalExpression(b []byte) (*expression, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return decodeExpression(b)
+}
+
+func (s *Server) writeKeyAndAttributes(w *cbor.Writer, t *table, item map[string]types.AttributeValue) error {
+	if err := w.WriteArrayHeader(2); err != nil {
+		return err
+	}
+	if err := cbor.EncodeItemKey(item, t.keys, w); err != nil {
+		return err
+	}
+	return s.writeNonKeyAttributes(w, t, item)
+}
+
+func (s *Server) writeNonKeyAttributes(w *cbor.Writer, t *table, item map[string]types.AttributeValue) error {
+	var buf bytes.Buffer
+	bw := cbor.NewWriter(&buf)
+	defer bw.Close()
+	if err := cbor.EncodeItemNonKeyAttributes(context.Background(), item, t.keys, s.namesToId, bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return w.WriteBytes(buf.Bytes())
+}
+
+// writeProjection writes the values of item at the paths of the encoded
+// projection, by their ordinal.
+func writeProjection(w *cbor.Writer, item map[string]types.AttributeValue, projection []byte) error {
+	e, err := decodeExpression(projection)
+	if err != nil {
+		return err
+	}
+	paths, err := e.paths()
+	if err != nil {
+		return err
+	}
+	values := make(map[int]types.AttributeValue, len(paths))
+	for i, p := range paths {
+		if v := lookup(item, p); v != nil {
+			values[i] = v
+		}
+	}
+	if err := w.WriteMapHeader(len(values)); err != nil {
+		return err
+	}
+	for i := range paths {
+		v, ok := values[i]
+		if !ok {
+			continue
+		}
+		if err := w.WriteInt(i); err != nil {
+			return err
+		}
+		if err := cbor.EncodeAttributeValue(v, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
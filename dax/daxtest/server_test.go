@@ -0,0 +1,241 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*Server, *dax.Dax) {
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	require.NoError(t, srv.CreateTable("orders",
+		types.AttributeDefinition{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS},
+		types.AttributeDefinition{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeN},
+	))
+	client, err := dax.New(srv.Config())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return srv, client
+}
+
+func order(customer string, n int, status string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"customer": &types.AttributeValueMemberS{Value: customer},
+		"order":    &types.AttributeValueMemberN{Value: strconv.Itoa(n)},
+		"status":   &types.AttributeValueMemberS{Value: status},
+	}
+}
+
+func TestServer_PutGetItem(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+
+	item := order("alice", 1, "open")
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("orders"), Item: item})
+	require.NoError(t, err)
+	assert.Equal(t, item, srv.Item("orders", order("alice", 1, "")))
+
+	key := map[string]types.AttributeValue{
+		"customer": &types.AttributeValueMemberS{Value: "alice"},
+		"order":    &types.AttributeValueMemberN{Value: "1"},
+	}
+	out, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("orders"), Key: key})
+	require.NoError(t, err)
+	assert.Equal(t, item, out.Item)
+
+	out, err = client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String("orders"),
+		Key:                  key,
+		ProjectionExpression: aws.String("#s"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.AttributeValue{"status": &types.AttributeValueMemberS{Value: "open"}}, out.Item)
+
+	key["order"] = &types.AttributeValueMemberN{Value: "2"}
+	out, err = client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("orders"), Key: key})
+	require.NoError(t, err)
+	assert.Nil(t, out.Item)
+}
+
+func TestServer_Query(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		status := "open"
+		if i%2 == 0 {
+			status = "closed"
+		}
+		require.NoError(t, srv.PutItem("orders", order("alice", i, status)))
+	}
+	require.NoError(t, srv.PutItem("orders", order("bob", 1, "open")))
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String("orders"),
+		KeyConditionExpression: aws.String("customer = :c AND #o > :o"),
+		FilterExpression:       aws.String("#s = :s"),
+		ExpressionAttributeNames: map[string]string{
+			"#o": "order",
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":c": &types.AttributeValueMemberS{Value: "alice"},
+			":o": &types.AttributeValueMemberN{Value: "1"},
+			":s": &types.AttributeValueMemberS{Value: "open"},
+		},
+		Limit: aws.Int32(2),
+	}
+	var orders []string
+	var pages int
+	for {
+		out, err := client.Query(ctx, input)
+		require.NoError(t, err)
+		pages++
+		for _, item := range out.Items {
+			orders = append(orders, item["order"].(*types.AttributeValueMemberN).Value)
+		}
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	assert.Equal(t, []string{"3", "5"}, orders)
+	assert.Equal(t, 2, pages)
+
+	input.ExclusiveStartKey = nil
+	input.Limit = nil
+	input.ScanIndexForward = aws.Bool(false)
+	out, err := client.Query(ctx, input)
+	require.NoError(t, err)
+	require.Len(t, out.Items, 2)
+	assert.Equal(t, order("alice", 5, "open"), out.Items[0])
+	assert.EqualValues(t, 2, out.Count)
+	assert.EqualValues(t, 4, out.ScannedCount)
+}
+
+func TestServer_ConditionalDelete(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	require.NoError(t, srv.PutItem("orders", order("alice", 1, "open")))
+
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String("orders"),
+		Key: map[string]types.AttributeValue{
+			"customer": &types.AttributeValueMemberS{Value: "alice"},
+			"order":    &types.AttributeValueMemberN{Value: "1"},
+		},
+		ConditionExpression: aws.String("#s = :s"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":s": &types.AttributeValueMemberS{Value: "closed"},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	}
+	_, err := client.DeleteItem(ctx, input)
+	var ccf *types.ConditionalCheckFailedException
+	assert.True(t, errors.As(err, &ccf), "got %v", err)
+	assert.NotNil(t, srv.Item("orders", input.Key))
+
+	input.ExpressionAttributeValues[":s"] = &types.AttributeValueMemberS{Value: "open"}
+	out, err := client.DeleteItem(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, order("alice", 1, "open"), out.Attributes)
+	assert.Nil(t, srv.Item("orders", input.Key))
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("missing"),
+		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}},
+	})
+	var rnf *types.ResourceNotFoundException
+	assert.True(t, errors.As(err, &rnf), "got %v", err)
+
+	srv.InjectFault("Scan", ValidationFault("injected"))
+	_, err = client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("orders")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "injected")
+
+	out, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("orders")})
+	require.NoError(t, err)
+	assert.Empty(t, out.Items)
+}
+
+func TestServer_CreateTable(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	assert.Error(t, srv.CreateTable("t"))
+	assert.Error(t, srv.CreateTable("t", types.AttributeDefinition{AttributeName: aws.String("id"), AttributeType: "SS"}))
+	require.NoError(t, srv.CreateTable("t", types.AttributeDefinition{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS}))
+	assert.Error(t, srv.CreateTable("t", types.AttributeDefinition{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS}))
+	assert.Error(t, srv.PutItem("t", map[string]types.AttributeValue{}))
+}
+
+func TestServer_itemCache(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	require.NoError(t, srv.CreateTable("orders",
+		types.AttributeDefinition{AttributeName: aws.String("customer"), AttributeType: types.ScalarAttributeTypeS},
+		types.AttributeDefinition{AttributeName: aws.String("order"), AttributeType: types.ScalarAttributeTypeN},
+	))
+	require.NoError(t, srv.PutItem("orders", order("alice", 1, "open")))
+	cfg := srv.Config()
+	cfg.ItemCacheTTL = time.Hour
+	client, err := dax.New(cfg)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	get := &dynamodb.GetItemInput{
+		TableName: aws.String("orders"),
+		Key: map[string]types.AttributeValue{
+			"customer": &types.AttributeValueMemberS{Value: "alice"},
+			"order":    &types.AttributeValueMemberN{Value: "1"},
+		},
+	}
+	_, err = client.GetItem(ctx, get)
+	require.NoError(t, err)
+
+	require.NoError(t, srv.PutItem("orders", order("alice", 1, "closed")))
+	out, err := client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Equal(t, order("alice", 1, "open"), out.Item, "served from the item cache")
+
+	_, err = client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("orders"), Item: order("alice", 1, "shipped")})
+	require.NoError(t, err)
+	out, err = client.GetItem(ctx, get)
+	require.NoError(t, err)
+	assert.Equal(t, order("alice", 1, "shipped"), out.Item, "dropped from the cache by the write")
+}
@@ -0,0 +1,84 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package daxtest
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// table holds the items of a table by their encoded key.
+type table struct {
+	keys  []types.AttributeDefinition // hash key first
+	items map[string]map[string]types.AttributeValue
+}
+
+// decodeKey decodes a key encoded by the client for t.
+func (t *table) decodeKey(encoded []byte) (map[string]types.AttributeValue, error) {
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	defer w.Close()
+	if err := w.WriteBytes(encoded); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return cbor.DecodeItemKey(cbor.NewSliceReader(buf.Bytes()), t.keys)
+}
+
+// key returns the key attributes of item.
+func (t *table) key(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := make(map[string]types.AttributeValue, len(t.keys))
+	for _, k := range t.keys {
+		name := aws.ToString(k.AttributeName)
+		key[name] = item[name]
+	}
+	return key
+}
+
+// compareKeys orders items by hash key, then by range key.
+func (t *table) compareKeys(a, b map[string]types.AttributeValue) int {
+	for _, k := range t.keys {
+		name := aws.ToString(k.AttributeName)
+		if c, _ := compare(a[name], b[name]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// sorted returns the items of t in key order.
+func (t *table) sorted() []map[string]types.AttributeValue {
+	items := make([]map[string]types.AttributeValue, 0, len(t.items))
+	for _, item := range t.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return t.compareKeys(items[i], items[j]) < 0 })
+	return items
+}
+
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	c := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,47 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// QueryEach is like Query, but passes the items of the page to fn as they are
+// read from the connection instead of collecting them in the output, whose
+// Items are nil. Large pages are processed without holding all their items
+// in memory at once.
+//
+// An error returned by fn stops the query and is returned as is. A request
+// which failed after items were passed to fn is not retried, the error is
+// returned and fn may have seen part of the page only.
+func (d *Dax) QueryEach(ctx context.Context, input *dynamodb.QueryInput, fn func(item map[string]types.AttributeValue) error, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return d.Query(ctx, input, append(optFns, withItemHandler(fn))...)
+}
+
+// ScanEach is like Scan, but passes the items of the page to fn as they are
+// read from the connection, like QueryEach.
+func (d *Dax) ScanEach(ctx context.Context, input *dynamodb.ScanInput, fn func(item map[string]types.AttributeValue) error, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return d.Scan(ctx, input, append(optFns, withItemHandler(fn))...)
+}
+
+func withItemHandler(fn func(map[string]types.AttributeValue) error) func(*dynamodb.Options) {
+	return withCallOptions(func(c *callOptions) {
+		c.onItem = fn
+	})
+}
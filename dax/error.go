@@ -0,0 +1,88 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"errors"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/smithy-go"
+)
+
+// DaxError is implemented by the errors a DAX node reports which have no
+// DynamoDB equivalent, such as a node failing to serve a request or an
+// unknown error code; errors with a DynamoDB equivalent are returned as the
+// types.*Exception a DynamoDB client would return.
+//
+//	var daxErr dax.DaxError
+//	if errors.As(err, &daxErr) {
+//		log.Printf("request %s failed with codes %v", daxErr.RequestID(), daxErr.CodeSequence())
+//	}
+type DaxError interface {
+	smithy.APIError
+
+	// CodeSequence returns the error codes of the DAX protocol, from the
+	// most general to the most specific. The first code tells whether the
+	// request may be retried: 1 on the same node, 2 once the cluster
+	// recovered, 3 and 4 not at all because of a server or client error.
+	CodeSequence() []int
+
+	// RequestID returns the ID of the failed request, empty if the node did
+	// not report one.
+	RequestID() string
+
+	// StatusCode returns the HTTP status code DynamoDB would have answered
+	// with. Nodes not reporting one get 400 for client errors and 500
+	// otherwise.
+	StatusCode() int
+}
+
+// AsDaxError returns the DaxError in the chain of err, if any.
+func AsDaxError(err error) (DaxError, bool) {
+	var daxErr DaxError
+	ok := errors.As(err, &daxErr)
+	return daxErr, ok
+}
+
+// ErrRequestQueueFull is returned by calls rejected because
+// MaxConcurrentRequests requests are in flight and MaxQueuedRequests calls
+// already wait for one of them to complete.
+var ErrRequestQueueFull = client.ErrRequestQueueFull
+
+// ErrRateLimitExceeded is returned by calls which would have to wait past
+// their deadline for the rate of a table in TableRateLimits.
+var ErrRateLimitExceeded = client.ErrRateLimitExceeded
+
+// IsRetryable reports whether the client retries a request failing with err:
+// throttles, failures the node reports as retryable (codes 1 and 2) and
+// network errors. Retry wrappers should use it to agree with the client.
+func IsRetryable(err error) bool {
+	return client.IsRetryableError(err)
+}
+
+// IsThrottle reports whether err is a throttle, such as a
+// ProvisionedThroughputExceededException or a ThrottlingException, which the
+// client retries with backoff.
+func IsThrottle(err error) bool {
+	return client.IsThrottleError(err)
+}
+
+// IsIO reports whether err is caused by a network error, a closed connection,
+// a timeout or a canceled context rather than by the node rejecting the
+// request.
+func IsIO(err error) bool {
+	return client.IsIOError(err)
+}
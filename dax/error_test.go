@@ -0,0 +1,75 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// DaxError must have the methods of the errors the client returns.
+var (
+	_ DaxError        = client.DaxError(nil)
+	_ client.DaxError = DaxError(nil)
+)
+
+type testDaxError struct {
+	*smithy.GenericAPIError
+}
+
+func (testDaxError) CodeSequence() []int { return []int{1, 2} }
+func (testDaxError) RequestID() string   { return "request-1" }
+func (testDaxError) StatusCode() int     { return 500 }
+
+func TestAsDaxError(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", testDaxError{&smithy.GenericAPIError{Code: "Code"}})
+	daxErr, ok := AsDaxError(err)
+	if !ok {
+		t.Fatal("expected a DaxError")
+	}
+	if daxErr.RequestID() != "request-1" || daxErr.ErrorCode() != "Code" {
+		t.Errorf("unexpected error %v", daxErr)
+	}
+
+	if _, ok := AsDaxError(&smithy.GenericAPIError{Code: "Code"}); ok {
+		t.Error("expected no DaxError")
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	throttle := &types.ProvisionedThroughputExceededException{Message: aws.String("m")}
+	if !IsThrottle(throttle) || !IsRetryable(throttle) || IsIO(throttle) {
+		t.Errorf("unexpected classification of %v", throttle)
+	}
+	eof := fmt.Errorf("wrapped: %w", io.EOF)
+	if IsThrottle(eof) || !IsIO(eof) {
+		t.Errorf("unexpected classification of %v", eof)
+	}
+	retryable := testDaxError{&smithy.GenericAPIError{Code: "Code"}}
+	if !IsRetryable(retryable) || IsThrottle(retryable) || IsIO(retryable) {
+		t.Errorf("unexpected classification of %v", retryable)
+	}
+	validation := &smithy.GenericAPIError{Code: "ValidationException"}
+	if IsRetryable(validation) || IsThrottle(validation) || IsIO(validation) {
+		t.Errorf("unexpected classification of %v", validation)
+	}
+}
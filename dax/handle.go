@@ -0,0 +1,110 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Handle holds a DAX client which can be replaced while in use, e.g. when a
+// configuration reload framework delivers new settings.
+//
+// Call Get for each request or unit of work instead of keeping the returned
+// client, requests made through a replaced client fail with os.ErrClosed.
+type Handle struct {
+	current atomic.Pointer[Dax]
+	warm    func(ctx context.Context, d *Dax) error
+
+	mu     sync.Mutex // serializes Swap and Close
+	closed bool       // protected by mu
+}
+
+// NewHandle creates a Handle holding a new client for cfg.
+func NewHandle(cfg Config) (*Handle, error) {
+	d, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handle{warm: warmClient}
+	h.current.Store(d)
+	return h, nil
+}
+
+// Get returns the current client.
+func (h *Handle) Get() *Dax {
+	return h.current.Load()
+}
+
+// Swap creates a client for cfg and checks that it can serve requests before
+// routing Get to it. The previous client then stops accepting new requests
+// and is closed once its in-flight requests complete or its
+// CloseDrainTimeout elapses, Swap returns after it is closed. Set
+// CloseDrainTimeout for in-flight requests to outlive the swap.
+//
+// If the new client cannot be created or warmed up it is discarded and the
+// current client stays in use. An error closing the previous client is
+// returned after the new client is already in use.
+func (h *Handle) Swap(ctx context.Context, cfg Config) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+
+	d, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	if err := h.warm(ctx, d); err != nil {
+		d.Close()
+		return err
+	}
+	return h.current.Swap(d).Close()
+}
+
+// Close closes the current client, later calls to Swap fail with os.ErrClosed.
+func (h *Handle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	return h.current.Load().Close()
+}
+
+// warmClient connects to the cluster and verifies that it serves requests.
+func warmClient(ctx context.Context, d *Dax) error {
+	res, err := d.HealthCheck(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Healthy {
+		return nil
+	}
+	errs := []error{errors.New("new DAX client failed its health check"), res.RefreshError}
+	for _, n := range res.Nodes {
+		if n.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Address, n.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle_Swap(t *testing.T) {
+	h, err := NewHandle(lazyTestConfig())
+	require.NoError(t, err)
+	var warmed []*Dax
+	h.warm = func(ctx context.Context, d *Dax) error {
+		warmed = append(warmed, d)
+		return nil
+	}
+
+	old := h.Get()
+	cfg := lazyTestConfig()
+	cfg.Region = "us-east-1"
+	require.NoError(t, h.Swap(context.Background(), cfg))
+
+	cur := h.Get()
+	assert.NotSame(t, old, cur)
+	assert.Equal(t, []*Dax{cur}, warmed)
+	assert.Equal(t, "us-east-1", cur.config.Region)
+	_, err = old.GetItem(context.Background(), &dynamodb.GetItemInput{})
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	// A client failing to warm up is discarded.
+	warmErr := errors.New("no healthy nodes")
+	h.warm = func(ctx context.Context, d *Dax) error { return warmErr }
+	assert.ErrorIs(t, h.Swap(context.Background(), lazyTestConfig()), warmErr)
+	assert.Same(t, cur, h.Get())
+
+	// So is an invalid configuration.
+	assert.Error(t, h.Swap(context.Background(), Config{}))
+	assert.Same(t, cur, h.Get())
+
+	require.NoError(t, h.Close())
+	require.NoError(t, h.Close())
+	assert.ErrorIs(t, h.Swap(context.Background(), cfg), os.ErrClosed)
+}
+
+func TestWarmClient_unhealthy(t *testing.T) {
+	d, err := New(lazyTestConfig())
+	require.NoError(t, err)
+	defer d.Close()
+
+	assert.Error(t, warmClient(context.Background(), d))
+}
@@ -33,7 +33,37 @@ const (
 	tagDocumentPathOrdinal
 )
 
+// MaxAttributeDepth is the maximum number of nested list and map levels
+// DynamoDB allows in a single attribute value. A top-level list or map counts
+// as the first level.
+const MaxAttributeDepth = 32
+
+// DepthLimitError is returned, wrapped in a smithy serialization or
+// deserialization error, when an attribute value nests lists or maps deeper
+// than Limit levels.
+type DepthLimitError struct {
+	Limit int
+}
+
+func (e *DepthLimitError) Error() string {
+	return fmt.Sprintf("attribute value exceeds maximum nesting depth of %d", e.Limit)
+}
+
+// DuplicateKeyError reports an attribute name that is repeated within a
+// decoded item or map.
+type DuplicateKeyError struct {
+	Key string
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate attribute name %q", e.Key)
+}
+
 func EncodeAttributeValue(value types.AttributeValue, writer *Writer) error {
+	return encodeAttributeValue(value, writer, 0)
+}
+
+func encodeAttributeValue(value types.AttributeValue, writer *Writer, depth int) error {
 	if value == nil {
 		return &smithy.SerializationError{Err: errors.New("invalid attribute value: nil")}
 	}
@@ -92,15 +122,21 @@ func EncodeAttributeValue(value types.AttributeValue, writer *Writer) error {
 			}
 		}
 	case *types.AttributeValueMemberL:
+		if depth >= MaxAttributeDepth {
+			return &smithy.SerializationError{Err: &DepthLimitError{Limit: MaxAttributeDepth}}
+		}
 		if err = writer.WriteArrayHeader(len(v.Value)); err != nil {
 			return err
 		}
 		for _, v := range v.Value {
-			if err := EncodeAttributeValue(v, writer); err != nil {
+			if err := encodeAttributeValue(v, writer, depth+1); err != nil {
 				return err
 			}
 		}
 	case *types.AttributeValueMemberM:
+		if depth >= MaxAttributeDepth {
+			return &smithy.SerializationError{Err: &DepthLimitError{Limit: MaxAttributeDepth}}
+		}
 		if err = writer.WriteMapHeader(len(v.Value)); err != nil {
 			return err
 		}
@@ -108,7 +144,7 @@ func EncodeAttributeValue(value types.AttributeValue, writer *Writer) error {
 			if err := writer.WriteString(k); err != nil {
 				return err
 			}
-			if err = EncodeAttributeValue(v, writer); err != nil {
+			if err = encodeAttributeValue(v, writer, depth+1); err != nil {
 				return err
 			}
 		}
@@ -146,11 +182,103 @@ func writeStringNumber(val string, writer *Writer) error {
 	return err
 }
 
+// decodeFrame tracks a partially decoded list or map while
+// DecodeAttributeValue walks nested containers.
+type decodeFrame struct {
+	list      []types.AttributeValue
+	m         map[string]types.AttributeValue
+	key       string
+	remaining int
+}
+
+func (f *decodeFrame) value() types.AttributeValue {
+	if f.m != nil {
+		return &types.AttributeValueMemberM{Value: f.m}
+	}
+	return &types.AttributeValueMemberL{Value: f.list}
+}
+
+// DecodeAttributeValue reads a single attribute value. Nested lists and maps
+// are decoded with an explicit stack rather than recursion, and values nested
+// deeper than the MaxDepth limit of the reader are rejected with a DepthLimitError.
 func DecodeAttributeValue(reader *Reader) (types.AttributeValue, error) {
-	hdr, err := reader.PeekHeader()
-	if err != nil {
-		return nil, err
+	var stack []*decodeFrame
+	for {
+		hdr, err := reader.PeekHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		var value types.AttributeValue
+		var frame *decodeFrame
+		switch hdr & MajorTypeMask {
+		case Array:
+			len, err := reader.ReadArrayLength()
+			if err != nil {
+				return nil, err
+			}
+			frame = &decodeFrame{list: make([]types.AttributeValue, 0, len), remaining: len}
+		case Map:
+			len, err := reader.ReadMapLength()
+			if err != nil {
+				return nil, err
+			}
+			frame = &decodeFrame{m: make(map[string]types.AttributeValue, len), remaining: len}
+		default:
+			if value, err = decodeScalarAttributeValue(reader, hdr); err != nil {
+				return nil, err
+			}
+		}
+
+		if frame != nil {
+			if limit := reader.limits.maxDepth(); len(stack) >= limit {
+				return nil, &smithy.DeserializationError{Err: &DepthLimitError{Limit: limit}}
+			}
+			if frame.remaining > 0 {
+				if frame.m != nil {
+					if frame.key, err = reader.ReadString(); err != nil {
+						return nil, err
+					}
+				}
+				stack = append(stack, frame)
+				continue
+			}
+			value = frame.value()
+		}
+
+		// Attach the completed value to its parent, unwinding every container
+		// that this value completes.
+		for {
+			if len(stack) == 0 {
+				return value, nil
+			}
+			top := stack[len(stack)-1]
+			if top.m != nil {
+				if _, dup := top.m[top.key]; dup {
+					if err := reader.DuplicateKey(top.key); err != nil {
+						return nil, err
+					}
+				}
+				top.m[top.key] = value
+			} else {
+				top.list = append(top.list, value)
+			}
+			top.remaining--
+			if top.remaining > 0 {
+				if top.m != nil {
+					if top.key, err = reader.ReadString(); err != nil {
+						return nil, err
+					}
+				}
+				break
+			}
+			stack = stack[:len(stack)-1]
+			value = top.value()
+		}
 	}
+}
+
+func decodeScalarAttributeValue(reader *Reader, hdr byte) (types.AttributeValue, error) {
 	major := hdr & MajorTypeMask
 	minor := hdr & MinorTypeMask
 
@@ -167,38 +295,6 @@ func DecodeAttributeValue(reader *Reader) (types.AttributeValue, error) {
 			return nil, err
 		}
 		return &types.AttributeValueMemberB{Value: b}, nil
-	case Array:
-		len, err := reader.ReadArrayLength()
-		if err != nil {
-			return nil, err
-		}
-		as := make([]types.AttributeValue, len)
-		for i := 0; i < len; i++ {
-			a, err := DecodeAttributeValue(reader)
-			if err != nil {
-				return nil, err
-			}
-			as[i] = a
-		}
-		return &types.AttributeValueMemberL{Value: as}, nil
-	case Map:
-		len, err := reader.ReadMapLength()
-		if err != nil {
-			return nil, err
-		}
-		m := make(map[string]types.AttributeValue, len)
-		for i := 0; i < len; i++ {
-			k, err := reader.ReadString()
-			if err != nil {
-				return nil, err
-			}
-			v, err := DecodeAttributeValue(reader)
-			if err != nil {
-				return nil, err
-			}
-			m[k] = v
-		}
-		return &types.AttributeValueMemberM{Value: m}, nil
 	case PosInt, NegInt:
 		s, err := reader.ReadCborIntegerToString()
 		if err != nil {
@@ -260,7 +356,11 @@ func DecodeAttributeValue(reader *Reader) (types.AttributeValue, error) {
 				}
 				ss := make([]string, len)
 				for i := 0; i < len; i++ {
-					av, err := DecodeAttributeValue(reader)
+					h, err := reader.PeekHeader()
+					if err != nil {
+						return nil, err
+					}
+					av, err := decodeScalarAttributeValue(reader, h)
 					if err != nil {
 						return nil, err
 					}
@@ -17,6 +17,7 @@ package cbor
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -160,6 +161,139 @@ func TestDecodeAttributeValue_InvalidData(t *testing.T) {
 	}
 }
 
+func nestedAttributeValue(depth int) types.AttributeValue {
+	var v types.AttributeValue = &types.AttributeValueMemberS{Value: "leaf"}
+	for i := 0; i < depth; i++ {
+		if i%2 == 0 {
+			v = &types.AttributeValueMemberL{Value: []types.AttributeValue{v}}
+		} else {
+			v = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"k": v}}
+		}
+	}
+	return v
+}
+
+func TestAttrVal_DepthLimit(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	val := nestedAttributeValue(MaxAttributeDepth)
+	if err := EncodeAttributeValue(val, w); err != nil {
+		t.Fatalf("unexpected error encoding depth %d: %v", MaxAttributeDepth, err)
+	}
+	w.Flush()
+
+	a, err := DecodeAttributeValue(NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("unexpected error decoding depth %d: %v", MaxAttributeDepth, err)
+	}
+	if !reflect.DeepEqual(val, a) {
+		t.Errorf("expected: %v, actual: %v", val, a)
+	}
+
+	buf.Reset()
+	w = NewWriter(&buf)
+	err = EncodeAttributeValue(nestedAttributeValue(MaxAttributeDepth+1), w)
+	var depthErr *DepthLimitError
+	if !errors.As(err, &depthErr) {
+		t.Fatalf("expected DepthLimitError encoding depth %d, got %v", MaxAttributeDepth+1, err)
+	}
+	if depthErr.Limit != MaxAttributeDepth {
+		t.Errorf("expected limit %d, actual %d", MaxAttributeDepth, depthErr.Limit)
+	}
+}
+
+func TestDecodeAttributeValue_DepthLimit(t *testing.T) {
+	encode := func(depth int) []byte {
+		// Nested single element arrays wrapping an empty array
+		b := bytes.Repeat([]byte{0x81}, depth-1)
+		return append(b, 0x80)
+	}
+
+	a, err := DecodeAttributeValue(NewReader(bytes.NewReader(encode(MaxAttributeDepth))))
+	if err != nil {
+		t.Fatalf("unexpected error decoding depth %d: %v", MaxAttributeDepth, err)
+	}
+	for i := 1; i < MaxAttributeDepth; i++ {
+		l, ok := a.(*types.AttributeValueMemberL)
+		if !ok || len(l.Value) != 1 {
+			t.Fatalf("unexpected value at depth %d: %v", i, a)
+		}
+		a = l.Value[0]
+	}
+	if l, ok := a.(*types.AttributeValueMemberL); !ok || len(l.Value) != 0 {
+		t.Errorf("expected empty list at depth %d, actual %v", MaxAttributeDepth, a)
+	}
+
+	for _, depth := range []int{MaxAttributeDepth + 1, 1000000} {
+		_, err := DecodeAttributeValue(NewReader(bytes.NewReader(encode(depth))))
+		var depthErr *DepthLimitError
+		if !errors.As(err, &depthErr) {
+			t.Errorf("expected DepthLimitError decoding depth %d, got %v", depth, err)
+		}
+	}
+}
+
+func TestDecodeAttributeValue_MaxDepth(t *testing.T) {
+	// [[[]]]
+	enc := []byte{0x81, 0x81, 0x80}
+
+	r := NewSliceReader(enc)
+	r.SetLimits(Limits{MaxDepth: 2})
+	_, err := DecodeAttributeValue(r)
+	var depthErr *DepthLimitError
+	if !errors.As(err, &depthErr) || depthErr.Limit != 2 {
+		t.Errorf("expected DepthLimitError with limit 2, got %v", err)
+	}
+
+	r = NewSliceReader(enc)
+	r.SetLimits(Limits{MaxDepth: 3})
+	if _, err := DecodeAttributeValue(r); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDecodeAttributeValue_DuplicateKey(t *testing.T) {
+	// {"a": 1, "m": {"b": 2, "b": 3}, "a": 4}
+	enc := []byte{0xa3, 0x61, 'a', 0x01, 0x61, 'm', 0xa2, 0x61, 'b', 0x02, 0x61, 'b', 0x03, 0x61, 'a', 0x04}
+
+	var dups []string
+	r := NewReader(bytes.NewReader(enc))
+	r.SetDuplicateKeyHandler(func(key string) error {
+		dups = append(dups, key)
+		return nil
+	})
+	av, err := DecodeAttributeValue(r)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"a": &types.AttributeValueMemberN{Value: "4"},
+		"m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"b": &types.AttributeValueMemberN{Value: "3"},
+		}},
+	}}
+	if !reflect.DeepEqual(expected, av) {
+		t.Errorf("expected %v, actual %v", expected, av)
+	}
+	if !reflect.DeepEqual([]string{"b", "a"}, dups) {
+		t.Errorf("expected duplicates [b a], actual %v", dups)
+	}
+
+	// A handler error fails decoding, including in readers returned by BytesReader.
+	wrapped := append([]byte{0x40 | byte(len(enc))}, enc...)
+	r = NewReader(bytes.NewReader(wrapped))
+	r.SetDuplicateKeyHandler(func(key string) error { return &DuplicateKeyError{Key: key} })
+	br, err := r.BytesReader()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	_, err = DecodeAttributeValue(br)
+	var dupErr *DuplicateKeyError
+	if !errors.As(err, &dupErr) || dupErr.Key != "b" {
+		t.Errorf("expected DuplicateKeyError for b, got %v", err)
+	}
+}
+
 // Helper function to check if an error message contains the expected substring
 func containsError(err error, substr string) bool {
 	return err != nil && strings.Contains(err.Error(), substr)
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"strconv"
 	"sync"
+	"unsafe"
 
 	"github.com/aws/smithy-go"
 )
@@ -33,6 +34,50 @@ const (
 	maxObjLenBytes = 1024 * 1024 * 1024
 )
 
+const (
+	// DefaultMaxArrayLength is the default maximum number of elements of an
+	// array or map. A page of items or a set attribute has far fewer.
+	DefaultMaxArrayLength = 1 << 20
+	// DefaultMaxStringLength is the default maximum number of bytes of a
+	// string or byte string, the size of the largest DynamoDB response.
+	DefaultMaxStringLength = 16 << 20
+)
+
+// Limits bound the lengths and nesting a Reader accepts, so a corrupted or
+// malicious frame fails with a deserialization error instead of an attempt
+// to allocate what its headers announce. Zero fields select the defaults.
+type Limits struct {
+	// MaxArrayLength is the maximum number of elements of an array or map.
+	MaxArrayLength int
+	// MaxStringLength is the maximum number of bytes of a string or byte
+	// string. It cannot be raised above 1 GiB.
+	MaxStringLength int
+	// MaxDepth is the maximum number of nested list and map levels of an
+	// attribute value, MaxAttributeDepth by default.
+	MaxDepth int
+}
+
+func (l Limits) maxArrayLength() uint64 {
+	if l.MaxArrayLength <= 0 {
+		return DefaultMaxArrayLength
+	}
+	return uint64(l.MaxArrayLength)
+}
+
+func (l Limits) maxStringLength() uint64 {
+	if l.MaxStringLength <= 0 {
+		return DefaultMaxStringLength
+	}
+	return min(uint64(l.MaxStringLength), maxObjLenBytes)
+}
+
+func (l Limits) maxDepth() int {
+	if l.MaxDepth <= 0 {
+		return MaxAttributeDepth
+	}
+	return l.MaxDepth
+}
+
 var ErrNaN = &smithy.GenericAPIError{
 	Code:    "InvalidParameter",
 	Message: fmt.Sprintf("cbor: not a number"),
@@ -40,6 +85,7 @@ var ErrNaN = &smithy.GenericAPIError{
 }
 var ErrObjTooBig = &smithy.DeserializationError{Err: fmt.Errorf("cbor: object too big")}
 var ErrNegLength = &smithy.DeserializationError{Err: fmt.Errorf("cbor: negative length")}
+var ErrTooManyElements = &smithy.DeserializationError{Err: fmt.Errorf("cbor: too many elements")}
 
 // A Writer writes cbor-encoded data.
 type Writer struct {
@@ -230,8 +276,12 @@ func (w *Writer) writeType64(typ uint64, ui uint64) error {
 	return err
 }
 
+// Close returns the buffer of the writer to a pool unless it was given a
+// bufio.Writer. It does not flush, the writer must not be used afterwards.
 func (w *Writer) Close() error {
 	if w.recycle {
+		w.recycle = false
+		w.bw.Reset(nil)
 		bufferedWriterPool.Put(w.bw)
 	}
 	return nil
@@ -243,30 +293,71 @@ var bufferedReaderPool = sync.Pool{
 	},
 }
 
+// source is the buffered input of a Reader.
+type source interface {
+	io.Reader
+	io.ByteReader
+	io.WriterTo
+	Peek(n int) ([]byte, error)
+}
+
+// A Reader reads cbor-encoded data.
+//
+// Strings and byte strings read from a Reader share memory with its input
+// when it reads from a byte slice, see NewSliceReader, and with the byte
+// string a reader returned by BytesReader was created for. Decoding a byte
+// string wrapped item thus allocates the item once instead of every string
+// and binary value in it, but a retained value keeps the whole item in
+// memory; copy values retained longer than the rest of the item.
 type Reader struct {
-	r  io.Reader
-	br *bufio.Reader
+	br     source
+	data   *sliceSource  // set when reading from a byte slice
+	pooled *bufio.Reader // returned to bufferedReaderPool by Close
 
 	buf     []byte
 	scratch [8]byte
-	recycle bool
+
+	onDuplicateKey func(key string) error
+	onMissingKey   func(table, key string) error
+	limits         Limits
 }
 
 func NewReader(r io.Reader) *Reader {
-	br, ok := r.(*bufio.Reader)
-	if !ok {
-		br = bufferedReaderPool.Get().(*bufio.Reader)
-		br.Reset(r)
-	}
-	rdr := Reader{
-		r:       r,
-		br:      br,
-		recycle: !ok,
+	var rdr Reader
+	if br, ok := r.(*bufio.Reader); ok {
+		rdr.br = br
+	} else {
+		rdr.pooled = bufferedReaderPool.Get().(*bufio.Reader)
+		rdr.pooled.Reset(r)
+		rdr.br = rdr.pooled
 	}
 	rdr.buf = rdr.scratch[:]
 	return &rdr
 }
 
+// NewSliceReader returns a Reader which reads from b without copying it.
+// Strings and byte strings read from the Reader share memory with b, which
+// must not be modified while they are in use.
+func NewSliceReader(b []byte) *Reader {
+	rdr := Reader{data: &sliceSource{b: b}}
+	rdr.br = rdr.data
+	rdr.buf = rdr.scratch[:]
+	return &rdr
+}
+
+// readN reads the next n bytes. The result is a sub-slice of the input of a
+// slice reader and freshly allocated otherwise, so it is never written to.
+func (r *Reader) readN(n int) ([]byte, error) {
+	if r.data != nil {
+		return r.data.next(n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.br, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 func (r *Reader) ReadString() (string, error) {
 	// TODO skip tags, indef length strings
 	hdr, value, err := r.readTypeHeader()
@@ -276,19 +367,18 @@ func (r *Reader) ReadString() (string, error) {
 	if err = r.verifyMajorType(hdr, Utf); err != nil {
 		return "", err
 	}
-	if value > maxObjLenBytes {
+	if value > r.limits.maxStringLength() {
 		return "", ErrObjTooBig
 	} else if value < 0 {
 		return "", ErrNegLength
 	} else if value == 0 {
 		return "", nil
 	}
-	b := make([]byte, value)
-	_, err = io.ReadFull(r.br, b)
+	b, err := r.readN(int(value))
 	if err != nil {
 		return "", err
 	}
-	return string(b), err
+	return unsafe.String(&b[0], len(b)), nil
 }
 
 func (r *Reader) ReadRawBytes(o io.Writer) error {
@@ -299,6 +389,9 @@ func (r *Reader) ReadRawBytes(o io.Writer) error {
 	if err = r.verifyMajorType(hdr, Bytes); err != nil {
 		return err
 	}
+	if value > r.limits.maxStringLength() {
+		return ErrObjTooBig
+	}
 	lr := io.LimitReader(r.br, int64(value))
 	if _, err = io.Copy(o, lr); err != nil {
 		return err
@@ -315,19 +408,14 @@ func (r *Reader) ReadBytes() ([]byte, error) {
 	if err = r.verifyMajorType(hdr, Bytes); err != nil {
 		return nil, err
 	}
-	if value > maxObjLenBytes {
+	if value > r.limits.maxStringLength() {
 		return nil, ErrObjTooBig
 	} else if value < 0 {
 		return nil, ErrNegLength
 	} else if value == 0 {
 		return []byte{}, nil
 	}
-	b := make([]byte, value)
-	_, err = io.ReadFull(r.br, b)
-	if err != nil {
-		return nil, err
-	}
-	return b, err
+	return r.readN(int(value))
 }
 
 func (r *Reader) BytesReader() (*Reader, error) {
@@ -339,9 +427,55 @@ func (r *Reader) BytesReader() (*Reader, error) {
 	if err = r.verifyMajorType(hdr, Bytes); err != nil {
 		return nil, err
 	}
-	// TODO avoid double buffering
-	lr := io.LimitReader(r.br, int64(value))
-	return NewReader(lr), nil
+	if value > r.limits.maxStringLength() {
+		return nil, ErrObjTooBig
+	}
+	b, err := r.readN(int(value))
+	if err != nil {
+		return nil, err
+	}
+	br := NewSliceReader(b)
+	br.onDuplicateKey = r.onDuplicateKey
+	br.onMissingKey = r.onMissingKey
+	br.limits = r.limits
+	return br, nil
+}
+
+// SetLimits sets the limits the reader enforces on its input. Readers
+// returned by BytesReader inherit them.
+func (r *Reader) SetLimits(l Limits) {
+	r.limits = l
+}
+
+// SetDuplicateKeyHandler sets the function called when a decoded map or item
+// repeats a key. The later value replaces the earlier one unless f returns an
+// error, which fails decoding. Readers returned by BytesReader inherit f.
+func (r *Reader) SetDuplicateKeyHandler(f func(key string) error) {
+	r.onDuplicateKey = f
+}
+
+// DuplicateKey reports a repeated key to the duplicate key handler.
+func (r *Reader) DuplicateKey(key string) error {
+	if r.onDuplicateKey == nil {
+		return nil
+	}
+	return r.onDuplicateKey(key)
+}
+
+// SetMissingKeyHandler sets the function called when an item decoded for
+// table lacks one of its key attributes. The item is returned as decoded
+// unless f returns an error, which fails decoding. Readers returned by
+// BytesReader inherit f.
+func (r *Reader) SetMissingKeyHandler(f func(table, key string) error) {
+	r.onMissingKey = f
+}
+
+// MissingKey reports a missing key attribute to the missing key handler.
+func (r *Reader) MissingKey(table, key string) error {
+	if r.onMissingKey == nil {
+		return nil
+	}
+	return r.onMissingKey(table, key)
 }
 
 func (r *Reader) ReadMapLength() (int, error) {
@@ -352,6 +486,9 @@ func (r *Reader) ReadMapLength() (int, error) {
 	if err = r.verifyMajorType(hdr, Map); err != nil {
 		return 0, err
 	}
+	if value > r.limits.maxArrayLength() {
+		return 0, ErrTooManyElements
+	}
 	return int(value), err
 }
 
@@ -363,6 +500,9 @@ func (r *Reader) ReadBytesLength() (int, error) {
 	if err = r.verifyMajorType(hdr, Bytes); err != nil {
 		return 0, err
 	}
+	if value > r.limits.maxStringLength() {
+		return 0, ErrObjTooBig
+	}
 	return int(value), err
 }
 
@@ -374,6 +514,9 @@ func (r *Reader) ReadArrayLength() (int, error) {
 	if err = r.verifyMajorType(hdr, Array); err != nil {
 		return 0, err
 	}
+	if value > r.limits.maxArrayLength() {
+		return 0, ErrTooManyElements
+	}
 	return int(value), err
 }
 
@@ -426,6 +569,73 @@ func (r *Reader) ReadBreak() (err error) {
 	return err
 }
 
+// Skip reads past the next item, including the items it contains, e.g. an
+// unknown parameter. Items nested deeper than the depth limit are rejected.
+func (r *Reader) Skip() error {
+	return r.skip(0)
+}
+
+func (r *Reader) skip(depth int) error {
+	if depth > r.limits.maxDepth() {
+		return &smithy.DeserializationError{Err: &DepthLimitError{Limit: r.limits.maxDepth()}}
+	}
+	hdr, value, err := r.readTypeHeader()
+	if err != nil {
+		return err
+	}
+	stream := hdr&MinorTypeMask == SizeStream
+	switch hdr & MajorTypeMask {
+	case Bytes, Utf:
+		if stream {
+			return r.skipStream(depth)
+		}
+		if value > r.limits.maxStringLength() {
+			return ErrObjTooBig
+		}
+		_, err = io.CopyN(io.Discard, r.br, int64(value))
+		return err
+	case Array, Map:
+		if stream {
+			return r.skipStream(depth)
+		}
+		if value > r.limits.maxArrayLength() {
+			return ErrTooManyElements
+		}
+		if hdr&MajorTypeMask == Map {
+			value *= 2
+		}
+		for i := uint64(0); i < value; i++ {
+			if err := r.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Tag:
+		return r.skip(depth + 1)
+	default:
+		if hdr == Break {
+			return &smithy.DeserializationError{Err: fmt.Errorf("cbor: unexpected break")}
+		}
+		return nil
+	}
+}
+
+// skipStream skips the items of an indefinite length item up to its break.
+func (r *Reader) skipStream(depth int) error {
+	for {
+		hdr, err := r.PeekHeader()
+		if err != nil {
+			return err
+		}
+		if hdr == Break {
+			return r.ReadBreak()
+		}
+		if err := r.skip(depth + 1); err != nil {
+			return err
+		}
+	}
+}
+
 // readRawTypeHeader reads a CBOR type header and also writes the raw bytes to output writer o
 func (r *Reader) readRawTypeHeader(o io.Writer) (hdr int, value uint64, err error) {
 	b, err := r.br.ReadByte()
@@ -545,8 +755,10 @@ func (r *Reader) ReadCborIntegerToString() (string, error) {
 }
 
 func (r *Reader) Close() error {
-	if r.recycle {
-		bufferedReaderPool.Put(r.br)
+	if r.pooled != nil {
+		r.pooled.Reset(nil)
+		bufferedReaderPool.Put(r.pooled)
+		r.pooled = nil
 	}
 	return nil
 }
@@ -632,6 +632,99 @@ func TestExceedMaxObjLen(t *testing.T) {
 	}
 }
 
+func TestReaderLimits(t *testing.T) {
+	header := func(typ int, n uint64) []byte {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.writeType(uint64(typ), n)
+		w.Flush()
+		return buf.Bytes()
+	}
+	limits := Limits{MaxArrayLength: 10, MaxStringLength: 100}
+
+	cases := []struct {
+		name string
+		in   []byte
+		read func(r *Reader) error
+		err  error
+	}{
+		{"array", header(Array, 11), func(r *Reader) error { _, err := r.ReadArrayLength(); return err }, ErrTooManyElements},
+		{"map", header(Map, 11), func(r *Reader) error { _, err := r.ReadMapLength(); return err }, ErrTooManyElements},
+		{"string", header(Utf, 101), func(r *Reader) error { _, err := r.ReadString(); return err }, ErrObjTooBig},
+		{"bytes", header(Bytes, 101), func(r *Reader) error { _, err := r.ReadBytes(); return err }, ErrObjTooBig},
+		{"bytes length", header(Bytes, 101), func(r *Reader) error { _, err := r.ReadBytesLength(); return err }, ErrObjTooBig},
+		{"raw bytes", header(Bytes, 101), func(r *Reader) error { return r.ReadRawBytes(&bytes.Buffer{}) }, ErrObjTooBig},
+		{"bytes reader", append(header(Bytes, 1), header(Array, 11)...), func(r *Reader) error {
+			br, err := r.BytesReader()
+			if err != nil {
+				return err
+			}
+			_, err = br.ReadArrayLength()
+			return err
+		}, ErrTooManyElements},
+		{"default array", header(Array, DefaultMaxArrayLength+1), func(r *Reader) error {
+			r.SetLimits(Limits{})
+			_, err := r.ReadArrayLength()
+			return err
+		}, ErrTooManyElements},
+		{"default string", header(Utf, DefaultMaxStringLength+1), func(r *Reader) error {
+			r.SetLimits(Limits{})
+			_, err := r.ReadString()
+			return err
+		}, ErrObjTooBig},
+	}
+	for _, c := range cases {
+		r := NewSliceReader(c.in)
+		r.SetLimits(limits)
+		if err := c.read(r); err != c.err {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+	}
+
+	r := NewSliceReader(header(Array, 10))
+	r.SetLimits(limits)
+	if n, err := r.ReadArrayLength(); err != nil || n != 10 {
+		t.Errorf("expected 10 elements, got %d, %v", n, err)
+	}
+}
+
+func TestReaderSkip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteMapStreamHeader()
+	w.WriteInt(1)
+	w.WriteArrayHeader(3)
+	w.WriteString("a")
+	w.WriteTag(TagDecimal)
+	w.WriteArrayHeader(2)
+	w.WriteInt(-2)
+	w.WriteInt(314)
+	w.WriteFloat64(1.5)
+	w.WriteInt(2)
+	w.WriteBytes([]byte("bytes"))
+	w.WriteStreamBreak()
+	w.WriteNull()
+	w.WriteString("next")
+	w.Flush()
+
+	r := NewSliceReader(buf.Bytes())
+	for i := 0; i < 2; i++ {
+		if err := r.Skip(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if s, err := r.ReadString(); err != nil || s != "next" {
+		t.Errorf("expected next, got %q, %v", s, err)
+	}
+
+	if err := NewSliceReader([]byte{Break}).Skip(); err == nil {
+		t.Errorf("expected error for a break")
+	}
+	if err := NewSliceReader([]byte{Array + 2, PosInt}).Skip(); err == nil {
+		t.Errorf("expected error for a truncated array")
+	}
+}
+
 func TestTruncatedFloat64(t *testing.T) {
 	truncatedFloatCBOR := []byte{0xfb, 0x40, 0x09, 0x21} // Incomplete 64-bit float
 	r := NewReader(bytes.NewReader(truncatedFloatCBOR))
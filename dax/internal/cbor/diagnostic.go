@@ -0,0 +1,230 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxDiagnosticDepth bounds nesting in Diagnose, deeper items are elided.
+const maxDiagnosticDepth = 64
+
+// Diagnose renders a sequence of cbor items in the diagnostic notation of
+// RFC 8949 section 8, e.g. [1, "a", h'00ff', {"k": 3321(["x"])}].
+// Items are separated by ", ". Truncated input is marked with <truncated>.
+//
+// When redact is set the content of text and byte strings is replaced with
+// its length, keeping the structure of the data visible without revealing
+// attribute values.
+func Diagnose(data []byte, redact bool) string {
+	d := diagnoser{data: data, redact: redact}
+	for d.pos < len(d.data) {
+		if d.pos > 0 {
+			d.sb.WriteString(", ")
+		}
+		if !d.item(0) {
+			d.sb.WriteString("<truncated>")
+			break
+		}
+	}
+	return d.sb.String()
+}
+
+type diagnoser struct {
+	data   []byte
+	pos    int
+	redact bool
+	sb     strings.Builder
+}
+
+// header reads an item header and returns its major type, minor type and argument.
+func (d *diagnoser) header() (major, minor byte, arg uint64, ok bool) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, false
+	}
+	hdr := d.data[d.pos]
+	d.pos++
+	major, minor = hdr&MajorTypeMask, hdr&MinorTypeMask
+	var n int
+	switch minor {
+	case Size8:
+		n = 1
+	case Size16:
+		n = 2
+	case Size32:
+		n = 4
+	case Size64:
+		n = 8
+	default:
+		return major, minor, uint64(minor), true
+	}
+	if len(d.data)-d.pos < n {
+		return 0, 0, 0, false
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 1:
+		arg = uint64(b[0])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(b))
+	default:
+		arg = binary.BigEndian.Uint64(b)
+	}
+	return major, minor, arg, true
+}
+
+func (d *diagnoser) isBreak() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == Break {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// item writes the next item and reports false if the input ended prematurely.
+func (d *diagnoser) item(depth int) bool {
+	major, minor, arg, ok := d.header()
+	if !ok {
+		return false
+	}
+
+	switch major {
+	case PosInt:
+		d.sb.WriteString(strconv.FormatUint(arg, 10))
+	case NegInt:
+		if arg == math.MaxUint64 {
+			d.sb.WriteString("-18446744073709551616")
+		} else {
+			d.sb.WriteString("-" + strconv.FormatUint(arg+1, 10))
+		}
+	case Bytes, Utf:
+		if minor == SizeStream {
+			d.sb.WriteString("(_ ")
+			for i := 0; !d.isBreak(); i++ {
+				if i > 0 {
+					d.sb.WriteString(", ")
+				}
+				if !d.item(depth + 1) {
+					return false
+				}
+			}
+			d.sb.WriteString(")")
+			return true
+		}
+		if uint64(len(d.data)-d.pos) < arg {
+			return false
+		}
+		b := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		switch {
+		case d.redact:
+			d.sb.WriteString("<redacted " + strconv.Itoa(len(b)) + " bytes>")
+		case major == Bytes:
+			d.sb.WriteString("h'" + hex.EncodeToString(b) + "'")
+		default:
+			d.sb.WriteString(strconv.Quote(string(b)))
+		}
+	case Array, Map:
+		open, close := "[", "]"
+		if major == Map {
+			open, close = "{", "}"
+		}
+		d.sb.WriteString(open)
+		if minor == SizeStream {
+			d.sb.WriteString("_ ")
+		}
+		if depth >= maxDiagnosticDepth {
+			d.sb.WriteString("..." + close)
+			d.pos = len(d.data)
+			return true
+		}
+		for i := uint64(0); minor == SizeStream || i < arg; i++ {
+			if minor == SizeStream && d.isBreak() {
+				break
+			}
+			if i > 0 {
+				d.sb.WriteString(", ")
+			}
+			if !d.item(depth + 1) {
+				return false
+			}
+			if major == Map {
+				d.sb.WriteString(": ")
+				if !d.item(depth + 1) {
+					return false
+				}
+			}
+		}
+		d.sb.WriteString(close)
+	case Tag:
+		d.sb.WriteString(strconv.FormatUint(arg, 10) + "(")
+		if depth >= maxDiagnosticDepth {
+			d.sb.WriteString("...)")
+			d.pos = len(d.data)
+			return true
+		}
+		if !d.item(depth + 1) {
+			return false
+		}
+		d.sb.WriteString(")")
+	default: // Simple
+		switch Simple + minor {
+		case False:
+			d.sb.WriteString("false")
+		case True:
+			d.sb.WriteString("true")
+		case Nil:
+			d.sb.WriteString("null")
+		case Undefined:
+			d.sb.WriteString("undefined")
+		case Float16:
+			d.sb.WriteString(strconv.FormatFloat(float64(halfToFloat32(uint16(arg))), 'g', -1, 32) + "_1")
+		case Float32:
+			d.sb.WriteString(strconv.FormatFloat(float64(math.Float32frombits(uint32(arg))), 'g', -1, 32) + "_2")
+		case Float64:
+			d.sb.WriteString(strconv.FormatFloat(math.Float64frombits(arg), 'g', -1, 64) + "_3")
+		case Break:
+			d.sb.WriteString("<break>")
+		default:
+			d.sb.WriteString("simple(" + strconv.FormatUint(arg, 10) + ")")
+		}
+	}
+	return true
+}
+
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch exp {
+	case 0:
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
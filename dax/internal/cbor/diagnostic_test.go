@@ -0,0 +1,82 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestDiagnose(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		redacted string
+		plain    string
+	}{
+		{name: "ints", data: []byte{0x01, 0x18, 0x64, 0x20, 0x38, 0x63}, plain: "1, 100, -1, -100"},
+		{name: "strings", data: []byte{0x63, 'a', 'b', 'c', 0x42, 0x00, 0xff}, plain: `"abc", h'00ff'`, redacted: "<redacted 3 bytes>, <redacted 2 bytes>"},
+		{name: "array and map", data: []byte{0x82, 0x01, 0xa1, 0x61, 'k', 0xf5}, plain: `[1, {"k": true}]`, redacted: `[1, {<redacted 1 bytes>: true}]`},
+		{name: "indefinite", data: []byte{0x9f, 0x01, 0x02, 0xff}, plain: "[_ 1, 2]"},
+		{name: "tag", data: []byte{0xd9, 0x0c, 0xf9, 0x81, 0x61, 'x'}, plain: `3321(["x"])`, redacted: "3321([<redacted 1 bytes>])"},
+		{name: "simple", data: []byte{0xf4, 0xf6, 0xf7, 0xf9, 0x3c, 0x00, 0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, plain: "false, null, undefined, 1_1, 1.5_3"},
+		{name: "truncated", data: []byte{0x82, 0x01}, plain: "[1, <truncated>"},
+		{name: "truncated string", data: []byte{0x63, 'a'}, plain: "<truncated>"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := Diagnose(c.data, false); actual != c.plain {
+				t.Errorf("expected %s, actual %s", c.plain, actual)
+			}
+			redacted := c.redacted
+			if redacted == "" {
+				redacted = c.plain
+			}
+			if actual := Diagnose(c.data, true); actual != redacted {
+				t.Errorf("expected redacted %s, actual %s", redacted, actual)
+			}
+		})
+	}
+}
+
+func TestDiagnoseAttributeValue(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	av := &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"ss": &types.AttributeValueMemberSS{Value: []string{"secret"}},
+	}}
+	if err := EncodeAttributeValue(av, w); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	w.Flush()
+
+	if actual, expected := Diagnose(buf.Bytes(), false), `{"ss": 3321(["secret"])}`; actual != expected {
+		t.Errorf("expected %s, actual %s", expected, actual)
+	}
+	if actual, expected := Diagnose(buf.Bytes(), true), `{<redacted 2 bytes>: 3321([<redacted 6 bytes>])}`; actual != expected {
+		t.Errorf("expected %s, actual %s", expected, actual)
+	}
+}
+
+func TestDiagnoseDeepNesting(t *testing.T) {
+	data := bytes.Repeat([]byte{0x81}, 100000)
+	if actual := Diagnose(data, false); len(actual) == 0 {
+		t.Error("expected output for deeply nested input")
+	}
+}
@@ -34,16 +34,25 @@ var ErrMissingKey = &smithy.GenericAPIError{
 }
 
 func EncodeItemKey(item map[string]types.AttributeValue, keydef []types.AttributeDefinition, writer *Writer) error {
-	keyBytes, err := GetEncodedItemKey(item, keydef)
-	if err != nil {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+	if err := encodeItemKey(item, keydef, buf); err != nil {
 		return err
 	}
-	return writer.WriteBytes(keyBytes)
+	return writer.WriteBytes(buf.Bytes())
 }
 
 func GetEncodedItemKey(item map[string]types.AttributeValue, keydef []types.AttributeDefinition) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeItemKey(item, keydef, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeItemKey(item map[string]types.AttributeValue, keydef []types.AttributeDefinition, buf *bytes.Buffer) error {
 	if item == nil {
-		return nil, &smithy.GenericAPIError{
+		return &smithy.GenericAPIError{
 			Code:    ErrCodeValidationException,
 			Message: "item cannot be nil",
 		}
@@ -52,11 +61,10 @@ func GetEncodedItemKey(item map[string]types.AttributeValue, keydef []types.Attr
 	hk := keydef[0]
 	hkval, foundKey := item[*hk.AttributeName]
 	if !foundKey {
-		return nil, ErrMissingKey
+		return ErrMissingKey
 	}
 
-	var buf bytes.Buffer
-	w := NewWriter(&buf)
+	w := NewWriter(buf)
 	defer w.Close()
 
 	if len(keydef) == 1 {
@@ -64,107 +72,104 @@ func GetEncodedItemKey(item map[string]types.AttributeValue, keydef []types.Attr
 		case types.ScalarAttributeTypeS:
 			sp, isExpectedType := hkval.(*types.AttributeValueMemberS)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.Write([]byte(sp.Value)); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeN:
 			_, isExpectedType := hkval.(*types.AttributeValueMemberN)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := EncodeAttributeValue(hkval, w); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeB:
 			b, isExpectedType := hkval.(*types.AttributeValueMemberB)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.Write(b.Value); err != nil {
-				return nil, err
+				return err
 			}
 		default:
-			return nil, fmt.Errorf("unsupported KeyType encountered in Hash Attribute: %s", hk.AttributeType)
+			return fmt.Errorf("unsupported KeyType encountered in Hash Attribute: %s", hk.AttributeType)
 		}
 	} else {
 		switch hk.AttributeType {
 		case types.ScalarAttributeTypeS:
 			sp, isExpectedType := hkval.(*types.AttributeValueMemberS)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.WriteString(sp.Value); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeN:
 			_, isExpectedType := hkval.(*types.AttributeValueMemberN)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := EncodeAttributeValue(hkval, w); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeB:
 			b, isExpectedType := hkval.(*types.AttributeValueMemberB)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.WriteBytes(b.Value); err != nil {
-				return nil, err
+				return err
 			}
 		default:
-			return nil, fmt.Errorf("unsupported KeyType encountered in Hash Attribute: %s", hk.AttributeType)
+			return fmt.Errorf("unsupported KeyType encountered in Hash Attribute: %s", hk.AttributeType)
 		}
 
 		rk := keydef[1]
 		rkval, foundKey := item[*rk.AttributeName]
 		if !foundKey {
-			return nil, ErrMissingKey
+			return ErrMissingKey
 		}
 		switch rk.AttributeType {
 		case types.ScalarAttributeTypeS:
 			sp, isExpectedType := rkval.(*types.AttributeValueMemberS)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.Write([]byte(sp.Value)); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeN:
 			n, isExpectedType := rkval.(*types.AttributeValueMemberN)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			d := new(Decimal)
 			d, isExpectedType = d.SetString(n.Value)
 			if !isExpectedType {
-				return nil, &smithy.GenericAPIError{
+				return &smithy.GenericAPIError{
 					Code:    ErrCodeValidationException,
 					Message: "invalid number " + n.Value,
 				}
 			}
 			if _, err := EncodeLexDecimal(d, w.bw); err != nil {
-				return nil, err
+				return err
 			}
 		case types.ScalarAttributeTypeB:
 			b, isExpectedType := rkval.(*types.AttributeValueMemberB)
 			if !isExpectedType {
-				return nil, ErrMissingKey
+				return ErrMissingKey
 			}
 			if err := w.Write(b.Value); err != nil {
-				return nil, err
+				return err
 			}
 		default:
-			return nil, fmt.Errorf("unsupported KeyType encountered in Range Attribute: %s", rk.AttributeType)
+			return fmt.Errorf("unsupported KeyType encountered in Range Attribute: %s", rk.AttributeType)
 		}
 	}
 
-	if err := w.Flush(); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
+	return w.Flush()
 }
 
 func DecodeItemKey(reader *Reader, keydef []types.AttributeDefinition) (map[string]types.AttributeValue, error) {
@@ -268,7 +273,7 @@ func DecodeItemKey(reader *Reader, keydef []types.AttributeDefinition) (map[stri
 }
 
 func EncodeItemNonKeyAttributes(ctx context.Context, item map[string]types.AttributeValue, keydef []types.AttributeDefinition,
-	attrNamesListToId *lru.Lru, writer *Writer) error {
+	attrNamesListToId *lru.Lru[lru.StringsKey, int64], writer *Writer) error {
 
 	keydeflen := len(keydef)
 	nonKeyAttrNames := make([]string, 0, len(item)-keydeflen)
@@ -284,12 +289,12 @@ func EncodeItemNonKeyAttributes(ctx context.Context, item map[string]types.Attri
 		nonKeyAttrValues[i] = item[k]
 	}
 
-	id, err := attrNamesListToId.GetWithContext(ctx, nonKeyAttrNames)
+	id, err := attrNamesListToId.GetWithContext(ctx, lru.NewStringsKey(nonKeyAttrNames))
 	if err != nil {
 		return err
 	}
 
-	if err = writer.WriteInt64(id.(int64)); err != nil {
+	if err = writer.WriteInt64(id); err != nil {
 		return err
 	}
 	for _, v := range nonKeyAttrValues {
@@ -301,7 +306,7 @@ func EncodeItemNonKeyAttributes(ctx context.Context, item map[string]types.Attri
 	return nil
 }
 
-func DecodeItemNonKeyAttributes(ctx context.Context, reader *Reader, attrListIdToNames *lru.Lru) (map[string]types.AttributeValue, error) {
+func DecodeItemNonKeyAttributes(ctx context.Context, reader *Reader, attrListIdToNames *lru.Lru[int64, []string]) (map[string]types.AttributeValue, error) {
 	id, err := reader.ReadInt64()
 	if err != nil {
 		return nil, err
@@ -312,11 +317,16 @@ func DecodeItemNonKeyAttributes(ctx context.Context, reader *Reader, attrListIdT
 	}
 
 	attrs := make(map[string]types.AttributeValue)
-	for _, n := range attrNames.([]string) {
+	for _, n := range attrNames {
 		av, err := DecodeAttributeValue(reader)
 		if err != nil {
 			return nil, err
 		}
+		if _, dup := attrs[n]; dup {
+			if err := reader.DuplicateKey(n); err != nil {
+				return nil, err
+			}
+		}
 		attrs[n] = av
 	}
 	return attrs, nil
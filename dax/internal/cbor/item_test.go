@@ -207,22 +207,17 @@ func TestItemNonKeyAttributes(t *testing.T) {
 	}
 	attrNames := []string{"av1", "av2", "av3"}
 	var attrListId int64 = 1
-	km := func(key lru.Key) lru.Key {
-		return fmt.Sprintf("%q", key)
-	}
-	attrNamesListToId := &lru.Lru{
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
-			an := key.([]string)
+	attrNamesListToId := &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(ctx context.Context, key lru.StringsKey) (int64, error) {
+			an := key.Strings()
 			if !reflect.DeepEqual(an, attrNames) {
-				return nil, errors.New(fmt.Sprintf("unknown attribute list %v %v", an, strings.Join(an, ",")))
+				return 0, errors.New(fmt.Sprintf("unknown attribute list %v %v", an, strings.Join(an, ",")))
 			}
 			return attrListId, nil
 		},
-		KeyMarshaller: km,
 	}
-	attrListIdToNames := &lru.Lru{
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
-			id := key.(int64)
+	attrListIdToNames := &lru.Lru[int64, []string]{
+		LoadFunc: func(ctx context.Context, id int64) ([]string, error) {
 			if id != attrListId {
 				return nil, errors.New(fmt.Sprintf("unknown attribute list id %v", id))
 			}
@@ -279,3 +274,32 @@ func TestItemKey_EmptyItem(t *testing.T) {
 		t.Errorf("expected error due to empty item, but got nil")
 	}
 }
+
+func BenchmarkEncodeItemNonKeyAttributes(b *testing.B) {
+	keydef := []types.AttributeDefinition{
+		{AttributeName: aws.String("hk"), AttributeType: types.ScalarAttributeTypeS},
+	}
+	item := map[string]types.AttributeValue{
+		"hk":  &types.AttributeValueMemberS{Value: "hkv"},
+		"av1": &types.AttributeValueMemberS{Value: "avs"},
+		"av2": &types.AttributeValueMemberN{Value: "456"},
+		"av3": &types.AttributeValueMemberBOOL{Value: true},
+	}
+	attrNamesListToId := &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(ctx context.Context, key lru.StringsKey) (int64, error) {
+			return 1, nil
+		},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := EncodeItemNonKeyAttributes(context.Background(), item, keydef, attrNamesListToId, w); err != nil {
+			b.Fatal(err)
+		}
+		w.Flush()
+	}
+}
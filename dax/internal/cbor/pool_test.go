@@ -0,0 +1,100 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestPutBuffer(t *testing.T) {
+	b := GetBuffer()
+	b.WriteString("data")
+	PutBuffer(b)
+	if b.Len() != 0 {
+		t.Errorf("expected a reset buffer, got %d bytes", b.Len())
+	}
+
+	large := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	large.WriteString("data")
+	PutBuffer(large)
+	if large.Len() == 0 {
+		t.Error("expected a large buffer to be left alone")
+	}
+}
+
+func TestWriterCloseTwice(t *testing.T) {
+	var a, b bytes.Buffer
+	w := NewWriter(&a)
+	w.Close()
+	w.Close()
+	w1, w2 := NewWriter(&a), NewWriter(&b)
+	if w1.bw == w2.bw {
+		t.Error("expected closing twice to pool the buffer once")
+	}
+}
+
+func TestEncodeItemKeyPooled(t *testing.T) {
+	keydef := []types.AttributeDefinition{
+		{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+		{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeN},
+	}
+	item := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "a"},
+		"sk": &types.AttributeValueMemberN{Value: "1.5"},
+	}
+	expected, err := GetEncodedItemKey(item, keydef)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if err := EncodeItemKey(item, keydef, w); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		w.Flush()
+		b, err := NewReader(&buf).ReadBytes()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !bytes.Equal(expected, b) {
+			t.Errorf("expected %x, actual %x", expected, b)
+		}
+	}
+}
+
+func BenchmarkEncodeItemKey(b *testing.B) {
+	keydef := []types.AttributeDefinition{
+		{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+		{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeS},
+	}
+	item := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "customer#123456"},
+		"sk": &types.AttributeValueMemberS{Value: "order#2024-01-01"},
+	}
+	w := NewWriter(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := EncodeItemKey(item, keydef, w); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TestRoundTripProperty encodes random items with the DAX cbor encoding and
+// with the JSON protocol of DynamoDB, as spoken to DynamoDB local, and checks
+// that both decode to items equal to the original. Numbers are compared by
+// value and sets regardless of order, as DynamoDB does.
+func TestRoundTripProperty(t *testing.T) {
+	seed := time.Now().UnixNano()
+	r := rand.New(rand.NewSource(seed))
+	iterations := 300
+	if testing.Short() {
+		iterations = 30
+	}
+
+	ddb := newEchoDynamoDB(t)
+	for i := 0; i < iterations; i++ {
+		item := randomItem(r, 0)
+
+		viaCbor, err := cborRoundTrip(item)
+		if err != nil {
+			t.Fatalf("seed %d: cbor round trip of %s: %v", seed, formatItem(item), err)
+		}
+		if !itemsEqual(item, viaCbor) {
+			t.Fatalf("seed %d: cbor round trip changed\n%s\nto\n%s", seed, formatItem(item), formatItem(viaCbor))
+		}
+
+		viaJSON, err := ddb.roundTrip(item)
+		if err != nil {
+			t.Fatalf("seed %d: json round trip of %s: %v", seed, formatItem(item), err)
+		}
+		if !itemsEqual(viaJSON, viaCbor) {
+			t.Fatalf("seed %d: cbor and json round trips differ\n%s\n%s", seed, formatItem(viaCbor), formatItem(viaJSON))
+		}
+	}
+}
+
+func cborRoundTrip(item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := EncodeAttributeValue(&types.AttributeValueMemberM{Value: item}, w); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	av, err := DecodeAttributeValue(NewReader(&buf))
+	if err != nil {
+		return nil, err
+	}
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, fmt.Errorf("decoded %T", av)
+	}
+	return m.Value, nil
+}
+
+// echoDynamoDB answers PutItem requests with the item as the old item, so a
+// PutItem with ReturnValues ALL_OLD round-trips the item through the JSON
+// serializer and deserializer of the DynamoDB client.
+type echoDynamoDB struct {
+	client *dynamodb.Client
+}
+
+func newEchoDynamoDB(t *testing.T) *echoDynamoDB {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Item json.RawMessage
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		fmt.Fprintf(w, `{"Attributes":%s}`, body.Item)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-west-2",
+		BaseEndpoint:     aws.String(srv.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+	return &echoDynamoDB{client: client}
+}
+
+func (e *echoDynamoDB) roundTrip(item map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	out, err := e.client.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName:    aws.String("t"),
+		Item:         item,
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Attributes, nil
+}
+
+func randomItem(r *rand.Rand, depth int) map[string]types.AttributeValue {
+	n := r.Intn(6)
+	if depth == 0 {
+		n++
+	}
+	item := make(map[string]types.AttributeValue, n)
+	for i := 0; i < n; i++ {
+		item[randomString(r, 1+r.Intn(8))] = randomAttributeValue(r, depth)
+	}
+	return item
+}
+
+func randomAttributeValue(r *rand.Rand, depth int) types.AttributeValue {
+	kinds := 10
+	if depth >= 3 {
+		kinds = 8 // no more lists or maps
+	}
+	switch r.Intn(kinds) {
+	case 0:
+		return &types.AttributeValueMemberS{Value: randomString(r, r.Intn(20))}
+	case 1:
+		return &types.AttributeValueMemberN{Value: randomNumber(r)}
+	case 2:
+		return &types.AttributeValueMemberB{Value: randomBytes(r, r.Intn(20))}
+	case 3:
+		return &types.AttributeValueMemberBOOL{Value: r.Intn(2) == 0}
+	case 4:
+		return &types.AttributeValueMemberNULL{Value: true}
+	case 5:
+		return &types.AttributeValueMemberSS{Value: randomSet(r, func() string { return randomString(r, r.Intn(10)) })}
+	case 6:
+		return &types.AttributeValueMemberNS{Value: randomSet(r, func() string { return randomNumber(r) })}
+	case 7:
+		bs := randomSet(r, func() string { return string(randomBytes(r, 1+r.Intn(10))) })
+		v := make([][]byte, len(bs))
+		for i, b := range bs {
+			v[i] = []byte(b)
+		}
+		return &types.AttributeValueMemberBS{Value: v}
+	case 8:
+		l := make([]types.AttributeValue, r.Intn(5))
+		for i := range l {
+			l[i] = randomAttributeValue(r, depth+1)
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	default:
+		return &types.AttributeValueMemberM{Value: randomItem(r, depth+1)}
+	}
+}
+
+// randomSet returns 1 to 5 distinct values, by numeric value for numbers.
+func randomSet(r *rand.Rand, gen func() string) []string {
+	n := 1 + r.Intn(5)
+	seen := map[string]bool{}
+	var set []string
+	for len(set) < n {
+		v := gen()
+		key := v
+		if rat, ok := new(big.Rat).SetString(v); ok {
+			key = rat.String()
+		}
+		if !seen[key] {
+			seen[key] = true
+			set = append(set, v)
+		}
+	}
+	return set
+}
+
+var stringRunes = []rune("aZ09 _-.:#é€日本\U0001F600")
+
+func randomString(r *rand.Rand, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteRune(stringRunes[r.Intn(len(stringRunes))])
+	}
+	return sb.String()
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
+
+// randomNumber returns a number DynamoDB accepts: small and large integers,
+// integers beyond 64 bits and decimals with up to 38 significant digits and
+// exponents, in the forms DynamoDB clients send them.
+func randomNumber(r *rand.Rand) string {
+	digits := func(n int) string {
+		var sb strings.Builder
+		sb.WriteByte(byte('1' + r.Intn(9)))
+		for i := 1; i < n; i++ {
+			sb.WriteByte(byte('0' + r.Intn(10)))
+		}
+		return sb.String()
+	}
+	sign := ""
+	if r.Intn(2) == 0 {
+		sign = "-"
+	}
+	switch r.Intn(6) {
+	case 0:
+		return strconv.Itoa(r.Intn(48) - 24)
+	case 1:
+		return strconv.FormatInt(r.Int63()>>uint(r.Intn(63)), 10)
+	case 2:
+		return sign + digits(19+r.Intn(20))
+	case 3:
+		d := digits(1 + r.Intn(38))
+		point := r.Intn(len(d) + 1)
+		if point == len(d) {
+			return sign + d + ".0"
+		}
+		return sign + d[:point] + "." + d[point:]
+	case 4:
+		return sign + "0.000" + digits(1+r.Intn(20))
+	default:
+		return sign + digits(1+r.Intn(20)) + "E" + strconv.Itoa(r.Intn(200)-100)
+	}
+}
+
+func itemsEqual(a, b map[string]types.AttributeValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, av := range a {
+		bv, ok := b[k]
+		if !ok || !attributeValuesEqual(av, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+func attributeValuesEqual(a, b types.AttributeValue) bool {
+	switch av := a.(type) {
+	case *types.AttributeValueMemberS:
+		bv, ok := b.(*types.AttributeValueMemberS)
+		return ok && av.Value == bv.Value
+	case *types.AttributeValueMemberN:
+		bv, ok := b.(*types.AttributeValueMemberN)
+		return ok && numbersEqual(av.Value, bv.Value)
+	case *types.AttributeValueMemberB:
+		bv, ok := b.(*types.AttributeValueMemberB)
+		return ok && bytes.Equal(av.Value, bv.Value)
+	case *types.AttributeValueMemberBOOL:
+		bv, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && av.Value == bv.Value
+	case *types.AttributeValueMemberNULL:
+		bv, ok := b.(*types.AttributeValueMemberNULL)
+		return ok && av.Value == bv.Value
+	case *types.AttributeValueMemberSS:
+		bv, ok := b.(*types.AttributeValueMemberSS)
+		return ok && setsEqual(av.Value, bv.Value, func(s string) string { return s })
+	case *types.AttributeValueMemberNS:
+		bv, ok := b.(*types.AttributeValueMemberNS)
+		return ok && setsEqual(av.Value, bv.Value, canonicalNumber)
+	case *types.AttributeValueMemberBS:
+		bv, ok := b.(*types.AttributeValueMemberBS)
+		if !ok {
+			return false
+		}
+		toStrings := func(bs [][]byte) []string {
+			s := make([]string, len(bs))
+			for i, b := range bs {
+				s[i] = string(b)
+			}
+			return s
+		}
+		return setsEqual(toStrings(av.Value), toStrings(bv.Value), func(s string) string { return s })
+	case *types.AttributeValueMemberL:
+		bv, ok := b.(*types.AttributeValueMemberL)
+		if !ok || len(av.Value) != len(bv.Value) {
+			return false
+		}
+		for i := range av.Value {
+			if !attributeValuesEqual(av.Value[i], bv.Value[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		bv, ok := b.(*types.AttributeValueMemberM)
+		return ok && itemsEqual(av.Value, bv.Value)
+	}
+	return false
+}
+
+func canonicalNumber(s string) string {
+	rat, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "invalid " + s
+	}
+	return rat.String()
+}
+
+func numbersEqual(a, b string) bool {
+	return canonicalNumber(a) == canonicalNumber(b)
+}
+
+func setsEqual(a, b []string, canonical func(string) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ca, cb := make([]string, len(a)), make([]string, len(b))
+	for i := range a {
+		ca[i], cb[i] = canonical(a[i]), canonical(b[i])
+	}
+	sort.Strings(ca)
+	sort.Strings(cb)
+	for i := range ca {
+		if ca[i] != cb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatItem(item map[string]types.AttributeValue) string {
+	var buf bytes.Buffer
+	formatAttributeValue(&buf, &types.AttributeValueMemberM{Value: item})
+	return buf.String()
+}
+
+func formatAttributeValue(w io.Writer, av types.AttributeValue) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberL:
+		io.WriteString(w, "L[")
+		for i, e := range v.Value {
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			formatAttributeValue(w, e)
+		}
+		io.WriteString(w, "]")
+	case *types.AttributeValueMemberM:
+		keys := make([]string, 0, len(v.Value))
+		for k := range v.Value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		io.WriteString(w, "M{")
+		for i, k := range keys {
+			if i > 0 {
+				io.WriteString(w, " ")
+			}
+			fmt.Fprintf(w, "%q:", k)
+			formatAttributeValue(w, v.Value[k])
+		}
+		io.WriteString(w, "}")
+	case *types.AttributeValueMemberS:
+		fmt.Fprintf(w, "S%q", v.Value)
+	case *types.AttributeValueMemberN:
+		fmt.Fprintf(w, "N(%s)", v.Value)
+	case *types.AttributeValueMemberB:
+		fmt.Fprintf(w, "B(%x)", v.Value)
+	case *types.AttributeValueMemberSS:
+		fmt.Fprintf(w, "SS%q", v.Value)
+	case *types.AttributeValueMemberNS:
+		fmt.Fprintf(w, "NS%v", v.Value)
+	case *types.AttributeValueMemberBS:
+		fmt.Fprintf(w, "BS%x", v.Value)
+	default:
+		fmt.Fprintf(w, "%T%+v", av, av)
+	}
+}
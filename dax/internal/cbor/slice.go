@@ -0,0 +1,81 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import "io"
+
+// sliceSource is the input of a Reader created with NewSliceReader.
+type sliceSource struct {
+	b   []byte
+	off int
+}
+
+func (s *sliceSource) Read(p []byte) (int, error) {
+	if s.off >= len(s.b) {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n := copy(p, s.b[s.off:])
+	s.off += n
+	return n, nil
+}
+
+func (s *sliceSource) ReadByte() (byte, error) {
+	if s.off >= len(s.b) {
+		return 0, io.EOF
+	}
+	c := s.b[s.off]
+	s.off++
+	return c, nil
+}
+
+func (s *sliceSource) Peek(n int) ([]byte, error) {
+	if rem := s.b[s.off:]; len(rem) < n {
+		return rem, io.EOF
+	}
+	return s.b[s.off : s.off+n], nil
+}
+
+func (s *sliceSource) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(s.b[s.off:])
+	s.off += n
+	return int64(n), err
+}
+
+// next returns the next n bytes without copying them. Their capacity is
+// capped so that appending to them does not overwrite the following bytes.
+func (s *sliceSource) next(n int) ([]byte, error) {
+	if len(s.b)-s.off < n {
+		s.off = len(s.b)
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := s.b[s.off : s.off+n : s.off+n]
+	s.off += n
+	return b, nil
+}
+
+// SkipItems returns the bytes of b following its first n cbor items.
+func SkipItems(b []byte, n int) ([]byte, error) {
+	r := NewSliceReader(b)
+	for i := 0; i < n; i++ {
+		if err := r.Skip(); err != nil {
+			return nil, err
+		}
+	}
+	return b[r.data.off:], nil
+}
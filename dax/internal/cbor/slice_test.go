@@ -0,0 +1,192 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"unsafe"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func encodeSliceTestData(t testing.TB) []byte {
+	var inner bytes.Buffer
+	iw := NewWriter(&inner)
+	iw.WriteString("xyz")
+	iw.Flush()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteString("abc")
+	w.WriteBytes([]byte{1, 2, 3})
+	w.WriteBytes(inner.Bytes())
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return buf.Bytes()
+}
+
+// within reports whether p points into b.
+func within(p *byte, b []byte) bool {
+	start := uintptr(unsafe.Pointer(unsafe.SliceData(b)))
+	return uintptr(unsafe.Pointer(p)) >= start && uintptr(unsafe.Pointer(p)) < start+uintptr(len(b))
+}
+
+func TestSliceReader(t *testing.T) {
+	data := encodeSliceTestData(t)
+	r := NewSliceReader(data)
+
+	s, err := r.ReadString()
+	if err != nil || s != "abc" {
+		t.Fatalf("expected abc, got %q %v", s, err)
+	}
+	if !within(unsafe.StringData(s), data) {
+		t.Error("expected the string to share memory with the input")
+	}
+
+	b, err := r.ReadBytes()
+	if err != nil || !bytes.Equal(b, []byte{1, 2, 3}) {
+		t.Fatalf("expected [1 2 3], got %v %v", b, err)
+	}
+	if !within(&b[0], data) {
+		t.Error("expected the bytes to share memory with the input")
+	}
+	if cap(b) != len(b) {
+		t.Errorf("expected the capacity to be capped at %d, got %d", len(b), cap(b))
+	}
+
+	br, err := r.BytesReader()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	s, err = br.ReadString()
+	if err != nil || s != "xyz" {
+		t.Fatalf("expected xyz, got %q %v", s, err)
+	}
+	if !within(unsafe.StringData(s), data) {
+		t.Error("expected the nested string to share memory with the input")
+	}
+	if _, err := br.PeekHeader(); err != io.EOF {
+		t.Errorf("expected EOF after the nested string, got %v", err)
+	}
+	if _, err := r.PeekHeader(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestBytesReader_stream(t *testing.T) {
+	data := encodeSliceTestData(t)
+	r := NewReader(bytes.NewReader(data))
+	defer r.Close()
+	if _, err := r.ReadString(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := r.ReadBytes(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	br, err := r.BytesReader()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if br.data == nil {
+		t.Fatal("expected a slice reader")
+	}
+	if s, err := br.ReadString(); err != nil || s != "xyz" {
+		t.Fatalf("expected xyz, got %q %v", s, err)
+	}
+}
+
+func TestSliceReader_truncated(t *testing.T) {
+	data := encodeSliceTestData(t)
+	for _, n := range []int{2, 6, len(data) - 1} {
+		r := NewSliceReader(data[:n])
+		var err error
+		for err == nil {
+			_, err = r.ReadString()
+			if err == nil {
+				_, err = r.ReadBytes()
+			}
+			if err == nil {
+				_, err = r.BytesReader()
+			}
+		}
+		if err != io.ErrUnexpectedEOF && err != io.EOF {
+			t.Errorf("expected EOF decoding %d bytes, got %v", n, err)
+		}
+	}
+}
+
+func TestSkipItems(t *testing.T) {
+	data := encodeSliceTestData(t)
+	rest, err := SkipItems(data, 1)
+	if err != nil || !bytes.Equal(rest, data[4:]) {
+		t.Fatalf("expected %x, got %x %v", data[4:], rest, err)
+	}
+	if rest, err := SkipItems(data, 3); err != nil || len(rest) != 0 {
+		t.Errorf("expected no bytes left, got %x %v", rest, err)
+	}
+	if _, err := SkipItems(data, 4); err == nil {
+		t.Error("expected an error skipping past the end")
+	}
+}
+
+func BenchmarkDecodeItemNonKeyAttributes(b *testing.B) {
+	keydef := []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}}
+	item := map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "key"}}
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = fmt.Sprintf("attr%02d", i)
+		item[names[i]] = &types.AttributeValueMemberS{Value: fmt.Sprintf("value of attribute %d", i)}
+	}
+	attrNamesListToId := &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(context.Context, lru.StringsKey) (int64, error) { return 1, nil },
+	}
+	attrListIdToNames := &lru.Lru[int64, []string]{
+		LoadFunc: func(context.Context, int64) ([]string, error) { return names, nil },
+	}
+
+	var attrs bytes.Buffer
+	aw := NewWriter(&attrs)
+	if err := EncodeItemNonKeyAttributes(context.Background(), item, keydef, attrNamesListToId, aw); err != nil {
+		b.Fatal(err)
+	}
+	aw.Flush()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteBytes(attrs.Bytes())
+	w.Flush()
+	data := buf.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewReader(bytes.NewReader(data))
+		br, err := r.BytesReader()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := DecodeItemNonKeyAttributes(context.Background(), br, attrListIdToNames); err != nil {
+			b.Fatal(err)
+		}
+		r.Close()
+	}
+}
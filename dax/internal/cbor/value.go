@@ -0,0 +1,252 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// ErrTruncated is returned by Values for input ending within an item.
+var ErrTruncated = errors.New("cbor: truncated input")
+
+var tagNames = map[uint64]string{
+	TagDatetime:            "Datetime",
+	TagTimestamp:           "Timestamp",
+	TagPosBigInt:           "PosBigInt",
+	TagNegBigInt:           "NegBigInt",
+	TagDecimal:             "Decimal",
+	TagBigFloat:            "BigFloat",
+	tagStringSet:           "StringSet",
+	tagNumberSet:           "NumberSet",
+	tagBinarySet:           "BinarySet",
+	tagDocumentPathOrdinal: "DocumentPathOrdinal",
+}
+
+// Values decodes a sequence of cbor items into values which marshal to JSON
+// for inspection: integers, float64, bool, nil, strings, []any, ByteString,
+// MapValue, TagValue or SimpleValue. Like Diagnose, redact replaces the
+// content of text and byte strings with their length.
+func Values(data []byte, redact bool) ([]any, error) {
+	v := valueDecoder{diagnoser: diagnoser{data: data, redact: redact}}
+	var values []any
+	for v.pos < len(v.data) {
+		val, err := v.value(0)
+		if err != nil {
+			return values, err
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// ByteString is a byte string, marshaled to JSON in hex along with its
+// content when it holds cbor items, as DAX embeds encoded keys and
+// attributes in byte strings.
+type ByteString []byte
+
+func (b ByteString) MarshalJSON() ([]byte, error) {
+	res := struct {
+		Bytes string `json:"bytes"`
+		CBOR  []any  `json:"cbor,omitempty"`
+	}{Bytes: hex.EncodeToString(b)}
+	if values, err := Values(b, false); err == nil {
+		res.CBOR = values
+	}
+	return marshalJSON(res)
+}
+
+// MapEntry is an entry of a MapValue.
+type MapEntry struct {
+	Key   any
+	Value any
+}
+
+// MapValue is a cbor map in encoding order, marshaled to a JSON object with its
+// keys formatted as strings.
+type MapValue []MapEntry
+
+func (m MapValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, ok := e.Key.(string)
+		if !ok {
+			key = fmt.Sprint(e.Key)
+		}
+		k, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := marshalJSON(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.Marshal without HTML escaping, which would turn the
+// redaction markers into "\u003credacted 3 bytes\u003e".
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// TagValue is a tagged item, Name is set for the tags known to DAX.
+type TagValue struct {
+	Tag   uint64 `json:"tag"`
+	Name  string `json:"name,omitempty"`
+	Value any    `json:"value"`
+}
+
+// SimpleValue is a simple value other than a boolean, null or a float,
+// e.g. "undefined"; it marshals to a JSON string.
+type SimpleValue string
+
+type valueDecoder struct {
+	diagnoser
+}
+
+func (v *valueDecoder) value(depth int) (any, error) {
+	if depth > maxDiagnosticDepth {
+		return nil, fmt.Errorf("cbor: items nested deeper than %d levels", maxDiagnosticDepth)
+	}
+	major, minor, arg, ok := v.header()
+	if !ok {
+		return nil, ErrTruncated
+	}
+
+	switch major {
+	case PosInt:
+		return arg, nil
+	case NegInt:
+		if arg > math.MaxInt64 {
+			n := new(big.Int).SetUint64(arg)
+			return json.Number(n.Neg(n).Sub(n, big.NewInt(1)).String()), nil
+		}
+		return -1 - int64(arg), nil
+	case Bytes, Utf:
+		var b []byte
+		if minor == SizeStream {
+			redact := v.redact
+			v.redact = false
+			for !v.isBreak() {
+				chunk, err := v.value(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				switch c := chunk.(type) {
+				case ByteString:
+					b = append(b, c...)
+				case string:
+					b = append(b, c...)
+				default:
+					return nil, fmt.Errorf("cbor: unexpected %T in a string stream", chunk)
+				}
+			}
+			v.redact = redact
+		} else {
+			if uint64(len(v.data)-v.pos) < arg {
+				return nil, ErrTruncated
+			}
+			b = v.data[v.pos : v.pos+int(arg)]
+			v.pos += int(arg)
+		}
+		if v.redact {
+			return "<redacted " + strconv.Itoa(len(b)) + " bytes>", nil
+		}
+		if major == Bytes {
+			return ByteString(b), nil
+		}
+		return string(b), nil
+	case Array:
+		l := []any{}
+		for i := uint64(0); minor == SizeStream || i < arg; i++ {
+			if minor == SizeStream && v.isBreak() {
+				break
+			}
+			e, err := v.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, e)
+		}
+		return l, nil
+	case Map:
+		m := MapValue{}
+		for i := uint64(0); minor == SizeStream || i < arg; i++ {
+			if minor == SizeStream && v.isBreak() {
+				break
+			}
+			k, err := v.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			e, err := v.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, MapEntry{Key: k, Value: e})
+		}
+		return m, nil
+	case Tag:
+		e, err := v.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return TagValue{Tag: arg, Name: tagNames[arg], Value: e}, nil
+	default: // Simple
+		switch Simple + minor {
+		case False:
+			return false, nil
+		case True:
+			return true, nil
+		case Nil:
+			return nil, nil
+		case Undefined:
+			return SimpleValue("undefined"), nil
+		case Float16:
+			return float64(halfToFloat32(uint16(arg))), nil
+		case Float32:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case Float64:
+			return math.Float64frombits(arg), nil
+		case Break:
+			return nil, errors.New("cbor: unexpected break")
+		default:
+			return SimpleValue("simple(" + strconv.FormatUint(arg, 10) + ")"), nil
+		}
+	}
+}
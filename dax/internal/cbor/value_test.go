@@ -0,0 +1,77 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		plain    string
+		redacted string
+	}{
+		{name: "ints", data: []byte{0x01, 0x18, 0x64, 0x20, 0x38, 0x63}, plain: "[1,100,-1,-100]"},
+		{name: "strings", data: []byte{0x63, 'a', 'b', 'c', 0x42, 0x00, 0xff}, plain: `["abc",{"bytes":"00ff"}]`, redacted: `["<redacted 3 bytes>","<redacted 2 bytes>"]`},
+		{name: "nested cbor", data: []byte{0x42, 0x61, 'k'}, plain: `[{"bytes":"616b","cbor":["k"]}]`, redacted: `["<redacted 2 bytes>"]`},
+		{name: "array and map", data: []byte{0x82, 0x01, 0xa2, 0x61, 'k', 0xf5, 0x02, 0xf6}, plain: `[[1,{"k":true,"2":null}]]`, redacted: `[[1,{"<redacted 1 bytes>":true,"2":null}]]`},
+		{name: "indefinite", data: []byte{0x9f, 0x01, 0x7f, 0x61, 'a', 0x61, 'b', 0xff, 0xff}, plain: `[[1,"ab"]]`, redacted: `[[1,"<redacted 2 bytes>"]]`},
+		{name: "tag", data: []byte{0xd9, 0x0c, 0xf9, 0x81, 0x61, 'x'}, plain: `[{"tag":3321,"name":"StringSet","value":["x"]}]`, redacted: `[{"tag":3321,"name":"StringSet","value":["<redacted 1 bytes>"]}]`},
+		{name: "simple", data: []byte{0xf4, 0xf7, 0xf9, 0x3c, 0x00, 0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, plain: `[false,"undefined",1,1.5]`},
+		{name: "big negative", data: []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, plain: "[-18446744073709551616]"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			redacted := c.redacted
+			if redacted == "" {
+				redacted = c.plain
+			}
+			for _, redact := range []bool{false, true} {
+				values, err := Values(c.data, redact)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				var buf bytes.Buffer
+				enc := json.NewEncoder(&buf)
+				enc.SetEscapeHTML(false)
+				if err := enc.Encode(values); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				expected := c.plain
+				if redact {
+					expected = redacted
+				}
+				if actual := strings.TrimSpace(buf.String()); actual != expected {
+					t.Errorf("expected %s, actual %s", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestValuesErrors(t *testing.T) {
+	for _, data := range [][]byte{{0x82, 0x01}, {0x63, 'a'}, {0xff}, bytes.Repeat([]byte{0x81}, 1000)} {
+		if _, err := Values(data, false); err == nil {
+			t.Errorf("expected error for %x", data)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/smithy-go/metrics"
+)
+
+// maxTrackedAttributeLists bounds the attribute lists remembered per table,
+// the reported counts stop growing past it.
+const maxTrackedAttributeLists = 1 << 16
+
+type attributeListTableKey struct{}
+
+// withAttributeListTable records the table whose item attributes are encoded
+// with ctx, for the attribute lists registered on its behalf.
+func withAttributeListTable(ctx context.Context, table string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, attributeListTableKey{}, table)
+}
+
+// attributeListTracker counts the distinct attribute lists registered for
+// each table by all nodes of a cluster. Every distinct set of non-key
+// attribute names written needs its own list, so applications using
+// unbounded attribute names keep registering new ones.
+type attributeListTracker struct {
+	threshold  int
+	onExceeded func(table string, n int)
+	metrics    *daxSdkMetrics
+	seed       maphash.Seed
+
+	mu     sync.Mutex
+	tables map[string]map[uint64]struct{} // hashes of the lists of every table
+}
+
+func newAttributeListTracker(threshold int, onExceeded func(table string, n int), sdkMetrics *daxSdkMetrics) *attributeListTracker {
+	return &attributeListTracker{
+		threshold:  threshold,
+		onExceeded: onExceeded,
+		metrics:    sdkMetrics,
+		seed:       maphash.MakeSeed(),
+		tables:     make(map[string]map[uint64]struct{}),
+	}
+}
+
+// observe records the registration of the attribute list names for the
+// table recorded in ctx.
+func (t *attributeListTracker) observe(ctx context.Context, names []string) {
+	table, ok := ctx.Value(attributeListTableKey{}).(string)
+	if !ok {
+		return
+	}
+	var h maphash.Hash
+	h.SetSeed(t.seed)
+	for _, n := range names {
+		h.WriteString(n)
+		h.WriteByte(0)
+	}
+	sum := h.Sum64()
+
+	t.mu.Lock()
+	lists := t.tables[table]
+	if lists == nil {
+		lists = make(map[uint64]struct{})
+		t.tables[table] = lists
+	}
+	_, seen := lists[sum]
+	if !seen && len(lists) < maxTrackedAttributeLists {
+		lists[sum] = struct{}{}
+	}
+	n := len(lists)
+	t.mu.Unlock()
+	if seen {
+		return
+	}
+
+	if g := t.metrics.gaugeFor(daxAttributeListsRegistered); g != nil {
+		g.Sample(ctx, int64(n), func(o *metrics.RecordMetricOptions) {
+			o.Properties.Set("table", table)
+		})
+	}
+	if t.threshold > 0 && n == t.threshold+1 {
+		countMetricInt64(ctx, t.metrics, daxAttributeListsExceeded, 1)
+		if t.onExceeded != nil {
+			t.onExceeded(table, n)
+		}
+	}
+}
+
+// counts returns the number of distinct attribute lists of every table.
+func (t *attributeListTracker) counts() map[string]int {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.tables) == 0 {
+		return nil
+	}
+	res := make(map[string]int, len(t.tables))
+	for table, lists := range t.tables {
+		res[table] = len(lists)
+	}
+	return res
+}
+
+// onAttributeListsExceeded warns that a table passed AttributeListThreshold.
+func (c *cluster) onAttributeListsExceeded(table string, n int) {
+	c.warnLog("Table %s uses more than %d distinct sets of attribute names, which DAX must register one by one. "+
+		"Avoid attribute names built from data, e.g. store such values in a map attribute instead.", table, n-1)
+	c.emit(types.ClusterEvent{Type: types.ClusterEventAttributeListsExceeded, Time: time.Now(), Table: table, AttributeLists: n})
+}
@@ -0,0 +1,101 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeListTracker(t *testing.T) {
+	om, err := buildDaxSdkMetrics(&testMeterProvider{})
+	require.NoError(t, err)
+	var exceeded []string
+	tracker := newAttributeListTracker(2, func(table string, n int) {
+		exceeded = append(exceeded, table)
+		assert.Equal(t, 3, n)
+	}, om)
+
+	ctx := withAttributeListTable(context.Background(), "t1")
+	tracker.observe(ctx, []string{"a"})
+	tracker.observe(ctx, []string{"a"})
+	tracker.observe(ctx, []string{"a", "b"})
+	tracker.observe(withAttributeListTable(context.Background(), "t2"), []string{"a"})
+	tracker.observe(context.Background(), []string{"c"})
+	assert.Equal(t, map[string]int{"t1": 2, "t2": 1}, tracker.counts())
+	assert.Empty(t, exceeded)
+	_, _, n := gauge(om, daxAttributeListsRegistered)
+	assert.Equal(t, 1, n)
+
+	// names are not concatenated ambiguously
+	tracker.observe(ctx, []string{"ab"})
+	tracker.observe(ctx, []string{"abc"})
+	assert.Equal(t, []string{"t1"}, exceeded)
+	assert.Equal(t, 4, tracker.counts()["t1"])
+	expectCounters(t, om, map[string]int{daxAttributeListsExceeded: 1})
+}
+
+func TestEncodeNonKeyAttributes_table(t *testing.T) {
+	var tables []string
+	attrNamesListToId := &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(ctx context.Context, key lru.StringsKey) (int64, error) {
+			table, _ := ctx.Value(attributeListTableKey{}).(string)
+			tables = append(tables, table)
+			return 1, nil
+		},
+	}
+	keys := []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}}
+	item := map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "k"},
+		"a":  &types.AttributeValueMemberS{Value: "v"},
+	}
+	w := cbor.NewWriter(&bytes.Buffer{})
+	defer w.Close()
+	require.NoError(t, encodeNonKeyAttributes(context.Background(), "t1", item, keys, attrNamesListToId, w))
+	assert.Equal(t, []string{"t1"}, tables)
+}
+
+func TestCluster_AttributeListThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	var events []daxTypes.ClusterEvent
+	cfg.OnClusterEvent = func(e daxTypes.ClusterEvent) { events = append(events, e) }
+	cfg.AttributeListThreshold = 1
+	c, err := newCluster(cfg)
+	require.NoError(t, err)
+
+	ctx := withAttributeListTable(context.Background(), "t1")
+	c.config.connConfig.attributeLists.observe(ctx, []string{"a"})
+	c.config.connConfig.attributeLists.observe(ctx, []string{"b"})
+	require.Len(t, events, 1)
+	assert.Equal(t, daxTypes.ClusterEventAttributeListsExceeded, events[0].Type)
+	assert.Equal(t, "t1", events[0].Table)
+	assert.Equal(t, 2, events[0].AttributeLists)
+	assert.Equal(t, map[string]int{"t1": 2}, c.state().AttributeLists)
+
+	cfg.AttributeListThreshold = -1
+	assert.Error(t, cfg.validate())
+}
@@ -0,0 +1,158 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/smithy-go"
+)
+
+// CacheInvalidator is implemented by clients which keep client-local caches.
+type CacheInvalidator interface {
+	InvalidateCaches(scope types.CacheScope) error
+}
+
+// TablePrewarmer is implemented by clients which cache the key schemas of tables.
+type TablePrewarmer interface {
+	PrewarmTables(ctx context.Context, tables ...string) error
+}
+
+func validateCacheScope(scope types.CacheScope) error {
+	invalidParams := smithy.InvalidParamsError{Context: "CacheScope"}
+	switch scope.Level {
+	case types.CacheScopeAll:
+	case types.CacheScopeTable:
+		if scope.TableName == "" {
+			invalidParams.Add(smithy.NewErrParamRequired("TableName"))
+		}
+	case types.CacheScopeKey:
+		if scope.TableName == "" {
+			invalidParams.Add(smithy.NewErrParamRequired("TableName"))
+		}
+		if len(scope.Key) == 0 {
+			invalidParams.Add(smithy.NewErrParamRequired("Key"))
+		}
+	default:
+		invalidParams.Add(NewCustomInvalidParamError("Level", fmt.Sprintf("unsupported cache scope %q", scope.Level)))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// InvalidateCaches drops the client-local cache entries selected by scope.
+// Key schemas and attribute lists are reloaded from the server on next use.
+func (client *SingleDaxClient) InvalidateCaches(scope types.CacheScope) error {
+	if err := validateCacheScope(scope); err != nil {
+		return err
+	}
+	switch scope.Level {
+	case types.CacheScopeAll:
+		client.keySchema.Clear()
+		client.attrNamesListToId.Clear()
+		client.attrListIdToNames.Clear()
+	case types.CacheScopeTable:
+		client.keySchema.Remove(scope.TableName)
+	case types.CacheScopeKey:
+		// Key schemas and attribute lists are not item specific.
+	}
+	return nil
+}
+
+// InvalidateCaches drops the client-local cache entries selected by scope on
+// every node client and from the item cache.
+func (cc *ClusterDaxClient) InvalidateCaches(scope types.CacheScope) error {
+	if err := validateCacheScope(scope); err != nil {
+		return err
+	}
+	if cc.items != nil {
+		cc.items.invalidate(scope)
+	}
+	return cc.cluster.invalidateCaches(scope)
+}
+
+func (c *cluster) invalidateCaches(scope types.CacheScope) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, cliAndCfg := range c.active {
+		if ci, ok := cliAndCfg.client.(CacheInvalidator); ok {
+			if err := ci.InvalidateCaches(scope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PrewarmTables fetches and caches the key schemas of tables which are not
+// cached yet.
+func (client *SingleDaxClient) PrewarmTables(ctx context.Context, tables ...string) error {
+	var errs []error
+	for _, table := range tables {
+		if table == "" {
+			errs = append(errs, smithy.NewErrParamRequired("TableName"))
+			continue
+		}
+		if _, err := client.keySchema.GetWithContext(ctx, table); err != nil {
+			errs = append(errs, fmt.Errorf("table %s: %w", table, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PrewarmTables fetches and caches the key schemas of tables on every node
+// client, concurrently. Nodes joining the cluster later fetch them on first use.
+func (cc *ClusterDaxClient) PrewarmTables(ctx context.Context, tables ...string) error {
+	return cc.cluster.prewarmTables(ctx, tables)
+}
+
+func (c *cluster) prewarmTables(ctx context.Context, tables []string) error {
+	c.lock.RLock()
+	if c.closed {
+		c.lock.RUnlock()
+		return os.ErrClosed
+	}
+	nodes := make([]clientAndConfig, 0, len(c.active))
+	for _, cliAndCfg := range c.active {
+		nodes = append(nodes, cliAndCfg)
+	}
+	c.lock.RUnlock()
+
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		tp, ok := n.client.(TablePrewarmer)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, n clientAndConfig) {
+			defer wg.Done()
+			if err := tp.PrewarmTables(ctx, tables...); err != nil {
+				errs[i] = fmt.Errorf("node %s: %w", nodeAddress(n), err)
+			}
+		}(i, n)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
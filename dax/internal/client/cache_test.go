@@ -0,0 +1,163 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheTestClient(t *testing.T) *SingleDaxClient {
+	cli, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, nil, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+
+	cli.keySchema.LoadFunc = func(ctx context.Context, table string) ([]ddbtypes.AttributeDefinition, error) {
+		return nil, nil
+	}
+	cli.attrNamesListToId.LoadFunc = func(ctx context.Context, key lru.StringsKey) (int64, error) {
+		return 1, nil
+	}
+	cli.attrListIdToNames.LoadFunc = func(ctx context.Context, id int64) ([]string, error) {
+		return nil, nil
+	}
+	return cli
+}
+
+func TestSingleDaxClient_InvalidateCaches(t *testing.T) {
+	fill := func(cli *SingleDaxClient) {
+		for _, table := range []string{"t1", "t2"} {
+			_, err := cli.keySchema.GetWithContext(context.Background(), table)
+			require.NoError(t, err)
+		}
+		_, err := cli.attrNamesListToId.GetWithContext(context.Background(), lru.NewStringsKey([]string{"a", "b"}))
+		require.NoError(t, err)
+		_, err = cli.attrListIdToNames.GetWithContext(context.Background(), int64(2))
+		require.NoError(t, err)
+	}
+
+	t.Run("all", func(t *testing.T) {
+		cli := newCacheTestClient(t)
+		fill(cli)
+		require.NoError(t, cli.InvalidateCaches(types.AllCaches()))
+		assert.Equal(t, 0, cli.keySchema.Len())
+		assert.Equal(t, 0, cli.attrNamesListToId.Len())
+		assert.Equal(t, 0, cli.attrListIdToNames.Len())
+	})
+
+	t.Run("table", func(t *testing.T) {
+		cli := newCacheTestClient(t)
+		fill(cli)
+		require.NoError(t, cli.InvalidateCaches(types.TableCaches("t1")))
+		assert.Equal(t, 1, cli.keySchema.Len())
+		assert.Equal(t, 1, cli.attrNamesListToId.Len())
+		assert.Equal(t, 1, cli.attrListIdToNames.Len())
+	})
+
+	t.Run("key", func(t *testing.T) {
+		cli := newCacheTestClient(t)
+		fill(cli)
+		key := map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: "v"}}
+		require.NoError(t, cli.InvalidateCaches(types.KeyCaches("t1", key)))
+		assert.Equal(t, 2, cli.keySchema.Len())
+	})
+}
+
+func TestValidateCacheScope(t *testing.T) {
+	key := map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: "v"}}
+	cases := []struct {
+		name  string
+		scope types.CacheScope
+		valid bool
+	}{
+		{"all", types.AllCaches(), true},
+		{"table", types.TableCaches("t"), true},
+		{"table without name", types.TableCaches(""), false},
+		{"key", types.KeyCaches("t", key), true},
+		{"key without key", types.KeyCaches("t", nil), false},
+		{"key without table", types.KeyCaches("", key), false},
+		{"unknown level", types.CacheScope{Level: "bogus"}, false},
+		{"zero value", types.CacheScope{}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateCacheScope(c.scope)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCluster_InvalidateCaches(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cli := newCacheTestClient(t)
+	_, err := cli.keySchema.GetWithContext(context.Background(), "t1")
+	require.NoError(t, err)
+	cluster.active = map[hostPort]clientAndConfig{
+		{"127.0.0.1", 8111}: {client: cli},
+	}
+	cc := &ClusterDaxClient{config: cluster.config, cluster: cluster}
+
+	require.NoError(t, cc.InvalidateCaches(types.AllCaches()))
+	assert.Equal(t, 0, cli.keySchema.Len())
+	assert.Error(t, cc.InvalidateCaches(types.TableCaches("")))
+}
+
+func TestSingleDaxClient_PrewarmTables(t *testing.T) {
+	cli := newCacheTestClient(t)
+	loads := 0
+	cli.keySchema.LoadFunc = func(ctx context.Context, table string) ([]ddbtypes.AttributeDefinition, error) {
+		loads++
+		if table == "missing" {
+			return nil, errors.New("table not found")
+		}
+		return nil, nil
+	}
+
+	require.NoError(t, cli.PrewarmTables(context.Background(), "t1", "t2"))
+	assert.Equal(t, 2, cli.keySchema.Len())
+	require.NoError(t, cli.PrewarmTables(context.Background(), "t1"))
+	assert.Equal(t, 2, loads)
+
+	err := cli.PrewarmTables(context.Background(), "missing", "", "t3")
+	assert.ErrorContains(t, err, "table missing")
+	assert.ErrorContains(t, err, "TableName")
+	assert.Equal(t, 3, cli.keySchema.Len())
+}
+
+func TestCluster_PrewarmTables(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cli1, cli2 := newCacheTestClient(t), newCacheTestClient(t)
+	cluster.active = map[hostPort]clientAndConfig{
+		{"127.0.0.1", 8111}: {client: cli1},
+		{"127.0.0.2", 8111}: {client: cli2},
+	}
+	cc := &ClusterDaxClient{config: cluster.config, cluster: cluster}
+
+	require.NoError(t, cc.PrewarmTables(context.Background(), "t1", "t2"))
+	assert.Equal(t, 2, cli1.keySchema.Len())
+	assert.Equal(t, 2, cli2.keySchema.Len())
+}
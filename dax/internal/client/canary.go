@@ -0,0 +1,419 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/metrics"
+)
+
+const (
+	daxCanaryRequests = "dax.canary.%s.requests"
+	daxCanaryFailures = "dax.canary.%s.failures"
+
+	// canaryBuckets is the number of buckets request hashes are mapped to,
+	// the canary percentage is applied with a precision of 0.01%.
+	canaryBuckets = 10000
+)
+
+// CanaryClient routes each request to one of two clusters, sending a
+// percentage of the requests to the canary cluster and the rest to the stable
+// one, e.g. during a blue/green migration.
+//
+// A request is assigned by a hash of its tables and keys, so requests for the
+// same items go to the same cluster as long as the percentage is unchanged.
+type CanaryClient struct {
+	clients   [2]DaxAPI // stable, canary
+	threshold atomic.Int64
+	requests  [2]metrics.Int64Counter
+	failures  [2]metrics.Int64Counter
+}
+
+// NewCanaryClient creates a client sending percent of the requests to canary.
+// The number of requests and failures per cluster is reported to mp.
+func NewCanaryClient(stable, canary DaxAPI, percent float64, mp metrics.MeterProvider) (*CanaryClient, error) {
+	if mp == nil {
+		mp = &metrics.NopMeterProvider{}
+	}
+	c := &CanaryClient{clients: [2]DaxAPI{stable, canary}}
+	if err := c.SetPercent(percent); err != nil {
+		return nil, err
+	}
+	meter := mp.Meter(daxMeterScope)
+	for i, name := range []string{"stable", "canary"} {
+		var err error
+		c.requests[i], err = operationCounter(meter, fmt.Sprintf(daxCanaryRequests, name), fmt.Sprintf("Requests sent to the %s cluster", name))
+		if err != nil {
+			return nil, err
+		}
+		c.failures[i], err = operationCounter(meter, fmt.Sprintf(daxCanaryFailures, name), fmt.Sprintf("Failed requests sent to the %s cluster", name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// SetPercent changes the percentage of requests sent to the canary cluster,
+// from 0 to 100. Requests already in flight are not affected.
+func (c *CanaryClient) SetPercent(percent float64) error {
+	if math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return NewCustomInvalidParamError("ConfigValidation", "canary percent must be between 0 and 100")
+	}
+	c.threshold.Store(int64(math.Round(percent * canaryBuckets / 100)))
+	return nil
+}
+
+// Percent returns the percentage of requests sent to the canary cluster.
+func (c *CanaryClient) Percent() float64 {
+	return float64(c.threshold.Load()) * 100 / canaryBuckets
+}
+
+// pick returns the index of the client serving a request with hash h.
+func (c *CanaryClient) pick(h uint64) int {
+	if int64(h%canaryBuckets) < c.threshold.Load() {
+		return 1
+	}
+	return 0
+}
+
+func canaryCall[O any](ctx context.Context, c *CanaryClient, h uint64, call func(DaxAPI) (O, error)) (O, error) {
+	i := c.pick(h)
+	if c.requests[i] != nil {
+		c.requests[i].Add(ctx, 1)
+	}
+	out, err := call(c.clients[i])
+	if err != nil && c.failures[i] != nil {
+		c.failures[i].Add(ctx, 1)
+	}
+	return out, err
+}
+
+func (c *CanaryClient) PutItemWithOptions(ctx context.Context, input *dynamodb.PutItemInput, output *dynamodb.PutItemOutput, opt RequestOptions) (*dynamodb.PutItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.item(c.itemKey(ctx, aws.ToString(input.TableName), input.Item))
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.PutItemOutput, error) {
+		return api.PutItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) DeleteItemWithOptions(ctx context.Context, input *dynamodb.DeleteItemInput, output *dynamodb.DeleteItemOutput, opt RequestOptions) (*dynamodb.DeleteItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.item(input.Key)
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.DeleteItemOutput, error) {
+		return api.DeleteItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) UpdateItemWithOptions(ctx context.Context, input *dynamodb.UpdateItemInput, output *dynamodb.UpdateItemOutput, opt RequestOptions) (*dynamodb.UpdateItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.item(input.Key)
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.UpdateItemOutput, error) {
+		return api.UpdateItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) GetItemWithOptions(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.item(input.Key)
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.GetItemOutput, error) {
+		return api.GetItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+// ScanWithOptions routes all pages of a scan segment to the same cluster.
+func (c *CanaryClient) ScanWithOptions(ctx context.Context, input *dynamodb.ScanInput, output *dynamodb.ScanOutput, opt RequestOptions) (*dynamodb.ScanOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.str(input.IndexName)
+		h.str(input.FilterExpression)
+		h.item(input.ExpressionAttributeValues)
+		if input.Segment != nil {
+			h.w.WriteInt(int(*input.Segment))
+		}
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.ScanOutput, error) {
+		return api.ScanWithOptions(ctx, input, output, opt)
+	})
+}
+
+// QueryWithOptions routes all pages of a query to the same cluster.
+func (c *CanaryClient) QueryWithOptions(ctx context.Context, input *dynamodb.QueryInput, output *dynamodb.QueryOutput, opt RequestOptions) (*dynamodb.QueryOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		h.str(input.TableName)
+		h.str(input.IndexName)
+		h.str(input.KeyConditionExpression)
+		h.item(input.ExpressionAttributeValues)
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.QueryOutput, error) {
+		return api.QueryWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) BatchWriteItemWithOptions(ctx context.Context, input *dynamodb.BatchWriteItemInput, output *dynamodb.BatchWriteItemOutput, opt RequestOptions) (*dynamodb.BatchWriteItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		for _, table := range sortedKeys(input.RequestItems) {
+			h.str(aws.String(table))
+			for _, wr := range input.RequestItems[table] {
+				if wr.PutRequest != nil {
+					h.item(c.itemKey(ctx, table, wr.PutRequest.Item))
+				}
+				if wr.DeleteRequest != nil {
+					h.item(wr.DeleteRequest.Key)
+				}
+			}
+		}
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.BatchWriteItemOutput, error) {
+		return api.BatchWriteItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) BatchGetItemWithOptions(ctx context.Context, input *dynamodb.BatchGetItemInput, output *dynamodb.BatchGetItemOutput, opt RequestOptions) (*dynamodb.BatchGetItemOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		for _, table := range sortedKeys(input.RequestItems) {
+			h.str(aws.String(table))
+			for _, key := range input.RequestItems[table].Keys {
+				h.item(key)
+			}
+		}
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.BatchGetItemOutput, error) {
+		return api.BatchGetItemWithOptions(ctx, input, output, opt)
+	})
+}
+
+// TransactWriteItemsWithOptions routes transactions with the same
+// ClientRequestToken to the same cluster, so a retried transaction is
+// recognized by the cluster which saw it first.
+func (c *CanaryClient) TransactWriteItemsWithOptions(ctx context.Context, input *dynamodb.TransactWriteItemsInput, output *dynamodb.TransactWriteItemsOutput, opt RequestOptions) (*dynamodb.TransactWriteItemsOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		if input.ClientRequestToken != nil {
+			h.str(input.ClientRequestToken)
+		} else {
+			for _, twi := range input.TransactItems {
+				switch {
+				case twi.Put != nil:
+					h.str(twi.Put.TableName)
+					h.item(c.itemKey(ctx, aws.ToString(twi.Put.TableName), twi.Put.Item))
+				case twi.Delete != nil:
+					h.str(twi.Delete.TableName)
+					h.item(twi.Delete.Key)
+				case twi.Update != nil:
+					h.str(twi.Update.TableName)
+					h.item(twi.Update.Key)
+				case twi.ConditionCheck != nil:
+					h.str(twi.ConditionCheck.TableName)
+					h.item(twi.ConditionCheck.Key)
+				}
+			}
+		}
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.TransactWriteItemsOutput, error) {
+		return api.TransactWriteItemsWithOptions(ctx, input, output, opt)
+	})
+}
+
+func (c *CanaryClient) TransactGetItemsWithOptions(ctx context.Context, input *dynamodb.TransactGetItemsInput, output *dynamodb.TransactGetItemsOutput, opt RequestOptions) (*dynamodb.TransactGetItemsOutput, error) {
+	h := newRequestHash()
+	if input != nil {
+		for _, tgi := range input.TransactItems {
+			if tgi.Get != nil {
+				h.str(tgi.Get.TableName)
+				h.item(tgi.Get.Key)
+			}
+		}
+	}
+	return canaryCall(ctx, c, h.sum(), func(api DaxAPI) (*dynamodb.TransactGetItemsOutput, error) {
+		return api.TransactGetItemsWithOptions(ctx, input, output, opt)
+	})
+}
+
+// itemKey returns the key attributes of item in table, so that writes of an
+// item go where the other requests for its key go. The key schema comes from
+// the stable cluster, the whole item is returned if it is unavailable.
+func (c *CanaryClient) itemKey(ctx context.Context, table string, item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	ke, ok := c.clients[0].(KeyExtractor)
+	if !ok {
+		return item
+	}
+	key, err := ke.ExtractKey(ctx, table, item)
+	if err != nil {
+		return item
+	}
+	return key.Attributes
+}
+
+// canaryEach calls f with each client implementing T, joining the errors.
+func canaryEach[T any](c *CanaryClient, f func(T) error) error {
+	var errs []error
+	for _, api := range c.clients {
+		if t, ok := api.(T); ok {
+			errs = append(errs, f(t))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InvalidateCaches invalidates the caches of both clients.
+func (c *CanaryClient) InvalidateCaches(scope daxTypes.CacheScope) error {
+	return canaryEach(c, func(ci CacheInvalidator) error {
+		return ci.InvalidateCaches(scope)
+	})
+}
+
+// PrewarmTables prewarms tables on both clusters.
+func (c *CanaryClient) PrewarmTables(ctx context.Context, tables ...string) error {
+	return canaryEach(c, func(tp TablePrewarmer) error {
+		return tp.PrewarmTables(ctx, tables...)
+	})
+}
+
+// RefreshCluster refreshes the membership of both clusters.
+func (c *CanaryClient) RefreshCluster(ctx context.Context) error {
+	return canaryEach(c, func(cr ClusterRefresher) error {
+		return cr.RefreshCluster(ctx)
+	})
+}
+
+// FlushTelemetry flushes the telemetry of both clients.
+func (c *CanaryClient) FlushTelemetry(ctx context.Context) error {
+	return canaryEach(c, func(tf TelemetryFlusher) error {
+		return tf.FlushTelemetry(ctx)
+	})
+}
+
+// ExtractKey extracts keys with the key schemas of the stable cluster, like
+// the routing of writes does.
+func (c *CanaryClient) ExtractKey(ctx context.Context, table string, item map[string]types.AttributeValue) (daxTypes.ItemKey, error) {
+	ke, ok := c.clients[0].(KeyExtractor)
+	if !ok {
+		return daxTypes.ItemKey{}, errors.New("key extraction is not supported by the client")
+	}
+	return ke.ExtractKey(ctx, table, item)
+}
+
+// HealthCheck checks both clusters. As both serve requests, the result is
+// healthy only if both are, and it lists the nodes of both.
+func (c *CanaryClient) HealthCheck(ctx context.Context) (daxTypes.HealthCheckResult, error) {
+	var res [2]daxTypes.HealthCheckResult
+	for i, api := range c.clients {
+		hc, ok := api.(HealthChecker)
+		if !ok {
+			return daxTypes.HealthCheckResult{}, errors.New("health check is not supported by the client")
+		}
+		var err error
+		if res[i], err = hc.HealthCheck(ctx); err != nil {
+			return daxTypes.HealthCheckResult{}, err
+		}
+	}
+	merged := daxTypes.HealthCheckResult{
+		Healthy:        res[0].Healthy && res[1].Healthy,
+		DiscoveryFresh: res[0].DiscoveryFresh && res[1].DiscoveryFresh,
+		LastRefresh:    res[0].LastRefresh, // the older one, zero if either never refreshed
+		RefreshError:   errors.Join(res[0].RefreshError, res[1].RefreshError),
+		Nodes:          append(res[0].Nodes[:len(res[0].Nodes):len(res[0].Nodes)], res[1].Nodes...),
+	}
+	if res[1].LastRefresh.Before(merged.LastRefresh) {
+		merged.LastRefresh = res[1].LastRefresh
+	}
+	return merged, nil
+}
+
+func (c *CanaryClient) endpoints(ctx context.Context, opt RequestOptions) ([]serviceEndpoint, error) {
+	return c.clients[0].endpoints(ctx, opt)
+}
+
+// Close closes both clients.
+func (c *CanaryClient) Close() error {
+	var errs []error
+	for _, api := range c.clients {
+		if cl, ok := api.(io.Closer); ok {
+			errs = append(errs, cl.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// requestHash hashes the parts of a request which identify the items it accesses.
+type requestHash struct {
+	h hash.Hash64
+	w *cbor.Writer
+}
+
+func newRequestHash() requestHash {
+	h := fnv.New64a()
+	return requestHash{h: h, w: cbor.NewWriter(h)}
+}
+
+func (r requestHash) str(s *string) {
+	r.w.WriteString(aws.ToString(s))
+}
+
+// item hashes the attributes of item in name order. Attributes which cannot
+// be encoded are left out, the request fails validation anyway.
+func (r requestHash) item(item map[string]types.AttributeValue) {
+	for _, name := range sortedKeys(item) {
+		r.w.WriteString(name)
+		cbor.EncodeAttributeValue(item[name], r.w)
+	}
+}
+
+func (r requestHash) sum() uint64 {
+	r.w.Flush()
+	r.w.Close()
+	return r.h.Sum64()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
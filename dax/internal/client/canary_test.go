@@ -0,0 +1,294 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canaryTestClient records the ids of the items it was asked for.
+type canaryTestClient struct {
+	DaxAPI
+	ids    []string
+	err    error
+	closed bool
+}
+
+func (c *canaryTestClient) GetItemWithOptions(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
+	c.ids = append(c.ids, input.Key["id"].(*types.AttributeValueMemberS).Value)
+	return output, c.err
+}
+
+func (c *canaryTestClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func getItems(t *testing.T, c *CanaryClient, n int) {
+	for i := 0; i < n; i++ {
+		c.GetItemWithOptions(context.Background(), &dynamodb.GetItemInput{
+			TableName: aws.String("table"),
+			Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: strconv.Itoa(i)}},
+		}, &dynamodb.GetItemOutput{}, RequestOptions{})
+	}
+}
+
+func TestCanaryClient_percent(t *testing.T) {
+	for _, percent := range []float64{0, 10, 50, 100} {
+		stable, canary := &canaryTestClient{}, &canaryTestClient{}
+		c, err := NewCanaryClient(stable, canary, percent, nil)
+		require.NoError(t, err)
+		assert.Equal(t, percent, c.Percent())
+
+		getItems(t, c, 10000)
+		assert.InDelta(t, percent*100, len(canary.ids), 200, "percent %v", percent)
+		assert.Equal(t, 10000, len(stable.ids)+len(canary.ids))
+	}
+}
+
+func TestCanaryClient_sameItemsSameCluster(t *testing.T) {
+	stable, canary := &canaryTestClient{}, &canaryTestClient{}
+	c, err := NewCanaryClient(stable, canary, 30, nil)
+	require.NoError(t, err)
+	getItems(t, c, 1000)
+	first := canary.ids
+	canary.ids = nil
+	getItems(t, c, 1000)
+	assert.Equal(t, first, canary.ids)
+
+	// Raising the percentage only moves items from stable to canary.
+	require.NoError(t, c.SetPercent(60))
+	canary.ids = nil
+	getItems(t, c, 1000)
+	assert.Subset(t, canary.ids, first)
+	assert.Greater(t, len(canary.ids), len(first))
+}
+
+func TestCanaryClient_setPercentValidation(t *testing.T) {
+	c, err := NewCanaryClient(&canaryTestClient{}, &canaryTestClient{}, 5, nil)
+	require.NoError(t, err)
+	for _, percent := range []float64{-1, 100.5} {
+		assert.Error(t, c.SetPercent(percent))
+	}
+	assert.Equal(t, 5.0, c.Percent())
+
+	_, err = NewCanaryClient(&canaryTestClient{}, &canaryTestClient{}, 101, nil)
+	assert.Error(t, err)
+}
+
+func TestCanaryClient_metrics(t *testing.T) {
+	mp := &testMeterProvider{}
+	stable, canary := &canaryTestClient{}, &canaryTestClient{err: errors.New("canary failure")}
+	c, err := NewCanaryClient(stable, canary, 50, mp)
+	require.NoError(t, err)
+	getItems(t, c, 100)
+
+	tm := mp.meters[daxMeterScope].(*testMeter)
+	assert.Equal(t, []int64{int64(len(stable.ids))}, tm.i64s["dax.canary.stable.requests"].data)
+	assert.Equal(t, []int64{int64(len(canary.ids))}, tm.i64s["dax.canary.canary.requests"].data)
+	assert.Equal(t, []int64{int64(len(canary.ids))}, tm.i64s["dax.canary.canary.failures"].data)
+	assert.Empty(t, tm.i64s["dax.canary.stable.failures"].data)
+}
+
+func TestCanaryClient_Close(t *testing.T) {
+	stable, canary := &canaryTestClient{}, &canaryTestClient{}
+	c, err := NewCanaryClient(stable, canary, 50, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	assert.True(t, stable.closed)
+	assert.True(t, canary.closed)
+}
+
+// keyedCanaryTestClient records the ids of the items written to it and knows
+// that id is the key of every table.
+type keyedCanaryTestClient struct {
+	canaryTestClient
+}
+
+func (c *keyedCanaryTestClient) ExtractKey(ctx context.Context, table string, item map[string]types.AttributeValue) (daxTypes.ItemKey, error) {
+	return daxTypes.ItemKey{Attributes: map[string]types.AttributeValue{"id": item["id"]}}, nil
+}
+
+func (c *keyedCanaryTestClient) PutItemWithOptions(ctx context.Context, input *dynamodb.PutItemInput, output *dynamodb.PutItemOutput, opt RequestOptions) (*dynamodb.PutItemOutput, error) {
+	c.ids = append(c.ids, input.Item["id"].(*types.AttributeValueMemberS).Value)
+	return output, nil
+}
+
+func (c *keyedCanaryTestClient) BatchWriteItemWithOptions(ctx context.Context, input *dynamodb.BatchWriteItemInput, output *dynamodb.BatchWriteItemOutput, opt RequestOptions) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, wr := range input.RequestItems["table"] {
+		c.ids = append(c.ids, wr.PutRequest.Item["id"].(*types.AttributeValueMemberS).Value)
+	}
+	return output, nil
+}
+
+func TestCanaryClient_writesFollowKeys(t *testing.T) {
+	stable, canary := &keyedCanaryTestClient{}, &keyedCanaryTestClient{}
+	c, err := NewCanaryClient(stable, canary, 50, nil)
+	require.NoError(t, err)
+	getItems(t, c, 200)
+	reads := canary.ids
+	canary.ids = nil
+
+	item := func(i int) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"id":    &types.AttributeValueMemberS{Value: strconv.Itoa(i)},
+			"value": &types.AttributeValueMemberN{Value: strconv.Itoa(i * 7)},
+		}
+	}
+	for i := 0; i < 200; i++ {
+		_, err := c.PutItemWithOptions(context.Background(), &dynamodb.PutItemInput{TableName: aws.String("table"), Item: item(i)}, &dynamodb.PutItemOutput{}, RequestOptions{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, reads, canary.ids, "items are written where their keys are read")
+
+	canary.ids = nil
+	for i := 0; i < 200; i++ {
+		_, err := c.BatchWriteItemWithOptions(context.Background(), &dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+			"table": {{PutRequest: &types.PutRequest{Item: item(i)}}},
+		}}, &dynamodb.BatchWriteItemOutput{}, RequestOptions{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, reads, canary.ids)
+}
+
+// capableCanaryTestClient implements the optional client capabilities.
+type capableCanaryTestClient struct {
+	keyedCanaryTestClient
+	invalidated []daxTypes.CacheScope
+	prewarmed   []string
+	refreshes   int
+	flushes     int
+	health      daxTypes.HealthCheckResult
+}
+
+func (c *capableCanaryTestClient) InvalidateCaches(scope daxTypes.CacheScope) error {
+	c.invalidated = append(c.invalidated, scope)
+	return c.err
+}
+
+func (c *capableCanaryTestClient) PrewarmTables(ctx context.Context, tables ...string) error {
+	c.prewarmed = append(c.prewarmed, tables...)
+	return nil
+}
+
+func (c *capableCanaryTestClient) RefreshCluster(ctx context.Context) error {
+	c.refreshes++
+	return nil
+}
+
+func (c *capableCanaryTestClient) FlushTelemetry(ctx context.Context) error {
+	c.flushes++
+	return c.err
+}
+
+func (c *capableCanaryTestClient) HealthCheck(ctx context.Context) (daxTypes.HealthCheckResult, error) {
+	return c.health, nil
+}
+
+func TestCanaryClient_capabilities(t *testing.T) {
+	now := time.Now()
+	stable := &capableCanaryTestClient{health: daxTypes.HealthCheckResult{
+		Healthy: true, DiscoveryFresh: true, LastRefresh: now,
+		Nodes: []daxTypes.NodeHealth{{Address: "stable:8111", Healthy: true}},
+	}}
+	canary := &capableCanaryTestClient{health: daxTypes.HealthCheckResult{
+		DiscoveryFresh: true, LastRefresh: now.Add(-time.Minute),
+		Nodes: []daxTypes.NodeHealth{{Address: "canary:8111"}},
+	}}
+	c, err := NewCanaryClient(stable, canary, 50, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	canary.err = errors.New("canary failed")
+	assert.ErrorIs(t, c.InvalidateCaches(daxTypes.AllCaches()), canary.err)
+	assert.ErrorIs(t, c.FlushTelemetry(ctx), canary.err)
+	require.NoError(t, c.PrewarmTables(ctx, "t1"))
+	require.NoError(t, c.RefreshCluster(ctx))
+	for _, cli := range []*capableCanaryTestClient{stable, canary} {
+		assert.Equal(t, []daxTypes.CacheScope{daxTypes.AllCaches()}, cli.invalidated)
+		assert.Equal(t, 1, cli.flushes)
+		assert.Equal(t, []string{"t1"}, cli.prewarmed)
+		assert.Equal(t, 1, cli.refreshes)
+	}
+
+	key, err := c.ExtractKey(ctx, "table", map[string]types.AttributeValue{
+		"id":    &types.AttributeValueMemberS{Value: "a"},
+		"value": &types.AttributeValueMemberN{Value: "1"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, key.Attributes, 1)
+
+	res, err := c.HealthCheck(ctx)
+	require.NoError(t, err)
+	assert.False(t, res.Healthy, "unhealthy while a cluster is")
+	assert.True(t, res.DiscoveryFresh)
+	assert.Equal(t, now.Add(-time.Minute), res.LastRefresh)
+	assert.Len(t, res.Nodes, 2)
+	canary.health.Healthy = true
+	res, err = c.HealthCheck(ctx)
+	require.NoError(t, err)
+	assert.True(t, res.Healthy)
+
+	// The stable client lacks the capabilities.
+	c, err = NewCanaryClient(&canaryTestClient{}, canary, 50, nil)
+	require.NoError(t, err)
+	_, err = c.HealthCheck(ctx)
+	assert.Error(t, err)
+	_, err = c.ExtractKey(ctx, "table", nil)
+	assert.Error(t, err)
+}
+
+func TestCanaryClient_nilInput(t *testing.T) {
+	newClient := func() DaxAPI {
+		cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+		return newClusterDaxClient(cluster.config, cluster)
+	}
+	c, err := NewCanaryClient(newClient(), newClient(), 50, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.PutItemWithOptions(ctx, nil, &dynamodb.PutItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.DeleteItemWithOptions(ctx, nil, &dynamodb.DeleteItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.UpdateItemWithOptions(ctx, nil, &dynamodb.UpdateItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.GetItemWithOptions(ctx, nil, &dynamodb.GetItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.ScanWithOptions(ctx, nil, &dynamodb.ScanOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.QueryWithOptions(ctx, nil, &dynamodb.QueryOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.BatchWriteItemWithOptions(ctx, nil, &dynamodb.BatchWriteItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.BatchGetItemWithOptions(ctx, nil, &dynamodb.BatchGetItemOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.TransactWriteItemsWithOptions(ctx, nil, &dynamodb.TransactWriteItemsOutput{}, RequestOptions{})
+	assert.Error(t, err)
+	_, err = c.TransactGetItemsWithOptions(ctx, nil, &dynamodb.TransactGetItemsOutput{}, RequestOptions{})
+	assert.Error(t, err)
+}
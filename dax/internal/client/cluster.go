@@ -17,12 +17,14 @@ package client
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"net"
 	"net/url"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -30,6 +32,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
 	"github.com/aws/aws-dax-go-v2/dax/types"
 	"github.com/aws/aws-dax-go-v2/dax/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -38,6 +42,8 @@ import (
 	"github.com/aws/smithy-go"
 	"github.com/aws/smithy-go/logging"
 	"github.com/aws/smithy-go/metrics"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gofrs/uuid"
 )
 
 type serviceEndpoint struct {
@@ -66,33 +72,345 @@ type Config struct {
 	IdleConnectionReapDelay      time.Duration
 	ClientHealthCheckInterval    time.Duration
 
+	// ClusterUpdateJitter randomizes every wait between background cluster
+	// refreshes by up to this fraction of the wait, in either direction, so
+	// that clients started together do not refresh in lockstep. It must be
+	// in [0, 1), zero disables jitter.
+	ClusterUpdateJitter float64
+
+	// ClusterUpdateMaxBackoff caps the wait between background refreshes
+	// while they fail, which doubles ClusterUpdateInterval for every
+	// consecutive failure. Zero disables the backoff.
+	ClusterUpdateMaxBackoff time.Duration
+
+	// FailoverRefreshThreshold enables fast leader change detection. When a
+	// write fails with a recoverable cluster error or a connection error, signs
+	// that the leader moved, the cluster is refreshed immediately so the retries
+	// of the write use the new leader, at most once per threshold. Writes are
+	// then unavailable for about the threshold plus a refresh and a retry
+	// delay, which the dax.cluster.failover.write_unavailable_us histogram
+	// measures. Zero leaves leader changes to the periodic refresh.
+	FailoverRefreshThreshold time.Duration
+
+	// StartupDelay spreads the load on the cluster when many clients start
+	// at once. New waits a random duration up to StartupDelay before the
+	// initial cluster discovery, then opens a first connection to every node
+	// at a random time within another StartupDelay. Zero disables both.
+	StartupDelay time.Duration
+
+	// BootstrapTimeout bounds the initial cluster discovery of New. When it
+	// elapses New returns anyway, without nodes, and the periodic refresh
+	// keeps trying. Zero leaves the discovery bounded by the timeout of each
+	// seed only.
+	BootstrapTimeout time.Duration
+
+	// BackgroundBootstrap makes New return immediately and run the initial
+	// cluster discovery in the background, so that an unavailable cluster
+	// does not block the startup of the application. Requests made before
+	// the discovery completes wait for it, within their own context.
+	BackgroundBootstrap bool
+
+	// HealthProbeInterval enables background health probes: every interval,
+	// each node is sent a lightweight request whether or not the application
+	// is sending it traffic. A node failing HealthProbeFailureThreshold probes
+	// in a row, 3 when zero, is taken out of rotation until a probe succeeds
+	// again, but never more than a third of the nodes. Each probe, like each
+	// node request of HealthCheck, is bounded by HealthProbeTimeout, one
+	// second when zero. Zero disables the probes.
+	HealthProbeInterval         time.Duration
+	HealthProbeTimeout          time.Duration
+	HealthProbeFailureThreshold int
+
+	// NodeWeight biases the choice of the node serving each request, e.g.
+	// away from smaller instance types in a mixed cluster. It returns the
+	// weight of node relative to the others, nodes with a weight of zero or
+	// less only get requests when no other node can serve them. It is called
+	// for every request and must be fast and not use the client. Nil weighs
+	// all nodes equally.
+	NodeWeight func(node types.NodeState) float64
+
+	// NodeRampUp gradually ramps up the traffic to nodes joining the cluster
+	// after the client started: their weight grows linearly from a twentieth
+	// to its full value over NodeRampUp. Zero sends them a full share at once.
+	NodeRampUp time.Duration
+
 	Region      string
 	HostPorts   []string
 	Credentials aws.CredentialsProvider
 	DialContext func(ctx context.Context, network string, address string) (net.Conn, error)
 	connConfig  connConfig
 
+	// SkipHostnameVerification disables the verification of node
+	// certificates altogether, chain included: connections stay encrypted
+	// but the nodes are not authenticated. Only use it in test environments.
 	SkipHostnameVerification bool
 	logger                   logging.Logger
 	logLevel                 utils.LogLevelType
 
+	// TLSServerName, if set, is the name node certificates are verified
+	// against instead of the hostname of the cluster endpoint.
+	TLSServerName string
+
+	// VerifyPeerHostname, if set, replaces the check that node certificates
+	// are valid for the cluster hostname, e.g. for private link setups whose
+	// certificates do not name the endpoint. The certificate chain is still
+	// verified against the system roots. The hook receives the node address,
+	// the hostname the certificate would have been verified against and the
+	// node certificate, and returns an error to reject the connection.
+	// SkipHostnameVerification takes precedence over this hook.
+	VerifyPeerHostname func(address, hostname string, cert *x509.Certificate) error
+
+	// RootCAs, if set, replaces the system roots node certificate chains
+	// are verified against, e.g. with the CA of a TLS-terminating proxy in
+	// a test environment.
+	RootCAs *x509.CertPool
+
+	// VerifyCertificate, if set, replaces the verification of node
+	// certificates entirely: it receives the node address, the hostname the
+	// certificates would have been verified against and the chain the node
+	// presented, leaf first, and returns an error to reject the connection.
+	// It takes precedence over RootCAs and VerifyPeerHostname, and
+	// SkipHostnameVerification takes precedence over it.
+	VerifyCertificate func(address, hostname string, certs []*x509.Certificate) error
+
 	MeterProvider metrics.MeterProvider
 
 	RouteManagerEnabled bool // this flag temporarily removes routes facing network errors.
 	IpDiscovery         types.IpDiscovery
+
+	// SigningAlgorithm selects how connections are authenticated, SigV4 by default.
+	// With SigV4A the signature is valid in every region of SigningRegionSet,
+	// which defaults to Region when empty.
+	SigningAlgorithm types.SigningAlgorithm
+	SigningRegionSet []string
+
+	// ReadPreference selects the nodes serving GetItem, Query, Scan and
+	// BatchGetItem, all nodes by default. Reads fall back to the other nodes,
+	// and retries may use them, while no preferred node is in rotation.
+	ReadPreference types.ReadPreference
+
+	// ParameterValidation set to strict fails requests using parameters DAX
+	// ignores or combinations DynamoDB rejects, such as Select with an
+	// unrelated ProjectionExpression, with an UnsupportedParameterError
+	// naming the parameter. Permissive, the default, sends them as they are.
+	ParameterValidation types.ParameterValidation
+
+	// ItemCacheTTL, if positive, enables a cache of GetItem results in client
+	// memory, in front of DAX, and is how long items are served from it.
+	// Only eventually consistent calls without a projection or consumed
+	// capacity are cached, missing items only with ItemCacheMissTTL. Items
+	// written through the client are dropped from the cache, but items
+	// written by others are served up to the TTL after they changed;
+	// InvalidateCaches drops items explicitly. The dax.cache metrics count
+	// the events of the cache with the cache property "items".
+	ItemCacheTTL time.Duration
+	// ItemCacheMissTTL, if positive, is how long the cache remembers that an
+	// item does not exist, for workloads looking up mostly missing keys. It
+	// enables the cache for missing items only without ItemCacheTTL. Keep it
+	// short: items created by others are reported missing until it expires.
+	ItemCacheMissTTL time.Duration
+	// ItemCacheTableTTLs overrides ItemCacheTTL for the tables it lists.
+	// Items of tables whose TTL is not positive are not cached, nor their
+	// absence if the TTL is listed.
+	ItemCacheTableTTLs map[string]time.Duration
+	// ItemCacheMaxEntries bounds the number of cached items, 10000 if zero.
+	ItemCacheMaxEntries int
+	// ItemCacheMaxBytes, if positive, also bounds the approximate memory of
+	// the cached items.
+	ItemCacheMaxBytes int64
+
+	// CoalesceGetItem sends a single request for concurrent GetItem calls of
+	// the same key, table, consistency and projection, whose callers then
+	// share its result, so that a hot key does not multiply the load on the
+	// cluster. The request uses the options and deadline of the call which
+	// started it, and is canceled when every waiting call has returned.
+	// Coalesced calls are counted by the dax.getitem.coalesced metric.
+	CoalesceGetItem bool
+
+	// MaxConcurrentRequests, if positive, bounds the calls in flight, retries
+	// included, so that overload turns into backpressure instead of piling
+	// up goroutines and connections. Further calls wait in a queue of
+	// MaxQueuedRequests calls, for at most their deadline, and calls finding
+	// the queue full fail at once with ErrRequestQueueFull. The
+	// dax.requests metrics report the queue time, the calls queued and
+	// those rejected.
+	MaxConcurrentRequests int
+	// MaxQueuedRequests bounds the calls waiting for MaxConcurrentRequests.
+	// Zero rejects calls as soon as MaxConcurrentRequests are in flight.
+	MaxQueuedRequests int
+
+	// TableRateLimits, if set, paces the requests of the tables it lists, per
+	// table and separately for reads and writes, with token buckets, so that
+	// one code path cannot starve the cluster for every other consumer on
+	// the host. Calls wait for their table, those which would wait past
+	// their deadline fail at once with ErrRateLimitExceeded. Requests of
+	// several tables take a token of each. Delayed calls are counted by the
+	// dax.requests.rate_limited metric.
+	TableRateLimits map[string]types.RateLimit
+
+	// RetryBudget, if positive, caps the retries of the whole client, as the
+	// standard retryer of the AWS SDK does, so that retries do not multiply
+	// the load on a cluster in a brownout. Every retry takes 5 tokens from a
+	// budget of RetryBudget tokens, 10 after a timeout, which are returned
+	// if the retry succeeds; calls succeeding at once return 1 token. Calls
+	// fail with their last error when the budget cannot afford a retry,
+	// counted by the dax.retries.budget_exhausted metric. The SDK uses 500.
+	RetryBudget int
+
+	// SlowRequestThreshold, if positive, reports the calls taking longer,
+	// retries included, with their operation, tables, duration and attempts,
+	// like the slow query log of a database. They are passed to
+	// OnSlowRequest if set, logged as warnings otherwise, and counted by
+	// the dax.requests.slow metric.
+	SlowRequestThreshold time.Duration
+	// OnSlowRequest, if set, receives the calls slower than
+	// SlowRequestThreshold instead of the logger. It is called from the
+	// goroutine of the call and should not block.
+	OnSlowRequest func(types.SlowRequest)
+
+	// OnRequest, OnResponse and OnError, if set, are lightweight hooks
+	// around every request sent to a node, retries and the requests
+	// fetching key schemas and attribute lists included, for applications
+	// which want hooks without middleware: OnRequest once the request is
+	// sent, then OnResponse once its response is read or OnError if it
+	// failed. They receive the operation, the node, the serialized sizes
+	// and the duration of the request, and are called from the goroutine
+	// of the call, so should not block.
+	OnRequest  func(ctx context.Context, e types.RequestEvent)
+	OnResponse func(ctx context.Context, e types.RequestEvent)
+	OnError    func(ctx context.Context, e types.RequestEvent)
+
+	// ConnectTimeout bounds establishing a new connection to a node: the TCP
+	// connection, the TLS handshake and the DAX protocol handshake. Requests
+	// failing to connect are retried on another node, as nothing was sent.
+	// Zero means no limit beyond the request's own deadline.
+	ConnectTimeout time.Duration
+
+	// TCPUserTimeout is how long data written to a node may remain
+	// unacknowledged before the connection is closed, so that requests to a
+	// node which silently went away fail quickly instead of after minutes of
+	// retransmissions. It sets TCP_USER_TIMEOUT on Linux and
+	// TCP_RXT_CONNDROPTIME, in whole seconds, on macOS, and is ignored on
+	// other platforms and with DialContext. Zero keeps the system default.
+	TCPUserTimeout time.Duration
+
+	// StandbyConnectionsPerNode is the number of spare connections kept open
+	// and authenticated for every node, which replace failed connections at
+	// once so that requests do not wait for connecting and authenticating,
+	// e.g. while nodes restart. Zero keeps no spare connections.
+	StandbyConnectionsPerNode int
+
+	// IdleConnectionValidation is how long a pooled connection may stay idle
+	// before it is validated with a ping request on reuse, so that requests
+	// do not fail on connections which a NAT gateway or load balancer dropped
+	// while they were idle. Connections failing validation are closed and
+	// replaced. Zero disables validation.
+	IdleConnectionValidation time.Duration
+
+	// RouteDrainTimeout is how long a node removed from the cluster keeps
+	// serving its in-flight requests before its connections are closed.
+	// Zero closes removed nodes immediately.
+	RouteDrainTimeout time.Duration
+
+	// CloseDrainTimeout is how long Close waits for in-flight requests to
+	// complete before closing connections. Requests started after Close
+	// fail with os.ErrClosed. Zero, the default, closes connections
+	// immediately.
+	CloseDrainTimeout time.Duration
+
+	// AppID and UserAgentExtras are appended to the user agent reported when
+	// authorizing connections, including those used for endpoint discovery.
+	AppID           string
+	UserAgentExtras map[string]string
+
+	// OnFlushTelemetry, if set, is called whenever the client flushes its
+	// telemetry, e.g. to flush a meter provider wrapped by an adapter.
+	OnFlushTelemetry func(ctx context.Context) error
+
+	// OnClusterEvent, if set, is called when nodes join or leave the cluster,
+	// when the leader changes, when a membership refresh fails and when a
+	// table passes AttributeListThreshold. It is called from the goroutine
+	// performing the refresh or request and should not block.
+	OnClusterEvent func(types.ClusterEvent)
+
+	// StrictResponseDecoding rejects responses which repeat an attribute name
+	// within an item or map with a cbor.DuplicateKeyError. By default the last
+	// value is kept and the dax.response.duplicate_keys metric is incremented.
+	StrictResponseDecoding bool
+
+	// ValidateResponseKeys checks that the keys of the items returned by
+	// Query, Scan and BatchGetItem without a projection decode with every key
+	// attribute of their table, to catch responses mixed up between requests.
+	// Keys lacking one are counted by the dax.response.key_mismatches metric,
+	// with StrictResponseDecoding the response is rejected with a
+	// ResponseKeyError.
+	ValidateResponseKeys bool
+
+	// DisableClientRequestTokens stops TransactWriteItems from generating a
+	// ClientRequestToken for calls without one. The generated token is shared
+	// by the retries of the call, so a transaction retried after a network
+	// error is not applied twice, and is not written to the caller's input.
+	DisableClientRequestTokens bool
+
+	// AttributeListThreshold is the number of distinct sets of non-key
+	// attribute names written to a table after which the client warns, with
+	// a ClusterEventAttributeListsExceeded event, a log message and the
+	// dax.attribute_lists.threshold_exceeded metric. DAX registers every set
+	// once, so attribute names built from data degrade writes. Zero disables
+	// the warning; the counts are still reported by ClusterState.
+	AttributeListThreshold int
+
+	// DecodeLimits bound the lengths and nesting accepted from responses.
+	DecodeLimits types.DecodeLimits
+
+	// MetadataCacheMaxBytes, if positive, caps the approximate memory of each
+	// of the key schema and attribute list caches the client keeps per node,
+	// in addition to their entry counts. Workloads with many tables or
+	// attribute lists trade round trips to define them again for memory.
+	MetadataCacheMaxBytes int64
+
+	// MetadataCacheTTL, if positive, is how long cached key schemas and
+	// attribute lists are used before they are fetched again, so a table
+	// recreated with a different key schema is noticed without a restart.
+	// Entries are refreshed in the background ahead of their expiry, and
+	// their TTLs are jittered so the nodes are not asked all at once.
+	MetadataCacheTTL time.Duration
 }
 
 type connConfig struct {
 	isEncrypted              bool
 	hostname                 string
 	skipHostnameVerification bool
+	tlsServerName            string
+	verifyPeerHostname       verifyPeerHostnameFunc
+	rootCAs                  *x509.CertPool
+	verifyCertificate        verifyCertificateFunc
+	signingAlgorithm         types.SigningAlgorithm
+	signingRegionSet         []string
+	connectTimeout           time.Duration
+	idleValidation           time.Duration
+	tcpUserTimeout           time.Duration
+	nodeHostname             string // hostname of the node, set per node
+	standbyConnections       int    // set per node
+	userAgent                string
+	strictResponseDecoding   bool
+	validateResponseKeys     bool
+	attributeLists           *attributeListTracker
+	decodeLimits             cbor.Limits
+	metadataCacheMaxBytes    int64
+	metadataCacheTTL         time.Duration
+	interceptors             interceptors
 }
 
 func (cfg *Config) validate() error {
 	if cfg.HostPorts == nil || len(cfg.HostPorts) == 0 {
 		return smithy.NewErrParamRequired("Endpoint")
 	}
+	return cfg.validateSettings()
+}
 
+// validateSettings validates the config apart from the discovery endpoints.
+func (cfg *Config) validateSettings() error {
 	if len(cfg.Region) == 0 {
 		return smithy.NewErrParamRequired("config.Region")
 	}
@@ -109,12 +427,153 @@ func (cfg *Config) validate() error {
 		return smithy.NewErrParamRequired("config.IpDiscovery must be 'ipv4' or 'ipv6'")
 	}
 
+	if cfg.ClusterUpdateInterval <= 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ClusterUpdateInterval must be positive")
+	}
+
+	if cfg.ClusterUpdateJitter < 0 || cfg.ClusterUpdateJitter >= 1 {
+		return NewCustomInvalidParamError("ConfigValidation", "ClusterUpdateJitter must be at least 0 and less than 1")
+	}
+
+	if cfg.AttributeListThreshold < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "AttributeListThreshold cannot be negative")
+	}
+
+	if cfg.DecodeLimits.MaxArrayLength < 0 || cfg.DecodeLimits.MaxStringLength < 0 || cfg.DecodeLimits.MaxDepth < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "DecodeLimits cannot be negative")
+	}
+
+	if cfg.MetadataCacheMaxBytes < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "MetadataCacheMaxBytes cannot be negative")
+	}
+
+	if cfg.MetadataCacheTTL < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "MetadataCacheTTL cannot be negative")
+	}
+
+	if cfg.ItemCacheTTL < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ItemCacheTTL cannot be negative")
+	}
+
+	if cfg.ItemCacheMissTTL < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ItemCacheMissTTL cannot be negative")
+	}
+
+	if cfg.ItemCacheMaxEntries < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ItemCacheMaxEntries cannot be negative")
+	}
+
+	if cfg.ItemCacheMaxBytes < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ItemCacheMaxBytes cannot be negative")
+	}
+
+	if cfg.FailoverRefreshThreshold < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "FailoverRefreshThreshold cannot be negative")
+	}
+
+	if cfg.ClusterUpdateMaxBackoff < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ClusterUpdateMaxBackoff cannot be negative")
+	}
+
+	if cfg.StartupDelay < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "StartupDelay cannot be negative")
+	}
+
+	if cfg.BootstrapTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "BootstrapTimeout cannot be negative")
+	}
+
+	if cfg.HealthProbeInterval < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "HealthProbeInterval cannot be negative")
+	}
+
+	if cfg.HealthProbeTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "HealthProbeTimeout cannot be negative")
+	}
+
+	if cfg.HealthProbeFailureThreshold < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "HealthProbeFailureThreshold cannot be negative")
+	}
+
+	if cfg.NodeRampUp < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "NodeRampUp cannot be negative")
+	}
+
+	if cfg.MaxConcurrentRequests < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "MaxConcurrentRequests cannot be negative")
+	}
+
+	if cfg.MaxQueuedRequests < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "MaxQueuedRequests cannot be negative")
+	}
+
+	for _, l := range cfg.TableRateLimits {
+		if l.ReadsPerSecond < 0 || l.WritesPerSecond < 0 || l.Burst < 0 {
+			return NewCustomInvalidParamError("ConfigValidation", "TableRateLimits cannot be negative")
+		}
+	}
+
+	if cfg.RetryBudget < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "RetryBudget cannot be negative")
+	}
+
+	if cfg.SlowRequestThreshold < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "SlowRequestThreshold cannot be negative")
+	}
+
+	if cfg.ConnectTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "ConnectTimeout cannot be negative")
+	}
+
+	if cfg.TCPUserTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "TCPUserTimeout cannot be negative")
+	}
+
+	if cfg.StandbyConnectionsPerNode < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "StandbyConnectionsPerNode cannot be negative")
+	}
+
+	if cfg.IdleConnectionValidation < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "IdleConnectionValidation cannot be negative")
+	}
+
+	if cfg.RouteDrainTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "RouteDrainTimeout cannot be negative")
+	}
+
+	if cfg.CloseDrainTimeout < 0 {
+		return NewCustomInvalidParamError("ConfigValidation", "CloseDrainTimeout cannot be negative")
+	}
+
+	if !cfg.SigningAlgorithm.IsValid() {
+		return smithy.NewErrParamRequired("config.SigningAlgorithm must be 'sigv4' or 'sigv4a'")
+	}
+
+	if !cfg.ReadPreference.IsValid() {
+		return NewCustomInvalidParamError("ConfigValidation", "ReadPreference must be 'any', 'leader' or 'replica'")
+	}
+
+	if !cfg.ParameterValidation.IsValid() {
+		return NewCustomInvalidParamError("ConfigValidation", "ParameterValidation must be 'permissive' or 'strict'")
+	}
+
 	return nil
 }
 
 func (cfg *Config) validateConnConfig() {
 	if cfg.connConfig.isEncrypted && cfg.SkipHostnameVerification {
 		cfg.logger.Logf(logging.Warn, "Skip hostname verification of TLS connections. The default is to perform hostname verification, setting this to True will skip verification. Be sure you understand the implication of doing so, which is the inability to authenticate the cluster that you are connecting to.")
+		return
+	}
+	if cfg.connConfig.isEncrypted && cfg.TLSServerName != "" {
+		cfg.logger.Logf(logging.Warn, "Verifying certificates of TLS connections against %s instead of the cluster hostname %s. Be sure that this name identifies the cluster you are connecting to.", cfg.TLSServerName, cfg.connConfig.hostname)
+	}
+	if cfg.connConfig.isEncrypted && cfg.VerifyCertificate != nil {
+		cfg.logger.Logf(logging.Warn, "Custom certificate verification of TLS connections. Certificates presented by the cluster nodes are only checked by VerifyCertificate. Be sure that it only accepts certificates of the cluster you are connecting to.")
+		return
+	}
+	if cfg.connConfig.isEncrypted && cfg.VerifyPeerHostname != nil {
+		cfg.logger.Logf(logging.Warn, "Custom hostname verification of TLS connections. Certificates presented by the cluster nodes are checked by VerifyPeerHostname instead of being matched against the cluster hostname. Be sure that it only accepts certificates of the cluster you are connecting to.")
 	}
 }
 
@@ -132,14 +591,19 @@ func DefaultConfig() Config {
 	cfg := Config{
 		MaxPendingConnectionsPerHost: 10,
 		ClusterUpdateInterval:        time.Second * 4,
+		ClusterUpdateJitter:          0.1,
+		ClusterUpdateMaxBackoff:      time.Second * 30,
 		ClusterUpdateThreshold:       time.Millisecond * 125,
 		ClientHealthCheckInterval:    time.Second * 5,
+		FailoverRefreshThreshold:     time.Millisecond * 250,
+		AttributeListThreshold:       attributeListLruCacheSize,
 
 		connConfig:               connConfig{},
 		SkipHostnameVerification: false,
 		logger:                   utils.NewDefaultLogger(),
 		logLevel:                 utils.LogOff,
 		IdleConnectionReapDelay:  30 * time.Second,
+		RouteDrainTimeout:        10 * time.Second,
 		RouteManagerEnabled:      false,
 		IpDiscovery:              "",
 
@@ -160,6 +624,11 @@ func DefaultConfig() Config {
 type ClusterDaxClient struct {
 	config  Config
 	cluster *cluster
+	flights *getItemFlights // nil unless Config.CoalesceGetItem
+	items   *itemCache      // nil unless Config.ItemCacheTTL or ItemCacheTableTTLs
+	limiter *requestLimiter // nil unless Config.MaxConcurrentRequests
+	rates   *rateLimiter    // nil unless Config.TableRateLimits
+	budget  *retryBudget    // nil unless Config.RetryBudget
 }
 
 func New(config Config) (*ClusterDaxClient, error) {
@@ -171,12 +640,45 @@ func New(config Config) (*ClusterDaxClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newClusterDaxClient(config, cluster), nil
+}
+
+// newClusterDaxClient returns the client of a started cluster.
+func newClusterDaxClient(config Config, cluster *cluster) *ClusterDaxClient {
 	client := &ClusterDaxClient{config: config, cluster: cluster}
-	return client, nil
+	if config.CoalesceGetItem {
+		client.flights = newGetItemFlights()
+	}
+	client.items = newItemCache(config, cluster.daxSdkMetrics)
+	client.limiter = newRequestLimiter(config, cluster.daxSdkMetrics)
+	client.rates = newRateLimiter(config, cluster.daxSdkMetrics)
+	client.budget = newRetryBudget(config)
+	return client
 }
 
+// Close closes all connections after logging a final snapshot of the
+// client, then flushes the meter provider and logger.
 func (cc *ClusterDaxClient) Close() error {
-	return cc.cluster.Close()
+	cc.cluster.logSnapshot()
+	err := cc.cluster.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+	defer cancel()
+	if ferr := cc.cluster.flushTelemetry(ctx); ferr != nil {
+		cc.cluster.warnLog("Failed to flush telemetry on close: %v", ferr)
+	}
+	return err
+}
+
+// ClusterRefresher is implemented by clients which can re-discover their cluster nodes on demand.
+type ClusterRefresher interface {
+	RefreshCluster(ctx context.Context) error
+}
+
+// RefreshCluster re-discovers the cluster nodes immediately instead of
+// waiting for the next periodic refresh, e.g. after a known scaling event.
+func (cc *ClusterDaxClient) RefreshCluster(ctx context.Context) error {
+	return cc.cluster.refreshCluster(ctx)
 }
 
 func (cc *ClusterDaxClient) endpoints(ctx context.Context, opt RequestOptions) ([]serviceEndpoint, error) {
@@ -186,7 +688,7 @@ func (cc *ClusterDaxClient) endpoints(ctx context.Context, opt RequestOptions) (
 		out, err = client.endpoints(ctx, o)
 		return err
 	}
-	if err = cc.retry(ctx, opEndpoints, action, opt); err != nil {
+	if err = cc.retry(ctx, opEndpoints, action, opt, nil); err != nil {
 		return nil, err
 	}
 	return out, nil
@@ -194,11 +696,22 @@ func (cc *ClusterDaxClient) endpoints(ctx context.Context, opt RequestOptions) (
 
 func (cc *ClusterDaxClient) PutItemWithOptions(ctx context.Context, input *dynamodb.PutItemInput, output *dynamodb.PutItemOutput, opt RequestOptions) (*dynamodb.PutItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpPutItem, input, opt); err != nil {
+		return output, err
+	}
+	defer cc.forgetWritten(ctx, input)
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.PutItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpPutItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpPutItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpPutItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -206,11 +719,22 @@ func (cc *ClusterDaxClient) PutItemWithOptions(ctx context.Context, input *dynam
 
 func (cc *ClusterDaxClient) DeleteItemWithOptions(ctx context.Context, input *dynamodb.DeleteItemInput, output *dynamodb.DeleteItemOutput, opt RequestOptions) (*dynamodb.DeleteItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpDeleteItem, input, opt); err != nil {
+		return output, err
+	}
+	defer cc.forgetWritten(ctx, input)
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.DeleteItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpDeleteItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpDeleteItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpDeleteItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -218,11 +742,22 @@ func (cc *ClusterDaxClient) DeleteItemWithOptions(ctx context.Context, input *dy
 
 func (cc *ClusterDaxClient) UpdateItemWithOptions(ctx context.Context, input *dynamodb.UpdateItemInput, output *dynamodb.UpdateItemOutput, opt RequestOptions) (*dynamodb.UpdateItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpUpdateItem, input, opt); err != nil {
+		return output, err
+	}
+	defer cc.forgetWritten(ctx, input)
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.UpdateItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpUpdateItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpUpdateItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpUpdateItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -230,11 +765,22 @@ func (cc *ClusterDaxClient) UpdateItemWithOptions(ctx context.Context, input *dy
 
 func (cc *ClusterDaxClient) BatchWriteItemWithOptions(ctx context.Context, input *dynamodb.BatchWriteItemInput, output *dynamodb.BatchWriteItemOutput, opt RequestOptions) (*dynamodb.BatchWriteItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpBatchWriteItem, input, opt); err != nil {
+		return output, err
+	}
+	defer cc.forgetWritten(ctx, input)
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.BatchWriteItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpBatchWriteItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpBatchWriteItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpBatchWriteItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -242,35 +788,119 @@ func (cc *ClusterDaxClient) BatchWriteItemWithOptions(ctx context.Context, input
 
 func (cc *ClusterDaxClient) TransactWriteItemsWithOptions(ctx context.Context, input *dynamodb.TransactWriteItemsInput, output *dynamodb.TransactWriteItemsOutput, opt RequestOptions) (*dynamodb.TransactWriteItemsOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpTransactWriteItems, input, opt); err != nil {
+		return output, err
+	}
+	defer cc.forgetWritten(ctx, input)
+	if !cc.config.DisableClientRequestTokens {
+		if input, err = withClientRequestToken(input); err != nil {
+			return output, err
+		}
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.TransactWriteItemsWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpTransactWriteItems, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpTransactWriteItems, action, opt, &metadata)
+	cc.reportSlow(ctx, OpTransactWriteItems, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
 }
 
+// withClientRequestToken returns a copy of input with a new ClientRequestToken
+// if it has none.
+func withClientRequestToken(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsInput, error) {
+	if input == nil || input.ClientRequestToken != nil {
+		return input, nil
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	in := *input
+	in.ClientRequestToken = aws.String(id.String())
+	return &in, nil
+}
+
 func (cc *ClusterDaxClient) TransactGetItemsWithOptions(ctx context.Context, input *dynamodb.TransactGetItemsInput, output *dynamodb.TransactGetItemsOutput, opt RequestOptions) (*dynamodb.TransactGetItemsOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpTransactGetItems, input, opt); err != nil {
+		return output, err
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.TransactGetItemsWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpTransactGetItems, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpTransactGetItems, action, opt, &metadata)
+	cc.reportSlow(ctx, OpTransactGetItems, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
 }
 
 func (cc *ClusterDaxClient) GetItemWithOptions(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
+	if cc.items != nil && cc.items.cacheable(input) {
+		table := aws.ToString(input.TableName)
+		if key, ok := cc.items.key(table, input.Key); ok {
+			return cc.items.get(cc.newContext(ctx, opt), key, table, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+				o := opt
+				o.Context = ctx
+				return cc.getItemCoalesced(ctx, input, &dynamodb.GetItemOutput{}, o)
+			})
+		}
+	}
+	return cc.getItemCoalesced(ctx, input, output, opt)
+}
+
+// getItemCoalesced is GetItemWithOptions without the item cache.
+func (cc *ClusterDaxClient) getItemCoalesced(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
+	if cc.flights != nil {
+		if key, ok := getItemFlightKey(input); ok {
+			out, shared, err := cc.flights.do(cc.newContext(ctx, opt), key, func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+				// The shared request must not end with the context of the call starting it.
+				o := opt
+				o.Context = ctx
+				return cc.getItem(ctx, input, &dynamodb.GetItemOutput{}, o)
+			})
+			if shared {
+				countMetricInt64(ctx, cc.cluster.daxSdkMetrics, daxGetItemCoalesced, 1)
+			}
+			return out, err
+		}
+	}
+	return cc.getItem(ctx, input, output, opt)
+}
+
+func (cc *ClusterDaxClient) getItem(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpGetItem, input, opt); err != nil {
+		return output, err
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.GetItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpGetItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpGetItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpGetItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -278,11 +908,21 @@ func (cc *ClusterDaxClient) GetItemWithOptions(ctx context.Context, input *dynam
 
 func (cc *ClusterDaxClient) QueryWithOptions(ctx context.Context, input *dynamodb.QueryInput, output *dynamodb.QueryOutput, opt RequestOptions) (*dynamodb.QueryOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpQuery, input, opt); err != nil {
+		return output, err
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.QueryWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpQuery, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpQuery, action, opt, &metadata)
+	cc.reportSlow(ctx, OpQuery, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -290,11 +930,21 @@ func (cc *ClusterDaxClient) QueryWithOptions(ctx context.Context, input *dynamod
 
 func (cc *ClusterDaxClient) ScanWithOptions(ctx context.Context, input *dynamodb.ScanInput, output *dynamodb.ScanOutput, opt RequestOptions) (*dynamodb.ScanOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpScan, input, opt); err != nil {
+		return output, err
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.ScanWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpScan, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpScan, action, opt, &metadata)
+	cc.reportSlow(ctx, OpScan, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
@@ -302,47 +952,108 @@ func (cc *ClusterDaxClient) ScanWithOptions(ctx context.Context, input *dynamodb
 
 func (cc *ClusterDaxClient) BatchGetItemWithOptions(ctx context.Context, input *dynamodb.BatchGetItemInput, output *dynamodb.BatchGetItemOutput, opt RequestOptions) (*dynamodb.BatchGetItemOutput, error) {
 	var err error
+	if err = cc.limitRate(ctx, OpBatchGetItem, input, opt); err != nil {
+		return output, err
+	}
 	action := func(client DaxAPI, o RequestOptions) error {
 		output, err = client.BatchGetItemWithOptions(ctx, input, output, o)
 		return err
 	}
-	if err = cc.retry(ctx, OpBatchGetItem, action, opt); err != nil {
+	var metadata middleware.Metadata
+	start := time.Now()
+	err = cc.retry(ctx, OpBatchGetItem, action, opt, &metadata)
+	cc.reportSlow(ctx, OpBatchGetItem, input, start, metadata, err)
+	if output != nil {
+		output.ResultMetadata = metadata
+	}
+	if err != nil {
 		return output, err
 	}
 	return output, nil
 }
 
-func (cc *ClusterDaxClient) retry(ctx context.Context, op string, action func(client DaxAPI, o RequestOptions) error, opt RequestOptions) (err error) {
+// retry executes action until it succeeds or is not retryable, recording
+// the attempts into metadata unless it is nil.
+func (cc *ClusterDaxClient) retry(ctx context.Context, op string, action func(client DaxAPI, o RequestOptions) error, opt RequestOptions, metadata *middleware.Metadata) (err error) {
 	defer func() {
 		if daxErr, ok := err.(daxError); ok {
 			err = convertDaxError(daxErr)
 		}
 	}()
 
+	if !cc.cluster.beginRequest() {
+		return &smithy.OperationError{ServiceID: service, OperationName: op, Err: os.ErrClosed}
+	}
+	defer cc.cluster.endRequest()
+
 	ctx = cc.newContext(ctx, opt)
 
+	if err := cc.cluster.awaitBootstrap(ctx); err != nil {
+		return &smithy.OperationError{ServiceID: service, OperationName: op, Err: err}
+	}
+
+	if cc.limiter != nil {
+		if err := cc.limiter.acquire(ctx); err != nil {
+			return &smithy.OperationError{ServiceID: service, OperationName: op, Err: err}
+		}
+		defer cc.limiter.release()
+	}
+
 	attempts := opt.RetryMaxAttempts
 	opt.RetryMaxAttempts = 0 // disable retries on single node client
+	streamItems(&opt)
 
 	var client DaxAPI
+	var throttles throttleStreak
+	var failover time.Time // first attempt failing because the leader moved
+	var retryCost int      // taken from the retry budget by the latest retry
+	om := newOperationMetadata(metadata)
+	defer om.finish()
 	// Start from 0 to accomodate for the initial request
 	for i := 0; i <= attempts; i++ {
 		if i > 0 && opt.Logger != nil && opt.LogLevel.Matches(utils.LogDebugWithRequestRetries) {
 			opt.Logger.Logf(logging.Debug, "Retrying Request %s/%s, attempt %d", service, op, i)
 		}
-		client, err = cc.cluster.client(client, op)
+		om.begin()
+		client, err = cc.cluster.clientFor(client, op, opt.ReadPreference)
 
 		if err == nil {
 			err = action(client, opt)
 		}
+		om.end(client, err)
 
 		if err == nil {
 			// success
+			if cc.budget != nil {
+				if i == 0 {
+					cc.budget.refund(retryBudgetSuccessRefund)
+				} else {
+					cc.budget.refund(retryCost)
+				}
+			}
+			if !failover.IsZero() {
+				histogramMicrosecondsInt64(ctx, cc.cluster.daxSdkMetrics, daxFailoverWriteUnavailable, failover)
+			}
 			return nil
 		}
+		if finished, ferr := opt.stream.finished(err); finished {
+			return ferr
+		}
 		if !isRetryable(opt, err) {
 			return err
 		}
+		if !isReadOp(op) && leaderMoved(err) {
+			if failover.IsZero() {
+				failover = time.Now()
+			}
+			cc.cluster.refreshForFailover(ctx)
+		}
+		if throttles.exhausted(opt.Retryer, err) {
+			if opt.Logger != nil && opt.LogLevel.Matches(utils.LogDebugWithRequestRetries) {
+				opt.Logger.Logf(logging.Debug, "Giving up request %s/%s after %d consecutive throttles : %s", service, op, throttles.count, err)
+			}
+			return err
+		}
 
 		if i != attempts {
 			if opt.Logger != nil && opt.LogLevel.Matches(utils.LogDebugWithRequestRetries) {
@@ -355,6 +1066,19 @@ func (cc *ClusterDaxClient) retry(ctx context.Context, op string, action func(cl
 				delay = opt.RetryDelay
 			}
 
+			if exceedsDeadline(ctx, delay) {
+				return err
+			}
+			if cc.budget != nil {
+				var ok bool
+				if retryCost, ok = cc.budget.take(err); !ok {
+					countMetricInt64(ctx, cc.cluster.daxSdkMetrics, daxRetryBudgetExhausted, 1)
+					if opt.Logger != nil && opt.LogLevel.Matches(utils.LogDebugWithRequestRetries) {
+						opt.Logger.Logf(logging.Debug, "Giving up request %s/%s, the retry budget is exhausted : %s", service, op, err)
+					}
+					return err
+				}
+			}
 			if delay > 0 {
 				if err = SleepWithContext(ctx, op, delay); err != nil {
 					return err
@@ -365,6 +1089,14 @@ func (cc *ClusterDaxClient) retry(ctx context.Context, op string, action func(cl
 	return err
 }
 
+// limitRate waits for the tables of input, see Config.TableRateLimits.
+func (cc *ClusterDaxClient) limitRate(ctx context.Context, op string, input any, opt RequestOptions) error {
+	if cc.rates == nil {
+		return nil
+	}
+	return cc.rates.wait(cc.newContext(ctx, opt), op, input)
+}
+
 func (cc *ClusterDaxClient) newContext(ctx context.Context, o RequestOptions) context.Context {
 	if o.Context != nil {
 		return o.Context
@@ -381,11 +1113,25 @@ type cluster struct {
 	routeManager   RouteManager                 // protected by lock
 	closed         bool                         // protected by lock
 	lastRefreshErr error                        // protected by lock
-
-	lastUpdateNs int64
-	executor     *taskExecutor
+	refreshErrors  int                          // consecutive failed refreshes, protected by lock
+	leader         hostPort                     // last known leader, protected by lock
+	refreshSource  discoverySource              // of the last successful refresh, protected by lock
+	probes         map[hostPort]*probeState     // health probe results of the active nodes, protected by lock
+	weights        atomic.Pointer[routeWeights] // cached weights of the routes, see cachedWeights
+
+	lastUpdateNs         int64
+	lastRefreshSuccessNs int64
+	lastRefreshAttemptNs int64
+	lastFailoverNs       int64 // last refresh triggered by a suspected leader change
+	executor             *taskExecutor
+	bootstrapped         chan struct{} // closed once a background bootstrap completes, nil otherwise
+
+	closing  atomic.Bool // set once Close starts, new requests are rejected
+	inFlight int64       // number of requests currently executing, accessed atomically
 
 	seeds         []hostPort
+	external      bool       // nodes are set with setTopology instead of being discovered from seeds
+	topologyLock  sync.Mutex // serializes setTopology
 	config        Config
 	clientBuilder clientBuilder
 	IpDiscovery   types.IpDiscovery
@@ -396,13 +1142,17 @@ type cluster struct {
 type clientAndConfig struct {
 	client DaxAPI
 	cfg    serviceEndpoint
+	added  time.Time // when the node joined, zero for the nodes of the initial discovery
 }
 
 func newCluster(cfg Config) (*cluster, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
+	return buildCluster(cfg)
+}
 
+func buildCluster(cfg Config) (*cluster, error) {
 	seeds, hostname, isEncrypted, err := getHostPorts(cfg.HostPorts)
 	if err != nil {
 		return nil, err
@@ -410,7 +1160,26 @@ func newCluster(cfg Config) (*cluster, error) {
 
 	cfg.connConfig.isEncrypted = isEncrypted
 	cfg.connConfig.skipHostnameVerification = cfg.SkipHostnameVerification
+	cfg.connConfig.tlsServerName = cfg.TLSServerName
+	cfg.connConfig.verifyPeerHostname = cfg.VerifyPeerHostname
+	cfg.connConfig.rootCAs = cfg.RootCAs
+	cfg.connConfig.verifyCertificate = cfg.VerifyCertificate
 	cfg.connConfig.hostname = hostname
+	cfg.connConfig.signingAlgorithm = cfg.SigningAlgorithm
+	cfg.connConfig.signingRegionSet = cfg.SigningRegionSet
+	if len(cfg.connConfig.signingRegionSet) == 0 {
+		cfg.connConfig.signingRegionSet = []string{cfg.Region}
+	}
+	cfg.connConfig.connectTimeout = cfg.ConnectTimeout
+	cfg.connConfig.idleValidation = cfg.IdleConnectionValidation
+	cfg.connConfig.tcpUserTimeout = cfg.TCPUserTimeout
+	cfg.connConfig.userAgent = buildUserAgent(cfg.AppID, cfg.UserAgentExtras)
+	cfg.connConfig.strictResponseDecoding = cfg.StrictResponseDecoding
+	cfg.connConfig.validateResponseKeys = cfg.ValidateResponseKeys
+	cfg.connConfig.decodeLimits = cbor.Limits(cfg.DecodeLimits)
+	cfg.connConfig.metadataCacheMaxBytes = cfg.MetadataCacheMaxBytes
+	cfg.connConfig.metadataCacheTTL = cfg.MetadataCacheTTL
+	cfg.connConfig.interceptors = interceptors{onRequest: cfg.OnRequest, onResponse: cfg.OnResponse, onError: cfg.OnError}
 	sdkMetrics, err := buildDaxSdkMetrics(cfg.MeterProvider)
 	if err != nil {
 		return nil, err
@@ -426,7 +1195,7 @@ func newCluster(cfg Config) (*cluster, error) {
 		sdkMetrics,
 	)
 
-	return &cluster{
+	c := &cluster{
 		seeds:         seeds,
 		config:        cfg,
 		executor:      newExecutor(),
@@ -434,7 +1203,9 @@ func newCluster(cfg Config) (*cluster, error) {
 		routeManager:  routeManager,
 		daxSdkMetrics: sdkMetrics,
 		IpDiscovery:   cfg.IpDiscovery,
-	}, nil
+	}
+	c.config.connConfig.attributeLists = newAttributeListTracker(cfg.AttributeListThreshold, c.onAttributeListsExceeded, sdkMetrics)
+	return c, nil
 }
 
 func getHostPorts(hosts []string) (hostPorts []hostPort, hostname string, isEncrypted bool, err error) {
@@ -526,20 +1297,130 @@ func parseHostPort(hostPort string) (host string, port int, scheme string, err e
 }
 
 func (c *cluster) start() error {
-	c.executor.start(c.config.ClusterUpdateInterval, func() error {
-		c.safeRefresh(false)
+	if !c.config.BackgroundBootstrap {
+		c.bootstrap(context.Background())
 		return nil
-	})
-	c.executor.start(c.config.IdleConnectionReapDelay, c.reapIdleConnections)
-	c.safeRefresh(false)
+	}
+	c.bootstrapped = make(chan struct{})
+	go func() {
+		defer close(c.bootstrapped)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-c.executor.close:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		c.bootstrap(ctx)
+	}()
 	return nil
 }
 
+// bootstrap starts the background tasks of the cluster and discovers its
+// nodes for the first time, within BootstrapTimeout.
+func (c *cluster) bootstrap(ctx context.Context) {
+	if c.config.StartupDelay > 0 {
+		timer := time.NewTimer(randomDelay(c.config.StartupDelay))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+	if !c.external {
+		c.executor.startWithDelay(c.refreshDelay, func() error {
+			c.safeRefresh(false)
+			return nil
+		})
+	}
+	c.executor.start(c.config.IdleConnectionReapDelay, c.reapIdleConnections)
+	if c.config.HealthProbeInterval > 0 {
+		c.executor.start(c.config.HealthProbeInterval, c.probeNodes)
+	}
+	if !c.external {
+		if c.config.BootstrapTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.config.BootstrapTimeout)
+			defer cancel()
+		}
+		atomic.StoreInt64(&c.lastUpdateNs, time.Now().UnixNano())
+		c.recordRefresh(c.refreshNowWithContext(ctx))
+	}
+	c.warmUp()
+}
+
+// awaitBootstrap waits until the initial cluster discovery running in the
+// background, if any, completes or ctx is done.
+func (c *cluster) awaitBootstrap(ctx context.Context) error {
+	if c.bootstrapped == nil {
+		return nil
+	}
+	select {
+	case <-c.bootstrapped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// warmUp opens a first connection to every active node at a random time
+// within StartupDelay, so that clients started together do not connect to
+// the nodes at the same time.
+func (c *cluster) warmUp() {
+	if c.config.StartupDelay <= 0 {
+		return
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, n := range c.active {
+		c.executor.runAfter(randomDelay(c.config.StartupDelay), func() {
+			ctx, cfn := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cfn()
+			if _, err := n.client.endpoints(ctx, RequestOptions{}); err != nil {
+				c.debugLog("Failed to warm up connection to %s : %s", nodeAddress(n), err)
+			}
+		})
+	}
+}
+
+// randomDelay returns a random duration in [0, d).
+func randomDelay(d time.Duration) time.Duration {
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// beginRequest registers a new request and reports false if the cluster is closing.
+func (c *cluster) beginRequest() bool {
+	atomic.AddInt64(&c.inFlight, 1)
+	if c.closing.Load() {
+		// Close may have observed the increment, undo it so that it stops waiting.
+		atomic.AddInt64(&c.inFlight, -1)
+		return false
+	}
+	return true
+}
+
+func (c *cluster) endRequest() {
+	atomic.AddInt64(&c.inFlight, -1)
+}
+
+// Close rejects new requests, waits up to CloseDrainTimeout for in-flight
+// requests to complete and then closes all clients.
 func (c *cluster) Close() error {
-	c.executor.stopAll()
+	if c.closing.CompareAndSwap(false, true) {
+		c.executor.stopAll()
+	}
+
+	if !drainCounter(&c.inFlight, time.Now().Add(c.config.CloseDrainTimeout)) {
+		c.debugLog("Close drain timeout elapsed with %d requests in flight", atomic.LoadInt64(&c.inFlight))
+	}
 
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	if c.closed {
+		return nil
+	}
 	c.closed = true
 	for _, config := range c.active {
 		c.closeClient(config.client)
@@ -561,9 +1442,35 @@ func (c *cluster) reapIdleConnections() error {
 }
 
 func (c *cluster) client(prev DaxAPI, op string) (DaxAPI, error) {
+	return c.clientFor(prev, op, "")
+}
+
+// clientFor returns the client of the next attempt of op, routing reads by
+// pref, or by Config.ReadPreference if pref is empty.
+func (c *cluster) clientFor(prev DaxAPI, op string, pref types.ReadPreference) (DaxAPI, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	route := c.routeManager.getRoute(prev)
+	if c.closed {
+		return nil, &smithy.OperationError{ServiceID: service, OperationName: op, Err: os.ErrClosed}
+	}
+	var route DaxAPI
+	if pref == "" {
+		pref = c.config.ReadPreference
+	}
+	if isReadOp(op) {
+		switch {
+		case pref.IsLeader():
+			route = c.leaderRoute(prev)
+		case pref.IsReplica():
+			route = c.replicaRoute(prev)
+		}
+	}
+	if route == nil && c.weighted() {
+		route = c.weightedRoute(c.routeManager.getAllRoutes(), prev)
+	}
+	if route == nil {
+		route = c.routeManager.getRoute(prev)
+	}
 	if route == nil {
 		return nil, &smithy.OperationError{
 			ServiceID:     service,
@@ -574,11 +1481,106 @@ func (c *cluster) client(prev DaxAPI, op string) (DaxAPI, error) {
 	return route, nil
 }
 
+func isReadOp(op string) bool {
+	switch op {
+	case OpGetItem, OpQuery, OpScan, OpBatchGetItem:
+		return true
+	}
+	return false
+}
+
+// leaderRoute returns the leader if it is in rotation and was not used by the previous attempt.
+// It must be called with the lock held.
+func (c *cluster) leaderRoute(prev DaxAPI) DaxAPI {
+	leader, ok := c.active[c.leader]
+	if !ok || leader.client == prev {
+		return nil
+	}
+	for _, r := range c.routeManager.getAllRoutes() {
+		if r == leader.client {
+			return r
+		}
+	}
+	return nil
+}
+
+// replicaRoute returns a random replica in rotation other than the one used by the previous attempt.
+// It must be called with the lock held.
+func (c *cluster) replicaRoute(prev DaxAPI) DaxAPI {
+	var leader DaxAPI
+	if l, ok := c.active[c.leader]; ok {
+		leader = l.client
+	}
+	routes := c.routeManager.getAllRoutes()
+	if c.weighted() {
+		return c.weightedRoute(routes, leader, prev)
+	}
+	n := 0
+	for _, r := range routes {
+		if r != leader && r != prev {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	i := rand.Intn(n)
+	for _, r := range routes {
+		if r != leader && r != prev {
+			if i == 0 {
+				return r
+			}
+			i--
+		}
+	}
+	return nil
+}
+
 func (c *cluster) safeRefresh(force bool) {
-	err := c.refresh(force)
+	c.recordRefresh(c.refresh(force))
+}
+
+// refreshCluster refreshes the cluster nodes immediately, regardless of when
+// the last refresh happened, and returns its error.
+func (c *cluster) refreshCluster(ctx context.Context) error {
+	if c.closing.Load() {
+		return os.ErrClosed
+	}
+	if c.external {
+		return errExternalTopology
+	}
+	atomic.StoreInt64(&c.lastUpdateNs, time.Now().UnixNano())
+	err := c.refreshNowWithContext(ctx)
+	c.recordRefresh(err)
+	return err
+}
+
+// recordRefresh records the outcome of a refresh for lastRefreshError and the refresh backoff.
+func (c *cluster) recordRefresh(err error) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.lastRefreshErr = err
+	if err != nil {
+		c.refreshErrors++
+	} else {
+		c.refreshErrors = 0
+	}
+}
+
+// refreshDelay returns the wait before the next background refresh.
+func (c *cluster) refreshDelay() time.Duration {
+	c.lock.RLock()
+	failures := c.refreshErrors
+	c.lock.RUnlock()
+
+	d := c.config.ClusterUpdateInterval
+	if maxBackoff := c.config.ClusterUpdateMaxBackoff; maxBackoff > d {
+		for i := 0; i < failures && d < maxBackoff; i++ {
+			d *= 2
+		}
+		d = min(d, maxBackoff)
+	}
+	return lru.Jitter(d, c.config.ClusterUpdateJitter)
 }
 
 func (c *cluster) lastRefreshError() error {
@@ -599,15 +1601,31 @@ func (c *cluster) refresh(force bool) error {
 }
 
 func (c *cluster) refreshNow() error {
-	cfg, err := c.pullEndpoints()
+	return c.refreshNowWithContext(context.Background())
+}
+
+func (c *cluster) refreshNowWithContext(ctx context.Context) error {
+	if c.external {
+		return errExternalTopology
+	}
+	atomic.StoreInt64(&c.lastRefreshAttemptNs, time.Now().UnixNano())
+	cfg, source, err := c.pullEndpoints(ctx)
 	if err != nil {
-		c.debugLog("ERROR: Failed to refresh endpoint : %s", err)
+		c.warnLog("Failed to refresh endpoint : %s", err)
+		c.emit(types.ClusterEvent{Type: types.ClusterEventRefreshFailed, Time: time.Now(), Err: err})
 		return err
 	}
-	if !c.hasChanged(cfg) {
-		return nil
+	atomic.StoreInt64(&c.lastRefreshSuccessNs, time.Now().UnixNano())
+	c.lock.Lock()
+	c.refreshSource = source
+	c.lock.Unlock()
+	if c.hasChanged(cfg) {
+		if err := c.update(cfg); err != nil {
+			return err
+		}
 	}
-	return c.update(cfg)
+	c.updateLeader(cfg)
+	return nil
 }
 
 // This method is responsible for updating the set of active routes tracked by
@@ -619,11 +1637,12 @@ func (c *cluster) update(config []serviceEndpoint) error {
 	}
 
 	newActive := make(map[hostPort]clientAndConfig, len(config))
-	newRoutes := make([]DaxAPI, len(config))
+	newRoutes := make([]DaxAPI, 0, len(config))
 	shouldUpdateRoutes := true
 	var toClose []clientAndConfig
 	// Track the newly created client instances, so that we can clean them up in case of partial failures.
 	var newCliCfg []clientAndConfig
+	var events []types.ClusterEvent
 
 	c.lock.Lock()
 
@@ -643,7 +1662,7 @@ func (c *cluster) update(config []serviceEndpoint) error {
 		}
 
 		// Create client instances for the new endpoints in roster.
-		for i, ep := range config {
+		for _, ep := range config {
 			cliAndCfg, alreadyExists := oldActive[ep.hostPort()]
 			if !alreadyExists {
 				cli, err := c.newSingleClient(ep)
@@ -652,6 +1671,9 @@ func (c *cluster) update(config []serviceEndpoint) error {
 					break
 				} else {
 					cliAndCfg = clientAndConfig{client: cli, cfg: ep}
+					if len(oldActive) > 0 {
+						cliAndCfg.added = time.Now()
+					}
 					newCliCfg = append(newCliCfg, cliAndCfg)
 				}
 
@@ -660,21 +1682,43 @@ func (c *cluster) update(config []serviceEndpoint) error {
 				}
 			}
 			newActive[ep.hostPort()] = cliAndCfg
-			newRoutes[i] = cliAndCfg.client
+			if !c.ejected(ep.hostPort()) {
+				newRoutes = append(newRoutes, cliAndCfg.client)
+			}
 		}
 	}
 
 	if shouldUpdateRoutes {
 		c.active = newActive
 		c.routeManager.setRoutes(newRoutes)
+		c.weights.Store(nil)
+		for hp := range c.probes {
+			if _, ok := newActive[hp]; !ok {
+				delete(c.probes, hp)
+			}
+		}
+		c.debugLog("Updated cluster routes: %d active, %d added, %d removed", len(newActive), len(newCliCfg), len(toClose))
+		for _, cliAndCfg := range newCliCfg {
+			events = append(events, clusterEvent(types.ClusterEventNodeAdded, cliAndCfg.cfg))
+		}
+		for _, cliAndCfg := range toClose {
+			events = append(events, clusterEvent(types.ClusterEventNodeRemoved, cliAndCfg.cfg))
+		}
 	} else {
 		// cleanup newly created clients if they are not going to be tracked further.
 		toClose = append(toClose, newCliCfg...)
 	}
 	c.lock.Unlock()
+	c.emit(events...)
 
 	go func() {
+		// Removed routes no longer receive new requests, give the ones
+		// already in flight until the drain deadline to complete.
+		deadline := time.Now().Add(c.config.RouteDrainTimeout)
 		for _, client := range toClose {
+			if d, ok := client.client.(drainer); ok && !d.drain(deadline) {
+				c.debugLog("Drain timeout elapsed with requests in flight for : %s", client.cfg.hostname)
+			}
 			c.debugLog("Closing client for : %s", client.cfg.hostname)
 			c.closeClient(client.client)
 		}
@@ -694,13 +1738,13 @@ func (c *cluster) onHealthCheckFailed(host hostPort) {
 		}
 
 		if err == nil {
-			c.active[host] = clientAndConfig{client: cli, cfg: oldClientConfig.cfg}
+			c.active[host] = clientAndConfig{client: cli, cfg: oldClientConfig.cfg, added: oldClientConfig.added}
 
-			newRoutes := make([]DaxAPI, len(c.active))
-			i := 0
-			for _, cliAndCfg := range c.active {
-				newRoutes[i] = cliAndCfg.client
-				i++
+			newRoutes := make([]DaxAPI, 0, len(c.active))
+			for hp, cliAndCfg := range c.active {
+				if !c.ejected(hp) {
+					newRoutes = append(newRoutes, cliAndCfg.client)
+				}
 			}
 			c.routeManager.setRoutes(newRoutes)
 		} else {
@@ -818,13 +1862,20 @@ func filterAndSelectAddress[T net.IP | serviceEndpoint](ipAddresses []T, userPro
 	return selectAddressType(ipv4Addresses, ipv6Addresses, userProvidedIpDiscovery)
 }
 
-func (c *cluster) pullEndpoints() ([]serviceEndpoint, error) {
+// discoverySource is the node which returned the cluster membership, and the
+// seed it was resolved from.
+type discoverySource struct {
+	seed    hostPort
+	address string
+}
+
+func (c *cluster) pullEndpoints(ctx context.Context) ([]serviceEndpoint, discoverySource, error) {
 	var lastErr error // TODO chain errors?
 	// Multiple seeds (known nodes with public address) are used as entry points for a given cluster, to handle fault tolerance
 	for _, s := range c.seeds {
 		// Address resolution: determine the IP addresses assigned to each known seed hostname.
 		// A seed hostname can resolve to multiple IPs, both ipv4 and ipv6
-		ips, err := net.LookupIP(s.host)
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip", s.host)
 		if err != nil {
 			lastErr = err
 			continue
@@ -842,11 +1893,11 @@ func (c *cluster) pullEndpoints() ([]serviceEndpoint, error) {
 		filteredIPsForCurrentSeed, apiErr := filterAndSelectAddress(ips, c.IpDiscovery)
 		if apiErr != nil {
 			c.debugLog("Failed to filter IPs for seed %s:%d with discovery mode %s: %v", s.host, s.port, c.IpDiscovery.String(), apiErr)
-			return nil, apiErr
+			return nil, discoverySource{}, apiErr
 		}
 
 		for _, ip := range filteredIPsForCurrentSeed {
-			endpoints, err := c.pullEndpointsFrom(ip, s.port)
+			endpoints, err := c.pullEndpointsFrom(ctx, ip, s.port)
 			if err != nil {
 				c.debugLog("Failed to pull endpoint from ip: " + ip.String() + " port: " + strconv.Itoa(s.port))
 				lastErr = err
@@ -856,33 +1907,63 @@ func (c *cluster) pullEndpoints() ([]serviceEndpoint, error) {
 			c.debugLog("Pulled endpoints from %s : %v", ip, endpoints)
 			if len(endpoints) > 0 {
 				// filter the endpoint's ip addresses based on user provided IpDiscovery
-				return filterAndSelectAddress(endpoints, c.IpDiscovery)
+				source := discoverySource{seed: s, address: net.JoinHostPort(ip.String(), strconv.Itoa(s.port))}
+				endpoints, err = filterAndSelectAddress(endpoints, c.IpDiscovery)
+				return endpoints, source, err
 			}
 		}
 	}
-	return nil, lastErr
+	return nil, discoverySource{}, lastErr
 }
 
-func (c *cluster) pullEndpointsFrom(ip net.IP, port int) ([]serviceEndpoint, error) {
+func (c *cluster) pullEndpointsFrom(ctx context.Context, ip net.IP, port int) ([]serviceEndpoint, error) {
 	client, err := c.clientBuilder.newClient(ip, port, c.config.connConfig, c.config.Region, c.config.Credentials,
 		c.config.MaxPendingConnectionsPerHost, c.config.DialContext, nil, c.daxSdkMetrics)
 	if err != nil {
 		return nil, err
 	}
 	defer c.closeClient(client)
-	ctx, cfn := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cfn := context.WithTimeout(ctx, 5*time.Second)
 	defer cfn()
 	opts := RequestOptions{}
 	opts.RetryMaxAttempts = 2
 	return client.endpoints(ctx, opts)
 }
 
+// drainer is implemented by clients that can wait for their in-flight requests to complete.
+type drainer interface {
+	// drain blocks until no requests are in flight or the deadline passes,
+	// and reports whether all requests completed.
+	drain(deadline time.Time) bool
+}
+
+// drainCounter blocks until the counter drops to zero or the deadline
+// passes, and reports whether it reached zero.
+func drainCounter(counter *int64, deadline time.Time) bool {
+	for atomic.LoadInt64(counter) > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		time.Sleep(min(remaining, drainPollInterval))
+	}
+	return true
+}
+
 func (c *cluster) closeClient(client DaxAPI) {
 	if d, ok := client.(io.Closer); ok {
 		d.Close()
 	}
 }
 
+// warnLog logs through the configured logger whatever its level, like the
+// warnings of validateConnConfig.
+func (c *cluster) warnLog(logString string, args ...interface{}) {
+	if c.config.logger != nil {
+		c.config.logger.Logf(logging.Warn, logString, args...)
+	}
+}
+
 func (c *cluster) debugLog(logString string, args ...interface{}) {
 	if c.config.logger != nil && c.config.logLevel.AtLeast(utils.LogDebug) {
 		{
@@ -914,7 +1995,10 @@ func (c *cluster) getAllRoutes() []DaxAPI {
 }
 
 func (c *cluster) newSingleClient(cfg serviceEndpoint) (DaxAPI, error) {
-	return c.clientBuilder.newClient(net.IP(cfg.address), cfg.port, c.config.connConfig, c.config.Region, c.config.Credentials, c.config.MaxPendingConnectionsPerHost, c.config.DialContext, c, c.daxSdkMetrics)
+	connConfig := c.config.connConfig
+	connConfig.nodeHostname = cfg.hostname
+	connConfig.standbyConnections = c.config.StandbyConnectionsPerNode
+	return c.clientBuilder.newClient(net.IP(cfg.address), cfg.port, connConfig, c.config.Region, c.config.Credentials, c.config.MaxPendingConnectionsPerHost, c.config.DialContext, c, c.daxSdkMetrics)
 }
 
 type clientBuilder interface {
@@ -976,6 +2060,61 @@ func (e *taskExecutor) start(d time.Duration, action func() error) {
 	}()
 }
 
+// startWithDelay runs action repeatedly, waiting for the duration returned by
+// delay before every run.
+func (e *taskExecutor) startWithDelay(delay func() time.Duration, action func() error) {
+	timer := time.NewTimer(delay())
+	atomic.AddInt32(&e.tasks, 1)
+	go func() {
+		for {
+			select {
+			case <-timer.C:
+				action()
+				timer.Reset(delay())
+			case <-e.close:
+				timer.Stop()
+				atomic.AddInt32(&e.tasks, -1)
+				return
+			}
+		}
+	}()
+}
+
+// startTriggered runs action at once, and then every d and whenever trigger
+// receives, until the executor is stopped.
+func (e *taskExecutor) startTriggered(d time.Duration, trigger <-chan struct{}, action func() error) {
+	ticker := time.NewTicker(d)
+	atomic.AddInt32(&e.tasks, 1)
+	go func() {
+		defer atomic.AddInt32(&e.tasks, -1)
+		defer ticker.Stop()
+		for {
+			action()
+			select {
+			case <-ticker.C:
+			case <-trigger:
+			case <-e.close:
+				return
+			}
+		}
+	}()
+}
+
+// runAfter runs action once after d, unless the executor is stopped first.
+func (e *taskExecutor) runAfter(d time.Duration, action func()) {
+	timer := time.NewTimer(d)
+	atomic.AddInt32(&e.tasks, 1)
+	go func() {
+		defer atomic.AddInt32(&e.tasks, -1)
+		select {
+		case <-timer.C:
+			action()
+		case <-e.close:
+			timer.Stop()
+		}
+	}()
+}
+
 func (e *taskExecutor) numTasks() int32 {
 	return atomic.LoadInt32(&e.tasks)
 }
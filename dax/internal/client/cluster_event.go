@@ -0,0 +1,60 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+)
+
+// emit reports events to the OnClusterEvent hook. It must not be called with the lock held.
+func (c *cluster) emit(events ...types.ClusterEvent) {
+	if c.config.OnClusterEvent == nil {
+		return
+	}
+	for _, e := range events {
+		c.config.OnClusterEvent(e)
+	}
+}
+
+func clusterEvent(t types.ClusterEventType, ep serviceEndpoint) types.ClusterEvent {
+	return types.ClusterEvent{Type: t, Time: time.Now(), Node: endpointState(ep)}
+}
+
+// updateLeader records the leader among endpoints and reports when it changed.
+func (c *cluster) updateLeader(endpoints []serviceEndpoint) {
+	e := types.ClusterEvent{Type: types.ClusterEventLeaderChanged, Time: time.Now()}
+	var leader hostPort
+	for _, ep := range endpoints {
+		if ep.role == roleLeader {
+			e.Node = endpointState(ep)
+			leader = ep.hostPort()
+			break
+		}
+	}
+
+	c.lock.Lock()
+	changed := !c.closed && c.leader != leader
+	if changed {
+		c.leader = leader
+	}
+	c.lock.Unlock()
+
+	if changed {
+		c.emit(e)
+	}
+}
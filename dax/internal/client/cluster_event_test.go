@@ -0,0 +1,83 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_clusterEvents(t *testing.T) {
+	var events []types.ClusterEvent
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.OnClusterEvent = func(e types.ClusterEvent) { events = append(events, e) }
+	cluster, _ := newTestClusterWithConfig(cfg)
+
+	node1 := serviceEndpoint{nodeId: 1, hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader}
+	node2 := serviceEndpoint{nodeId: 2, hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121, role: roleReplica}
+	setExpectation(cluster, []serviceEndpoint{node1, node2})
+	require.NoError(t, cluster.refreshNow())
+
+	require.Len(t, events, 3)
+	assert.Equal(t, types.ClusterEventNodeAdded, events[0].Type)
+	assert.Equal(t, types.ClusterEventNodeAdded, events[1].Type)
+	assert.ElementsMatch(t, []string{"127.0.0.1:8121", "127.0.0.2:8121"}, []string{events[0].Node.Address, events[1].Node.Address})
+	assert.Equal(t, types.ClusterEventLeaderChanged, events[2].Type)
+	assert.Equal(t, "127.0.0.1:8121", events[2].Node.Address)
+	assert.Equal(t, types.NodeRoleLeader, events[2].Node.Role)
+	assert.False(t, events[2].Time.IsZero())
+
+	// An unchanged roster reports nothing.
+	events = nil
+	require.NoError(t, cluster.refreshNow())
+	assert.Empty(t, events)
+
+	// The leader leaves and the replica is promoted.
+	node2.role = roleLeader
+	setExpectation(cluster, []serviceEndpoint{node2})
+	require.NoError(t, cluster.refreshNow())
+	require.Len(t, events, 2)
+	assert.Equal(t, types.ClusterEventNodeRemoved, events[0].Type)
+	assert.Equal(t, "127.0.0.1:8121", events[0].Node.Address)
+	assert.Equal(t, types.ClusterEventLeaderChanged, events[1].Type)
+	assert.Equal(t, "127.0.0.2:8121", events[1].Node.Address)
+
+	// Losing the leader is reported with an empty node.
+	events = nil
+	node2.role = roleReplica
+	setExpectation(cluster, []serviceEndpoint{node2})
+	require.NoError(t, cluster.refreshNow())
+	require.Len(t, events, 1)
+	assert.Equal(t, types.ClusterEventLeaderChanged, events[0].Type)
+	assert.Empty(t, events[0].Node.Address)
+
+	events = nil
+	refreshErr := errors.New("connection refused")
+	cluster.clientBuilder = &failingClientBuilder{err: refreshErr}
+	require.Error(t, cluster.refreshNow())
+	require.Len(t, events, 1)
+	assert.Equal(t, types.ClusterEventRefreshFailed, events[0].Type)
+	assert.ErrorIs(t, events[0].Err, refreshErr)
+
+	require.NoError(t, cluster.Close())
+}
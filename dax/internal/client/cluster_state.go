@@ -0,0 +1,141 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+)
+
+// ClusterStateReporter is implemented by clients which can describe the cluster nodes they know about.
+type ClusterStateReporter interface {
+	ClusterState() types.ClusterState
+}
+
+func (cc *ClusterDaxClient) ClusterState() types.ClusterState {
+	return cc.cluster.state()
+}
+
+// DiscoveryStatusReporter is implemented by clients which can describe their
+// cluster membership refreshes.
+type DiscoveryStatusReporter interface {
+	DiscoveryStatus() types.DiscoveryStatus
+}
+
+func (cc *ClusterDaxClient) DiscoveryStatus() types.DiscoveryStatus {
+	return cc.cluster.discoveryStatus()
+}
+
+// discoveryStatus describes the last membership refreshes and where the
+// membership came from.
+func (c *cluster) discoveryStatus() types.DiscoveryStatus {
+	c.lock.RLock()
+	res := types.DiscoveryStatus{
+		RefreshError:        c.lastRefreshErr,
+		ConsecutiveFailures: c.refreshErrors,
+		Source:              c.refreshSource.address,
+	}
+	if seed := c.refreshSource.seed; seed.host != "" {
+		res.Seed = net.JoinHostPort(seed.host, strconv.Itoa(seed.port))
+	}
+	c.lock.RUnlock()
+
+	if ns := atomic.LoadInt64(&c.lastRefreshSuccessNs); ns > 0 {
+		res.LastRefresh = time.Unix(0, ns)
+	}
+	if ns := atomic.LoadInt64(&c.lastRefreshAttemptNs); ns > 0 {
+		res.LastAttempt = time.Unix(0, ns)
+	}
+	return res
+}
+
+// state describes the active nodes and the client's connection pools for them.
+func (c *cluster) state() types.ClusterState {
+	c.lock.RLock()
+	res := types.ClusterState{RefreshError: c.lastRefreshErr}
+	routed := make(map[DaxAPI]bool)
+	if c.routeManager != nil {
+		for _, r := range c.routeManager.getAllRoutes() {
+			routed[r] = true
+		}
+	}
+	res.Nodes = make([]types.NodeState, 0, len(c.active))
+	now := time.Now()
+	for _, n := range c.active {
+		ns := nodeState(n, routed[n.client])
+		if c.weighted() {
+			ns.Weight = c.nodeWeight(n, now)
+		}
+		res.Nodes = append(res.Nodes, ns)
+	}
+	c.lock.RUnlock()
+
+	if ns := atomic.LoadInt64(&c.lastRefreshSuccessNs); ns > 0 {
+		res.LastRefresh = time.Unix(0, ns)
+	}
+	res.AttributeLists = c.config.connConfig.attributeLists.counts()
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i].Address < res.Nodes[j].Address })
+	return res
+}
+
+func nodeState(n clientAndConfig, routed bool) types.NodeState {
+	ns := endpointState(n.cfg)
+	ns.Healthy = routed
+	if r, ok := n.client.(poolReporter); ok {
+		s := r.poolStats()
+		ns.IdleConnections = int(s.idle)
+		ns.PendingConnections = int(s.pending)
+		ns.StandbyConnections = int(s.standby)
+		ns.InFlightRequests = int(s.inFlight)
+		ns.BytesSent = s.wire.bytesSent
+		ns.BytesReceived = s.wire.bytesReceived
+		ns.FramesSent = s.wire.framesSent
+		ns.FramesReceived = s.wire.framesReceived
+		ns.LargestResponse = s.wire.largestResponse
+	}
+	return ns
+}
+
+// endpointState describes the identity, role and availability zone of a node.
+func endpointState(ep serviceEndpoint) types.NodeState {
+	ns := types.NodeState{
+		NodeID:           ep.nodeId,
+		Address:          endpointAddress(ep),
+		Hostname:         ep.hostname,
+		AvailabilityZone: ep.availabilityZone,
+	}
+	switch ep.role {
+	case roleLeader:
+		ns.Role = types.NodeRoleLeader
+	case roleReplica:
+		ns.Role = types.NodeRoleReplica
+	}
+	return ns
+}
+
+func nodeAddress(n clientAndConfig) string {
+	return endpointAddress(n.cfg)
+}
+
+func endpointAddress(ep serviceEndpoint) string {
+	hp := ep.hostPort()
+	return net.JoinHostPort(hp.host, strconv.Itoa(hp.port))
+}
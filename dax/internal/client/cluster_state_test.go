@@ -0,0 +1,92 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_state(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	setExpectation(cluster, []serviceEndpoint{
+		{nodeId: 2, hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121, role: roleReplica, availabilityZone: "us-east-1b"},
+		{nodeId: 1, hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader, availabilityZone: "us-east-1a"},
+	})
+	require.NoError(t, cluster.refreshNow())
+
+	st := cluster.state()
+	assert.NoError(t, st.RefreshError)
+	assert.WithinDuration(t, time.Now(), st.LastRefresh, time.Minute)
+	require.Len(t, st.Nodes, 2)
+	assert.Equal(t, types.NodeState{
+		NodeID:           1,
+		Address:          "127.0.0.1:8121",
+		Hostname:         "node1",
+		Role:             types.NodeRoleLeader,
+		AvailabilityZone: "us-east-1a",
+		Healthy:          true,
+	}, st.Nodes[0])
+	assert.Equal(t, "127.0.0.2:8121", st.Nodes[1].Address)
+	assert.Equal(t, types.NodeRoleReplica, st.Nodes[1].Role)
+	assert.True(t, st.Nodes[1].Healthy)
+
+	// A node taken out of rotation is reported as unhealthy.
+	cluster.routeManager.setRoutes([]DaxAPI{cluster.active[hostPort{"127.0.0.1", 8121}].client})
+	st = cluster.state()
+	assert.True(t, st.Nodes[0].Healthy)
+	assert.False(t, st.Nodes[1].Healthy)
+
+	require.NoError(t, cluster.Close())
+	st = cluster.state()
+	assert.Empty(t, st.Nodes)
+}
+
+func TestCluster_discoveryStatus(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	defer cluster.Close()
+	assert.Equal(t, types.DiscoveryStatus{}, cluster.discoveryStatus())
+
+	setExpectation(cluster, []serviceEndpoint{
+		{nodeId: 1, hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader},
+	})
+	require.NoError(t, cluster.refreshCluster(context.Background()))
+	st := cluster.discoveryStatus()
+	assert.NoError(t, st.RefreshError)
+	assert.Zero(t, st.ConsecutiveFailures)
+	assert.WithinDuration(t, time.Now(), st.LastRefresh, time.Minute)
+	assert.False(t, st.LastAttempt.After(st.LastRefresh))
+	assert.Equal(t, "127.0.0.1:8111", st.Seed)
+	assert.Equal(t, "127.0.0.1:8111", st.Source)
+
+	failed := errors.New("unreachable")
+	cluster.clientBuilder = &failingClientBuilder{err: failed}
+	require.Error(t, cluster.refreshCluster(context.Background()))
+	require.Error(t, cluster.refreshCluster(context.Background()))
+	failing := cluster.discoveryStatus()
+	assert.ErrorIs(t, failing.RefreshError, failed)
+	assert.Equal(t, 2, failing.ConsecutiveFailures)
+	assert.Equal(t, st.LastRefresh, failing.LastRefresh)
+	assert.False(t, failing.LastAttempt.Before(st.LastRefresh))
+	assert.Equal(t, "127.0.0.1:8111", failing.Source, "the source of the membership in use")
+}
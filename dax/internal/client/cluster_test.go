@@ -17,8 +17,10 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"reflect"
 	"strconv"
 	"sync"
@@ -26,7 +28,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
 	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-dax-go-v2/dax/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -135,7 +139,7 @@ func TestClusterDaxClient_retry(t *testing.T) {
 			},
 		}
 
-		err := cc.retry(context.Background(), "op", action, opt)
+		err := cc.retry(context.Background(), "op", action, opt, nil)
 		maxAttempts := retries + 1
 		if successfulAttempt <= maxAttempts {
 			if calls != successfulAttempt {
@@ -159,6 +163,184 @@ func TestClusterDaxClient_retry(t *testing.T) {
 	}
 }
 
+func TestClusterDaxClient_transactWriteClientRequestToken(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		cluster, clientBuilder := newTestCluster([]string{"127.0.0.1:8111"})
+		cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+		node := clientBuilder.clients[len(clientBuilder.clients)-1]
+		node.transactWriteErr = newDaxRequestFailure([]int{1}, "RetryableError", "", "", 500, smithy.FaultServer)
+		cfg := DefaultConfig()
+		cfg.DisableClientRequestTokens = disabled
+		cc := ClusterDaxClient{config: cfg, cluster: cluster}
+
+		input := &dynamodb.TransactWriteItemsInput{}
+		opt := RequestOptions{
+			Options: dynamodb.Options{RetryMaxAttempts: 2},
+			Retryer: DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Millisecond},
+		}
+		for call := 0; call < 2; call++ {
+			if _, err := cc.TransactWriteItemsWithOptions(context.Background(), input, &dynamodb.TransactWriteItemsOutput{}, opt); err == nil {
+				t.Fatal("expected error")
+			}
+		}
+
+		if input.ClientRequestToken != nil {
+			t.Errorf("expected the input to be left unchanged, got token %q", *input.ClientRequestToken)
+		}
+		if len(node.transactWriteTokens) != 6 {
+			t.Fatalf("expected 6 attempts, got %d", len(node.transactWriteTokens))
+		}
+		first, second := node.transactWriteTokens[:3], node.transactWriteTokens[3:]
+		if disabled {
+			for _, token := range node.transactWriteTokens {
+				if token != nil {
+					t.Errorf("expected no token, got %q", *token)
+				}
+			}
+			continue
+		}
+		for _, attempts := range [][]*string{first, second} {
+			if attempts[0] == nil || *attempts[0] != *attempts[1] || *attempts[0] != *attempts[2] {
+				t.Errorf("expected retries to share a token, got %v", aws.ToStringSlice(attempts))
+			}
+		}
+		if *first[0] == *second[0] {
+			t.Errorf("expected a new token per call, got %q twice", *first[0])
+		}
+	}
+
+	// A token set by the caller is used as is.
+	token := "caller-token"
+	input, err := withClientRequestToken(&dynamodb.TransactWriteItemsInput{ClientRequestToken: &token})
+	if err != nil || input.ClientRequestToken != &token {
+		t.Errorf("expected the caller token to be kept, got %v, %v", input, err)
+	}
+}
+
+func TestClusterDaxClient_failoverRefresh(t *testing.T) {
+	leaderErr := newDaxRequestFailure([]int{2}, "ClusterFailure", "", "", 500, smithy.FaultServer)
+	cases := []struct {
+		name      string
+		op        string
+		err       error
+		threshold time.Duration
+		refreshed bool
+	}{
+		{"write", OpPutItem, leaderErr, time.Second, true},
+		{"read", OpGetItem, leaderErr, time.Second, false},
+		{"other error", OpPutItem, newDaxRequestFailure([]int{1}, "RetryableError", "", "", 500, smithy.FaultServer), time.Second, false},
+		{"disabled", OpPutItem, leaderErr, 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.HostPorts = []string{"127.0.0.1:8111"}
+			cfg.Region = "us-west-2"
+			cfg.FailoverRefreshThreshold = c.threshold
+			cluster, clientBuilder := newTestClusterWithConfig(cfg)
+			cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+			before := len(clientBuilder.clients)
+			cc := ClusterDaxClient{config: cfg, cluster: cluster}
+
+			opt := RequestOptions{
+				Options: dynamodb.Options{RetryMaxAttempts: 3},
+				Retryer: DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Millisecond},
+			}
+			err := cc.retry(context.Background(), c.op, func(client DaxAPI, o RequestOptions) error {
+				return c.err
+			}, opt, nil)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			refreshes := 0
+			for _, client := range clientBuilder.clients[before:] {
+				refreshes += client.endpointsCalls
+			}
+			if c.refreshed && refreshes != 1 {
+				t.Errorf("expected a single refresh across retries, got %d", refreshes)
+			}
+			if !c.refreshed && refreshes != 0 {
+				t.Errorf("expected no refresh, got %d", refreshes)
+			}
+		})
+	}
+}
+
+func TestClusterDaxClient_failoverUnavailability(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	mp := &testMeterProvider{}
+	cfg.MeterProvider = mp
+	cluster, clientBuilder := newTestClusterWithConfig(cfg)
+	clientBuilder.ep = []serviceEndpoint{{hostname: "localhost", port: 8121}}
+	cluster.update(clientBuilder.ep)
+	cc := ClusterDaxClient{config: cfg, cluster: cluster}
+
+	attempts := 0
+	opt := RequestOptions{
+		Options: dynamodb.Options{RetryMaxAttempts: 3},
+		Retryer: DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Millisecond},
+	}
+	err := cc.retry(context.Background(), OpPutItem, func(client DaxAPI, o RequestOptions) error {
+		if attempts++; attempts == 1 {
+			return newDaxRequestFailure([]int{2}, "ClusterFailure", "", "", 500, smithy.FaultServer)
+		}
+		return nil
+	}, opt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	meter := mp.meters[daxMeterScope].(*testMeter)
+	if got := meter.i64s[daxFailoverRefreshes].data; len(got) != 1 || got[0] != 1 {
+		t.Errorf("expected one failover refresh, got %v", got)
+	}
+	if got := meter.i64s[daxFailoverWriteUnavailable].data; len(got) != 1 {
+		t.Errorf("expected one unavailability sample, got %v", got)
+	}
+}
+
+func TestCluster_refreshForFailoverContext(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.FailoverRefreshThreshold = time.Nanosecond
+	cluster, clientBuilder := newTestClusterWithConfig(cfg)
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "write")
+	before := len(clientBuilder.clients)
+	cluster.refreshForFailover(ctx)
+	require.Greater(t, len(clientBuilder.clients), before)
+	seed := clientBuilder.clients[len(clientBuilder.clients)-1]
+	assert.Equal(t, "write", seed.endpointsCtx.Value(ctxKey{}), "the refresh runs with the context of the write")
+
+	// A refresh cut short by the write is not recorded as failed.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	cluster.clientBuilder = &failingClientBuilder{err: context.Canceled}
+	time.Sleep(time.Millisecond)
+	cluster.refreshForFailover(canceled)
+	assert.NoError(t, cluster.lastRefreshError())
+}
+
+func TestCluster_warnLog(t *testing.T) {
+	logger := &recordingLogger{}
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.SetLogger(logger, utils.LogOff)
+	cluster, _ := newTestClusterWithConfig(cfg)
+	cluster.clientBuilder = &failingClientBuilder{err: errors.New("unreachable")}
+
+	require.Error(t, cluster.refreshNow())
+	require.Len(t, logger.lines, 1, "warnings are logged without LogDebug, debug output is not")
+	assert.Contains(t, logger.lines[0], "Failed to refresh endpoint")
+}
+
 func TestClusterDaxClient_retrySleepCycleCount(t *testing.T) {
 	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
 	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
@@ -183,7 +365,7 @@ func TestClusterDaxClient_retrySleepCycleCount(t *testing.T) {
 	}
 
 	delays = nil
-	err := cc.retry(context.Background(), "op", action, opt)
+	err := cc.retry(context.Background(), "op", action, opt, nil)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -205,7 +387,7 @@ func TestClusterDaxClient_retrySleepCycleCount(t *testing.T) {
 		},
 	}
 
-	err = cc.retry(context.Background(), "op", action, opt)
+	err = cc.retry(context.Background(), "op", action, opt, nil)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -263,7 +445,7 @@ func TestClusterDaxClient_throttleRetry(t *testing.T) {
 		},
 	}
 
-	err := cc.retry(context.Background(), "op", action, opt)
+	err := cc.retry(context.Background(), "op", action, opt, nil)
 	if err != nil {
 		t.Fatalf("Expected success after retries, got error: %v", err)
 	}
@@ -273,6 +455,77 @@ func TestClusterDaxClient_throttleRetry(t *testing.T) {
 	}
 }
 
+func TestClusterDaxClient_retryStopsOnConsecutiveThrottles(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: DefaultConfig(), cluster: cluster}
+
+	attempts := 0
+	action := func(client DaxAPI, o RequestOptions) error {
+		attempts++
+		return &types.ProvisionedThroughputExceededException{
+			Message: aws.String("Throttled request"),
+		}
+	}
+
+	opt := RequestOptions{
+		Options: dynamodb.Options{
+			RetryMaxAttempts: 10,
+		},
+		Retryer: DaxRetryer{
+			BaseThrottleDelay:       time.Millisecond,
+			MaxBackoffDelay:         time.Millisecond * 10,
+			MaxConsecutiveThrottles: 3,
+		},
+	}
+
+	err := cc.retry(context.Background(), "op", action, opt, nil)
+	if !IsThrottleError(err) {
+		t.Fatalf("Expected throttle error, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestClusterDaxClient_retryFailsFastOnShortDeadline(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: DefaultConfig(), cluster: cluster}
+
+	attempts := 0
+	action := func(client DaxAPI, o RequestOptions) error {
+		attempts++
+		return &types.ProvisionedThroughputExceededException{
+			Message: aws.String("Throttled request"),
+		}
+	}
+
+	opt := RequestOptions{
+		Options: dynamodb.Options{
+			RetryMaxAttempts: 3,
+		},
+		Retryer: DaxRetryer{
+			BaseThrottleDelay: time.Minute,
+			MaxBackoffDelay:   time.Minute,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	err := cc.retry(ctx, "op", action, opt, nil)
+	if !IsThrottleError(err) {
+		t.Fatalf("Expected throttle error, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to fail fast, took %v", elapsed)
+	}
+}
+
 func TestClusterDaxClient_retryReturnsLastError(t *testing.T) {
 	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
 	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
@@ -287,7 +540,7 @@ func TestClusterDaxClient_retryReturnsLastError(t *testing.T) {
 	opt := RequestOptions{}
 	opt.RetryMaxAttempts = 2
 
-	err := cc.retry(context.Background(), "op", action, opt)
+	err := cc.retry(context.Background(), "op", action, opt, nil)
 	expectedError := fmt.Errorf("Error_%d", callCount)
 	if err.Error() != expectedError.Error() {
 		t.Fatalf("Wrong error. Expected %v, but got %v", expectedError, err)
@@ -409,7 +662,7 @@ func TestClusterDaxClient_retryReturnsCorrectErrorType(t *testing.T) {
 
 		opt := RequestOptions{}
 
-		err := cc.retry(context.Background(), "op", action, opt)
+		err := cc.retry(context.Background(), "op", action, opt, nil)
 		actualClass := reflect.TypeOf(err)
 		if actualClass != c.class {
 			t.Errorf("conversion of code sequence %v failed: expected %s, but got %s", c.codes, c.class.String(), actualClass.String())
@@ -1271,10 +1524,23 @@ func (b *testClientBuilder) newClient(ip net.IP, port int, _ connConfig, _ strin
 	return t, nil
 }
 
+type failingClientBuilder struct {
+	err error
+}
+
+func (b *failingClientBuilder) newClient(net.IP, int, connConfig, string, aws.CredentialsProvider, int, dialContext, RouteListener, *daxSdkMetrics) (DaxAPI, error) {
+	return nil, b.err
+}
+
 type testClient struct {
 	hp                                           hostPort
 	ep                                           []serviceEndpoint
+	endpointsErr                                 error
 	endpointsCalls, closeCalls, healthCheckCalls int
+	endpointsCtx                                 context.Context
+
+	transactWriteTokens []*string
+	transactWriteErr    error
 }
 
 var _ DaxAPI = (*testClient)(nil)
@@ -1283,8 +1549,12 @@ func (c *testClient) startHealthChecks(_ *cluster, _ hostPort) {
 	c.healthCheckCalls++
 }
 
-func (c *testClient) endpoints(_ context.Context, _ RequestOptions) ([]serviceEndpoint, error) {
+func (c *testClient) endpoints(ctx context.Context, _ RequestOptions) ([]serviceEndpoint, error) {
 	c.endpointsCalls++
+	c.endpointsCtx = ctx
+	if c.endpointsErr != nil {
+		return nil, c.endpointsErr
+	}
 	return c.ep, nil
 }
 
@@ -1325,8 +1595,9 @@ func (c *testClient) BatchGetItemWithOptions(_ context.Context, _ *dynamodb.Batc
 	panic("not implemented")
 }
 
-func (c *testClient) TransactWriteItemsWithOptions(_ context.Context, _ *dynamodb.TransactWriteItemsInput, _ *dynamodb.TransactWriteItemsOutput, _ RequestOptions) (*dynamodb.TransactWriteItemsOutput, error) {
-	panic("not implemented")
+func (c *testClient) TransactWriteItemsWithOptions(_ context.Context, input *dynamodb.TransactWriteItemsInput, output *dynamodb.TransactWriteItemsOutput, _ RequestOptions) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.transactWriteTokens = append(c.transactWriteTokens, input.ClientRequestToken)
+	return output, c.transactWriteErr
 }
 
 func (c *testClient) TransactGetItemsWithOptions(_ context.Context, _ *dynamodb.TransactGetItemsInput, _ *dynamodb.TransactGetItemsOutput, _ RequestOptions) (*dynamodb.TransactGetItemsOutput, error) {
@@ -1343,3 +1614,427 @@ func (p *testCredentialProvider) Retrieve(_ context.Context) (aws.Credentials, e
 		SessionToken:    "token",
 	}, nil
 }
+
+func TestClusterDaxClient_CloseDrainsInFlightRequests(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.config.CloseDrainTimeout = 10 * time.Second
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: cluster.config, cluster: cluster}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	reqDone := make(chan error, 1)
+	go func() {
+		reqDone <- cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+			close(started)
+			<-release
+			return nil
+		}, RequestOptions{}, nil)
+	}()
+	<-started
+
+	closeDone := make(chan error, 1)
+	go func() { closeDone <- cc.Close() }()
+	for !cluster.closing.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+		t.Error("request started after Close")
+		return nil
+	}, RequestOptions{}, nil)
+	assert.ErrorIs(t, err, os.ErrClosed)
+	select {
+	case <-closeDone:
+		t.Fatal("Close returned with a request in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-reqDone; err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := <-closeDone; err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err = cluster.client(nil, OpGetItem)
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.NoError(t, cc.Close())
+}
+
+func TestClusterDaxClient_CloseDrainTimeout(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.config.CloseDrainTimeout = 20 * time.Millisecond
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: cluster.config, cluster: cluster}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+		close(started)
+		<-release
+		return nil
+	}, RequestOptions{}, nil)
+	<-started
+
+	st := time.Now()
+	if err := cc.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if elapsed := time.Since(st); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected Close to wait for the drain timeout, took %v", elapsed)
+	}
+}
+
+func TestCluster_refreshDelay(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.config.ClusterUpdateInterval = 4 * time.Second
+	cluster.config.ClusterUpdateMaxBackoff = 30 * time.Second
+	cluster.config.ClusterUpdateJitter = 0
+
+	for failures, want := range []time.Duration{4, 8, 16, 30, 30} {
+		cluster.refreshErrors = failures
+		assert.Equal(t, want*time.Second, cluster.refreshDelay(), "after %d failures", failures)
+	}
+
+	builder := cluster.clientBuilder
+	cluster.clientBuilder = &failingClientBuilder{err: errors.New("connection refused")}
+	cluster.safeRefresh(true)
+	assert.Equal(t, 5, cluster.refreshErrors)
+	cluster.clientBuilder = builder
+	setExpectation(cluster, []serviceEndpoint{{hostname: "localhost", address: net.ParseIP("127.0.0.1"), port: 8121}})
+	cluster.safeRefresh(true)
+	assert.NoError(t, cluster.lastRefreshError())
+	assert.Equal(t, 0, cluster.refreshErrors)
+
+	cluster.config.ClusterUpdateMaxBackoff = 0
+	cluster.refreshErrors = 3
+	assert.Equal(t, 4*time.Second, cluster.refreshDelay())
+
+	cluster.config.ClusterUpdateJitter = 0.25
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		d := cluster.refreshDelay()
+		assert.GreaterOrEqual(t, d, 3*time.Second)
+		assert.LessOrEqual(t, d, 5*time.Second)
+		seen[d] = true
+	}
+	assert.Greater(t, len(seen), 1, "expected jittered delays to differ")
+}
+
+func TestConfig_validateClusterUpdate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		update  func(cfg *Config)
+		invalid bool
+	}{
+		{name: "defaults", update: func(cfg *Config) {}},
+		{name: "zero jitter and backoff", update: func(cfg *Config) { cfg.ClusterUpdateJitter, cfg.ClusterUpdateMaxBackoff = 0, 0 }},
+		{name: "zero interval", update: func(cfg *Config) { cfg.ClusterUpdateInterval = 0 }, invalid: true},
+		{name: "negative jitter", update: func(cfg *Config) { cfg.ClusterUpdateJitter = -0.1 }, invalid: true},
+		{name: "jitter of one", update: func(cfg *Config) { cfg.ClusterUpdateJitter = 1 }, invalid: true},
+		{name: "negative backoff", update: func(cfg *Config) { cfg.ClusterUpdateMaxBackoff = -time.Second }, invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.HostPorts = []string{"127.0.0.1:8111"}
+			cfg.Region = "us-west-2"
+			tc.update(&cfg)
+			if err := cfg.validate(); tc.invalid {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTaskExecutor_startWithDelay(t *testing.T) {
+	executor := newExecutor()
+	var delays, runs int32
+	executor.startWithDelay(func() time.Duration {
+		atomic.AddInt32(&delays, 1)
+		return time.Millisecond
+	}, func() error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	for atomic.LoadInt32(&runs) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	executor.stopAll()
+	for executor.numTasks() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the delay is computed once up front and once after every run
+	assert.Equal(t, atomic.LoadInt32(&runs)+1, atomic.LoadInt32(&delays))
+}
+
+func TestCluster_refreshCluster(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	setExpectation(cluster, []serviceEndpoint{{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121}})
+	require.NoError(t, cluster.refreshNow())
+	require.Len(t, cluster.active, 1)
+
+	// a refresh that just happened would normally suppress the next one
+	atomic.StoreInt64(&cluster.lastUpdateNs, time.Now().UnixNano())
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+	})
+	require.NoError(t, cluster.refreshCluster(context.Background()))
+	assert.Len(t, cluster.active, 2)
+	assert.NoError(t, cluster.lastRefreshError())
+
+	builder := cluster.clientBuilder
+	refreshErr := errors.New("connection refused")
+	cluster.clientBuilder = &failingClientBuilder{err: refreshErr}
+	assert.ErrorIs(t, cluster.refreshCluster(context.Background()), refreshErr)
+	assert.ErrorIs(t, cluster.lastRefreshError(), refreshErr)
+	assert.Equal(t, 1, cluster.refreshErrors)
+	assert.Len(t, cluster.active, 2, "a failed refresh keeps the known nodes")
+	cluster.clientBuilder = builder
+
+	require.NoError(t, cluster.Close())
+	assert.ErrorIs(t, cluster.refreshCluster(context.Background()), os.ErrClosed)
+}
+
+func TestCluster_readPreference(t *testing.T) {
+	leader := serviceEndpoint{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader}
+	replica1 := serviceEndpoint{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121, role: roleReplica}
+	replica2 := serviceEndpoint{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121, role: roleReplica}
+
+	newCluster := func(t *testing.T, pref daxTypes.ReadPreference, endpoints ...serviceEndpoint) *cluster {
+		cfg := DefaultConfig()
+		cfg.HostPorts = []string{"127.0.0.1:8111"}
+		cfg.Region = "us-west-2"
+		cfg.ReadPreference = pref
+		cluster, _ := newTestClusterWithConfig(cfg)
+		setExpectation(cluster, endpoints)
+		require.NoError(t, cluster.refreshNow())
+		t.Cleanup(func() { cluster.Close() })
+		return cluster
+	}
+	nodeOf := func(cluster *cluster, client DaxAPI) string {
+		for hp, cc := range cluster.active {
+			if cc.client == client {
+				return hp.host
+			}
+		}
+		return ""
+	}
+
+	t.Run("leader", func(t *testing.T) {
+		cluster := newCluster(t, daxTypes.ReadPreferenceLeader, leader, replica1, replica2)
+		for i := 0; i < 20; i++ {
+			c, err := cluster.client(nil, OpGetItem)
+			require.NoError(t, err)
+			assert.Equal(t, "127.0.0.1", nodeOf(cluster, c))
+		}
+		// Retries move away from a failing leader.
+		prev := cluster.active[leader.hostPort()].client
+		c, err := cluster.client(prev, OpQuery)
+		require.NoError(t, err)
+		assert.NotEqual(t, "127.0.0.1", nodeOf(cluster, c))
+	})
+
+	t.Run("replica", func(t *testing.T) {
+		cluster := newCluster(t, daxTypes.ReadPreferenceReplica, leader, replica1, replica2)
+		seen := map[string]bool{}
+		for i := 0; i < 50; i++ {
+			c, err := cluster.client(nil, OpScan)
+			require.NoError(t, err)
+			seen[nodeOf(cluster, c)] = true
+		}
+		assert.Equal(t, map[string]bool{"127.0.0.2": true, "127.0.0.3": true}, seen)
+
+		prev := cluster.active[replica1.hostPort()].client
+		c, err := cluster.client(prev, OpBatchGetItem)
+		require.NoError(t, err)
+		assert.Equal(t, "127.0.0.3", nodeOf(cluster, c))
+	})
+
+	t.Run("replica falls back to leader", func(t *testing.T) {
+		cluster := newCluster(t, daxTypes.ReadPreferenceReplica, leader)
+		c, err := cluster.client(nil, OpGetItem)
+		require.NoError(t, err)
+		assert.Equal(t, "127.0.0.1", nodeOf(cluster, c))
+	})
+
+	t.Run("request preference", func(t *testing.T) {
+		cluster := newCluster(t, daxTypes.ReadPreferenceReplica, leader, replica1, replica2)
+		for i := 0; i < 20; i++ {
+			c, err := cluster.clientFor(nil, OpBatchGetItem, daxTypes.ReadPreferenceLeader)
+			require.NoError(t, err)
+			assert.Equal(t, "127.0.0.1", nodeOf(cluster, c))
+		}
+	})
+
+	t.Run("writes ignore preference", func(t *testing.T) {
+		cluster := newCluster(t, daxTypes.ReadPreferenceLeader, leader, replica1, replica2)
+		seen := map[string]bool{}
+		for i := 0; i < 50; i++ {
+			c, err := cluster.client(nil, OpPutItem)
+			require.NoError(t, err)
+			seen[nodeOf(cluster, c)] = true
+		}
+		assert.Len(t, seen, 3)
+	})
+}
+
+func TestConfig_validateReadPreference(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	for _, pref := range []daxTypes.ReadPreference{"", daxTypes.ReadPreferenceAny, daxTypes.ReadPreferenceLeader, "Replica"} {
+		cfg.ReadPreference = pref
+		assert.NoError(t, cfg.validate(), pref)
+	}
+	cfg.ReadPreference = "nearest"
+	assert.Error(t, cfg.validate())
+}
+
+func TestTaskExecutor_runAfter(t *testing.T) {
+	executor := newExecutor()
+	var runs int32
+	executor.runAfter(time.Millisecond, func() { atomic.AddInt32(&runs, 1) })
+	executor.runAfter(time.Hour, func() { atomic.AddInt32(&runs, 1) })
+
+	for atomic.LoadInt32(&runs) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	executor.stopAll()
+	for executor.numTasks() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+}
+
+func TestCluster_startupDelay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.StartupDelay = 20 * time.Millisecond
+	cluster, builder := newTestClusterWithConfig(cfg)
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+	})
+
+	start := time.Now()
+	require.NoError(t, cluster.start())
+	assert.Less(t, time.Since(start), time.Second)
+	require.Len(t, cluster.active, 2)
+
+	// The refresh and idle connection reaper tasks keep running, the warm-up tasks finish.
+	for cluster.executor.numTasks() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	for _, c := range builder.clients[1:] {
+		assert.Equal(t, 1, c.endpointsCalls, c.hp.host)
+	}
+	require.NoError(t, cluster.Close())
+
+	cfg.StartupDelay = -time.Second
+	assert.Error(t, cfg.validate())
+}
+
+// blockingClientBuilder builds clients whose discovery waits until release
+// is closed or the request context is done.
+type blockingClientBuilder struct {
+	testClientBuilder
+	release chan struct{}
+}
+
+func (b *blockingClientBuilder) newClient(ip net.IP, port int, cfg connConfig, region string, creds aws.CredentialsProvider, maxPending int, dial dialContext, l RouteListener, m *daxSdkMetrics) (DaxAPI, error) {
+	c, _ := b.testClientBuilder.newClient(ip, port, cfg, region, creds, maxPending, dial, l, m)
+	return &blockingClient{testClient: c.(*testClient), release: b.release}, nil
+}
+
+type blockingClient struct {
+	*testClient
+	release chan struct{}
+}
+
+func (c *blockingClient) endpoints(ctx context.Context, opt RequestOptions) ([]serviceEndpoint, error) {
+	select {
+	case <-c.release:
+		return c.ep, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCluster_bootstrapTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.BootstrapTimeout = 20 * time.Millisecond
+	cluster, _ := newTestClusterWithConfig(cfg)
+	cluster.clientBuilder = &blockingClientBuilder{release: make(chan struct{})}
+
+	start := time.Now()
+	require.NoError(t, cluster.start())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, cluster.lastRefreshError(), context.DeadlineExceeded)
+	assert.Empty(t, cluster.active)
+	require.NoError(t, cluster.Close())
+
+	cfg.BootstrapTimeout = -time.Second
+	assert.Error(t, cfg.validate())
+}
+
+func TestCluster_backgroundBootstrap(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.BackgroundBootstrap = true
+	cluster, _ := newTestClusterWithConfig(cfg)
+	builder := &blockingClientBuilder{release: make(chan struct{})}
+	builder.ep = []serviceEndpoint{{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121}}
+	cluster.clientBuilder = builder
+	cc := newClusterDaxClient(cfg, cluster)
+
+	require.NoError(t, cluster.start())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := cc.retry(ctx, OpGetItem, func(DaxAPI, RequestOptions) error { return nil }, RequestOptions{}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "requests wait for the discovery")
+
+	close(builder.release)
+	require.NoError(t, cluster.awaitBootstrap(context.Background()))
+	assert.Len(t, cluster.active, 1)
+	err = cc.retry(context.Background(), OpGetItem, func(DaxAPI, RequestOptions) error { return nil }, RequestOptions{}, nil)
+	assert.NoError(t, err)
+	require.NoError(t, cluster.Close())
+}
+
+func TestCluster_backgroundBootstrapClose(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.BackgroundBootstrap = true
+	cluster, _ := newTestClusterWithConfig(cfg)
+	cluster.clientBuilder = &blockingClientBuilder{release: make(chan struct{})}
+
+	require.NoError(t, cluster.start())
+	require.NoError(t, cluster.Close())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, cluster.awaitBootstrap(ctx), "closing the cluster stops the discovery")
+}
+
+func TestConfig_DecodeLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.DecodeLimits = daxTypes.DecodeLimits{MaxArrayLength: 10, MaxStringLength: 100, MaxDepth: 4}
+	c, err := newCluster(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, cbor.Limits{MaxArrayLength: 10, MaxStringLength: 100, MaxDepth: 4}, c.config.connConfig.decodeLimits)
+
+	cfg.DecodeLimits.MaxDepth = -1
+	assert.Error(t, cfg.validate())
+}
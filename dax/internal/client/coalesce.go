@@ -0,0 +1,136 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getItemFlights coalesces concurrent identical GetItem calls into one
+// request, see Config.CoalesceGetItem.
+type getItemFlights struct {
+	mu      sync.Mutex
+	flights map[string]*getItemFlight
+}
+
+// getItemFlight is a GetItem request shared by the calls waiting for it.
+type getItemFlight struct {
+	done   chan struct{} // closed once output and err are set
+	output *dynamodb.GetItemOutput
+	err    error
+
+	waiters int // guarded by getItemFlights.mu
+	cancel  context.CancelFunc
+}
+
+func newGetItemFlights() *getItemFlights {
+	return &getItemFlights{flights: make(map[string]*getItemFlight)}
+}
+
+// do returns the result of get for the flight of key, starting the flight
+// if there is none. The flight runs with the values and deadline of the
+// call starting it, until it completes or every waiting call gave up.
+func (f *getItemFlights) do(ctx context.Context, key string, get func(ctx context.Context) (*dynamodb.GetItemOutput, error)) (*dynamodb.GetItemOutput, bool, error) {
+	f.mu.Lock()
+	fl, shared := f.flights[key]
+	if !shared {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if deadline, ok := ctx.Deadline(); ok {
+			fctx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		}
+		fl = &getItemFlight{done: make(chan struct{}), cancel: cancel}
+		f.flights[key] = fl
+		go func() {
+			fl.output, fl.err = get(fctx)
+			f.remove(key, fl)
+			cancel()
+			close(fl.done)
+		}()
+	}
+	fl.waiters++
+	f.mu.Unlock()
+
+	select {
+	case <-fl.done:
+		if fl.output == nil {
+			return nil, shared, fl.err
+		}
+		// Callers may modify their output, they share the attribute values.
+		out := *fl.output
+		out.ResultMetadata = fl.output.ResultMetadata.Clone()
+		if fl.output.Item != nil {
+			out.Item = make(map[string]types.AttributeValue, len(fl.output.Item))
+			for k, v := range fl.output.Item {
+				out.Item[k] = v
+			}
+		}
+		return &out, shared, fl.err
+	case <-ctx.Done():
+		f.mu.Lock()
+		fl.waiters--
+		if fl.waiters == 0 {
+			fl.cancel()
+			if f.flights[key] == fl {
+				delete(f.flights, key)
+			}
+		}
+		f.mu.Unlock()
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (f *getItemFlights) remove(key string, fl *getItemFlight) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.flights[key] == fl {
+		delete(f.flights, key)
+	}
+}
+
+// getItemFlightKey identifies the GetItem calls returning the same result,
+// false if the key attributes cannot be encoded.
+func getItemFlightKey(input *dynamodb.GetItemInput) (string, bool) {
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	defer w.Close()
+
+	w.WriteString(aws.ToString(input.TableName))
+	w.WriteBoolean(aws.ToBool(input.ConsistentRead))
+	w.WriteString(aws.ToString(input.ProjectionExpression))
+	w.WriteString(string(input.ReturnConsumedCapacity))
+	w.WriteArrayHeader(len(input.AttributesToGet))
+	for _, name := range input.AttributesToGet {
+		w.WriteString(name)
+	}
+	for _, name := range sortedKeys(input.ExpressionAttributeNames) {
+		w.WriteString(name)
+		w.WriteString(input.ExpressionAttributeNames[name])
+	}
+	if err := writeItemKey(w, input.Key); err != nil {
+		return "", false
+	}
+	if err := w.Flush(); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
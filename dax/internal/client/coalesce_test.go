@@ -0,0 +1,211 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForWaiters(f *getItemFlights, key string, n int) {
+	for {
+		f.mu.Lock()
+		fl := f.flights[key]
+		done := fl != nil && fl.waiters == n
+		f.mu.Unlock()
+		if done {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestGetItemFlights_coalesce(t *testing.T) {
+	f := newGetItemFlights()
+	release := make(chan struct{})
+	var calls atomic.Int32
+	item := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}}
+	get := func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+		calls.Add(1)
+		<-release
+		return &dynamodb.GetItemOutput{Item: item}, nil
+	}
+
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	outs := make([]*dynamodb.GetItemOutput, 3)
+	for i := range outs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, s, err := f.do(context.Background(), "k", get)
+			assert.NoError(t, err)
+			if s {
+				shared.Add(1)
+			}
+			outs[i] = out
+		}(i)
+	}
+	waitForWaiters(f, "k", 3)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, calls.Load())
+	assert.EqualValues(t, 2, shared.Load())
+	for _, out := range outs {
+		assert.Equal(t, item, out.Item)
+	}
+	outs[0].Item["other"] = &types.AttributeValueMemberS{Value: "x"}
+	assert.Len(t, outs[1].Item, 1, "outputs are not shared")
+	assert.Empty(t, f.flights)
+
+	_, s, err := f.do(context.Background(), "k", func(context.Context) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{}, nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, s, "a completed flight is not reused")
+}
+
+func TestGetItemFlights_cancel(t *testing.T) {
+	f := newGetItemFlights()
+	canceled := make(chan error, 1)
+	get := func(ctx context.Context) (*dynamodb.GetItemOutput, error) {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return nil, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		go func(ctx context.Context) {
+			_, _, err := f.do(ctx, "k", get)
+			errs <- err
+		}(ctx)
+	}
+	waitForWaiters(f, "k", 2)
+
+	cancel1()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	select {
+	case <-canceled:
+		t.Fatal("the flight is canceled while a call waits for it")
+	default:
+	}
+	cancel2()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+}
+
+func TestGetItemFlightKey(t *testing.T) {
+	input := func(id string, consistent bool) *dynamodb.GetItemInput {
+		return &dynamodb.GetItemInput{
+			TableName:      aws.String("t"),
+			ConsistentRead: aws.Bool(consistent),
+			Key: map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: id},
+				"sk": &types.AttributeValueMemberN{Value: "1"},
+			},
+		}
+	}
+	key := func(in *dynamodb.GetItemInput) string {
+		k, ok := getItemFlightKey(in)
+		require.True(t, ok)
+		return k
+	}
+
+	assert.Equal(t, key(input("a", false)), key(input("a", false)))
+	assert.NotEqual(t, key(input("a", false)), key(input("b", false)))
+	assert.NotEqual(t, key(input("a", false)), key(input("a", true)))
+	projected := input("a", false)
+	projected.ProjectionExpression = aws.String("x")
+	assert.NotEqual(t, key(input("a", false)), key(projected))
+	legacy := input("a", false)
+	legacy.AttributesToGet = []string{"x"}
+	assert.NotEqual(t, key(input("a", false)), key(legacy))
+	other := input("a", false)
+	other.AttributesToGet = []string{"y"}
+	assert.NotEqual(t, key(legacy), key(other))
+
+	_, ok := getItemFlightKey(&dynamodb.GetItemInput{
+		TableName: aws.String("t"),
+		Key:       map[string]types.AttributeValue{"pk": nil},
+	})
+	assert.False(t, ok)
+}
+
+// blockingGetItemClient answers GetItem once release is closed, unless the
+// context of the request is done first.
+type blockingGetItemClient struct {
+	*testClient
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (c *blockingGetItemClient) GetItemWithOptions(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt RequestOptions) (*dynamodb.GetItemOutput, error) {
+	c.calls.Add(1)
+	if opt.Context != nil {
+		ctx = opt.Context
+	}
+	select {
+	case <-c.release:
+		output.Item = input.Key
+		return output, nil
+	case <-ctx.Done():
+		return output, ctx.Err()
+	}
+}
+
+func TestClusterDaxClient_GetItemCoalescedCancel(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	node := &blockingGetItemClient{testClient: &testClient{}, release: make(chan struct{})}
+	cluster.routeManager.setRoutes([]DaxAPI{node})
+	cfg := DefaultConfig()
+	cfg.CoalesceGetItem = true
+	cc := newClusterDaxClient(cfg, cluster)
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String("t"),
+		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}},
+	}
+	key, ok := getItemFlightKey(input)
+	require.True(t, ok)
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{ctx1, context.Background()} {
+		go func(ctx context.Context) {
+			_, err := cc.GetItemWithOptions(ctx, input, &dynamodb.GetItemOutput{}, RequestOptions{Context: ctx})
+			errs <- err
+		}(ctx)
+	}
+	waitForWaiters(cc.flights, key, 2)
+
+	cancel1()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	close(node.release)
+	assert.NoError(t, <-errs, "the other caller gets the result of the shared request")
+	assert.EqualValues(t, 1, node.calls.Load())
+}
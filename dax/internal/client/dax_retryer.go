@@ -16,14 +16,29 @@
 package client
 
 import (
+	"errors"
 	"math/rand"
 	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
 )
 
 // DaxRetryer implements retry strategy with equal jitter backoff for throttled requests
 type DaxRetryer struct {
 	BaseThrottleDelay time.Duration
 	MaxBackoffDelay   time.Duration
+	// BaseIODelay and MaxIOBackoffDelay are the backoff of retries after
+	// network errors, which recover best when retried at once on another
+	// node. Zero BaseIODelay leaves them to RequestOptions.RetryDelay.
+	BaseIODelay       time.Duration
+	MaxIOBackoffDelay time.Duration
+	// ThrottleJitter and IOJitter randomize the delays after throttles and
+	// network errors respectively, equal jitter by default.
+	ThrottleJitter types.BackoffJitter
+	IOJitter       types.BackoffJitter
+	// MaxConsecutiveThrottles stops retrying once this many consecutive attempts
+	// failed with the same throttle error code. Zero disables the limit.
+	MaxConsecutiveThrottles int
 }
 
 const (
@@ -46,17 +61,34 @@ func (r *DaxRetryer) setRetryerDefaults() {
 func (r DaxRetryer) RetryDelay(attempts int, err error) time.Duration {
 	if IsThrottleError(err) {
 		r.setRetryerDefaults()
-		minDelay := time.Duration(1<<uint64(attempts)) * r.BaseThrottleDelay
-		if minDelay > r.MaxBackoffDelay {
-			minDelay = r.MaxBackoffDelay
+		return backoffDelay(attempts, r.BaseThrottleDelay, r.MaxBackoffDelay, r.ThrottleJitter)
+	}
+	if r.BaseIODelay > 0 && IsIOError(err) {
+		maxDelay := r.MaxIOBackoffDelay
+		if maxDelay <= 0 {
+			maxDelay = DefaultMaxBackoffDelay
 		}
-		jitter := time.Duration(rand.Intn(int(minDelay)/2 + 1))
-
-		return minDelay/2 + jitter
+		return backoffDelay(attempts, r.BaseIODelay, maxDelay, r.IOJitter)
 	}
 	return 0
 }
 
+// backoffDelay returns the delay before retry attempts of an exponential
+// backoff from base capped by maxDelay, randomized as jitter selects.
+func backoffDelay(attempts int, base, maxDelay time.Duration, jitter types.BackoffJitter) time.Duration {
+	minDelay := time.Duration(1<<uint64(attempts)) * base
+	if minDelay > maxDelay || minDelay <= 0 {
+		minDelay = maxDelay
+	}
+	switch {
+	case jitter.IsNone():
+		return minDelay
+	case jitter.IsFull():
+		return time.Duration(rand.Int63n(int64(minDelay) + 1))
+	}
+	return minDelay/2 + time.Duration(rand.Int63n(int64(minDelay)/2+1))
+}
+
 // MaxAttempts returns the maximum number of retry attempts
 func (r DaxRetryer) MaxAttempts() int {
 	return 0 // You can adjust this value based on your requirements
@@ -88,6 +120,32 @@ func isAuthCRequiredException(codes []int) bool {
 	return len(codes) == 4 && codes[0] == 4 && codes[1] == 23 && codes[2] == 31 && codes[3] == 33
 }
 
+// throttleStreak tracks consecutive attempts failing with the same throttle error code.
+type throttleStreak struct {
+	code  string
+	count int
+}
+
+// exhausted records the error of the latest attempt and reports whether the
+// retryer's MaxConsecutiveThrottles has been reached.
+func (s *throttleStreak) exhausted(r DaxRetryer, err error) bool {
+	if !IsThrottleError(err) {
+		s.code, s.count = "", 0
+		return false
+	}
+	var ec interface{ ErrorCode() string }
+	code := ""
+	if errors.As(err, &ec) {
+		code = ec.ErrorCode()
+	}
+	if s.count > 0 && code == s.code {
+		s.count++
+	} else {
+		s.code, s.count = code, 1
+	}
+	return r.MaxConsecutiveThrottles > 0 && s.count >= r.MaxConsecutiveThrottles
+}
+
 func isRetryable(o RequestOptions, err error) bool {
 	return o.Retryer.IsErrorRetryable(err)
 }
@@ -17,8 +17,12 @@ package client
 
 import (
 	"fmt"
+	"net"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-dax-go-v2/dax/types"
 	"github.com/aws/smithy-go"
 )
 
@@ -91,6 +95,54 @@ func TestDaxRetryer_RetryDelay(t *testing.T) {
 	}
 }
 
+func TestDaxRetryer_RetryDelayIO(t *testing.T) {
+	ioErr := &net.OpError{Op: "read", Err: syscall.ECONNRESET}
+	throttleErr := newDaxRequestFailure([]int{}, "ThrottlingException", "", "", 400, smithy.FaultClient)
+
+	retryer := DaxRetryer{BaseThrottleDelay: time.Second, MaxBackoffDelay: time.Minute}
+	if delay := retryer.RetryDelay(1, ioErr); delay != 0 {
+		t.Errorf("Expected network errors to be retried at once, got %v", delay)
+	}
+
+	retryer.BaseIODelay = time.Millisecond
+	retryer.MaxIOBackoffDelay = 4 * time.Millisecond
+	for attempts, max := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond} {
+		delay := retryer.RetryDelay(attempts, ioErr)
+		if delay < max/2 || delay > max {
+			t.Errorf("attempt %d: expected a delay in [%v, %v], got %v", attempts, max/2, max, delay)
+		}
+	}
+	if delay := retryer.RetryDelay(1, throttleErr); delay < time.Second {
+		t.Errorf("Expected throttles to keep their backoff, got %v", delay)
+	}
+	if delay := retryer.RetryDelay(100, ioErr); delay > 4*time.Millisecond {
+		t.Errorf("Expected the delay to be capped, got %v", delay)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	if delay := backoffDelay(2, time.Millisecond, time.Second, types.BackoffJitterNone); delay != 4*time.Millisecond {
+		t.Errorf("Expected 4ms without jitter, got %v", delay)
+	}
+	if delay := backoffDelay(5, time.Millisecond, 10*time.Millisecond, types.BackoffJitterNone); delay != 10*time.Millisecond {
+		t.Errorf("Expected the delay to be capped, got %v", delay)
+	}
+	for i := 0; i < 100; i++ {
+		if delay := backoffDelay(2, time.Millisecond, time.Second, types.BackoffJitterFull); delay < 0 || delay > 4*time.Millisecond {
+			t.Fatalf("Expected a delay in [0, 4ms] with full jitter, got %v", delay)
+		}
+		if delay := backoffDelay(2, time.Millisecond, time.Second, ""); delay < 2*time.Millisecond || delay > 4*time.Millisecond {
+			t.Fatalf("Expected a delay in [2ms, 4ms] with equal jitter, got %v", delay)
+		}
+	}
+
+	retryer := DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Second, ThrottleJitter: types.BackoffJitterNone}
+	throttleErr := newDaxRequestFailure([]int{}, "ThrottlingException", "", "", 400, smithy.FaultClient)
+	if delay := retryer.RetryDelay(3, throttleErr); delay != 8*time.Millisecond {
+		t.Errorf("Expected 8ms, got %v", delay)
+	}
+}
+
 // Test MaxAttempts
 func TestDaxRetryer_MaxAttempts(t *testing.T) {
 	retryer := &DaxRetryer{}
@@ -98,3 +150,37 @@ func TestDaxRetryer_MaxAttempts(t *testing.T) {
 		t.Errorf("Expected MaxAttempts to return 0, got %d", retryer.MaxAttempts())
 	}
 }
+
+func TestThrottleStreak_exhausted(t *testing.T) {
+	retryer := DaxRetryer{MaxConsecutiveThrottles: 3}
+	throttle := &smithy.GenericAPIError{Code: "ThrottlingException"}
+	otherThrottle := &smithy.GenericAPIError{Code: "ProvisionedThroughputExceededException"}
+	other := &smithy.GenericAPIError{Code: "InternalServerError"}
+
+	var s throttleStreak
+	steps := []struct {
+		err      error
+		expected bool
+	}{
+		{throttle, false},
+		{throttle, false},
+		{otherThrottle, false}, // different code restarts the streak
+		{otherThrottle, false},
+		{other, false}, // non-throttle error resets the streak
+		{throttle, false},
+		{throttle, false},
+		{throttle, true},
+	}
+	for i, step := range steps {
+		if actual := s.exhausted(retryer, step.err); actual != step.expected {
+			t.Errorf("step %d: expected %v, got %v", i, step.expected, actual)
+		}
+	}
+
+	s = throttleStreak{}
+	for i := 0; i < 10; i++ {
+		if s.exhausted(DaxRetryer{}, throttle) {
+			t.Fatal("expected no limit when MaxConsecutiveThrottles is zero")
+		}
+	}
+}
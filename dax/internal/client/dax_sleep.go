@@ -45,3 +45,10 @@ func SleepWithContext(ctx context.Context, op string, dur time.Duration) error {
 
 	return nil
 }
+
+// exceedsDeadline reports whether sleeping for dur would outlast the context's deadline,
+// in which case retrying is pointless and the caller should fail fast instead.
+func exceedsDeadline(ctx context.Context, dur time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < dur
+}
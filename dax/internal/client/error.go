@@ -43,6 +43,8 @@ const (
 	ErrCodeInternalServerError = "InternalServerError"
 )
 
+// daxError is implemented by the errors reported by DAX nodes. It is exported
+// as dax.DaxError, which must keep the same methods.
 type daxError interface {
 	smithy.APIError
 	CodeSequence() []int
@@ -50,6 +52,9 @@ type daxError interface {
 	StatusCode() int
 }
 
+// DaxError lets the dax package check that dax.DaxError matches daxError.
+type DaxError = daxError
+
 type daxRequestFailure struct {
 	*smithy.GenericAPIError
 	codes      []int
@@ -144,6 +149,14 @@ func translateError(err error) smithy.APIError {
 	}
 }
 
+// Bounds of the error frames decodeError accepts, beyond the limits of the
+// reader. Error code sequences have a handful of codes and transactions at
+// most maxTransactionItems items, each with a code, message and item.
+const (
+	maxErrorCodes       = 32
+	maxTransactionItems = 100
+)
+
 func decodeError(reader *cbor.Reader) (error, error) {
 	length, err := reader.ReadArrayLength()
 	if err != nil {
@@ -152,6 +165,9 @@ func decodeError(reader *cbor.Reader) (error, error) {
 	if length == 0 {
 		return nil, nil
 	}
+	if length > maxErrorCodes {
+		return nil, &smithy.DeserializationError{Err: fmt.Errorf("expected at most %d error codes, got %d", maxErrorCodes, length)}
+	}
 
 	codes := make([]int, length)
 	for i := 0; i < length; i++ {
@@ -224,6 +240,9 @@ func decodeError(reader *cbor.Reader) (error, error) {
 			if arrLen%3 != 0 {
 				return nil, &smithy.DeserializationError{Err: fmt.Errorf("error found when parsing CancellationReasons")}
 			}
+			if arrLen > 3*maxTransactionItems {
+				return nil, &smithy.DeserializationError{Err: fmt.Errorf("expected at most %d CancellationReasons, got %d", maxTransactionItems, arrLen/3)}
+			}
 			cancellationReasonsLen := arrLen / 3
 			cancellationReasonCodes = make([]*string, cancellationReasonsLen)
 			cancellationReasonMsgs = make([]*string, cancellationReasonsLen)
@@ -274,9 +293,14 @@ func decodeError(reader *cbor.Reader) (error, error) {
 }
 
 // convertDAXError converts DAX error to specific error type based on error code sequence returned from server.
+// Sequences it does not know are converted based on the error code instead, so errors.As finds the
+// types.*Exception a DynamoDB client would return.
 func convertDaxError(e daxError) error {
 	codes := e.CodeSequence()
 	if len(codes) < 2 {
+		if ex := exceptionForErrorCode(e); ex != nil {
+			return ex
+		}
 		return e
 	}
 	switch codes[1] {
@@ -375,6 +399,9 @@ func convertDaxError(e daxError) error {
 			}
 		}
 	}
+	if ex := exceptionForErrorCode(e); ex != nil {
+		return ex
+	}
 	return &smithy.GenericAPIError{
 		Code:    ErrCodeUnknown,
 		Message: e.Error(),
@@ -382,15 +409,50 @@ func convertDaxError(e daxError) error {
 	}
 }
 
+// exceptionForErrorCode returns the types.*Exception named by the error code of e, nil if there is none.
+func exceptionForErrorCode(e daxError) error {
+	msg := aws.String(e.Error())
+	switch e.ErrorCode() {
+	case "ConditionalCheckFailedException":
+		return &types.ConditionalCheckFailedException{Message: msg}
+	case "TransactionCanceledException":
+		ex := &types.TransactionCanceledException{Message: msg}
+		if tcFailure, ok := e.(*daxTransactionCanceledFailure); ok {
+			ex.CancellationReasons = tcFailure.cancellationReasons
+		}
+		return ex
+	case "ResourceNotFoundException":
+		return &types.ResourceNotFoundException{Message: msg}
+	case "ResourceInUseException":
+		return &types.ResourceInUseException{Message: msg}
+	case "ProvisionedThroughputExceededException":
+		return &types.ProvisionedThroughputExceededException{Message: msg}
+	case "RequestLimitExceeded":
+		return &types.RequestLimitExceeded{Message: msg}
+	case "ItemCollectionSizeLimitExceededException":
+		return &types.ItemCollectionSizeLimitExceededException{Message: msg}
+	case "LimitExceededException":
+		return &types.LimitExceededException{Message: msg}
+	case "TransactionConflictException":
+		return &types.TransactionConflictException{Message: msg}
+	case "TransactionInProgressException":
+		return &types.TransactionInProgressException{Message: msg}
+	case "IdempotentParameterMismatchException":
+		return &types.IdempotentParameterMismatchException{Message: msg}
+	}
+	// InternalServerError is left out: translateError also uses it for network errors.
+	return nil
+}
+
 func decodeTransactionCancellationReasons(ctx context.Context, failure *daxTransactionCanceledFailure,
-	keys []map[string]types.AttributeValue, attrListIdToNames *lru.Lru) ([]types.CancellationReason, error) {
+	keys []map[string]types.AttributeValue, attrListIdToNames *lru.Lru[int64, []string]) ([]types.CancellationReason, error) {
 	inputL := len(keys)
 	outputL := len(failure.cancellationReasonCodes)
 	if inputL != outputL {
 		return nil, &smithy.DeserializationError{Err: errors.New("Cancellation reasons must be the same length as transact items in the request")}
 	}
 	reasons := make([]types.CancellationReason, outputL)
-	r := cbor.NewReader(bytes.NewReader(failure.cancellationReasonItems))
+	r := cbor.NewSliceReader(failure.cancellationReasonItems)
 	for i := 0; i < outputL; i++ {
 		reason := types.CancellationReason{}
 		reason.Code = failure.cancellationReasonCodes[i]
@@ -446,6 +508,17 @@ func isIOError(err error) bool {
 	return false
 }
 
+// ResponseKeyError reports a returned item lacking a key attribute of the
+// table it was read from.
+type ResponseKeyError struct {
+	Table     string
+	Attribute string
+}
+
+func (e *ResponseKeyError) Error() string {
+	return fmt.Sprintf("item returned for table %s lacks key attribute %s", e.Table, e.Attribute)
+}
+
 // CustomInvalidParamError is a custom error type that implements smithy.InvalidParamError
 type CustomInvalidParamError struct {
 	smithy.InvalidParamError
@@ -488,3 +561,30 @@ var ThrottleChecker = retry.ThrottleErrorCode{
 func IsThrottleError(err error) bool {
 	return ThrottleChecker.IsErrorThrottle(err) == aws.TrueTernary
 }
+
+// IsRetryableError reports whether the client retries a request failing with
+// err, looking through wrapped errors: throttles, failures the node reports as
+// retryable and network errors, which the client reports with code 2.
+func IsRetryableError(err error) bool {
+	if IsThrottleError(err) {
+		return true
+	}
+	var de daxError
+	if errors.As(err, &de) {
+		return DaxRetryer{}.IsErrorRetryable(de)
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// IsIOError reports whether err, or an error it wraps, is a network error,
+// EOF, timeout or cancellation, as the client classifies them when marking
+// nodes unhealthy and detecting failovers.
+func IsIOError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if isIOError(err) {
+			return true
+		}
+	}
+	return false
+}
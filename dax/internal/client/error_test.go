@@ -23,6 +23,7 @@ import (
 	"io"
 	"net"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
@@ -83,6 +84,42 @@ func TestDecodeError(t *testing.T) {
 	}
 }
 
+func TestDecodeError_limits(t *testing.T) {
+	cases := map[string]func(w *cbor.Writer){
+		"error codes": func(w *cbor.Writer) {
+			_ = w.WriteArrayHeader(maxErrorCodes + 1)
+		},
+		"cancellation reasons": func(w *cbor.Writer) {
+			_ = w.WriteArrayHeader(1)
+			_ = w.WriteInt(4)
+			_ = w.WriteString("msg")
+			_ = w.WriteArrayHeader(4)
+			_ = w.WriteNull()
+			_ = w.WriteNull()
+			_ = w.WriteNull()
+			_ = w.WriteArrayHeader(3 * (maxTransactionItems + 1))
+		},
+		"message": func(w *cbor.Writer) {
+			_ = w.WriteArrayHeader(1)
+			_ = w.WriteInt(4)
+			_ = w.WriteString(strings.Repeat("m", 101))
+		},
+	}
+	for name, write := range cases {
+		var b bytes.Buffer
+		w := cbor.NewWriter(&b)
+		write(w)
+		_ = w.Flush()
+
+		r := cbor.NewReader(&b)
+		r.SetLimits(cbor.Limits{MaxStringLength: 100})
+		e, err := decodeError(r)
+		assert.Nil(t, e, name)
+		var de *smithy.DeserializationError
+		assert.ErrorAs(t, err, &de, name)
+	}
+}
+
 func TestDecodeTransactionCanceledException(t *testing.T) {
 	errCodes := []int{4, 37, 38, 39, 58}
 	requestID := "request-1"
@@ -204,23 +241,13 @@ func TestDecodeTransactionCancellationReasons(t *testing.T) {
 		nil,
 	}
 	attrs := []string{"attr"}
-	attrsToID := &lru.Lru{
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
+	attrsToID := &lru.Lru[lru.StringsKey, int64]{
+		LoadFunc: func(ctx context.Context, key lru.StringsKey) (int64, error) {
 			return int64(12345), nil
 		},
-		KeyMarshaller: func(key lru.Key) lru.Key {
-			var buf bytes.Buffer
-			w := cbor.NewWriter(&buf)
-			defer w.Close()
-			for _, v := range key.([]string) {
-				_ = w.WriteString(v)
-			}
-			_ = w.Flush()
-			return string(buf.Bytes())
-		},
 	}
-	idToAttrs := &lru.Lru{
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
+	idToAttrs := &lru.Lru[int64, []string]{
+		LoadFunc: func(ctx context.Context, id int64) ([]string, error) {
 			return attrs, nil
 		},
 	}
@@ -277,6 +304,29 @@ func TestDecodeTransactionCancellationReasons(t *testing.T) {
 	}
 }
 
+// TestConvertTransactionCanceledFailure tests that the items of conditional
+// check failures requested with ReturnValuesOnConditionCheckFailure reach the
+// TransactionCanceledException, as they do with DynamoDB.
+func TestConvertTransactionCanceledFailure(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"hk":   &types.AttributeValueMemberN{Value: "0"},
+		"attr": &types.AttributeValueMemberS{Value: "old"},
+	}
+	failure := newDaxTransactionCanceledFailure([]int{4, 37, 38, 39, 58}, "TransactionCanceledException", "Transaction was cancelled.", "",
+		400, []*string{aws.String("None"), aws.String("ConditionalCheckFailed")}, []*string{nil, aws.String("The conditional request failed")}, nil)
+	failure.cancellationReasons = []types.CancellationReason{
+		{Code: aws.String("None")},
+		{Code: aws.String("ConditionalCheckFailed"), Message: aws.String("The conditional request failed"), Item: item},
+	}
+
+	var canceled *types.TransactionCanceledException
+	if !errors.As(convertDaxError(failure), &canceled) {
+		t.Fatalf("expected a TransactionCanceledException")
+	}
+	assert.Equal(t, failure.cancellationReasons, canceled.CancellationReasons)
+	assert.Equal(t, item, canceled.CancellationReasons[1].Item)
+}
+
 func TestDecodeNilErrorDetail(t *testing.T) {
 	var b bytes.Buffer
 	errCodes := []int{4, 37, 38, 39, 43}
@@ -326,6 +376,46 @@ func TestDecodeNilErrorDetail(t *testing.T) {
 
 }
 
+func TestConvertDaxErrorErrorsAs(t *testing.T) {
+	cases := []struct {
+		codes     []int
+		errorCode string
+		target    func(error) bool
+	}{
+		{[]int{4, 37, 38, 39, 43}, "", func(err error) bool {
+			var ex *types.ConditionalCheckFailedException
+			return errors.As(err, &ex)
+		}},
+		{[]int{4, 23, 24}, "", func(err error) bool {
+			var ex *types.ResourceNotFoundException
+			return errors.As(err, &ex)
+		}},
+		// unknown sequences fall back to the error code
+		{[]int{4, 37, 38, 39, 99}, "ConditionalCheckFailedException", func(err error) bool {
+			var ex *types.ConditionalCheckFailedException
+			return errors.As(err, &ex)
+		}},
+		{[]int{4}, "RequestLimitExceeded", func(err error) bool {
+			var ex *types.RequestLimitExceeded
+			return errors.As(err, &ex)
+		}},
+		{[]int{4, 37, 38, 39, 99}, "TransactionCanceledException", func(err error) bool {
+			var ex *types.TransactionCanceledException
+			return errors.As(err, &ex)
+		}},
+		{[]int{2}, ErrCodeInternalServerError, func(err error) bool {
+			var de daxError
+			return errors.As(err, &de)
+		}},
+	}
+	for _, c := range cases {
+		err := convertDaxError(newDaxRequestFailure(c.codes, c.errorCode, "message", "", 400, smithy.FaultServer))
+		if !c.target(err) {
+			t.Errorf("codes %v and error code %q: unexpected error type %T", c.codes, c.errorCode, err)
+		}
+	}
+}
+
 func TestTranslateError(t *testing.T) {
 	cases := []struct {
 		input  error
@@ -478,3 +568,33 @@ func TestIsThrottleError(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"throttle", &types.ProvisionedThroughputExceededException{Message: aws.String("m")}, true},
+		{"wrapped code 1", fmt.Errorf("op: %w", newDaxRequestFailure([]int{1}, "", "", "", 500, smithy.FaultServer)), true},
+		{"network", translateError(&net.OpError{Op: "read", Err: errors.New("connection reset")}), true},
+		{"net error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"client error", newDaxRequestFailure([]int{4, 37, 38, 39, 43}, "", "", "", 400, smithy.FaultClient), false},
+		{"condition failed", &types.ConditionalCheckFailedException{Message: aws.String("m")}, false},
+		{"other", errors.New("other"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryableError(tt.err))
+		})
+	}
+}
+
+func TestIsIOError(t *testing.T) {
+	assert.True(t, IsIOError(fmt.Errorf("op: %w", io.EOF)))
+	assert.True(t, IsIOError(&smithy.OperationError{Err: &net.OpError{Op: "read", Err: errors.New("reset")}}))
+	assert.True(t, IsIOError(translateError(&net.OpError{Op: "read", Err: errors.New("reset")})))
+	assert.False(t, IsIOError(&types.ProvisionedThroughputExceededException{Message: aws.String("m")}))
+	assert.False(t, IsIOError(nil))
+}
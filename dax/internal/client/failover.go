@@ -0,0 +1,60 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// leaderMoved reports whether a write failing with err may have been sent to
+// a leader which failed over: the node reported a recoverable cluster
+// failure, code 2, or could not be reached.
+func leaderMoved(err error) bool {
+	var de daxError
+	if errors.As(err, &de) {
+		codes := de.CodeSequence()
+		return len(codes) > 0 && codes[0] == 2
+	}
+	return isIOError(err)
+}
+
+// refreshForFailover refreshes the cluster after a write failed because the
+// leader may have moved, unless another refresh for that reason happened
+// within FailoverRefreshThreshold. The refresh is bounded by ctx, the context
+// of the write, which waits for it before its next attempt.
+func (c *cluster) refreshForFailover(ctx context.Context) {
+	threshold := c.config.FailoverRefreshThreshold
+	if threshold <= 0 || c.external || c.closing.Load() {
+		return
+	}
+	last := atomic.LoadInt64(&c.lastFailoverNs)
+	now := time.Now().UnixNano()
+	if now-last < threshold.Nanoseconds() || !atomic.CompareAndSwapInt64(&c.lastFailoverNs, last, now) {
+		return
+	}
+	countMetricInt64(ctx, c.daxSdkMetrics, daxFailoverRefreshes, 1)
+	c.debugLog("Refreshing cluster after a write failed on a possible leader change")
+	atomic.StoreInt64(&c.lastUpdateNs, now)
+	err := c.refreshNowWithContext(ctx)
+	if ctx.Err() != nil {
+		// Cut short by the write, not a failure of the cluster.
+		return
+	}
+	c.recordRefresh(err)
+}
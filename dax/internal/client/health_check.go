@@ -0,0 +1,101 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+)
+
+// discoveryStaleIntervals is the number of missed refresh intervals after which cluster discovery is considered stale.
+const discoveryStaleIntervals = 3
+
+// HealthChecker is implemented by clients which can report their health.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) (types.HealthCheckResult, error)
+}
+
+func (cc *ClusterDaxClient) HealthCheck(ctx context.Context) (types.HealthCheckResult, error) {
+	return cc.cluster.healthCheck(ctx)
+}
+
+// healthCheck checks the freshness of cluster discovery and sends a request
+// to every active node, which verifies connectivity and authentication.
+func (c *cluster) healthCheck(ctx context.Context) (types.HealthCheckResult, error) {
+	c.lock.RLock()
+	if c.closed {
+		c.lock.RUnlock()
+		return types.HealthCheckResult{}, os.ErrClosed
+	}
+	nodes := make([]clientAndConfig, 0, len(c.active))
+	for _, cliAndCfg := range c.active {
+		nodes = append(nodes, cliAndCfg)
+	}
+	res := types.HealthCheckResult{RefreshError: c.lastRefreshErr}
+	c.lock.RUnlock()
+
+	if ns := atomic.LoadInt64(&c.lastRefreshSuccessNs); ns > 0 {
+		res.LastRefresh = time.Unix(0, ns)
+		interval := c.config.ClusterUpdateInterval
+		res.DiscoveryFresh = interval <= 0 || c.external || time.Since(res.LastRefresh) <= discoveryStaleIntervals*interval
+	}
+
+	res.Nodes = make([]types.NodeHealth, len(nodes))
+	timeout := c.healthProbeTimeout()
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n clientAndConfig) {
+			defer wg.Done()
+			res.Nodes[i] = checkNode(ctx, n, timeout)
+		}(i, n)
+	}
+	wg.Wait()
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i].Address < res.Nodes[j].Address })
+
+	if res.DiscoveryFresh {
+		for _, n := range res.Nodes {
+			if n.Healthy {
+				res.Healthy = true
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// checkNode sends a request to n, which fails if it takes longer than
+// timeout so one unresponsive node does not hold up the whole check.
+func checkNode(ctx context.Context, n clientAndConfig, timeout time.Duration) types.NodeHealth {
+	nh := types.NodeHealth{
+		Address:  nodeAddress(n),
+		Hostname: n.cfg.hostname,
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	_, err := n.client.endpoints(ctx, RequestOptions{})
+	nh.Latency = time.Since(start)
+	nh.Healthy = err == nil
+	nh.Err = err
+	return nh
+}
@@ -0,0 +1,106 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthCheckTestCluster(t *testing.T) *cluster {
+	t.Helper()
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+	})
+	require.NoError(t, cluster.refreshNow())
+	return cluster
+}
+
+func TestCluster_healthCheck(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	nodeErr := errors.New("connection refused")
+	cluster.active[hostPort{"127.0.0.2", 8121}].client.(*testClient).endpointsErr = nodeErr
+
+	res, err := cluster.healthCheck(context.Background())
+	require.NoError(t, err)
+	assert.True(t, res.Healthy)
+	assert.True(t, res.DiscoveryFresh)
+	assert.WithinDuration(t, time.Now(), res.LastRefresh, time.Minute)
+	require.Len(t, res.Nodes, 2)
+
+	assert.Equal(t, "127.0.0.1:8121", res.Nodes[0].Address)
+	assert.Equal(t, "node1", res.Nodes[0].Hostname)
+	assert.True(t, res.Nodes[0].Healthy)
+	assert.NoError(t, res.Nodes[0].Err)
+
+	assert.Equal(t, "127.0.0.2:8121", res.Nodes[1].Address)
+	assert.False(t, res.Nodes[1].Healthy)
+	assert.ErrorIs(t, res.Nodes[1].Err, nodeErr)
+}
+
+func TestCluster_healthCheckNodeTimeout(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	cluster.config.HealthProbeTimeout = time.Minute
+
+	_, err := cluster.healthCheck(context.Background())
+	require.NoError(t, err)
+	for _, n := range cluster.active {
+		deadline, ok := n.client.(*testClient).endpointsCtx.Deadline()
+		require.True(t, ok, "each node is checked with a timeout")
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 10*time.Second)
+	}
+}
+
+func TestCluster_healthCheckAllNodesDown(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	for _, n := range cluster.active {
+		n.client.(*testClient).endpointsErr = errors.New("unrecognized client")
+	}
+
+	res, err := cluster.healthCheck(context.Background())
+	require.NoError(t, err)
+	assert.False(t, res.Healthy)
+	assert.True(t, res.DiscoveryFresh)
+}
+
+func TestCluster_healthCheckStaleDiscovery(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	atomic.StoreInt64(&cluster.lastRefreshSuccessNs, time.Now().Add(-time.Hour).UnixNano())
+
+	res, err := cluster.healthCheck(context.Background())
+	require.NoError(t, err)
+	assert.False(t, res.Healthy)
+	assert.False(t, res.DiscoveryFresh)
+	assert.True(t, res.Nodes[0].Healthy)
+}
+
+func TestCluster_healthCheckClosed(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	require.NoError(t, cluster.Close())
+
+	_, err := cluster.healthCheck(context.Background())
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
@@ -0,0 +1,142 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthProbeTimeout          = time.Second
+	defaultHealthProbeFailureThreshold = 3
+)
+
+// probeState is the outcome of the recent health probes of a node.
+type probeState struct {
+	failures int  // consecutive failed probes
+	ejected  bool // the node is out of rotation until a probe succeeds
+}
+
+// probeNodes sends a health probe to every active node and takes the nodes
+// which keep failing out of rotation, see Config.HealthProbeInterval.
+func (c *cluster) probeNodes() error {
+	c.lock.RLock()
+	nodes := make([]clientAndConfig, 0, len(c.active))
+	for _, cliAndCfg := range c.active {
+		nodes = append(nodes, cliAndCfg)
+	}
+	c.lock.RUnlock()
+
+	timeout := c.healthProbeTimeout()
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n clientAndConfig) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			_, err := n.client.endpoints(ctx, RequestOptions{})
+			c.recordProbe(n.cfg.hostPort(), err)
+		}(n)
+	}
+	wg.Wait()
+	return nil
+}
+
+// recordProbe records the outcome of a health probe of the node at hp,
+// taking it out of rotation or back into it.
+func (c *cluster) recordProbe(hp hostPort, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	node, ok := c.active[hp]
+	if c.closed || !ok {
+		return
+	}
+	if c.probes == nil {
+		c.probes = make(map[hostPort]*probeState)
+	}
+	st := c.probes[hp]
+	if st == nil {
+		st = &probeState{}
+		c.probes[hp] = st
+	}
+
+	routes := c.routeManager.getAllRoutes()
+	inRotation := false
+	for _, r := range routes {
+		if r == node.client {
+			inRotation = true
+			break
+		}
+	}
+	if st.ejected && inRotation {
+		// The route manager put the node back, e.g. when failing open.
+		st.ejected = false
+	}
+
+	if err == nil {
+		st.failures = 0
+		if st.ejected {
+			st.ejected = false
+			c.routeManager.setRoutes(append(routes[:len(routes):len(routes)], node.client))
+			c.debugLog("Health probe succeeded, added %s back to active routes", hp.host)
+		}
+		return
+	}
+
+	st.failures++
+	countMetricInt64(context.Background(), c.daxSdkMetrics, daxHealthProbeFailures, 1)
+	threshold := c.config.HealthProbeFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultHealthProbeFailureThreshold
+	}
+	if !inRotation || st.failures < threshold {
+		return
+	}
+	// Never take more than a third of the nodes out of rotation.
+	if float32(len(routes)-1) < 2*float32(len(c.active))/3 {
+		c.debugLog("Health probes of %s failed %d times, keeping it in active routes: %v", hp.host, st.failures, err)
+		return
+	}
+	newRoutes := make([]DaxAPI, 0, len(routes))
+	for _, r := range routes {
+		if r != node.client {
+			newRoutes = append(newRoutes, r)
+		}
+	}
+	c.routeManager.setRoutes(newRoutes)
+	st.ejected = true
+	countMetricInt64(context.Background(), c.daxSdkMetrics, daxHealthProbeEjections, 1)
+	c.warnLog("Health probes of %s failed %d times, removed it from active routes: %v", hp.host, st.failures, err)
+}
+
+// ejected reports whether health probes took the node at hp out of
+// rotation. It must be called with the lock held.
+func (c *cluster) ejected(hp hostPort) bool {
+	st := c.probes[hp]
+	return st != nil && st.ejected
+}
+
+// healthProbeTimeout bounds the request sent to each node by the health
+// probes and HealthCheck, see Config.HealthProbeTimeout.
+func (c *cluster) healthProbeTimeout() time.Duration {
+	if c.config.HealthProbeTimeout > 0 {
+		return c.config.HealthProbeTimeout
+	}
+	return defaultHealthProbeTimeout
+}
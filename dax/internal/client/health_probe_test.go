@@ -0,0 +1,116 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_probeNodes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.HealthProbeFailureThreshold = 2
+	cluster, builder := newTestClusterWithConfig(cfg)
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+		{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121},
+	})
+	require.NoError(t, cluster.refreshNow())
+	defer cluster.Close()
+	nodes := builder.clients[1:]
+	require.Len(t, nodes, 3)
+
+	nodes[0].endpointsErr = errors.New("timeout")
+	require.NoError(t, cluster.probeNodes())
+	assert.Len(t, cluster.getAllRoutes(), 3, "a single failure keeps the node in rotation")
+	require.NoError(t, cluster.probeNodes())
+	assert.Len(t, cluster.getAllRoutes(), 2)
+	assert.NotContains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]))
+
+	nodes[1].endpointsErr = errors.New("timeout")
+	require.NoError(t, cluster.probeNodes())
+	require.NoError(t, cluster.probeNodes())
+	assert.Len(t, cluster.getAllRoutes(), 2, "at most a third of the nodes is taken out of rotation")
+
+	// A membership refresh keeps ejected nodes out of rotation.
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+		{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121},
+		{hostname: "node4", address: net.ParseIP("127.0.0.4"), port: 8121},
+	})
+	require.NoError(t, cluster.refreshNow())
+	assert.Len(t, cluster.getAllRoutes(), 3)
+	assert.NotContains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]))
+
+	nodes[0].endpointsErr = nil
+	require.NoError(t, cluster.probeNodes())
+	assert.Contains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]), "a successful probe puts the node back")
+}
+
+func TestConfig_healthProbes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.HealthProbeInterval = -1
+	assert.Error(t, cfg.validate())
+	cfg.HealthProbeInterval = 0
+	cfg.HealthProbeTimeout = -1
+	assert.Error(t, cfg.validate())
+	cfg.HealthProbeTimeout = 0
+	cfg.HealthProbeFailureThreshold = -1
+	assert.Error(t, cfg.validate())
+}
+
+func TestCluster_probeNodesRouteManager(t *testing.T) {
+	cluster, builder := newTestClusterWithRouteManagerEnabled([]string{"127.0.0.1:8111"})
+	cluster.config.HealthProbeFailureThreshold = 1
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121},
+		{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121},
+	})
+	require.NoError(t, cluster.refreshNow())
+	defer cluster.Close()
+	nodes := builder.clients[1:]
+	require.Len(t, nodes, 3)
+
+	nodes[0].endpointsErr = errors.New("timeout")
+	require.NoError(t, cluster.probeNodes())
+	assert.NotContains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]))
+
+	// The route manager puts the node back, the next failed probe takes it
+	// out again.
+	cluster.addRoute("node1", nodes[0])
+	assert.Contains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]))
+	require.NoError(t, cluster.probeNodes())
+	assert.NotContains(t, cluster.getAllRoutes(), DaxAPI(nodes[0]))
+
+	// A successful probe after the route manager put it back does not add
+	// the node twice.
+	cluster.addRoute("node1", nodes[0])
+	nodes[0].endpointsErr = nil
+	require.NoError(t, cluster.probeNodes())
+	assert.Len(t, cluster.getAllRoutes(), 3)
+	assert.False(t, cluster.probes[nodes[0].hp].ejected)
+}
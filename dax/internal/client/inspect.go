@@ -0,0 +1,81 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/smithy-go"
+)
+
+var methodNames = map[int64]string{
+	authorizeConnection_1489122155_1_Id:    "AuthorizeConnection",
+	defineAttributeList_670678385_1_Id:     "DefineAttributeList",
+	defineAttributeListId_N1230579644_1_Id: "DefineAttributeListId",
+	defineKeySchema_N742646399_1_Id:        "DefineKeySchema",
+	endpoints_455855874_1_Id:               "Endpoints",
+	methods_785068263_1_Id:                 "Methods",
+	services_N1016793520_1_Id:              "Services",
+	transactWriteItems_N1160037738_1_Id:    OpTransactWriteItems,
+	transactGetItems_1866287579_1_Id:       OpTransactGetItems,
+	batchGetItem_N697851100_1_Id:           OpBatchGetItem,
+	batchWriteItem_116217951_1_Id:          OpBatchWriteItem,
+	getItem_263244906_1_Id:                 OpGetItem,
+	putItem_N2106490455_1_Id:               OpPutItem,
+	deleteItem_1013539361_1_Id:             OpDeleteItem,
+	updateItem_1425579023_1_Id:             OpUpdateItem,
+	query_N931250863_1_Id:                  OpQuery,
+	scan_N1875390620_1_Id:                  OpScan,
+	createTable_N313431286_1_Id:            "CreateTable",
+	deleteTable_2120496185_1_Id:            "DeleteTable",
+	describeTable_N819330193_1_Id:          "DescribeTable",
+	updateTable_383747477_1_Id:             "UpdateTable",
+	listTables_1874119219_1_Id:             "ListTables",
+	describeLimits_N475661135_1_Id:         "DescribeLimits",
+}
+
+// MethodName returns the name of the DAX method with the given ID, empty for
+// unknown methods.
+func MethodName(id int64) string {
+	return methodNames[id]
+}
+
+// encodedMagic starts the preamble written by newTube.
+var encodedMagic = append([]byte{cbor.Utf + byte(len(magic))}, magic...)
+
+// HasPreamble reports whether frame starts with the connection preamble
+// written by newTube, made of PreambleItems cbor items.
+func HasPreamble(frame []byte) bool {
+	return bytes.HasPrefix(frame, encodedMagic)
+}
+
+// PreambleItems is the number of cbor items of the connection preamble: the
+// magic, layering, session, header and client mode.
+const PreambleItems = 5
+
+// ExceptionName returns the error code of the exception the client returns
+// for a failure with the given code sequence and error code, e.g.
+// "ConditionalCheckFailedException" for [4 37 38 39 43].
+func ExceptionName(codes []int, errorCode string) string {
+	err := convertDaxError(newDaxRequestFailure(codes, errorCode, "", "", 0, smithy.FaultServer))
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
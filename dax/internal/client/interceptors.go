@@ -0,0 +1,115 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+)
+
+// interceptors are the hooks called around requests, see Config.OnRequest.
+type interceptors struct {
+	onRequest  func(ctx context.Context, e types.RequestEvent)
+	onResponse func(ctx context.Context, e types.RequestEvent)
+	onError    func(ctx context.Context, e types.RequestEvent)
+}
+
+func (i interceptors) empty() bool {
+	return i.onRequest == nil && i.onResponse == nil && i.onError == nil
+}
+
+// wireCounter is implemented by tubes counting the bytes exchanged on their
+// connection.
+type wireCounter interface {
+	wireBytes() (sent, received int64)
+}
+
+// interception reports a request to the interceptors of a client.
+type interception struct {
+	interceptors
+	event types.RequestEvent
+	start time.Time
+
+	counter        wireCounter // nil until the request is written
+	sent, received int64       // counts of counter when the request started
+}
+
+// intercept starts reporting a request of op, nil without interceptors.
+func (client *SingleDaxClient) intercept(op string) *interception {
+	if client.interceptors.empty() {
+		return nil
+	}
+	i := &interception{interceptors: client.interceptors, start: time.Now()}
+	i.event.Operation = op
+	if client.pool != nil {
+		i.event.Node = client.pool.address
+	}
+	return i
+}
+
+// writing marks the start of the request on t, once it is authorized.
+func (i *interception) writing(t tube) {
+	if i == nil {
+		return
+	}
+	if c, ok := t.(wireCounter); ok {
+		i.counter = c
+		i.sent, i.received = c.wireBytes()
+	}
+}
+
+// written reports the request once it is sent to OnRequest.
+func (i *interception) written(ctx context.Context) {
+	if i == nil {
+		return
+	}
+	i.update()
+	if i.onRequest != nil {
+		i.onRequest(ctx, i.event)
+	}
+}
+
+// done reports the end of the request, which failed with err if not nil,
+// to OnResponse or OnError.
+func (i *interception) done(ctx context.Context, err error) {
+	if i == nil {
+		return
+	}
+	i.update()
+	if err == nil {
+		if i.onResponse != nil {
+			i.onResponse(ctx, i.event)
+		}
+		return
+	}
+	if failure, ok := err.(daxError); ok {
+		err = convertDaxError(failure)
+	}
+	i.event.Err = err
+	if i.onError != nil {
+		i.onError(ctx, i.event)
+	}
+}
+
+func (i *interception) update() {
+	i.event.Duration = time.Since(i.start)
+	if i.counter != nil {
+		sent, received := i.counter.wireBytes()
+		i.event.RequestSize, i.event.ResponseSize = sent-i.sent, received-i.received
+	}
+}
@@ -0,0 +1,78 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteInterceptors(t *testing.T) {
+	var requests, responses, errs []types.RequestEvent
+	cfg := unEncryptedConnConfig
+	cfg.interceptors = interceptors{
+		onRequest:  func(_ context.Context, e types.RequestEvent) { requests = append(requests, e) },
+		onResponse: func(_ context.Context, e types.RequestEvent) { responses = append(responses, e) },
+		onError:    func(_ context.Context, e types.RequestEvent) { errs = append(errs, e) },
+	}
+	newClient := func(conn *mockConn) *SingleDaxClient {
+		client, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+			return conn, nil
+		}, nil, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	encoder := func(writer *cbor.Writer) error { return writer.WriteString("abc") }
+	decoder := func(reader *cbor.Reader) error { return nil }
+
+	client := newClient(&mockConn{rd: []byte{cbor.Array + 0}})
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	require.Len(t, requests, 1)
+	assert.Equal(t, OpGetItem, requests[0].Operation)
+	assert.Equal(t, ":9121", requests[0].Node)
+	assert.EqualValues(t, 4, requests[0].RequestSize)
+	assert.Zero(t, requests[0].ResponseSize)
+	require.Len(t, responses, 1)
+	assert.EqualValues(t, 4, responses[0].RequestSize)
+	assert.EqualValues(t, 1, responses[0].ResponseSize)
+	assert.Positive(t, responses[0].Duration)
+	assert.Empty(t, errs)
+
+	client = newClient(&mockConn{re: io.ErrUnexpectedEOF})
+	assert.Error(t, client.executeWithContext(context.Background(), OpPutItem, encoder, decoder, RequestOptions{}))
+	assert.Len(t, requests, 2, "the request was sent")
+	assert.Len(t, responses, 1)
+	require.Len(t, errs, 1)
+	assert.Equal(t, OpPutItem, errs[0].Operation)
+	assert.ErrorIs(t, errs[0].Err, io.ErrUnexpectedEOF)
+
+	failed := errors.New("invalid input")
+	client = newClient(&mockConn{})
+	assert.ErrorIs(t, client.executeWithContext(context.Background(), OpPutItem, func(*cbor.Writer) error { return failed }, decoder, RequestOptions{}), failed)
+	assert.Len(t, requests, 2, "the request was not sent")
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[1].Err, failed)
+	assert.Zero(t, errs[1].RequestSize)
+}
@@ -0,0 +1,363 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// defaultItemCacheMaxEntries bounds the item cache without ItemCacheMaxEntries.
+const defaultItemCacheMaxEntries = 10000
+
+// itemCache serves GetItem calls from client memory, see Config.ItemCacheTTL.
+type itemCache struct {
+	ttl       time.Duration
+	missTTL   time.Duration
+	tableTTLs map[string]time.Duration
+	items     *lru.Lru[string, cachedItem]
+
+	// Invalidating a table moves it to a new generation, which is part of
+	// the cache keys, so that its entries are no longer found and age out.
+	mu          sync.RWMutex
+	generations map[string]uint64
+}
+
+// cachedItem is the result of a GetItem call in the item cache.
+type cachedItem struct {
+	table  string
+	output *dynamodb.GetItemOutput
+}
+
+// newItemCache returns the item cache configured by cfg, nil if it caches
+// no table.
+func newItemCache(cfg Config, sdkMetrics *daxSdkMetrics) *itemCache {
+	enabled := cfg.ItemCacheTTL > 0 || cfg.ItemCacheMissTTL > 0
+	for _, ttl := range cfg.ItemCacheTableTTLs {
+		enabled = enabled || ttl > 0
+	}
+	if !enabled {
+		return nil
+	}
+	c := &itemCache{
+		ttl:         cfg.ItemCacheTTL,
+		missTTL:     cfg.ItemCacheMissTTL,
+		tableTTLs:   cfg.ItemCacheTableTTLs,
+		generations: make(map[string]uint64),
+	}
+	c.items = &lru.Lru[string, cachedItem]{
+		MaxEntries: cfg.ItemCacheMaxEntries,
+		MaxBytes:   cfg.ItemCacheMaxBytes,
+		SizeFunc:   cachedItemSize,
+		TTLFunc:    c.entryTTL,
+		OnEvent:    cacheEvents(sdkMetrics, "items"),
+	}
+	if c.items.MaxEntries == 0 {
+		c.items.MaxEntries = defaultItemCacheMaxEntries
+	}
+	return c
+}
+
+func (c *itemCache) tableTTL(table string) time.Duration {
+	if ttl, ok := c.tableTTLs[table]; ok {
+		return ttl
+	}
+	return c.ttl
+}
+
+// tableMissTTL returns how long the absence of an item of table is cached.
+func (c *itemCache) tableMissTTL(table string) time.Duration {
+	if ttl, ok := c.tableTTLs[table]; ok && ttl <= 0 {
+		return 0
+	}
+	return c.missTTL
+}
+
+// caches reports whether items of table, or their absence, are cached.
+func (c *itemCache) caches(table string) bool {
+	return c.tableTTL(table) > 0 || c.tableMissTTL(table) > 0
+}
+
+// entryTTL is the lru.Lru TTLFunc of the item cache.
+func (c *itemCache) entryTTL(_ string, v cachedItem) time.Duration {
+	var ttl time.Duration
+	if v.output == nil || v.output.Item == nil {
+		ttl = c.tableMissTTL(v.table)
+	} else {
+		ttl = c.tableTTL(v.table)
+	}
+	if ttl <= 0 {
+		return -1
+	}
+	return ttl
+}
+
+// key returns the cache key of the item of table with the given key
+// attributes, false if it cannot be encoded.
+func (c *itemCache) key(table string, key map[string]types.AttributeValue) (string, bool) {
+	c.mu.RLock()
+	gen := c.generations[table]
+	c.mu.RUnlock()
+
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	defer w.Close()
+	w.WriteString(table)
+	w.WriteInt64(int64(gen))
+	if err := writeItemKey(w, key); err != nil {
+		return "", false
+	}
+	if err := w.Flush(); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// cacheable reports whether the result of a GetItem call may be cached:
+// eventually consistent calls without a projection or consumed capacity, of
+// tables with a positive TTL or miss TTL.
+func (c *itemCache) cacheable(input *dynamodb.GetItemInput) bool {
+	return input != nil &&
+		!aws.ToBool(input.ConsistentRead) &&
+		aws.ToString(input.ProjectionExpression) == "" &&
+		len(input.AttributesToGet) == 0 &&
+		(input.ReturnConsumedCapacity == "" || input.ReturnConsumedCapacity == types.ReturnConsumedCapacityNone) &&
+		c.caches(aws.ToString(input.TableName))
+}
+
+// get returns the item cached for key, loading it with load if it is not.
+// Concurrent misses share the load, so it runs with the values and deadline
+// of ctx but is not canceled with it; get returns early if ctx is done first.
+func (c *itemCache) get(ctx context.Context, key string, table string, load func(ctx context.Context) (*dynamodb.GetItemOutput, error)) (*dynamodb.GetItemOutput, error) {
+	getOrLoad := func(ctx context.Context) (cachedItem, error) {
+		return c.items.GetOrLoad(ctx, key, func(ctx context.Context, _ string) (cachedItem, error) {
+			out, err := load(ctx)
+			return cachedItem{table: table, output: out}, err
+		})
+	}
+
+	var v cachedItem
+	var err error
+	if ctx.Done() == nil {
+		v, err = getOrLoad(ctx)
+	} else {
+		lctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if deadline, ok := ctx.Deadline(); ok {
+			lctx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		}
+		type result struct {
+			v   cachedItem
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			defer cancel()
+			v, err := getOrLoad(lctx)
+			done <- result{v, err}
+		}()
+		select {
+		case r := <-done:
+			v, err = r.v, r.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil || v.output == nil {
+		return nil, err
+	}
+	// Callers may modify their output, they share the attribute values.
+	out := *v.output
+	out.ResultMetadata = v.output.ResultMetadata.Clone()
+	if v.output.Item != nil {
+		out.Item = make(map[string]types.AttributeValue, len(v.output.Item))
+		for k, av := range v.output.Item {
+			out.Item[k] = av
+		}
+	}
+	return &out, nil
+}
+
+// forget drops the cached item of table with the given key attributes.
+func (c *itemCache) forget(table string, key map[string]types.AttributeValue) {
+	if k, ok := c.key(table, key); ok {
+		c.items.Remove(k)
+	} else {
+		c.forgetTable(table)
+	}
+}
+
+// forgetTable drops the cached items of table.
+func (c *itemCache) forgetTable(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generations[table]++
+}
+
+// invalidate drops the cached items selected by scope.
+func (c *itemCache) invalidate(scope daxTypes.CacheScope) {
+	switch scope.Level {
+	case daxTypes.CacheScopeAll:
+		c.items.Clear()
+	case daxTypes.CacheScopeTable:
+		c.forgetTable(scope.TableName)
+	case daxTypes.CacheScopeKey:
+		c.forget(scope.TableName, scope.Key)
+	}
+}
+
+// forgetWritten drops the cached items which a write call may have changed,
+// whether it succeeded or not.
+func (cc *ClusterDaxClient) forgetWritten(ctx context.Context, input any) {
+	if cc.items == nil {
+		return
+	}
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		if in != nil {
+			cc.forgetPut(ctx, aws.ToString(in.TableName), in.Item)
+		}
+	case *dynamodb.DeleteItemInput:
+		if in != nil {
+			cc.items.forget(aws.ToString(in.TableName), in.Key)
+		}
+	case *dynamodb.UpdateItemInput:
+		if in != nil {
+			cc.items.forget(aws.ToString(in.TableName), in.Key)
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if in == nil {
+			return
+		}
+		for table, requests := range in.RequestItems {
+			for _, r := range requests {
+				if r.PutRequest != nil {
+					cc.forgetPut(ctx, table, r.PutRequest.Item)
+				}
+				if r.DeleteRequest != nil {
+					cc.items.forget(table, r.DeleteRequest.Key)
+				}
+			}
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		if in == nil {
+			return
+		}
+		for _, ti := range in.TransactItems {
+			switch {
+			case ti.Put != nil:
+				cc.forgetPut(ctx, aws.ToString(ti.Put.TableName), ti.Put.Item)
+			case ti.Update != nil:
+				cc.items.forget(aws.ToString(ti.Update.TableName), ti.Update.Key)
+			case ti.Delete != nil:
+				cc.items.forget(aws.ToString(ti.Delete.TableName), ti.Delete.Key)
+			}
+		}
+	}
+}
+
+// forgetPut drops the cached item replaced by item, or the items of the
+// table if the key of item cannot be told.
+func (cc *ClusterDaxClient) forgetPut(ctx context.Context, table string, item map[string]types.AttributeValue) {
+	if !cc.items.caches(table) {
+		return
+	}
+	key, err := cc.ExtractKey(context.WithoutCancel(ctx), table, item)
+	if err != nil {
+		cc.items.forgetTable(table)
+		return
+	}
+	cc.items.forget(table, key.Attributes)
+}
+
+// writeItemKey writes key attributes in a canonical order.
+func writeItemKey(w *cbor.Writer, key map[string]types.AttributeValue) error {
+	if err := w.WriteMapHeader(len(key)); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(key) {
+		if err := w.WriteString(name); err != nil {
+			return err
+		}
+		if err := cbor.EncodeAttributeValue(key[name], w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cachedItemSize approximates the memory of an item cache entry.
+func cachedItemSize(key string, v cachedItem) int64 {
+	n := int64(cacheEntryOverhead + len(key))
+	if v.output != nil {
+		for name, av := range v.output.Item {
+			n += 16 + int64(len(name)) + attributeValueMemory(av)
+		}
+	}
+	return n
+}
+
+// attributeValueMemory approximates the memory of an attribute value.
+func attributeValueMemory(av types.AttributeValue) int64 {
+	const overhead = 32
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return overhead + int64(len(v.Value))
+	case *types.AttributeValueMemberN:
+		return overhead + int64(len(v.Value))
+	case *types.AttributeValueMemberB:
+		return overhead + int64(len(v.Value))
+	case *types.AttributeValueMemberSS:
+		n := int64(overhead)
+		for _, s := range v.Value {
+			n += 16 + int64(len(s))
+		}
+		return n
+	case *types.AttributeValueMemberNS:
+		n := int64(overhead)
+		for _, s := range v.Value {
+			n += 16 + int64(len(s))
+		}
+		return n
+	case *types.AttributeValueMemberBS:
+		n := int64(overhead)
+		for _, b := range v.Value {
+			n += 24 + int64(len(b))
+		}
+		return n
+	case *types.AttributeValueMemberL:
+		n := int64(overhead)
+		for _, e := range v.Value {
+			n += 16 + attributeValueMemory(e)
+		}
+		return n
+	case *types.AttributeValueMemberM:
+		n := int64(overhead)
+		for name, e := range v.Value {
+			n += 16 + int64(len(name)) + attributeValueMemory(e)
+		}
+		return n
+	}
+	return overhead
+}
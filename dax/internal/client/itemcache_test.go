@@ -0,0 +1,221 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestItemCache(t *testing.T) *itemCache {
+	c := newItemCache(Config{
+		ItemCacheTTL:       time.Hour,
+		ItemCacheTableTTLs: map[string]time.Duration{"uncached": 0},
+	}, &daxSdkMetrics{})
+	require.NotNil(t, c)
+	return c
+}
+
+// getCached gets the item of table with the given id from c, counting loads.
+func getCached(t *testing.T, c *itemCache, table, id string, loads *int) *dynamodb.GetItemOutput {
+	key, ok := c.key(table, map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}})
+	require.True(t, ok)
+	out, err := c.get(context.Background(), key, table, func(context.Context) (*dynamodb.GetItemOutput, error) {
+		*loads++
+		return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+			"id":   &types.AttributeValueMemberS{Value: id},
+			"load": &types.AttributeValueMemberN{Value: "1"},
+		}}, nil
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestNewItemCache(t *testing.T) {
+	assert.Nil(t, newItemCache(Config{}, &daxSdkMetrics{}))
+	assert.Nil(t, newItemCache(Config{ItemCacheTableTTLs: map[string]time.Duration{"t": 0}}, &daxSdkMetrics{}))
+	c := newItemCache(Config{ItemCacheTableTTLs: map[string]time.Duration{"t": time.Minute}}, &daxSdkMetrics{})
+	require.NotNil(t, c)
+	assert.Equal(t, defaultItemCacheMaxEntries, c.items.MaxEntries)
+	assert.Equal(t, time.Minute, c.tableTTL("t"))
+	assert.Zero(t, c.tableTTL("other"))
+}
+
+func TestItemCache_missTTL(t *testing.T) {
+	c := newItemCache(Config{
+		ItemCacheMissTTL:   time.Minute,
+		ItemCacheTableTTLs: map[string]time.Duration{"items": time.Hour, "uncached": 0},
+	}, &daxSdkMetrics{})
+	require.NotNil(t, c)
+	missing := cachedItem{table: "t", output: &dynamodb.GetItemOutput{}}
+	found := cachedItem{table: "t", output: &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{}}}
+
+	assert.Equal(t, time.Minute, c.entryTTL("", missing))
+	assert.Negative(t, c.entryTTL("", found), "items are not cached without a TTL")
+	found.table = "items"
+	assert.Equal(t, time.Hour, c.entryTTL("", found))
+	missing.table = "uncached"
+	assert.Negative(t, c.entryTTL("", missing))
+	assert.True(t, c.caches("t"))
+	assert.False(t, c.caches("uncached"))
+
+	var loads int
+	key, _ := c.key("t", map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "missing"}})
+	for i := 0; i < 2; i++ {
+		out, err := c.get(context.Background(), key, "t", func(context.Context) (*dynamodb.GetItemOutput, error) {
+			loads++
+			return &dynamodb.GetItemOutput{}, nil
+		})
+		require.NoError(t, err)
+		assert.Nil(t, out.Item)
+	}
+	assert.Equal(t, 1, loads, "the missing item is cached")
+}
+
+func TestItemCache_cacheable(t *testing.T) {
+	c := newTestItemCache(t)
+	input := func(fn func(in *dynamodb.GetItemInput)) *dynamodb.GetItemInput {
+		in := &dynamodb.GetItemInput{TableName: aws.String("t")}
+		fn(in)
+		return in
+	}
+	assert.True(t, c.cacheable(input(func(*dynamodb.GetItemInput) {})))
+	assert.True(t, c.cacheable(input(func(in *dynamodb.GetItemInput) { in.ReturnConsumedCapacity = types.ReturnConsumedCapacityNone })))
+	assert.False(t, c.cacheable(input(func(in *dynamodb.GetItemInput) { in.ConsistentRead = aws.Bool(true) })))
+	assert.False(t, c.cacheable(input(func(in *dynamodb.GetItemInput) { in.ProjectionExpression = aws.String("a") })))
+	assert.False(t, c.cacheable(input(func(in *dynamodb.GetItemInput) { in.ReturnConsumedCapacity = types.ReturnConsumedCapacityTotal })))
+	assert.False(t, c.cacheable(input(func(in *dynamodb.GetItemInput) { in.TableName = aws.String("uncached") })))
+	assert.False(t, c.cacheable(nil))
+}
+
+func TestItemCache_get(t *testing.T) {
+	c := newTestItemCache(t)
+	var loads int
+	out := getCached(t, c, "t", "a", &loads)
+	out.Item["extra"] = &types.AttributeValueMemberS{Value: "x"}
+	out = getCached(t, c, "t", "a", &loads)
+	assert.Equal(t, 1, loads)
+	assert.Len(t, out.Item, 2, "outputs are copies")
+
+	getCached(t, c, "other", "a", &loads)
+	getCached(t, c, "t", "b", &loads)
+	assert.Equal(t, 3, loads)
+
+	key, _ := c.key("t", map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "missing"}})
+	for i := 0; i < 2; i++ {
+		out, err := c.get(context.Background(), key, "t", func(context.Context) (*dynamodb.GetItemOutput, error) {
+			loads++
+			return &dynamodb.GetItemOutput{}, nil
+		})
+		require.NoError(t, err)
+		assert.Nil(t, out.Item)
+	}
+	assert.Equal(t, 5, loads, "missing items are not cached")
+
+	_, err := c.get(context.Background(), key, "t", func(context.Context) (*dynamodb.GetItemOutput, error) {
+		return nil, errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+}
+
+func TestItemCache_invalidate(t *testing.T) {
+	c := newTestItemCache(t)
+	var loads int
+	getCached(t, c, "t", "a", &loads)
+	getCached(t, c, "t", "b", &loads)
+	getCached(t, c, "u", "a", &loads)
+
+	c.invalidate(daxTypes.KeyCaches("t", map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}}))
+	getCached(t, c, "t", "a", &loads)
+	getCached(t, c, "t", "b", &loads)
+	assert.Equal(t, 4, loads)
+
+	c.invalidate(daxTypes.TableCaches("t"))
+	getCached(t, c, "t", "a", &loads)
+	getCached(t, c, "t", "b", &loads)
+	getCached(t, c, "u", "a", &loads)
+	assert.Equal(t, 6, loads)
+
+	c.invalidate(daxTypes.AllCaches())
+	getCached(t, c, "u", "a", &loads)
+	assert.Equal(t, 7, loads)
+}
+
+func TestClusterDaxClient_forgetWritten(t *testing.T) {
+	cc := &ClusterDaxClient{items: newTestItemCache(t)}
+	key := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}}
+	writes := []any{
+		&dynamodb.DeleteItemInput{TableName: aws.String("t"), Key: key},
+		&dynamodb.UpdateItemInput{TableName: aws.String("t"), Key: key},
+		&dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+			"t": {{DeleteRequest: &types.DeleteRequest{Key: key}}},
+		}},
+		&dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{TableName: aws.String("t"), Key: key}},
+		}},
+	}
+	var loads int
+	for _, w := range writes {
+		getCached(t, cc.items, "t", "a", &loads)
+		getCached(t, cc.items, "t", "b", &loads)
+		cc.forgetWritten(context.Background(), w)
+	}
+	getCached(t, cc.items, "t", "a", &loads)
+	assert.Equal(t, len(writes)+2, loads, "only the written item is dropped")
+
+	cc.forgetWritten(context.Background(), (*dynamodb.DeleteItemInput)(nil))
+	(&ClusterDaxClient{}).forgetWritten(context.Background(), writes[0])
+}
+
+func TestClusterDaxClient_GetItemCachedCancel(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	node := &blockingGetItemClient{testClient: &testClient{}, release: make(chan struct{})}
+	cluster.routeManager.setRoutes([]DaxAPI{node})
+	cfg := DefaultConfig()
+	cfg.ItemCacheTTL = time.Hour
+	cc := newClusterDaxClient(cfg, cluster)
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String("t"),
+		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}},
+	}
+	get := func(ctx context.Context) error {
+		_, err := cc.GetItemWithOptions(ctx, input, &dynamodb.GetItemOutput{}, RequestOptions{Context: ctx})
+		return err
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { errs <- get(ctx1) }()
+	for node.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel1()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	go func() { errs <- get(context.Background()) }()
+	close(node.release)
+	assert.NoError(t, <-errs)
+	assert.EqualValues(t, 1, node.calls.Load(), "the load is not canceled with the caller starting it")
+}
@@ -0,0 +1,76 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// KeyExtractor is implemented by clients which know the key schemas of tables.
+type KeyExtractor interface {
+	ExtractKey(ctx context.Context, table string, item map[string]types.AttributeValue) (daxTypes.ItemKey, error)
+}
+
+// ExtractKey returns the key of item in table, using the cached key schema
+// of the table, which is fetched on first use.
+func (client *SingleDaxClient) ExtractKey(ctx context.Context, table string, item map[string]types.AttributeValue) (daxTypes.ItemKey, error) {
+	if table == "" {
+		return daxTypes.ItemKey{}, smithy.NewErrParamRequired("TableName")
+	}
+	keydef, err := client.keySchema.GetWithContext(ctx, table)
+	if err != nil {
+		return daxTypes.ItemKey{}, err
+	}
+	return extractKey(item, keydef)
+}
+
+// ExtractKey returns the key of item in table, fetching the key schema of
+// the table from a node of the cluster on first use.
+func (cc *ClusterDaxClient) ExtractKey(ctx context.Context, table string, item map[string]types.AttributeValue) (daxTypes.ItemKey, error) {
+	var key daxTypes.ItemKey
+	action := func(client DaxAPI, o RequestOptions) error {
+		ke, ok := client.(KeyExtractor)
+		if !ok {
+			return NewCustomInvalidParamError("ExtractKey", "the client does not know key schemas")
+		}
+		var err error
+		key, err = ke.ExtractKey(ctx, table, item)
+		return err
+	}
+	if err := cc.retry(ctx, opDefineKeySchema, action, RequestOptions{}, nil); err != nil {
+		return daxTypes.ItemKey{}, err
+	}
+	return key, nil
+}
+
+func extractKey(item map[string]types.AttributeValue, keydef []types.AttributeDefinition) (daxTypes.ItemKey, error) {
+	encoded, err := cbor.GetEncodedItemKey(item, keydef)
+	if err != nil {
+		return daxTypes.ItemKey{}, err
+	}
+	attrs := make(map[string]types.AttributeValue, len(keydef))
+	for _, kd := range keydef {
+		name := aws.ToString(kd.AttributeName)
+		attrs[name] = item[name]
+	}
+	return daxTypes.ItemKey{Attributes: attrs, Encoded: encoded}, nil
+}
@@ -0,0 +1,77 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleClient_ExtractKey(t *testing.T) {
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{}, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.keySchema.LoadFunc = func(ctx context.Context, table string) ([]types.AttributeDefinition, error) {
+		return []types.AttributeDefinition{
+			{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeN},
+		}, nil
+	}
+	ctx := context.Background()
+
+	item := map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "a"},
+		"sk":   &types.AttributeValueMemberN{Value: "1"},
+		"attr": &types.AttributeValueMemberS{Value: "x"},
+	}
+	key, err := client.ExtractKey(ctx, "t", item)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.AttributeValue{"pk": item["pk"], "sk": item["sk"]}, key.Attributes)
+	assert.NotEmpty(t, key.Encoded)
+
+	other, err := client.ExtractKey(ctx, "t", map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "a"},
+		"sk":   &types.AttributeValueMemberN{Value: "1"},
+		"attr": &types.AttributeValueMemberS{Value: "y"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, key.Encoded, other.Encoded)
+
+	other, err = client.ExtractKey(ctx, "t", map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: "a"},
+		"sk": &types.AttributeValueMemberN{Value: "2"},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Encoded, other.Encoded)
+
+	_, err = client.ExtractKey(ctx, "t", map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}})
+	assert.Error(t, err, "missing sort key")
+	_, err = client.ExtractKey(ctx, "t", map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberN{Value: "1"},
+		"sk": &types.AttributeValueMemberN{Value: "1"},
+	})
+	assert.Error(t, err, "wrong key type")
+	_, err = client.ExtractKey(ctx, "", item)
+	assert.Error(t, err, "missing table")
+}
@@ -36,9 +36,32 @@ const (
 	daxConnectionsClosedError       = "dax.connections.closed.error"
 	daxConnectionsClosedIdle        = "dax.connections.closed.idle"
 	daxConnectionsClosedSession     = "dax.connections.closed.session"
+	daxConnectionsBytesSent         = "dax.connections.bytes_sent"
+	daxConnectionsBytesReceived     = "dax.connections.bytes_received"
 	daxRouteManagerRoutesAdded      = "dax.route_manager.routes.added"
 	daxRouteManagerRoutesRemoved    = "dax.route_manager.routes.removed"
 	daxRouteManagerFailOpenEvents   = "dax.route_manager.fail_open.events"
+	daxResponseDuplicateKeys        = "dax.response.duplicate_keys"
+	daxResponseKeyMismatches        = "dax.response.key_mismatches"
+	daxFailoverRefreshes            = "dax.cluster.failover.refreshes"
+	daxHealthProbeFailures          = "dax.cluster.probe.failures"
+	daxHealthProbeEjections         = "dax.cluster.probe.ejections"
+	daxAttributeListsRegistered     = "dax.attribute_lists.registered" // gauge, per table
+	daxAttributeListsExceeded       = "dax.attribute_lists.threshold_exceeded"
+	daxGetItemCoalesced             = "dax.getitem.coalesced"
+	daxCacheHits                    = "dax.cache.hits"                            // per cache
+	daxCacheMisses                  = "dax.cache.misses"                          // per cache
+	daxCacheEvictions               = "dax.cache.evictions"                       // per cache
+	daxCacheLoadErrors              = "dax.cache.load_errors"                     // per cache
+	daxFailoverWriteUnavailable     = "dax.cluster.failover.write_unavailable_us" // histogram
+	daxReadCacheableLatencyUs       = "dax.read.cacheable.latency_us"             // histogram
+	daxReadPassthroughLatencyUs     = "dax.read.passthrough.latency_us"           // histogram
+	daxRequestQueueTime             = "dax.requests.queue_time_us"                // histogram
+	daxRequestsQueued               = "dax.requests.queued"                       // gauge
+	daxRequestsRejected             = "dax.requests.rejected"
+	daxRequestsRateLimited          = "dax.requests.rate_limited"
+	daxRetryBudgetExhausted         = "dax.retries.budget_exhausted"
+	daxRequestsSlow                 = "dax.requests.slow"
 )
 
 type daxSdkMetrics struct {
@@ -67,9 +90,26 @@ func buildCounters(meter metrics.Meter, om *daxSdkMetrics, ops []string) (err er
 		daxConnectionsClosedError:     "Number of closed connections due to errors",
 		daxConnectionsClosedIdle:      "Number of closed connections due to inactivity",
 		daxConnectionsClosedSession:   "Number of closed connections due to poll session change",
+		daxConnectionsBytesSent:       "Number of bytes of requests sent to the cluster",
+		daxConnectionsBytesReceived:   "Number of bytes of responses received from the cluster",
 		daxRouteManagerRoutesAdded:    "The number of routes added back to the active pool.",
 		daxRouteManagerRoutesRemoved:  "The number of routes removed from the active pool due to problems.",
 		daxRouteManagerFailOpenEvents: `The number of events when the manager enters the "fail-open" state.`,
+		daxResponseDuplicateKeys:      "The number of attribute names repeated within a response.",
+		daxResponseKeyMismatches:      "The number of returned items lacking a key attribute of their table.",
+		daxFailoverRefreshes:          "The number of cluster refreshes triggered by writes failing after a leader change.",
+		daxHealthProbeFailures:        "The number of failed background health probes of nodes.",
+		daxHealthProbeEjections:       "The number of nodes taken out of rotation after failing health probes.",
+		daxAttributeListsExceeded:     "The number of tables which passed the attribute list threshold.",
+		daxCacheHits:                  "The number of lookups served from a client-local cache.",
+		daxCacheMisses:                "The number of lookups a client-local cache had to fetch from the cluster.",
+		daxCacheEvictions:             "The number of entries evicted from a client-local cache.",
+		daxCacheLoadErrors:            "The number of failed fetches of client-local cache entries.",
+		daxGetItemCoalesced:           "The number of GetItem calls served by the request of a concurrent identical call.",
+		daxRequestsRejected:           "The number of calls rejected because the request queue was full.",
+		daxRequestsRateLimited:        "The number of calls delayed or rejected by the rate limit of a table.",
+		daxRetryBudgetExhausted:       "The number of calls not retried because the retry budget was exhausted.",
+		daxRequestsSlow:               "The number of calls slower than the slow request threshold.",
 	}
 
 	for name, description := range counters {
@@ -100,7 +140,11 @@ func buildCounters(meter metrics.Meter, om *daxSdkMetrics, ops []string) (err er
 
 func buildHistograms(meter metrics.Meter, om *daxSdkMetrics, ops []string) (err error) {
 	histograms := map[string]string{
-		daxOpNameLatencyUs: "Operations %s latency in microseconds",
+		daxOpNameLatencyUs:          "Operations %s latency in microseconds",
+		daxFailoverWriteUnavailable: "Time from the first failure of a write after a leader change to its success, in microseconds",
+		daxReadCacheableLatencyUs:   "Latency in microseconds of reads which DAX may serve from its cache",
+		daxReadPassthroughLatencyUs: "Latency in microseconds of reads which DAX forwards to DynamoDB",
+		daxRequestQueueTime:         "Time calls waited for one of MaxConcurrentRequests, in microseconds",
 	}
 
 	// build histograms
@@ -133,6 +177,8 @@ func buildGauges(meter metrics.Meter, om *daxSdkMetrics, ops []string) (err erro
 	gauges := map[string]string{
 		daxConnectionsIdle:              "Current number of inactive connections in the pool",
 		daxConcurrentConnectionAttempts: "Current number of concurrent connection attempts",
+		daxAttributeListsRegistered:     "Number of distinct attribute lists registered for a table",
+		daxRequestsQueued:               "Current number of calls waiting for one of MaxConcurrentRequests",
 	}
 
 	// build gauges
@@ -0,0 +1,222 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+// ErrRateLimitExceeded is returned by calls which would have to wait past
+// their deadline for the rate of a table in Config.TableRateLimits.
+var ErrRateLimitExceeded = errors.New("dax: table rate limit exceeded")
+
+// rateLimiter paces requests per table, see Config.TableRateLimits.
+type rateLimiter struct {
+	limits     map[string]types.RateLimit
+	sdkMetrics *daxSdkMetrics
+	now        func() time.Time // time.Now unless set by tests
+
+	mu      sync.Mutex
+	buckets map[rateBucketKey]*tokenBucket
+}
+
+type rateBucketKey struct {
+	table string
+	write bool
+}
+
+// newRateLimiter returns the rate limiter configured by cfg, nil if it
+// limits no table.
+func newRateLimiter(cfg Config, sdkMetrics *daxSdkMetrics) *rateLimiter {
+	enabled := false
+	for _, l := range cfg.TableRateLimits {
+		enabled = enabled || l.ReadsPerSecond > 0 || l.WritesPerSecond > 0
+	}
+	if !enabled {
+		return nil
+	}
+	return &rateLimiter{
+		limits:     cfg.TableRateLimits,
+		sdkMetrics: sdkMetrics,
+		now:        time.Now,
+		buckets:    make(map[rateBucketKey]*tokenBucket),
+	}
+}
+
+// bucket returns the bucket of the reads or writes of table, nil if they
+// are not limited.
+func (l *rateLimiter) bucket(table string, write bool) *tokenBucket {
+	limit := l.limits[table]
+	rate := limit.ReadsPerSecond
+	if write {
+		rate = limit.WritesPerSecond
+	}
+	if rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := rateBucketKey{table: table, write: write}
+	b, ok := l.buckets[key]
+	if !ok {
+		burst := float64(limit.Burst)
+		if burst <= 0 {
+			burst = math.Max(1, math.Ceil(rate))
+		}
+		b = &tokenBucket{rate: rate, burst: burst, tokens: burst, last: l.now()}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// wait takes a token of every table of input, waiting until the tokens are
+// available. It fails at once with ErrRateLimitExceeded if they are not
+// before the deadline of ctx, without taking them.
+func (l *rateLimiter) wait(ctx context.Context, op string, input any) error {
+	tables, write := requestTables(input)
+	var taken []*tokenBucket
+	var delay time.Duration
+	now := l.now()
+	for _, table := range tables {
+		if b := l.bucket(table, write); b != nil {
+			delay = max(delay, b.take(now))
+			taken = append(taken, b)
+		}
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	countMetricInt64(ctx, l.sdkMetrics, daxRequestsRateLimited, 1)
+	err := error(&smithy.OperationError{ServiceID: service, OperationName: op, Err: ErrRateLimitExceeded})
+	if !exceedsDeadline(ctx, delay) {
+		err = SleepWithContext(ctx, op, delay)
+	}
+	if err != nil {
+		for _, b := range taken {
+			b.giveBack()
+		}
+	}
+	return err
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens per second.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64 // negative while callers wait for tokens
+	last   time.Time
+}
+
+// take takes a token and returns how long to wait until it is available.
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// giveBack returns a token taken by a call which did not wait for it.
+func (b *tokenBucket) giveBack() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// requestTables returns the tables input accesses, and whether it writes.
+func requestTables(input any) ([]string, bool) {
+	switch in := input.(type) {
+	case *dynamodb.GetItemInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, false
+		}
+	case *dynamodb.QueryInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, false
+		}
+	case *dynamodb.ScanInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, false
+		}
+	case *dynamodb.BatchGetItemInput:
+		if in != nil {
+			return sortedKeys(in.RequestItems), false
+		}
+	case *dynamodb.TransactGetItemsInput:
+		if in != nil {
+			tables := make(map[string]struct{})
+			for _, ti := range in.TransactItems {
+				if ti.Get != nil {
+					tables[aws.ToString(ti.Get.TableName)] = struct{}{}
+				}
+			}
+			return sortedKeys(tables), false
+		}
+	case *dynamodb.PutItemInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, true
+		}
+	case *dynamodb.DeleteItemInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, true
+		}
+	case *dynamodb.UpdateItemInput:
+		if in != nil {
+			return []string{aws.ToString(in.TableName)}, true
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if in != nil {
+			return sortedKeys(in.RequestItems), true
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		if in != nil {
+			tables := make(map[string]struct{})
+			for _, ti := range in.TransactItems {
+				switch {
+				case ti.Put != nil:
+					tables[aws.ToString(ti.Put.TableName)] = struct{}{}
+				case ti.Update != nil:
+					tables[aws.ToString(ti.Update.TableName)] = struct{}{}
+				case ti.Delete != nil:
+					tables[aws.ToString(ti.Delete.TableName)] = struct{}{}
+				case ti.ConditionCheck != nil:
+					tables[aws.ToString(ti.ConditionCheck.TableName)] = struct{}{}
+				}
+			}
+			return sortedKeys(tables), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,106 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := &tokenBucket{rate: 10, burst: 2, tokens: 2, last: now}
+	assert.Zero(t, b.take(now))
+	assert.Zero(t, b.take(now))
+	assert.Equal(t, 100*time.Millisecond, b.take(now))
+	assert.Equal(t, 200*time.Millisecond, b.take(now))
+	b.giveBack()
+
+	now = now.Add(time.Second)
+	assert.Zero(t, b.take(now), "tokens are refilled")
+	assert.Zero(t, b.take(now))
+	assert.Equal(t, 100*time.Millisecond, b.take(now), "up to the burst")
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(Config{TableRateLimits: map[string]daxTypes.RateLimit{"t": {Burst: 5}}}, &daxSdkMetrics{}))
+
+	l := newRateLimiter(Config{TableRateLimits: map[string]daxTypes.RateLimit{
+		"t": {ReadsPerSecond: 1, WritesPerSecond: 2.5},
+	}}, &daxSdkMetrics{})
+	require.NotNil(t, l)
+	now := time.Unix(1000, 0)
+	l.now = func() time.Time { return now }
+
+	assert.Nil(t, l.bucket("other", false))
+	assert.Equal(t, 1.0, l.bucket("t", false).burst)
+	assert.Equal(t, 3.0, l.bucket("t", true).burst)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	get := &dynamodb.GetItemInput{TableName: aws.String("t")}
+	require.NoError(t, l.wait(ctx, OpGetItem, get))
+	require.NoError(t, l.wait(ctx, OpGetItem, &dynamodb.GetItemInput{TableName: aws.String("other")}))
+	require.NoError(t, l.wait(ctx, OpPutItem, &dynamodb.PutItemInput{TableName: aws.String("t")}), "writes are limited apart")
+
+	short, cancelShort := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelShort()
+	assert.ErrorIs(t, l.wait(short, OpGetItem, get), ErrRateLimitExceeded)
+	assert.ErrorIs(t, l.wait(short, OpGetItem, get), ErrRateLimitExceeded, "rejected calls take no token")
+
+	now = now.Add(time.Second)
+	assert.NoError(t, l.wait(short, OpGetItem, get))
+}
+
+func TestRequestTables(t *testing.T) {
+	cases := []struct {
+		input  any
+		tables []string
+		write  bool
+	}{
+		{&dynamodb.GetItemInput{TableName: aws.String("a")}, []string{"a"}, false},
+		{&dynamodb.QueryInput{TableName: aws.String("a")}, []string{"a"}, false},
+		{&dynamodb.ScanInput{TableName: aws.String("a")}, []string{"a"}, false},
+		{&dynamodb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{"b": {}, "a": {}}}, []string{"a", "b"}, false},
+		{&dynamodb.TransactGetItemsInput{TransactItems: []types.TransactGetItem{
+			{Get: &types.Get{TableName: aws.String("b")}},
+			{Get: &types.Get{TableName: aws.String("a")}},
+			{Get: &types.Get{TableName: aws.String("b")}},
+		}}, []string{"a", "b"}, false},
+		{&dynamodb.PutItemInput{TableName: aws.String("a")}, []string{"a"}, true},
+		{&dynamodb.DeleteItemInput{TableName: aws.String("a")}, []string{"a"}, true},
+		{&dynamodb.UpdateItemInput{TableName: aws.String("a")}, []string{"a"}, true},
+		{&dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{"a": nil}}, []string{"a"}, true},
+		{&dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{TableName: aws.String("a")}},
+			{ConditionCheck: &types.ConditionCheck{TableName: aws.String("c")}},
+		}}, []string{"a", "c"}, true},
+		{(*dynamodb.GetItemInput)(nil), nil, false},
+	}
+	for _, c := range cases {
+		tables, write := requestTables(c.input)
+		assert.Equal(t, c.tables, tables)
+		assert.Equal(t, c.write, write)
+	}
+}
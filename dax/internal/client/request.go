@@ -26,11 +26,9 @@ import (
 	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
 	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
 	"github.com/aws/aws-dax-go-v2/dax/internal/parser"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/smithy-go"
-	"github.com/gofrs/uuid"
 )
 
 const daxServiceId = 1
@@ -216,7 +214,7 @@ func encodeDefineKeySchemaInput(table string, writer *cbor.Writer) error {
 	return writer.WriteBytes([]byte(table))
 }
 
-func encodePutItemInput(ctx context.Context, input *dynamodb.PutItemInput, keySchema *lru.Lru, attrNamesListToId *lru.Lru, writer *cbor.Writer) error {
+func encodePutItemInput(ctx context.Context, input *dynamodb.PutItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[lru.StringsKey, int64], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -243,7 +241,7 @@ func encodePutItemInput(ctx context.Context, input *dynamodb.PutItemInput, keySc
 	if err := cbor.EncodeItemKey(input.Item, keys, writer); err != nil {
 		return err
 	}
-	if err := encodeNonKeyAttributes(ctx, input.Item, keys, attrNamesListToId, writer); err != nil {
+	if err := encodeNonKeyAttributes(ctx, table, input.Item, keys, attrNamesListToId, writer); err != nil {
 		return err
 	}
 
@@ -255,7 +253,7 @@ func encodePutItemInput(ctx context.Context, input *dynamodb.PutItemInput, keySc
 		nil, input.ConditionExpression, nil, input.ExpressionAttributeNames, input.ExpressionAttributeValues, writer)
 }
 
-func encodeDeleteItemInput(ctx context.Context, input *dynamodb.DeleteItemInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeDeleteItemInput(ctx context.Context, input *dynamodb.DeleteItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -291,7 +289,7 @@ func encodeDeleteItemInput(ctx context.Context, input *dynamodb.DeleteItemInput,
 		nil, input.ConditionExpression, nil, input.ExpressionAttributeNames, input.ExpressionAttributeValues, writer)
 }
 
-func encodeUpdateItemInput(ctx context.Context, input *dynamodb.UpdateItemInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeUpdateItemInput(ctx context.Context, input *dynamodb.UpdateItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -327,7 +325,7 @@ func encodeUpdateItemInput(ctx context.Context, input *dynamodb.UpdateItemInput,
 		nil, input.ConditionExpression, input.UpdateExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, writer)
 }
 
-func encodeGetItemInput(ctx context.Context, input *dynamodb.GetItemInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeGetItemInput(ctx context.Context, input *dynamodb.GetItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -361,7 +359,7 @@ func encodeGetItemInput(ctx context.Context, input *dynamodb.GetItemInput, keySc
 		input.ProjectionExpression, nil, nil, input.ExpressionAttributeNames, nil, writer)
 }
 
-func encodeScanInput(ctx context.Context, input *dynamodb.ScanInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeScanInput(ctx context.Context, input *dynamodb.ScanInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -391,7 +389,7 @@ func encodeScanInput(ctx context.Context, input *dynamodb.ScanInput, keySchema *
 		expressions, input.Segment, input.TotalSegments, input.Limit, nil, input.ExclusiveStartKey, keySchema, *input.TableName, writer)
 }
 
-func encodeQueryInput(ctx context.Context, input *dynamodb.QueryInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeQueryInput(ctx context.Context, input *dynamodb.QueryInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -427,7 +425,7 @@ func encodeQueryInput(ctx context.Context, input *dynamodb.QueryInput, keySchema
 		expressions, nil, nil, input.Limit, input.ScanIndexForward, input.ExclusiveStartKey, keySchema, *input.TableName, writer)
 }
 
-func encodeBatchWriteItemInput(ctx context.Context, input *dynamodb.BatchWriteItemInput, keySchema *lru.Lru, attrNamesListToId *lru.Lru, writer *cbor.Writer) error {
+func encodeBatchWriteItemInput(ctx context.Context, input *dynamodb.BatchWriteItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[lru.StringsKey, int64], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -483,7 +481,7 @@ func encodeBatchWriteItemInput(ctx context.Context, input *dynamodb.BatchWriteIt
 				if err = cbor.EncodeItemKey(attrs, keys, writer); err != nil {
 					return err
 				}
-				if err = encodeNonKeyAttributes(ctx, attrs, keys, attrNamesListToId, writer); err != nil {
+				if err = encodeNonKeyAttributes(ctx, table, attrs, keys, attrNamesListToId, writer); err != nil {
 					return err
 				}
 			} else if dr := wr.DeleteRequest; dr != nil {
@@ -505,7 +503,7 @@ func encodeBatchWriteItemInput(ctx context.Context, input *dynamodb.BatchWriteIt
 		nil, nil, nil, nil, nil, nil, writer)
 }
 
-func encodeBatchGetItemInput(ctx context.Context, input *dynamodb.BatchGetItemInput, keySchema *lru.Lru, writer *cbor.Writer) error {
+func encodeBatchGetItemInput(ctx context.Context, input *dynamodb.BatchGetItemInput, keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer) error {
 	if input == nil {
 		return smithy.NewErrParamRequired("input cannot be nil")
 	}
@@ -541,15 +539,11 @@ func encodeBatchGetItemInput(ctx context.Context, input *dynamodb.BatchGetItemIn
 		if kaas.ProjectionExpression != nil {
 			expressions := make(map[int]string)
 			expressions[parser.ProjectionExpr] = *kaas.ProjectionExpression
-			encoder := parser.NewExpressionEncoder(expressions, kaas.ExpressionAttributeNames, nil)
-			if _, err = encoder.Parse(); err != nil {
-				return err
-			}
-			var buf bytes.Buffer
-			if err = encoder.Write(parser.ProjectionExpr, &buf); err != nil {
+			encoded, err := parser.NewExpressionEncoder(expressions, kaas.ExpressionAttributeNames, nil).Parse()
+			if err != nil {
 				return err
 			}
-			if err = writer.WriteBytes(buf.Bytes()); err != nil {
+			if err = writer.WriteBytes(encoded[parser.ProjectionExpr]); err != nil {
 				return err
 			}
 		} else {
@@ -589,7 +583,7 @@ func encodeBatchGetItemInput(ctx context.Context, input *dynamodb.BatchGetItemIn
 func encodeTransactWriteItemsInput(
 	ctx context.Context,
 	input *dynamodb.TransactWriteItemsInput,
-	keySchema *lru.Lru, attrNamesListToId *lru.Lru, writer *cbor.Writer,
+	keySchema *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[lru.StringsKey, int64], writer *cbor.Writer,
 	extractedKeys []map[string]types.AttributeValue,
 ) error {
 	if input == nil {
@@ -748,7 +742,7 @@ func encodeTransactWriteItemsInput(
 				return err
 			}
 		case putOperation:
-			if err := encodeNonKeyAttributes(ctx, item, keydef, attrNamesListToId, valuesWriter); err != nil {
+			if err := encodeNonKeyAttributes(ctx, *tableName, item, keydef, attrNamesListToId, valuesWriter); err != nil {
 				return err
 			}
 			key = map[string]types.AttributeValue{}
@@ -841,13 +835,6 @@ func encodeTransactWriteItemsInput(
 		return err
 	}
 
-	if input.ClientRequestToken == nil {
-		id, err := uuid.NewV4()
-		if err != nil {
-			return err
-		}
-		input.ClientRequestToken = aws.String(id.String())
-	}
 	return encodeItemOperationOptionalParamsWithToken(
 		types.ReturnValueNone,
 		input.ReturnConsumedCapacity,
@@ -858,7 +845,7 @@ func encodeTransactWriteItemsInput(
 func encodeTransactGetItemsInput(
 	ctx context.Context,
 	input *dynamodb.TransactGetItemsInput,
-	keySchema *lru.Lru, writer *cbor.Writer,
+	keySchema *lru.Lru[string, []types.AttributeDefinition], writer *cbor.Writer,
 	extractedKeys []map[string]types.AttributeValue,
 ) error {
 	if input == nil {
@@ -964,8 +951,9 @@ func encodeTransactGetItemsInput(
 }
 
 func encodeCompoundKey(key map[string]types.AttributeValue, writer *cbor.Writer) error {
-	var buf bytes.Buffer
-	w := cbor.NewWriter(&buf)
+	buf := cbor.GetBuffer()
+	defer cbor.PutBuffer(buf)
+	w := cbor.NewWriter(buf)
 	defer w.Close()
 	if err := w.WriteMapStreamHeader(); err != nil {
 		return err
@@ -989,10 +977,12 @@ func encodeCompoundKey(key map[string]types.AttributeValue, writer *cbor.Writer)
 	return writer.WriteBytes(buf.Bytes())
 }
 
-func encodeNonKeyAttributes(ctx context.Context, item map[string]types.AttributeValue, keys []types.AttributeDefinition,
-	attrNamesListToId *lru.Lru, writer *cbor.Writer) error {
-	var buf bytes.Buffer
-	w := cbor.NewWriter(&buf)
+func encodeNonKeyAttributes(ctx context.Context, table string, item map[string]types.AttributeValue, keys []types.AttributeDefinition,
+	attrNamesListToId *lru.Lru[lru.StringsKey, int64], writer *cbor.Writer) error {
+	ctx = withAttributeListTable(ctx, table)
+	buf := cbor.GetBuffer()
+	defer cbor.PutBuffer(buf)
+	w := cbor.NewWriter(buf)
 	defer w.Close()
 	if err := cbor.EncodeItemNonKeyAttributes(ctx, item, keys, attrNamesListToId, w); err != nil {
 		return err
@@ -1010,7 +1000,7 @@ func encodeScanQueryOptionalParams(
 	returnConsumedCapacity types.ReturnConsumedCapacity,
 	consistentRead *bool,
 	encodedExpressions map[int][]byte, segment, totalSegment, limit *int32, forward *bool,
-	startKey map[string]types.AttributeValue, keySchema *lru.Lru, table string, writer *cbor.Writer) error {
+	startKey map[string]types.AttributeValue, keySchema *lru.Lru[string, []types.AttributeDefinition], table string, writer *cbor.Writer) error {
 
 	var err error
 	if err = writer.WriteMapStreamHeader(); err != nil {
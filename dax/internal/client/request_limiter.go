@@ -0,0 +1,86 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRequestQueueFull is returned by calls rejected because
+// Config.MaxConcurrentRequests requests are in flight and
+// Config.MaxQueuedRequests calls already wait for one to complete.
+var ErrRequestQueueFull = errors.New("dax: request queue full")
+
+// requestLimiter bounds the requests in flight, see Config.MaxConcurrentRequests.
+type requestLimiter struct {
+	slots      chan struct{}
+	maxQueued  int64
+	queued     atomic.Int64
+	sdkMetrics *daxSdkMetrics
+}
+
+// newRequestLimiter returns the limiter configured by cfg, nil without
+// MaxConcurrentRequests.
+func newRequestLimiter(cfg Config, sdkMetrics *daxSdkMetrics) *requestLimiter {
+	if cfg.MaxConcurrentRequests <= 0 {
+		return nil
+	}
+	return &requestLimiter{
+		slots:      make(chan struct{}, cfg.MaxConcurrentRequests),
+		maxQueued:  int64(cfg.MaxQueuedRequests),
+		sdkMetrics: sdkMetrics,
+	}
+}
+
+// acquire takes a slot for a request, waiting in the queue while none is
+// free. It fails at once with ErrRequestQueueFull if the queue is full, and
+// with the error of ctx if it is done first. A slot taken is given back
+// with release.
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		histogramMicrosecondsInt64(ctx, l.sdkMetrics, daxRequestQueueTime, time.Now())
+		return nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueued {
+		l.queued.Add(-1)
+		countMetricInt64(ctx, l.sdkMetrics, daxRequestsRejected, 1)
+		return ErrRequestQueueFull
+	}
+	gaugeInt64(ctx, l.sdkMetrics, daxRequestsQueued, l.queued.Load())
+	defer func() {
+		gaugeInt64(ctx, l.sdkMetrics, daxRequestsQueued, l.queued.Add(-1))
+	}()
+
+	start := time.Now()
+	select {
+	case l.slots <- struct{}{}:
+		histogramMicrosecondsInt64(ctx, l.sdkMetrics, daxRequestQueueTime, start)
+		return nil
+	case <-ctx.Done():
+		histogramMicrosecondsInt64(ctx, l.sdkMetrics, daxRequestQueueTime, start)
+		return ctx.Err()
+	}
+}
+
+func (l *requestLimiter) release() {
+	<-l.slots
+}
@@ -0,0 +1,106 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	assert.Nil(t, newRequestLimiter(Config{MaxQueuedRequests: 1}, &daxSdkMetrics{}))
+
+	om, err := buildDaxSdkMetrics(&testMeterProvider{})
+	require.NoError(t, err)
+	l := newRequestLimiter(Config{MaxConcurrentRequests: 1, MaxQueuedRequests: 1}, om)
+	require.NotNil(t, l)
+	require.NoError(t, l.acquire(context.Background()))
+
+	queued := make(chan error)
+	go func() { queued <- l.acquire(context.Background()) }()
+	for l.queued.Load() == 0 {
+		runtime.Gosched()
+	}
+	_, _, v := gauge(om, daxRequestsQueued)
+	assert.Equal(t, 1, v)
+
+	assert.ErrorIs(t, l.acquire(context.Background()), ErrRequestQueueFull)
+	_, _, v = counter(om, daxRequestsRejected)
+	assert.Equal(t, 1, v)
+
+	l.release()
+	require.NoError(t, <-queued)
+	_, _, v = gauge(om, daxRequestsQueued)
+	assert.Equal(t, 0, v)
+	_, _, v = histogram(om, daxRequestQueueTime)
+	assert.Equal(t, 2, v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.acquire(ctx), context.Canceled)
+	assert.Zero(t, l.queued.Load())
+
+	l.release()
+	require.NoError(t, l.acquire(context.Background()))
+}
+
+func TestRequestLimiter_noQueue(t *testing.T) {
+	l := newRequestLimiter(Config{MaxConcurrentRequests: 2}, &daxSdkMetrics{})
+	require.NoError(t, l.acquire(context.Background()))
+	require.NoError(t, l.acquire(context.Background()))
+	assert.ErrorIs(t, l.acquire(context.Background()), ErrRequestQueueFull)
+	l.release()
+	assert.NoError(t, l.acquire(context.Background()))
+}
+
+func TestClusterDaxClient_MaxConcurrentRequests(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: cluster.config, cluster: cluster}
+	cc.limiter = newRequestLimiter(Config{MaxConcurrentRequests: 1}, cluster.daxSdkMetrics)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+			close(started)
+			<-release
+			return nil
+		}, RequestOptions{}, nil)
+	}()
+	<-started
+
+	err := cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+		t.Error("request started over MaxConcurrentRequests")
+		return nil
+	}, RequestOptions{}, nil)
+	assert.ErrorIs(t, err, ErrRequestQueueFull)
+	var opErr *smithy.OperationError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, OpGetItem, opErr.OperationName)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.NoError(t, cc.retry(context.Background(), OpGetItem, func(client DaxAPI, o RequestOptions) error {
+		return nil
+	}, RequestOptions{}, nil))
+}
@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"time"
 
+	daxTypes "github.com/aws/aws-dax-go-v2/dax/types"
 	"github.com/aws/aws-dax-go-v2/dax/utils"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/smithy-go/middleware"
 )
 
@@ -32,6 +34,18 @@ type RequestOptions struct {
 	Context    context.Context
 	//Retryer implements equal jitter backoff stratergy for throttled requests
 	Retryer DaxRetryer
+	// Passthrough marks reads DAX forwards to DynamoDB instead of serving
+	// them from its cache, their latency is reported apart from other reads.
+	Passthrough bool
+	// ReadPreference, if set, overrides Config.ReadPreference for the
+	// request.
+	ReadPreference daxTypes.ReadPreference
+	// OnItem, if set, receives the items of a Query or Scan one at a time as
+	// they are read from the connection, instead of them being collected in
+	// the output. An error it returns fails the request. Requests are not
+	// retried once an item was passed on.
+	OnItem func(item map[string]types.AttributeValue) error
+	stream *itemStream
 }
 
 // rejectCustomMiddleware checks if APIOptions are present and returns an error if they are.
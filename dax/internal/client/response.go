@@ -176,7 +176,7 @@ func decodeDefineKeySchemaOutput(reader *cbor.Reader) ([]types.AttributeDefiniti
 	return keys, nil
 }
 
-func decodePutItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.PutItemInput, keySchemaCache *lru.Lru, attrListIdToNames *lru.Lru, output *dynamodb.PutItemOutput) (*dynamodb.PutItemOutput, error) {
+func decodePutItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.PutItemInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.PutItemOutput) (*dynamodb.PutItemOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return output, err
 	} else if consumed {
@@ -191,7 +191,7 @@ func decodePutItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 	err = consumeMap(reader, func(key int, reader *cbor.Reader) error {
 		switch key {
 		case responseParamConsumedCapacity:
-			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader); err != nil {
+			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader, true); err != nil {
 				return err
 			}
 		case responseParamItemCollectionMetrics:
@@ -213,6 +213,11 @@ func decodePutItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 			}
 			for _, ad := range keys {
 				k := *ad.AttributeName
+				if _, dup := attrs[k]; dup {
+					if err := reader.DuplicateKey(k); err != nil {
+						return err
+					}
+				}
 				attrs[k] = input.Item[k]
 			}
 			output.Attributes = attrs
@@ -228,7 +233,7 @@ func decodePutItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 	return output, nil
 }
 
-func decodeDeleteItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.DeleteItemInput, keySchemaCache *lru.Lru, attrListIdToNames *lru.Lru, output *dynamodb.DeleteItemOutput) (*dynamodb.DeleteItemOutput, error) {
+func decodeDeleteItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.DeleteItemInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.DeleteItemOutput) (*dynamodb.DeleteItemOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return output, err
 	} else if consumed {
@@ -243,7 +248,7 @@ func decodeDeleteItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 	err = consumeMap(reader, func(key int, reader *cbor.Reader) error {
 		switch key {
 		case responseParamConsumedCapacity:
-			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader); err != nil {
+			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader, true); err != nil {
 				return err
 			}
 		case responseParamItemCollectionMetrics:
@@ -259,8 +264,8 @@ func decodeDeleteItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 			if err != nil {
 				return err
 			}
-			for k, v := range input.Key {
-				attrs[k] = v
+			if err := mergeKey(reader, attrs, input.Key); err != nil {
+				return err
 			}
 			output.Attributes = attrs
 		default:
@@ -275,7 +280,7 @@ func decodeDeleteItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 	return output, nil
 }
 
-func decodeUpdateItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.UpdateItemInput, keySchemaCache *lru.Lru, attrListIdToNames *lru.Lru, output *dynamodb.UpdateItemOutput) (*dynamodb.UpdateItemOutput, error) {
+func decodeUpdateItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.UpdateItemInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.UpdateItemOutput) (*dynamodb.UpdateItemOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return output, err
 	} else if consumed {
@@ -290,7 +295,7 @@ func decodeUpdateItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 	err = consumeMap(reader, func(key int, reader *cbor.Reader) error {
 		switch key {
 		case responseParamConsumedCapacity:
-			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader); err != nil {
+			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader, true); err != nil {
 				return err
 			}
 		case responseParamItemCollectionMetrics:
@@ -309,8 +314,8 @@ func decodeUpdateItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 				if err != nil {
 					return err
 				}
-				for k, v := range input.Key {
-					attrs[k] = v
+				if err := mergeKey(reader, attrs, input.Key); err != nil {
+					return err
 				}
 				output.Attributes = attrs
 			case types.ReturnValueUpdatedNew, types.ReturnValueUpdatedOld:
@@ -332,7 +337,7 @@ func decodeUpdateItemOutput(ctx context.Context, reader *cbor.Reader, input *dyn
 	return output, nil
 }
 
-func decodeGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.GetItemInput, attrListIdToNames *lru.Lru, output *dynamodb.GetItemOutput) (*dynamodb.GetItemOutput, error) {
+func decodeGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.GetItemInput, attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.GetItemOutput) (*dynamodb.GetItemOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return output, err
 	} else if consumed {
@@ -349,7 +354,7 @@ func decodeGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 	err = consumeMap(reader, func(key int, reader *cbor.Reader) error {
 		switch key {
 		case responseParamConsumedCapacity:
-			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader); err != nil {
+			if output.ConsumedCapacity, err = decodeConsumedCapacity(reader, false); err != nil {
 				return err
 			}
 		case responseParamItem:
@@ -358,8 +363,8 @@ func decodeGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 				return err
 			}
 			if len(projectionOrdinals) == 0 {
-				for k, v := range input.Key {
-					item[k] = v
+				if err := mergeKey(reader, item, input.Key); err != nil {
+					return err
 				}
 			}
 			output.Item = item
@@ -375,8 +380,8 @@ func decodeGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamo
 	return output, nil
 }
 
-func decodeScanOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.ScanInput, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru, output *dynamodb.ScanOutput) (*dynamodb.ScanOutput, error) {
-	out, err := decodeScanQueryOutput(ctx, reader, *input.TableName, input.IndexName != nil, input.ProjectionExpression, input.ExpressionAttributeNames, keySchemaCache, attrNamesListToId)
+func decodeScanOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.ScanInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], output *dynamodb.ScanOutput, onItem func(map[string]types.AttributeValue) error) (*dynamodb.ScanOutput, error) {
+	out, err := decodeScanQueryOutput(ctx, reader, *input.TableName, input.IndexName != nil, input.ProjectionExpression, input.ExpressionAttributeNames, keySchemaCache, attrNamesListToId, onItem)
 	if err != nil {
 		return output, err
 	}
@@ -386,8 +391,8 @@ func decodeScanOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.
 	return out.scanOutput(output), nil
 }
 
-func decodeQueryOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.QueryInput, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru, output *dynamodb.QueryOutput) (*dynamodb.QueryOutput, error) {
-	out, err := decodeScanQueryOutput(ctx, reader, *input.TableName, input.IndexName != nil, input.ProjectionExpression, input.ExpressionAttributeNames, keySchemaCache, attrNamesListToId)
+func decodeQueryOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.QueryInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], output *dynamodb.QueryOutput, onItem func(map[string]types.AttributeValue) error) (*dynamodb.QueryOutput, error) {
+	out, err := decodeScanQueryOutput(ctx, reader, *input.TableName, input.IndexName != nil, input.ProjectionExpression, input.ExpressionAttributeNames, keySchemaCache, attrNamesListToId, onItem)
 	if err != nil {
 		return output, err
 	}
@@ -431,7 +436,7 @@ func (o *scanQueryOutput) queryOutput(output *dynamodb.QueryOutput) *dynamodb.Qu
 	}
 }
 
-func decodeScanQueryOutput(ctx context.Context, reader *cbor.Reader, table string, indexed bool, projection *string, exprAttrNames map[string]string, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru) (*scanQueryOutput, error) {
+func decodeScanQueryOutput(ctx context.Context, reader *cbor.Reader, table string, indexed bool, projection *string, exprAttrNames map[string]string, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], onItem func(map[string]types.AttributeValue) error) (*scanQueryOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return nil, err
 	} else if consumed {
@@ -439,7 +444,9 @@ func decodeScanQueryOutput(ctx context.Context, reader *cbor.Reader, table strin
 	}
 
 	out := &scanQueryOutput{}
-	out.Items = []map[string]types.AttributeValue{}
+	if onItem == nil {
+		out.Items = []map[string]types.AttributeValue{}
+	}
 	var err error
 	err = consumeMap(reader, func(key int, reader *cbor.Reader) error {
 		switch key {
@@ -448,11 +455,11 @@ func decodeScanQueryOutput(ctx context.Context, reader *cbor.Reader, table strin
 			if err != nil {
 				return err
 			}
-			if out.Items, err = decodeScanQueryItems(ctx, reader, table, keySchemaCache, attrNamesListToId, projectionOrdinals); err != nil {
+			if out.Items, err = decodeScanQueryItems(ctx, reader, table, keySchemaCache, attrNamesListToId, projectionOrdinals, onItem); err != nil {
 				return err
 			}
 		case responseParamConsumedCapacity:
-			if out.ConsumedCapacity, err = decodeConsumedCapacity(reader); err != nil {
+			if out.ConsumedCapacity, err = decodeConsumedCapacity(reader, false); err != nil {
 				return err
 			}
 		case responseParamCount:
@@ -486,7 +493,7 @@ func decodeScanQueryOutput(ctx context.Context, reader *cbor.Reader, table strin
 	return out, nil
 }
 
-func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru, output *dynamodb.BatchWriteItemOutput) (*dynamodb.BatchWriteItemOutput, error) {
+func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], output *dynamodb.BatchWriteItemOutput) (*dynamodb.BatchWriteItemOutput, error) {
 	if output != nil {
 		output.UnprocessedItems = map[string][]types.WriteRequest{}
 	}
@@ -532,8 +539,8 @@ func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySch
 				if item == nil {
 					wr.DeleteRequest = &types.DeleteRequest{Key: keys}
 				} else {
-					for k, v := range keys {
-						item[k] = v
+					if err := mergeKey(reader, item, keys); err != nil {
+						return output, err
 					}
 					wr.PutRequest = &types.PutRequest{Item: item}
 				}
@@ -551,13 +558,15 @@ func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySch
 		return output, err
 	}
 	if numCC > 0 {
-		output.ConsumedCapacity = make([]types.ConsumedCapacity, numCC)
+		output.ConsumedCapacity = make([]types.ConsumedCapacity, 0, numCC)
 		for i := 0; i < numCC; i++ {
-			capacity, err := decodeConsumedCapacity(reader)
+			capacity, err := decodeConsumedCapacity(reader, true)
 			if err != nil {
 				return output, err
 			}
-			output.ConsumedCapacity[i] = *capacity
+			if capacity != nil {
+				output.ConsumedCapacity = append(output.ConsumedCapacity, *capacity)
+			}
 		}
 	}
 
@@ -581,13 +590,15 @@ func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySch
 			if err != nil {
 				return output, err
 			}
-			metrics := make([]types.ItemCollectionMetrics, numMetrics)
+			metrics := make([]types.ItemCollectionMetrics, 0, numMetrics)
 			for j := 0; j < numMetrics; j++ {
 				itemCollectionMetric, err := decodeItemCollectionMetrics(reader, pkey)
 				if err != nil {
 					return output, err
 				}
-				metrics[j] = *itemCollectionMetric
+				if itemCollectionMetric != nil {
+					metrics = append(metrics, *itemCollectionMetric)
+				}
 			}
 			output.ItemCollectionMetrics[table] = metrics
 		}
@@ -596,7 +607,7 @@ func decodeBatchWriteItemOutput(ctx context.Context, reader *cbor.Reader, keySch
 	return output, nil
 }
 
-func decodeBatchGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.BatchGetItemInput, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru, output *dynamodb.BatchGetItemOutput) (*dynamodb.BatchGetItemOutput, error) {
+func decodeBatchGetItemOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.BatchGetItemInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], output *dynamodb.BatchGetItemOutput) (*dynamodb.BatchGetItemOutput, error) {
 	if consumed, err := consumeNil(reader); err != nil {
 		return output, err
 	} else if consumed {
@@ -667,12 +678,15 @@ func decodeBatchGetItemOutput(ctx context.Context, reader *cbor.Reader, input *d
 					if err != nil {
 						return output, err
 					}
+					if err := checkKey(reader, table, keys, tableKeys); err != nil {
+						return output, err
+					}
 					item, err := decodeNonKeyAttributes(ctx, reader, attrNamesListToId, projections)
 					if err != nil {
 						return output, err
 					}
-					for k, v := range keys {
-						item[k] = v
+					if err := mergeKey(reader, item, keys); err != nil {
+						return output, err
 					}
 					items[j] = item
 				}
@@ -729,20 +743,22 @@ func decodeBatchGetItemOutput(ctx context.Context, reader *cbor.Reader, input *d
 		return output, err
 	}
 	if numCC > 0 {
-		output.ConsumedCapacity = make([]types.ConsumedCapacity, numCC)
+		output.ConsumedCapacity = make([]types.ConsumedCapacity, 0, numCC)
 		for i := 0; i < numCC; i++ {
-			capacity, err := decodeConsumedCapacity(reader)
+			capacity, err := decodeConsumedCapacity(reader, false)
 			if err != nil {
 				return output, err
 			}
-			output.ConsumedCapacity[i] = *capacity
+			if capacity != nil {
+				output.ConsumedCapacity = append(output.ConsumedCapacity, *capacity)
+			}
 		}
 	}
 
 	return output, nil
 }
 
-func decodeTransactWriteItemsOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.TransactWriteItemsInput, keySchemaCache *lru.Lru, attrListIdToNames *lru.Lru, output *dynamodb.TransactWriteItemsOutput) (*dynamodb.TransactWriteItemsOutput, error) {
+func decodeTransactWriteItemsOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.TransactWriteItemsInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.TransactWriteItemsOutput) (*dynamodb.TransactWriteItemsOutput, error) {
 	len, err := reader.ReadArrayLength()
 	if err != nil {
 		return output, err
@@ -765,13 +781,15 @@ func decodeTransactWriteItemsOutput(ctx context.Context, reader *cbor.Reader, in
 		return output, err
 	}
 	if numCC > 0 {
-		output.ConsumedCapacity = make([]types.ConsumedCapacity, numCC)
+		output.ConsumedCapacity = make([]types.ConsumedCapacity, 0, numCC)
 		for i := 0; i < numCC; i++ {
 			capacity, err := decodeConsumedCapacityExtended(reader)
 			if err != nil {
 				return output, err
 			}
-			output.ConsumedCapacity[i] = *capacity
+			if capacity != nil {
+				output.ConsumedCapacity = append(output.ConsumedCapacity, *capacity)
+			}
 		}
 	}
 
@@ -795,13 +813,15 @@ func decodeTransactWriteItemsOutput(ctx context.Context, reader *cbor.Reader, in
 			if err != nil {
 				return output, err
 			}
-			metrics := make([]types.ItemCollectionMetrics, numMetrics)
+			metrics := make([]types.ItemCollectionMetrics, 0, numMetrics)
 			for j := 0; j < numMetrics; j++ {
 				itemCollectionMetric, err := decodeItemCollectionMetrics(reader, pkey)
 				if err != nil {
 					return output, err
 				}
-				metrics[j] = *itemCollectionMetric
+				if itemCollectionMetric != nil {
+					metrics = append(metrics, *itemCollectionMetric)
+				}
 			}
 			output.ItemCollectionMetrics[table] = metrics
 		}
@@ -810,7 +830,7 @@ func decodeTransactWriteItemsOutput(ctx context.Context, reader *cbor.Reader, in
 	return output, nil
 }
 
-func decodeTransactGetItemsOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.TransactGetItemsInput, keySchemaCache *lru.Lru, attrListIdToNames *lru.Lru, output *dynamodb.TransactGetItemsOutput) (*dynamodb.TransactGetItemsOutput, error) {
+func decodeTransactGetItemsOutput(ctx context.Context, reader *cbor.Reader, input *dynamodb.TransactGetItemsInput, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrListIdToNames *lru.Lru[int64, []string], output *dynamodb.TransactGetItemsOutput) (*dynamodb.TransactGetItemsOutput, error) {
 	length, err := reader.ReadArrayLength()
 	if err != nil {
 		return output, err
@@ -845,8 +865,8 @@ func decodeTransactGetItemsOutput(ctx context.Context, reader *cbor.Reader, inpu
 		}
 		// The key attributes are only added if it's NOT a projection
 		if item != nil && len(projectionOrdinals) == 0 {
-			for k, v := range get.Key {
-				item[k] = v
+			if err := mergeKey(reader, item, get.Key); err != nil {
+				return output, err
 			}
 		}
 		responses[i] = types.ItemResponse{Item: item}
@@ -858,20 +878,24 @@ func decodeTransactGetItemsOutput(ctx context.Context, reader *cbor.Reader, inpu
 		return output, err
 	}
 	if numCC > 0 {
-		output.ConsumedCapacity = make([]types.ConsumedCapacity, numCC)
+		output.ConsumedCapacity = make([]types.ConsumedCapacity, 0, numCC)
 		for i := 0; i < numCC; i++ {
 			capacity, err := decodeConsumedCapacityExtended(reader)
 			if err != nil {
 				return output, err
 			}
-			output.ConsumedCapacity[i] = *capacity
+			if capacity != nil {
+				output.ConsumedCapacity = append(output.ConsumedCapacity, *capacity)
+			}
 		}
 	}
 
 	return output, nil
 }
 
-func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string, keySchemaCache *lru.Lru, attrNamesListToId *lru.Lru, projectionOrdinals []documentPath) ([]map[string]types.AttributeValue, error) {
+// decodeScanQueryItems decodes the items of a Query or Scan, which are
+// passed to onItem instead of being returned if it is set.
+func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], attrNamesListToId *lru.Lru[int64, []string], projectionOrdinals []documentPath, onItem func(map[string]types.AttributeValue) error) ([]map[string]types.AttributeValue, error) {
 	consumed, err := consumeNil(reader)
 	if err != nil {
 		return nil, err
@@ -880,15 +904,26 @@ func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string
 		return nil, nil
 	}
 
-	items := []map[string]types.AttributeValue{}
+	var items []map[string]types.AttributeValue
+	if onItem == nil {
+		items = []map[string]types.AttributeValue{}
+		onItem = func(item map[string]types.AttributeValue) error {
+			items = append(items, item)
+			return nil
+		}
+	}
 	if len(projectionOrdinals) > 0 {
 		err := consumeArray(reader, func(reader *cbor.Reader) error {
+			// Stop at the next item once canceled, a page buffered in full
+			// is not interrupted by the tube deadline.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			i, err := decodeProjection(reader, projectionOrdinals)
 			if err != nil {
 				return err
 			}
-			items = append(items, i)
-			return nil
+			return onItem(i)
 		})
 		if err != nil {
 			return nil, err
@@ -899,6 +934,9 @@ func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string
 			return nil, err
 		}
 		err = consumeArray(reader, func(reader *cbor.Reader) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			len, err := reader.ReadArrayLength()
 			if err != nil {
 				return err
@@ -910,15 +948,17 @@ func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string
 			if err != nil {
 				return err
 			}
+			if err := checkKey(reader, table, key, tableKeys); err != nil {
+				return err
+			}
 			item, err := decodeNonKeyAttributes(ctx, reader, attrNamesListToId, projectionOrdinals)
 			if err != nil {
 				return err
 			}
-			for k, v := range key {
-				item[k] = v
+			if err := mergeKey(reader, item, key); err != nil {
+				return err
 			}
-			items = append(items, item)
-			return nil
+			return onItem(item)
 		})
 		if err != nil {
 			return nil, err
@@ -927,7 +967,7 @@ func decodeScanQueryItems(ctx context.Context, reader *cbor.Reader, table string
 	return items, nil
 }
 
-func decodeLastEvaluatedKey(ctx context.Context, reader *cbor.Reader, table string, indexed bool, keySchemaCache *lru.Lru) (map[string]types.AttributeValue, error) {
+func decodeLastEvaluatedKey(ctx context.Context, reader *cbor.Reader, table string, indexed bool, keySchemaCache *lru.Lru[string, []types.AttributeDefinition]) (map[string]types.AttributeValue, error) {
 	if indexed {
 		key, err := decodeCompoundKey(reader)
 		if err != nil {
@@ -1053,6 +1093,19 @@ func decodeKey(reader *cbor.Reader, keys []types.AttributeDefinition) (map[strin
 	return k, nil
 }
 
+// checkKey reports the first attribute of keys missing from a key decoded
+// for table to the reader's missing key handler. A response key decoded as
+// nil leaves its item without any key attribute once merged.
+func checkKey(reader *cbor.Reader, table string, key map[string]types.AttributeValue, keys []types.AttributeDefinition) error {
+	for _, def := range keys {
+		name := aws.ToString(def.AttributeName)
+		if _, ok := key[name]; !ok {
+			return reader.MissingKey(table, name)
+		}
+	}
+	return nil
+}
+
 func decodeCompoundKey(reader *cbor.Reader) (map[string]types.AttributeValue, error) {
 	consumed, err := consumeNil(reader)
 	if err != nil {
@@ -1097,12 +1150,31 @@ func decodeCompoundKey(reader *cbor.Reader) (map[string]types.AttributeValue, er
 		if err != nil {
 			return nil, err
 		}
+		if _, dup := key[k]; dup {
+			if err := r.DuplicateKey(k); err != nil {
+				return nil, err
+			}
+		}
 		key[k] = v
 	}
 	return key, nil
 }
 
-func decodeNonKeyAttributes(ctx context.Context, reader *cbor.Reader, attrNamesListToId *lru.Lru, projectionOrdinals []documentPath) (map[string]types.AttributeValue, error) {
+// mergeKey adds the key attributes to item, the key values replace non-key
+// attributes of the same name, which are reported as duplicates.
+func mergeKey(reader *cbor.Reader, item, key map[string]types.AttributeValue) error {
+	for k, v := range key {
+		if _, dup := item[k]; dup {
+			if err := reader.DuplicateKey(k); err != nil {
+				return err
+			}
+		}
+		item[k] = v
+	}
+	return nil
+}
+
+func decodeNonKeyAttributes(ctx context.Context, reader *cbor.Reader, attrNamesListToId *lru.Lru[int64, []string], projectionOrdinals []documentPath) (map[string]types.AttributeValue, error) {
 	hdr, err := reader.PeekHeader()
 	if err != nil {
 		return nil, err
@@ -1153,7 +1225,7 @@ func decodeProjection(reader *cbor.Reader, projectionOrdinals []documentPath) (m
 	return ib.toItem(), nil
 }
 
-func decodeAttributeProjection(ctx context.Context, reader *cbor.Reader, attrListIdToNames *lru.Lru) (map[string]types.AttributeValue, error) {
+func decodeAttributeProjection(ctx context.Context, reader *cbor.Reader, attrListIdToNames *lru.Lru[int64, []string]) (map[string]types.AttributeValue, error) {
 	r, err := reader.BytesReader()
 	if err != nil {
 		return nil, err
@@ -1164,14 +1236,10 @@ func decodeAttributeProjection(ctx context.Context, reader *cbor.Reader, attrLis
 	if err != nil {
 		return nil, err
 	}
-	attrNames, err := attrListIdToNames.GetWithContext(ctx, attrListId)
+	ans, err := attrListIdToNames.GetWithContext(ctx, attrListId)
 	if err != nil {
 		return nil, err
 	}
-	ans, ok := attrNames.([]string)
-	if !ok {
-		return nil, &smithy.SerializationError{Err: errors.New("invalid type for attribute names list")}
-	}
 	attrs := make(map[string]types.AttributeValue)
 	err = consumeMap(r, func(ord int, reader *cbor.Reader) error {
 		if ord > len(ans) {
@@ -1187,7 +1255,10 @@ func decodeAttributeProjection(ctx context.Context, reader *cbor.Reader, attrLis
 	return attrs, nil
 }
 
-func decodeConsumedCapacity(reader *cbor.Reader) (*types.ConsumedCapacity, error) {
+// decodeConsumedCapacity decodes the capacity consumed by a read or a write,
+// as write tells. DAX only reports capacity units, they are also set as read
+// or write capacity units, as DynamoDB does.
+func decodeConsumedCapacity(reader *cbor.Reader, write bool) (*types.ConsumedCapacity, error) {
 	consumed, err := consumeNil(reader)
 	if err != nil {
 		return nil, err
@@ -1237,9 +1308,31 @@ func decodeConsumedCapacity(reader *cbor.Reader) (*types.ConsumedCapacity, error
 		return nil, err
 	}
 
+	cc.ReadCapacityUnits, cc.WriteCapacityUnits = splitCapacityUnits(cc.CapacityUnits, write)
+	if cc.Table != nil {
+		cc.Table.ReadCapacityUnits, cc.Table.WriteCapacityUnits = splitCapacityUnits(cc.Table.CapacityUnits, write)
+	}
+	for _, indexes := range []map[string]types.Capacity{cc.GlobalSecondaryIndexes, cc.LocalSecondaryIndexes} {
+		for name, c := range indexes {
+			c.ReadCapacityUnits, c.WriteCapacityUnits = splitCapacityUnits(c.CapacityUnits, write)
+			indexes[name] = c
+		}
+	}
 	return cc, nil
 }
 
+// splitCapacityUnits returns units as read or write capacity units.
+func splitCapacityUnits(units *float64, write bool) (read, written *float64) {
+	if units == nil {
+		return nil, nil
+	}
+	u := *units
+	if write {
+		return nil, &u
+	}
+	return &u, nil
+}
+
 func decodeConsumedCapacityExtended(reader *cbor.Reader) (*types.ConsumedCapacity, error) {
 	consumed, err := consumeNil(reader)
 	if err != nil {
@@ -1421,14 +1514,6 @@ func decodeItemCollectionMetrics(reader *cbor.Reader, partitionKey string) (*typ
 	return &icm, nil
 }
 
-func getKeySchema(ctx context.Context, keySchemaCache *lru.Lru, table string) ([]types.AttributeDefinition, error) {
-	k, err := keySchemaCache.GetWithContext(ctx, table)
-	if err != nil {
-		return nil, err
-	}
-	keys, ok := k.([]types.AttributeDefinition)
-	if !ok {
-		return nil, &smithy.SerializationError{Err: errors.New("invalid type for keyschema")}
-	}
-	return keys, nil
+func getKeySchema(ctx context.Context, keySchemaCache *lru.Lru[string, []types.AttributeDefinition], table string) ([]types.AttributeDefinition, error) {
+	return keySchemaCache.GetWithContext(ctx, table)
 }
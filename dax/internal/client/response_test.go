@@ -0,0 +1,192 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConsumedCapacity writes cc in the format DAX uses outside of transactions.
+func writeConsumedCapacity(t *testing.T, w *cbor.Writer, table string, units, tableUnits float64, gsi map[string]float64) {
+	var buf bytes.Buffer
+	inner := cbor.NewWriter(&buf)
+	require.NoError(t, inner.WriteString(table))
+	require.NoError(t, inner.WriteFloat64(units))
+	require.NoError(t, inner.WriteFloat64(tableUnits))
+	require.NoError(t, inner.WriteMapHeader(len(gsi)))
+	for name, u := range gsi {
+		require.NoError(t, inner.WriteString(name))
+		require.NoError(t, inner.WriteFloat64(u))
+	}
+	require.NoError(t, inner.WriteNull())
+	require.NoError(t, inner.Flush())
+	require.NoError(t, w.WriteBytes(buf.Bytes()))
+}
+
+func TestDecodeConsumedCapacity(t *testing.T) {
+	for _, write := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := cbor.NewWriter(&buf)
+		writeConsumedCapacity(t, w, "table", 3, 2, map[string]float64{"gsi": 1})
+		require.NoError(t, w.Flush())
+
+		cc, err := decodeConsumedCapacity(cbor.NewReader(&buf), write)
+		require.NoError(t, err)
+		units := func(u float64) (*float64, *float64) {
+			if write {
+				return nil, aws.Float64(u)
+			}
+			return aws.Float64(u), nil
+		}
+
+		read, written := units(3)
+		assert.Equal(t, &types.ConsumedCapacity{
+			TableName:          aws.String("table"),
+			CapacityUnits:      aws.Float64(3),
+			ReadCapacityUnits:  read,
+			WriteCapacityUnits: written,
+			Table:              capacity(units, 2),
+			GlobalSecondaryIndexes: map[string]types.Capacity{
+				"gsi": *capacity(units, 1),
+			},
+		}, cc)
+	}
+}
+
+func capacity(units func(float64) (*float64, *float64), u float64) *types.Capacity {
+	read, written := units(u)
+	return &types.Capacity{CapacityUnits: aws.Float64(u), ReadCapacityUnits: read, WriteCapacityUnits: written}
+}
+
+func TestDecodeBatchWriteItemOutput_nilConsumedCapacity(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	require.NoError(t, w.WriteMapHeader(0))
+	require.NoError(t, w.WriteArrayHeader(2))
+	writeConsumedCapacity(t, w, "table", 2, 2, nil)
+	require.NoError(t, w.WriteNull())
+	require.NoError(t, w.WriteMapHeader(0))
+	require.NoError(t, w.Flush())
+
+	output, err := decodeBatchWriteItemOutput(context.Background(), cbor.NewReader(&buf), nil, nil, &dynamodb.BatchWriteItemOutput{})
+	require.NoError(t, err)
+	require.Len(t, output.ConsumedCapacity, 1)
+	assert.Equal(t, aws.Float64(2), output.ConsumedCapacity[0].WriteCapacityUnits)
+}
+
+// writeItemCollectionMetrics writes the metrics of the item collection with partition key value pk.
+func writeItemCollectionMetrics(t *testing.T, w *cbor.Writer, pk string, lower, upper float64) {
+	var buf bytes.Buffer
+	inner := cbor.NewWriter(&buf)
+	require.NoError(t, cbor.EncodeAttributeValue(&types.AttributeValueMemberS{Value: pk}, inner))
+	require.NoError(t, inner.WriteFloat64(lower))
+	require.NoError(t, inner.WriteFloat64(upper))
+	require.NoError(t, inner.Flush())
+	require.NoError(t, w.WriteBytes(buf.Bytes()))
+}
+
+func testKeySchema() *lru.Lru[string, []types.AttributeDefinition] {
+	return &lru.Lru[string, []types.AttributeDefinition]{
+		LoadFunc: func(ctx context.Context, table string) ([]types.AttributeDefinition, error) {
+			return []types.AttributeDefinition{{AttributeName: aws.String("pk")}, {AttributeName: aws.String("sk")}}, nil
+		},
+	}
+}
+
+func TestDecodeWriteOutput_itemCollectionMetrics(t *testing.T) {
+	expected := &types.ItemCollectionMetrics{
+		ItemCollectionKey:   map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}},
+		SizeEstimateRangeGB: []float64{0, 1},
+	}
+	response := func() *cbor.Reader {
+		var buf bytes.Buffer
+		w := cbor.NewWriter(&buf)
+		require.NoError(t, w.WriteMapHeader(1))
+		require.NoError(t, w.WriteInt(responseParamItemCollectionMetrics))
+		writeItemCollectionMetrics(t, w, "a", 0, 1)
+		require.NoError(t, w.Flush())
+		return cbor.NewReader(&buf)
+	}
+	ctx := context.Background()
+
+	put, err := decodePutItemOutput(ctx, response(), &dynamodb.PutItemInput{TableName: aws.String("table")}, testKeySchema(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, put.ItemCollectionMetrics)
+
+	update, err := decodeUpdateItemOutput(ctx, response(), &dynamodb.UpdateItemInput{TableName: aws.String("table")}, testKeySchema(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, update.ItemCollectionMetrics)
+
+	del, err := decodeDeleteItemOutput(ctx, response(), &dynamodb.DeleteItemInput{TableName: aws.String("table")}, testKeySchema(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, del.ItemCollectionMetrics)
+}
+
+func TestDecodeBatchWriteItemOutput_itemCollectionMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	require.NoError(t, w.WriteMapHeader(0))
+	require.NoError(t, w.WriteArrayHeader(0))
+	require.NoError(t, w.WriteMapHeader(1))
+	require.NoError(t, w.WriteString("table"))
+	require.NoError(t, w.WriteArrayHeader(3))
+	writeItemCollectionMetrics(t, w, "a", 0, 1)
+	require.NoError(t, w.WriteNull())
+	writeItemCollectionMetrics(t, w, "b", 1, 2)
+	require.NoError(t, w.Flush())
+
+	output, err := decodeBatchWriteItemOutput(context.Background(), cbor.NewReader(&buf), testKeySchema(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]types.ItemCollectionMetrics{
+		"table": {
+			{ItemCollectionKey: map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}}, SizeEstimateRangeGB: []float64{0, 1}},
+			{ItemCollectionKey: map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "b"}}, SizeEstimateRangeGB: []float64{1, 2}},
+		},
+	}, output.ItemCollectionMetrics)
+}
+
+func TestDecodeScanQueryItems_canceled(t *testing.T) {
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	require.NoError(t, w.WriteArrayHeader(3))
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, w.WriteMapHeader(1))
+		require.NoError(t, w.WriteInt(0))
+		require.NoError(t, cbor.EncodeAttributeValue(&types.AttributeValueMemberS{Value: id}, w))
+	}
+	require.NoError(t, w.Flush())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	projection := []documentPath{{elements: []documentPathElement{documentPathElementFromName("id")}}}
+	var seen int
+	_, err := decodeScanQueryItems(ctx, cbor.NewReader(&buf), "table", nil, nil, projection, func(map[string]types.AttributeValue) error {
+		seen++
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, seen, "items buffered after the cancellation are not decoded")
+}
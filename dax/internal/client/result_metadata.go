@@ -0,0 +1,111 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/gofrs/uuid"
+)
+
+// attemptsKey is the ResultMetadata key of the attempts of an operation.
+type attemptsKey struct{}
+
+// GetAttempts returns the attempts of the operation metadata is the
+// ResultMetadata of.
+func GetAttempts(metadata middleware.Metadata) ([]types.Attempt, bool) {
+	attempts, ok := metadata.Get(attemptsKey{}).([]types.Attempt)
+	return attempts, ok
+}
+
+// GetServingNode returns the address of the node which served the operation
+// metadata is the ResultMetadata of, false if its last attempt failed.
+func GetServingNode(metadata middleware.Metadata) (string, bool) {
+	attempts, _ := GetAttempts(metadata)
+	if len(attempts) == 0 || attempts[len(attempts)-1].Err != nil {
+		return "", false
+	}
+	return attempts[len(attempts)-1].Node, true
+}
+
+// MergeResultMetadata adds the attempts of src to dst, for outputs merged
+// from several operations. dst keeps its request ID, if it has one.
+func MergeResultMetadata(dst *middleware.Metadata, src middleware.Metadata) {
+	if _, ok := awsmiddleware.GetRequestIDMetadata(*dst); !ok {
+		if id, ok := awsmiddleware.GetRequestIDMetadata(src); ok {
+			awsmiddleware.SetRequestIDMetadata(dst, id)
+		}
+	}
+	if attempts, ok := GetAttempts(src); ok {
+		prev, _ := GetAttempts(*dst)
+		dst.Set(attemptsKey{}, append(prev[:len(prev):len(prev)], attempts...))
+	}
+}
+
+// operationMetadata records the attempts of an operation for the
+// ResultMetadata of its output.
+type operationMetadata struct {
+	metadata *middleware.Metadata // nil if not recorded
+	attempts []types.Attempt
+	start    time.Time
+}
+
+func newOperationMetadata(metadata *middleware.Metadata) *operationMetadata {
+	return &operationMetadata{metadata: metadata}
+}
+
+// begin starts an attempt.
+func (m *operationMetadata) begin() {
+	if m.metadata != nil {
+		m.start = time.Now()
+	}
+}
+
+// end records the attempt of client which ended with err.
+func (m *operationMetadata) end(client DaxAPI, err error) {
+	if m.metadata == nil {
+		return
+	}
+	a := types.Attempt{Err: err, Duration: time.Since(m.start)}
+	if sc, ok := client.(*SingleDaxClient); ok && sc.pool != nil {
+		a.Node = sc.pool.address
+	}
+	var failure daxError
+	if errors.As(err, &failure) {
+		a.RequestID = failure.RequestID()
+	}
+	if failure, ok := err.(daxError); ok {
+		a.Err = convertDaxError(failure)
+	}
+	m.attempts = append(m.attempts, a)
+}
+
+// finish sets the request ID and the attempts of the operation. DAX nodes do
+// not report request IDs for requests which succeed, the request ID is one
+// the client generates for the operation.
+func (m *operationMetadata) finish() {
+	if m.metadata == nil {
+		return
+	}
+	if id, err := uuid.NewV4(); err == nil {
+		awsmiddleware.SetRequestIDMetadata(m.metadata, id.String())
+	}
+	m.metadata.Set(attemptsKey{}, m.attempts)
+}
@@ -0,0 +1,99 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterDaxClient_retryRecordsAttempts(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: DefaultConfig(), cluster: cluster}
+
+	calls := 0
+	action := func(client DaxAPI, o RequestOptions) error {
+		calls++
+		if calls == 1 {
+			return newDaxRequestFailure([]int{1}, "RetryableError", "", "req-1", 500, smithy.FaultServer)
+		}
+		return nil
+	}
+	opt := RequestOptions{
+		Options: dynamodb.Options{RetryMaxAttempts: 2},
+		Retryer: DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Millisecond},
+	}
+	var metadata middleware.Metadata
+	require.NoError(t, cc.retry(context.Background(), OpGetItem, action, opt, &metadata))
+
+	attempts, ok := GetAttempts(metadata)
+	require.True(t, ok)
+	require.Len(t, attempts, 2)
+	assert.Error(t, attempts[0].Err)
+	assert.Equal(t, "req-1", attempts[0].RequestID)
+	assert.NoError(t, attempts[1].Err)
+	assert.Empty(t, attempts[1].RequestID)
+	id, ok := awsmiddleware.GetRequestIDMetadata(metadata)
+	assert.True(t, ok)
+	assert.NotEmpty(t, id)
+
+	var other middleware.Metadata
+	require.NoError(t, cc.retry(context.Background(), OpGetItem, action, opt, &other))
+	otherID, _ := awsmiddleware.GetRequestIDMetadata(other)
+	assert.NotEqual(t, id, otherID, "every operation has its own request ID")
+}
+
+func TestMergeResultMetadata(t *testing.T) {
+	var a, b, dst middleware.Metadata
+	awsmiddleware.SetRequestIDMetadata(&a, "a")
+	a.Set(attemptsKey{}, []types.Attempt{{Node: "n1"}})
+	awsmiddleware.SetRequestIDMetadata(&b, "b")
+	b.Set(attemptsKey{}, []types.Attempt{{Node: "n2"}, {Node: "n3"}})
+
+	MergeResultMetadata(&dst, a)
+	MergeResultMetadata(&dst, b)
+	id, _ := awsmiddleware.GetRequestIDMetadata(dst)
+	assert.Equal(t, "a", id)
+	attempts, _ := GetAttempts(dst)
+	assert.Equal(t, []types.Attempt{{Node: "n1"}, {Node: "n2"}, {Node: "n3"}}, attempts)
+	attempts, _ = GetAttempts(a)
+	assert.Len(t, attempts, 1, "the merged metadata is not modified")
+}
+
+func TestGetServingNode(t *testing.T) {
+	var metadata middleware.Metadata
+	_, ok := GetServingNode(metadata)
+	assert.False(t, ok)
+
+	metadata.Set(attemptsKey{}, []types.Attempt{{Node: "n1", Err: smithy.NewErrParamRequired("x")}, {Node: "n2"}})
+	node, ok := GetServingNode(metadata)
+	assert.True(t, ok)
+	assert.Equal(t, "n2", node)
+
+	metadata.Set(attemptsKey{}, []types.Attempt{{Node: "n1"}, {Node: "n2", Err: smithy.NewErrParamRequired("x")}})
+	_, ok = GetServingNode(metadata)
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// Costs of retries in a retry budget, those of the standard retryer of the
+// AWS SDK.
+const (
+	retryBudgetRetryCost     = 5
+	retryBudgetTimeoutCost   = 10
+	retryBudgetSuccessRefund = 1
+)
+
+// retryBudget caps the retries of a client, see Config.RetryBudget.
+type retryBudget struct {
+	mu       sync.Mutex
+	capacity int
+	tokens   int
+}
+
+// newRetryBudget returns the retry budget configured by cfg, nil without
+// RetryBudget.
+func newRetryBudget(cfg Config) *retryBudget {
+	if cfg.RetryBudget <= 0 {
+		return nil
+	}
+	return &retryBudget{capacity: cfg.RetryBudget, tokens: cfg.RetryBudget}
+}
+
+// take takes the cost of a retry after err from the budget and returns it,
+// false if the budget cannot afford it.
+func (b *retryBudget) take(err error) (int, bool) {
+	cost := retryBudgetRetryCost
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		cost = retryBudgetTimeoutCost
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < cost {
+		return 0, false
+	}
+	b.tokens -= cost
+	return cost, true
+}
+
+// refund returns n tokens to the budget, up to its capacity.
+func (b *retryBudget) refund(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.capacity, b.tokens+n)
+}
@@ -0,0 +1,90 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBudget(t *testing.T) {
+	assert.Nil(t, newRetryBudget(Config{}))
+	b := newRetryBudget(Config{RetryBudget: 12})
+	require.NotNil(t, b)
+
+	cost, ok := b.take(errors.New("failed"))
+	assert.True(t, ok)
+	assert.Equal(t, retryBudgetRetryCost, cost)
+	_, ok = b.take(os.ErrDeadlineExceeded)
+	assert.False(t, ok, "timeouts cost more")
+	_, ok = b.take(errors.New("failed"))
+	assert.True(t, ok)
+	_, ok = b.take(errors.New("failed"))
+	assert.False(t, ok)
+	assert.Equal(t, 2, b.tokens)
+
+	b.refund(retryBudgetRetryCost)
+	b.refund(retryBudgetRetryCost)
+	assert.Equal(t, 12, b.tokens, "refunds are capped by the capacity")
+}
+
+func TestClusterDaxClient_retryBudget(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	cluster.update([]serviceEndpoint{{hostname: "localhost", port: 8121}})
+	cc := ClusterDaxClient{config: DefaultConfig(), cluster: cluster, budget: newRetryBudget(Config{RetryBudget: 12})}
+	opt := RequestOptions{
+		Options: dynamodb.Options{RetryMaxAttempts: 10},
+		Retryer: DaxRetryer{BaseThrottleDelay: time.Millisecond, MaxBackoffDelay: time.Millisecond},
+	}
+
+	attempts := 0
+	throttled := func(client DaxAPI, o RequestOptions) error {
+		attempts++
+		return &types.ProvisionedThroughputExceededException{Message: aws.String("Throttled request")}
+	}
+	err := cc.retry(context.Background(), OpGetItem, throttled, opt, nil)
+	assert.True(t, IsThrottleError(err))
+	assert.Equal(t, 3, attempts, "the budget affords two retries")
+	assert.Equal(t, 2, cc.budget.tokens)
+
+	attempts = 0
+	err = cc.retry(context.Background(), OpGetItem, throttled, opt, nil)
+	assert.True(t, IsThrottleError(err))
+	assert.Equal(t, 1, attempts)
+
+	require.NoError(t, cc.retry(context.Background(), OpGetItem, func(DaxAPI, RequestOptions) error { return nil }, opt, nil))
+	assert.Equal(t, 3, cc.budget.tokens, "successful calls return a token")
+
+	cc.budget.tokens = 7
+	attempts = 0
+	require.NoError(t, cc.retry(context.Background(), OpGetItem, func(DaxAPI, RequestOptions) error {
+		attempts++
+		if attempts == 1 {
+			return &types.ProvisionedThroughputExceededException{Message: aws.String("Throttled request")}
+		}
+		return nil
+	}, opt, nil))
+	assert.Equal(t, 7, cc.budget.tokens, "successful retries return their cost")
+}
@@ -126,22 +126,26 @@ func appendCredentialScope(in []byte, time time.Time, region, service, terminato
 	return out
 }
 
-func appendCanonicalRequest(in []byte, method string, headers map[string]string, signedHeaders []byte, payload string) []byte {
+func appendCanonicalRequest(in []byte, method string, headers map[string]string, signedHeadersBytes []byte, payload string) []byte {
+	return appendCanonicalRequestWithHeaders(in, method, headers, signedHeaders, signedHeadersBytes, payload)
+}
+
+func appendCanonicalRequestWithHeaders(in []byte, method string, headers map[string]string, signedHeaders []string, signedHeadersBytes []byte, payload string) []byte {
 	out := append(in, method...)
 	out = append(out, '\n')
 	out = append(out, '/')
 	out = append(out, '\n')
 	// uri = ""
 	out = append(out, '\n')
-	out = appendCanonicalHeaders(out, headers)
+	out = appendCanonicalHeaders(out, headers, signedHeaders)
 	out = append(out, '\n')
-	out = append(out, signedHeaders...)
+	out = append(out, signedHeadersBytes...)
 	out = append(out, '\n')
 	out = appendSha256Hex(out, []byte(payload))
 	return out
 }
 
-func appendCanonicalHeaders(in []byte, headers map[string]string) []byte {
+func appendCanonicalHeaders(in []byte, headers map[string]string, signedHeaders []string) []byte {
 	out := in
 	for _, h := range signedHeaders {
 		out = append(out, []byte(h)...)
@@ -0,0 +1,158 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	headerRegionSet = "x-amz-region-set"
+	signVersionA    = "AWS4A"
+	signMethodA     = "AWS4-ECDSA-P256-SHA256"
+)
+
+// headers in the canonical request should be sorted by lowercase character code
+var signedHeadersA = []string{headerHost, headerDate, headerRegionSet}
+var signedHeadersABytes = []byte(strings.Join(signedHeadersA, ";"))
+
+var (
+	p256          = elliptic.P256()
+	nMinusTwoP256 = new(big.Int).Sub(p256.Params().N, big.NewInt(2))
+)
+
+func generateSigV4A(credentials aws.Credentials, hostname string, regionSet []string, payload string) (string, string, error) {
+	return generateSigV4AWithTime(credentials, hostname, regionSet, payload, time.Now().UTC())
+}
+
+// generateSigV4AWithTime signs the connection using the asymmetric SigV4A scheme.
+// Unlike SigV4 the credential scope does not contain a region, the regions the
+// signature is valid for are carried in the signed x-amz-region-set header.
+func generateSigV4AWithTime(credentials aws.Credentials, hostname string, regionSet []string, payload string, time time.Time) (string, string, error) {
+	headers := sigv4Headers(hostname, time, credentials.SessionToken)
+	headers[headerRegionSet] = strings.Join(regionSet, ",")
+
+	canonicalRequest := make([]byte, 0, 256)
+	canonicalRequest = appendCanonicalRequestWithHeaders(canonicalRequest, method, headers, signedHeadersA, signedHeadersABytes, payload)
+
+	stringToSign := make([]byte, 0, 180)
+	stringToSign = appendStringToSignA(stringToSign, time, service, signerTerminator, canonicalRequest)
+
+	key, err := sigv4aSigningKey(credentials.AccessKeyID, credentials.SecretAccessKey)
+	if err != nil {
+		return "", "", err
+	}
+	digest := sha256.Sum256(stringToSign)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", "", err
+	}
+	return string(stringToSign), hex.EncodeToString(sig), nil
+}
+
+func appendStringToSignA(in []byte, time time.Time, service, terminator string, canonicalRequest []byte) []byte {
+	out := append(in, signMethodA...)
+	out = append(out, '\n')
+	out = time.AppendFormat(out, dateTimeFormat)
+	out = append(out, '\n')
+	out = time.AppendFormat(out, dateFormat)
+	out = append(out, '/')
+	out = append(out, service...)
+	out = append(out, '/')
+	out = append(out, terminator...)
+	out = append(out, '\n')
+	out = appendSha256Hex(out, canonicalRequest)
+	return out
+}
+
+// sigv4aSigningKey deterministically derives the P-256 signing key from the access key pair.
+// See NIST SP 800-108 for the counter mode key derivation function.
+func sigv4aSigningKey(accessKey, secretKey string) (*ecdsa.PrivateKey, error) {
+	bitLen := p256.Params().BitSize
+	inputKey := append([]byte(signVersionA), secretKey...)
+	nMinusTwo := nMinusTwoP256.FillBytes(make([]byte, bitLen/8))
+
+	var kdfContext bytes.Buffer
+	d := new(big.Int)
+	for counter := 1; ; counter++ {
+		if counter > 0xFF {
+			return nil, errors.New("sigv4a: exhausted single byte external counter")
+		}
+		kdfContext.Reset()
+		kdfContext.WriteString(accessKey)
+		kdfContext.WriteByte(byte(counter))
+
+		candidate := hmacKeyDerivation(bitLen, inputKey, []byte(signMethodA), kdfContext.Bytes())
+		if constantTimeLess(candidate, nMinusTwo) {
+			d.SetBytes(candidate)
+			break
+		}
+	}
+	d.Add(d, big.NewInt(1))
+
+	priv := new(ecdsa.PrivateKey)
+	priv.PublicKey.Curve = p256
+	priv.D = d
+	priv.PublicKey.X, priv.PublicKey.Y = p256.ScalarBaseMult(d.Bytes())
+	return priv, nil
+}
+
+func hmacKeyDerivation(bitLen int, key, label, context []byte) []byte {
+	n := (bitLen/8 + sha256.Size - 1) / sha256.Size
+	bitLenBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(bitLenBytes, uint32(bitLen))
+
+	var out []byte
+	i := make([]byte, 4)
+	for idx := 1; idx <= n; idx++ {
+		binary.BigEndian.PutUint32(i, uint32(idx))
+		h := hmac.New(sha256.New, key)
+		h.Write(i)
+		h.Write(label)
+		h.Write([]byte{0x00})
+		h.Write(context)
+		h.Write(bitLenBytes)
+		out = h.Sum(out)
+	}
+	return out[:bitLen/8]
+}
+
+// constantTimeLess reports whether big endian a < b, both a and b must have the same length.
+func constantTimeLess(a, b []byte) bool {
+	lt, gt := 0, 0
+	for i := range a {
+		x, y := int(a[i]), int(b[i])
+		// only the first differing byte decides the result
+		undecided := 1 - (lt | gt)
+		lt |= undecided & subtle.ConstantTimeLessOrEq(x+1, y)
+		gt |= undecided & subtle.ConstantTimeLessOrEq(y+1, x)
+	}
+	return lt == 1
+}
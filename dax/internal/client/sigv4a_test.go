@@ -0,0 +1,132 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestSigV4ASigningKey(t *testing.T) {
+	key, err := sigv4aSigningKey("AKISORANDOMAASORANDOM", "q+jcrXGc+0zWN6uzclKVhvMmUsIfRPa4rlRandom")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expectedX, _ := new(big.Int).SetString("15D242CEEBF8D8169FD6A8B5A746C41140414C3B07579038DA06AF89190FFFCB", 16)
+	expectedY, _ := new(big.Int).SetString("0515242CEDD82E94799482E4C0514B505AFCCF2C0C98D6A553BF539F424C5EC0", 16)
+	if key.PublicKey.X.Cmp(expectedX) != 0 {
+		t.Errorf("expected X %X, got %X", expectedX, key.PublicKey.X)
+	}
+	if key.PublicKey.Y.Cmp(expectedY) != 0 {
+		t.Errorf("expected Y %X, got %X", expectedY, key.PublicKey.Y)
+	}
+}
+
+func TestSigV4A(t *testing.T) {
+	creds := aws.Credentials{AccessKeyID: "ak", SecretAccessKey: "sk", SessionToken: "st"}
+	endpoint := "dynamodb.us-east-1.amazonaws.com"
+	payload := "payload"
+	time := time.Unix(1519755552, 0).UTC()
+
+	stringToSign, signature, err := generateSigV4AWithTime(creds, endpoint, []string{"us-east-1", "us-west-2"}, payload, time)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	canonicalRequest := fmt.Sprintf("POST\n/\n\nhost:%s\nx-amz-date:20180227T181912Z\nx-amz-region-set:us-east-1,us-west-2\n\nhost;x-amz-date;x-amz-region-set\n%x",
+		endpoint, sha256.Sum256([]byte(payload)))
+	expected := fmt.Sprintf("%s\n%s\n%s\n%x", "AWS4-ECDSA-P256-SHA256", "20180227T181912Z", "20180227/dax/aws4_request", sha256.Sum256([]byte(canonicalRequest)))
+	if stringToSign != expected {
+		t.Errorf("expected %v, got %v", expected, stringToSign)
+	}
+
+	key, err := sigv4aSigningKey(creds.AccessKeyID, creds.SecretAccessKey)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	digest := sha256.Sum256([]byte(stringToSign))
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Errorf("signature %v does not verify", signature)
+	}
+}
+
+func TestSingleDaxClient_signAlgorithm(t *testing.T) {
+	creds := aws.Credentials{AccessKeyID: "ak", SecretAccessKey: "sk"}
+	now := time.Unix(1519755552, 0).UTC()
+
+	cfg := connConfig{signingAlgorithm: types.SigningAlgorithmSigV4A, signingRegionSet: []string{"*"}}
+	cli, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer cli.Close()
+	stringToSign, _, err := cli.sign(creds, now)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expected := "20180227/dax/aws4_request"; !containsLine(stringToSign, expected) {
+		t.Errorf("expected SigV4A credential scope %v in %v", expected, stringToSign)
+	}
+
+	cli, err = newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer cli.Close()
+	stringToSign, _, err = cli.sign(creds, now)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expected := "20180227/us-west-2/dax/aws4_request"; !containsLine(stringToSign, expected) {
+		t.Errorf("expected SigV4 credential scope %v in %v", expected, stringToSign)
+	}
+}
+
+func TestConfig_validateSigningAlgorithm(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.SigningAlgorithm = "sigv5"
+	if err := cfg.validate(); err == nil {
+		t.Errorf("expected error for invalid signing algorithm")
+	}
+	cfg.SigningAlgorithm = types.SigningAlgorithmSigV4A
+	if err := cfg.validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func containsLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
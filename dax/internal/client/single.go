@@ -16,10 +16,11 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
@@ -41,6 +42,12 @@ const (
 	tubeAuthWindowScalar = 0.75
 
 	emptyAttributeListId = 1
+
+	drainPollInterval = 10 * time.Millisecond
+
+	// idlePingTimeout bounds validating an idle connection, see
+	// Config.IdleConnectionValidation.
+	idlePingTimeout = time.Second
 )
 
 const (
@@ -64,20 +71,38 @@ const (
 const (
 	keySchemaLruCacheSize     = 100
 	attributeListLruCacheSize = 1000
+
+	// With Config.MetadataCacheTTL, metadata cache entries are refreshed
+	// once metadataCacheSoftTTL of it passed, and both are jittered by
+	// metadataCacheTTLJitter.
+	metadataCacheSoftTTL   = 0.75
+	metadataCacheTTLJitter = 0.1
 )
 
 type SingleDaxClient struct {
 	region             string
+	regionSet          []string
+	useSigV4A          bool
+	userAgent          string
 	credentials        aws.CredentialsProvider
 	tubeAuthWindowSecs int64
 	executor           *taskExecutor
 
 	pool              *tubePool
-	keySchema         *lru.Lru
-	attrNamesListToId *lru.Lru
-	attrListIdToNames *lru.Lru
+	keySchema         *lru.Lru[string, []types.AttributeDefinition]
+	attrNamesListToId *lru.Lru[lru.StringsKey, int64]
+	attrListIdToNames *lru.Lru[int64, []string]
 
 	healthStatus HealthStatus
+	inFlight     int64 // number of requests currently executing, accessed atomically
+	wire         wireStats
+
+	onDuplicateKey func(key string) error
+	onMissingKey   func(table, key string) error
+	attributeLists *attributeListTracker // shared by the clients of a cluster
+	decodeLimits   cbor.Limits
+	interceptors   interceptors
+	idleValidation time.Duration
 
 	daxSdkMetrics *daxSdkMetrics
 }
@@ -111,8 +136,15 @@ func newSingleClientWithOptions(
 
 	po.dialContext = dialContextFn
 
+	if connConfigData.userAgent == "" {
+		connConfigData.userAgent = userAgent
+	}
+
 	client := &SingleDaxClient{
 		region:             region,
+		regionSet:          connConfigData.signingRegionSet,
+		useSigV4A:          connConfigData.signingAlgorithm.IsSigV4A(),
+		userAgent:          connConfigData.userAgent,
 		credentials:        credentials,
 		tubeAuthWindowSecs: authTtlSecs * tubeAuthWindowScalar,
 		pool:               newTubePoolWithOptions(endpoint, po, connConfigData, sdkMetrics),
@@ -120,14 +152,24 @@ func newSingleClientWithOptions(
 		healthStatus:       newHealthStatus(endpoint, routeListener),
 		daxSdkMetrics:      sdkMetrics,
 	}
+	client.onDuplicateKey = client.duplicateKeyHandler(connConfigData.strictResponseDecoding)
+	if connConfigData.validateResponseKeys {
+		client.onMissingKey = client.missingKeyHandler(connConfigData.strictResponseDecoding)
+	}
+	client.attributeLists = connConfigData.attributeLists
+	client.decodeLimits = connConfigData.decodeLimits
+	client.interceptors = connConfigData.interceptors
+	client.idleValidation = connConfigData.idleValidation
 
-	client.keySchema = &lru.Lru{
+	client.keySchema = &lru.Lru[string, []types.AttributeDefinition]{
 		MaxEntries: keySchemaLruCacheSize,
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
-			table, ok := key.(string)
-			if !ok {
-				return nil, &smithy.SerializationError{Err: errors.New("unexpected type for table name")}
-			}
+		MaxBytes:   connConfigData.metadataCacheMaxBytes,
+		TTL:        connConfigData.metadataCacheTTL,
+		SoftTTL:    time.Duration(metadataCacheSoftTTL * float64(connConfigData.metadataCacheTTL)),
+		TTLJitter:  metadataCacheTTLJitter,
+		OnEvent:    cacheEvents(client.daxSdkMetrics, "key_schema"),
+		SizeFunc:   keySchemaSize,
+		LoadFunc: func(ctx context.Context, table string) ([]types.AttributeDefinition, error) {
 			if ctx == nil {
 				ctx = context.Background()
 			}
@@ -135,37 +177,38 @@ func newSingleClientWithOptions(
 		},
 	}
 
-	client.attrNamesListToId = &lru.Lru{
+	client.attrNamesListToId = &lru.Lru[lru.StringsKey, int64]{
 		MaxEntries: attributeListLruCacheSize,
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
-			attrNames, ok := key.([]string)
-			if !ok {
-				return nil, &smithy.SerializationError{Err: errors.New("unexpected type for attribute list")}
-			}
+		MaxBytes:   connConfigData.metadataCacheMaxBytes,
+		TTL:        connConfigData.metadataCacheTTL,
+		SoftTTL:    time.Duration(metadataCacheSoftTTL * float64(connConfigData.metadataCacheTTL)),
+		TTLJitter:  metadataCacheTTLJitter,
+		OnEvent:    cacheEvents(client.daxSdkMetrics, "attribute_list_ids"),
+		SizeFunc: func(key lru.StringsKey, _ int64) int64 {
+			return cacheEntryOverhead + int64(key.Size())
+		},
+		LoadFunc: func(ctx context.Context, key lru.StringsKey) (int64, error) {
 			if ctx == nil {
 				ctx = context.Background()
 			}
-			return client.defineAttributeListId(ctx, attrNames)
-		},
-		KeyMarshaller: func(key lru.Key) lru.Key {
-			var buf bytes.Buffer
-			w := cbor.NewWriter(&buf)
-			defer w.Close()
-			for _, v := range key.([]string) {
-				w.WriteString(v)
+			names := key.Strings()
+			id, err := client.defineAttributeListId(ctx, names)
+			if err == nil && client.attributeLists != nil {
+				client.attributeLists.observe(ctx, names)
 			}
-			w.Flush()
-			return string(buf.Bytes())
+			return id, err
 		},
 	}
 
-	client.attrListIdToNames = &lru.Lru{
+	client.attrListIdToNames = &lru.Lru[int64, []string]{
 		MaxEntries: attributeListLruCacheSize,
-		LoadFunc: func(ctx context.Context, key lru.Key) (interface{}, error) {
-			id, ok := key.(int64)
-			if !ok {
-				return nil, &smithy.SerializationError{Err: errors.New("unexpected type for attribute list id")}
-			}
+		MaxBytes:   connConfigData.metadataCacheMaxBytes,
+		TTL:        connConfigData.metadataCacheTTL,
+		SoftTTL:    time.Duration(metadataCacheSoftTTL * float64(connConfigData.metadataCacheTTL)),
+		TTLJitter:  metadataCacheTTLJitter,
+		OnEvent:    cacheEvents(client.daxSdkMetrics, "attribute_lists"),
+		SizeFunc:   attributeNamesSize,
+		LoadFunc: func(ctx context.Context, id int64) ([]string, error) {
 			if ctx == nil {
 				ctx = context.Background()
 			}
@@ -173,9 +216,57 @@ func newSingleClientWithOptions(
 		},
 	}
 
+	if connConfigData.standbyConnections > 0 {
+		client.keepStandby(connConfigData.standbyConnections)
+	}
+
 	return client, nil
 }
 
+// cacheEntryOverhead approximates the memory of a metadata cache entry
+// besides its strings: the entry, its map slot and slice headers.
+const cacheEntryOverhead = 128
+
+func keySchemaSize(table string, keys []types.AttributeDefinition) int64 {
+	n := cacheEntryOverhead + len(table)
+	for _, k := range keys {
+		n += 48 + len(aws.ToString(k.AttributeName)) + len(k.AttributeType)
+	}
+	return int64(n)
+}
+
+func attributeNamesSize(_ int64, names []string) int64 {
+	n := cacheEntryOverhead
+	for _, name := range names {
+		n += 16 + len(name)
+	}
+	return int64(n)
+}
+
+var cacheEventMetrics = [...]string{
+	lru.EventHit:       daxCacheHits,
+	lru.EventMiss:      daxCacheMisses,
+	lru.EventEviction:  daxCacheEvictions,
+	lru.EventLoadError: daxCacheLoadErrors,
+}
+
+// cacheEvents returns the lru.Lru OnEvent counting the events of the named
+// client-local cache.
+func cacheEvents(m *daxSdkMetrics, cache string) func(ctx context.Context, e lru.Event) {
+	return func(ctx context.Context, e lru.Event) {
+		c := m.counterFor(cacheEventMetrics[e])
+		if c == nil {
+			return
+		}
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		c.Add(ctx, 1, func(o *metrics.RecordMetricOptions) {
+			o.Properties.Set("cache", cache)
+		})
+	}
+}
+
 func (client *SingleDaxClient) Close() error {
 	client.executor.stopAll()
 	if client.pool != nil {
@@ -184,6 +275,10 @@ func (client *SingleDaxClient) Close() error {
 	return nil
 }
 
+func (client *SingleDaxClient) drain(deadline time.Time) bool {
+	return drainCounter(&client.inFlight, deadline)
+}
+
 func (client *SingleDaxClient) startHealthChecks(cc *cluster, host hostPort) {
 	cc.debugLog("Starting health checks for :: " + host.host)
 	client.executor.start(cc.config.ClientHealthCheckInterval, func() error {
@@ -344,9 +439,10 @@ func (client *SingleDaxClient) ScanWithOptions(ctx context.Context, input *dynam
 	encoder := func(writer *cbor.Writer) error {
 		return encodeScanInput(ctx, input, client.keySchema, writer)
 	}
+	streamItems(&opt)
 	var err error
 	decoder := func(reader *cbor.Reader) error {
-		output, err = decodeScanOutput(ctx, reader, input, client.keySchema, client.attrListIdToNames, output)
+		output, err = decodeScanOutput(ctx, reader, input, client.keySchema, client.attrListIdToNames, output, opt.OnItem)
 		return err
 	}
 	if err = client.executeWithRetries(ctx, OpScan, opt, encoder, decoder); err != nil {
@@ -361,9 +457,10 @@ func (client *SingleDaxClient) QueryWithOptions(ctx context.Context, input *dyna
 	encoder := func(writer *cbor.Writer) error {
 		return encodeQueryInput(ctx, input, client.keySchema, writer)
 	}
+	streamItems(&opt)
 	var err error
 	decoder := func(reader *cbor.Reader) error {
-		output, err = decodeQueryOutput(ctx, reader, input, client.keySchema, client.attrListIdToNames, output)
+		output, err = decodeQueryOutput(ctx, reader, input, client.keySchema, client.attrListIdToNames, output, opt.OnItem)
 		return err
 	}
 	if err = client.executeWithRetries(ctx, OpQuery, opt, encoder, decoder); err != nil {
@@ -465,6 +562,9 @@ func (client *SingleDaxClient) newContext(ctx context.Context, o RequestOptions)
 }
 
 func (client *SingleDaxClient) executeWithRetries(ctx context.Context, op string, o RequestOptions, encoder func(writer *cbor.Writer) error, decoder func(reader *cbor.Reader) error) error {
+	atomic.AddInt64(&client.inFlight, 1)
+	defer atomic.AddInt64(&client.inFlight, -1)
+
 	ctx = client.newContext(ctx, o)
 
 	var err error
@@ -483,9 +583,18 @@ func (client *SingleDaxClient) executeWithRetries(ctx context.Context, op string
 		if errors.Is(err, context.Canceled) {
 			return &smithy.CanceledError{Err: err}
 		}
+		if finished, ferr := o.stream.finished(err); finished {
+			if ferr != err {
+				return ferr
+			}
+			break
+		}
 
 		if i != attempts {
 			delay := o.RetryDelay
+			if exceedsDeadline(ctx, delay) {
+				break
+			}
 			if sleepErr := SleepWithContext(ctx, op, delay); sleepErr != nil {
 				return &smithy.OperationError{Err: sleepErr, ServiceID: service, OperationName: op}
 			}
@@ -504,6 +613,11 @@ func (client *SingleDaxClient) executeWithContext(ctx context.Context, op string
 
 	defer func() {
 		histogramMicrosecondsInt64(ctx, client.daxSdkMetrics, fmt.Sprintf(daxOpNameLatencyUs, op), startTime)
+		if opt.Passthrough {
+			histogramMicrosecondsInt64(ctx, client.daxSdkMetrics, daxReadPassthroughLatencyUs, startTime)
+		} else if isReadOp(op) {
+			histogramMicrosecondsInt64(ctx, client.daxSdkMetrics, daxReadCacheableLatencyUs, startTime)
+		}
 
 		if out != nil {
 			countMetricInt64(ctx, client.daxSdkMetrics, fmt.Sprintf(daxOpNameFailure, op), 1)
@@ -514,7 +628,12 @@ func (client *SingleDaxClient) executeWithContext(ctx context.Context, op string
 		countMetricInt64(ctx, client.daxSdkMetrics, fmt.Sprintf(daxOpNameSuccess, op), 1)
 	}()
 
-	t, err := client.pool.getWithContext(ctx, client.isHighPriority(op), opt)
+	ic := client.intercept(op)
+	defer func() {
+		ic.done(ctx, out)
+	}()
+
+	t, err := client.getTube(ctx, op, opt)
 	if err != nil {
 		return err
 	}
@@ -531,13 +650,25 @@ func (client *SingleDaxClient) executeWithContext(ctx context.Context, op string
 		return err
 	}
 
+	// Interrupt blocking reads and writes as soon as the context is done,
+	// the deadline set above does not cover cancellation.
+	stopInterrupt := context.AfterFunc(ctx, func() {
+		t.SetDeadline(time.Unix(1, 0))
+	})
+	defer stopInterrupt()
+
 	if err = client.auth(ctx, t); err != nil {
 		// Auth method writes in the tube and
 		// it is not guaranteed that it will be drained completely on error
 		client.pool.closeTube(t)
-		return err
+		return interruptedError(ctx, stopInterrupt, err)
 	}
 
+	stopWireDump := startWireDump(t, op, opt)
+	defer stopWireDump()
+
+	ic.writing(t)
+	wt := client.tally(t)
 	writer := t.CborWriter()
 	if err = encoder(writer); err != nil {
 		// Validation errors will cause connection to be closed as there is no guarantee
@@ -547,35 +678,91 @@ func (client *SingleDaxClient) executeWithContext(ctx context.Context, op string
 	}
 
 	// actual request is sent here
-	if err := writer.Flush(); err != nil {
+	err = writer.Flush()
+	wt.sent(ctx, err == nil)
+	if err != nil {
 		client.pool.closeTube(t)
 
-		return err
+		return interruptedError(ctx, stopInterrupt, err)
 	}
+	ic.written(ctx)
 
 	reader := t.CborReader()
+	reader.SetDuplicateKeyHandler(client.onDuplicateKey)
+	reader.SetMissingKeyHandler(client.onMissingKey)
+	reader.SetLimits(client.decodeLimits)
 	ex, err := decodeError(reader)
 
 	if err != nil { // decode or network error - doesn't guarantee completely drained tube
+		wt.received(ctx, false)
 		client.pool.closeTube(t)
-		return err
+		return interruptedError(ctx, stopInterrupt, err)
 	}
 	if ex != nil { // user or server error
+		wt.received(ctx, true)
+		stopWireDump()
+		if !stopInterrupt() {
+			// the tube was interrupted and can't be reused
+			client.pool.closeTube(t)
+			return ex
+		}
 		client.recycleTube(t, ex)
 		return ex
 	}
 
 	err = decoder(reader)
-	if err != nil {
-		// we are not able to completely drain tube
+	wt.received(ctx, err == nil)
+	stopWireDump()
+	if err != nil || !stopInterrupt() {
+		// we are not able to completely drain tube, or it was interrupted
 		client.pool.closeTube(t)
 	} else {
 		client.pool.put(t)
 	}
 
+	if err != nil {
+		return interruptedError(ctx, stopInterrupt, err)
+	}
+	return nil
+}
+
+// interruptedError returns context.Canceled instead of err if err is the
+// timeout of a read or write interrupted because ctx was canceled, so that
+// callers can tell cancellation apart from a slow node.
+func interruptedError(ctx context.Context, stopInterrupt func() bool, err error) error {
+	if !stopInterrupt() && errors.Is(err, os.ErrDeadlineExceeded) && errors.Is(ctx.Err(), context.Canceled) {
+		return ctx.Err()
+	}
 	return err
 }
 
+// duplicateKeyHandler returns the handler for attribute names repeated within
+// a response. The later value is kept and counted, unless strict is set and
+// the response is rejected.
+func (client *SingleDaxClient) duplicateKeyHandler(strict bool) func(key string) error {
+	return func(key string) error {
+		countMetricInt64(context.Background(), client.daxSdkMetrics, daxResponseDuplicateKeys, 1)
+		if strict {
+			return &smithy.DeserializationError{Err: &cbor.DuplicateKeyError{Key: key}}
+		}
+		return nil
+	}
+}
+
+// missingKeyHandler returns the handler for response keys lacking a key
+// attribute of their table, a sign of a response meant for another request or
+// decoded from a wrong offset. The item is kept and counted, unless strict is
+// set and the response is rejected.
+func (client *SingleDaxClient) missingKeyHandler(strict bool) func(table, key string) error {
+	return func(table, key string) error {
+		countMetricInt64(context.Background(), client.daxSdkMetrics, daxResponseKeyMismatches, 1)
+		if strict {
+			return &smithy.DeserializationError{Err: &ResponseKeyError{Table: table, Attribute: key}}
+		}
+		return nil
+	}
+}
+
 func (client *SingleDaxClient) isHighPriority(op string) bool {
 	switch op {
 	case opDefineAttributeListId, opDefineAttributeList, opDefineKeySchema:
@@ -585,6 +772,61 @@ func (client *SingleDaxClient) isHighPriority(op string) bool {
 	}
 }
 
+// getTube gets a tube for a request of op from the pool, replacing tubes
+// which were idle for longer than Config.IdleConnectionValidation and do not
+// answer a ping.
+func (client *SingleDaxClient) getTube(ctx context.Context, op string, opt RequestOptions) (tube, error) {
+	for {
+		t, err := client.pool.getWithContext(ctx, client.isHighPriority(op), opt)
+		if err != nil || client.idleValidation <= 0 {
+			return t, err
+		}
+		it, ok := t.(idleTracker)
+		if !ok || it.idleSince().IsZero() || time.Since(it.idleSince()) <= client.idleValidation {
+			return t, nil
+		}
+		err = client.ping(ctx, t)
+		if err == nil {
+			return t, nil
+		}
+		client.pool.closeTube(t)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		client.pool.debugLog(opt, "Closed idle connection to %s failing validation: %s", client.pool.address, err)
+	}
+}
+
+// ping sends an endpoints request on t, which verifies that the connection
+// still reaches the node. t is drained if ping succeeds.
+func (client *SingleDaxClient) ping(ctx context.Context, t tube) error {
+	ctx, cancel := context.WithTimeout(ctx, idlePingTimeout)
+	defer cancel()
+	if err := client.pool.setDeadline(ctx, t); err != nil {
+		return err
+	}
+	if err := client.auth(ctx, t); err != nil {
+		return err
+	}
+	writer := t.CborWriter()
+	if err := encodeEndpointsInput(writer); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	reader := t.CborReader()
+	ex, err := decodeError(reader)
+	if err != nil {
+		return err
+	}
+	if ex != nil {
+		return ex
+	}
+	_, err = decodeEndpointsOutput(reader)
+	return err
+}
+
 func (client *SingleDaxClient) recycleTube(t tube, err error) {
 	if t == nil {
 		return
@@ -625,12 +867,17 @@ func (client *SingleDaxClient) auth(ctx context.Context, t tube) error {
 		return err
 	}
 
+	// Rotated credentials are authenticated again on the same connection,
+	// including session tokens renewed for the same access key.
 	now := time.Now().UTC()
-	if t.CompareAndSwapAuthID(creds.AccessKeyID) || t.AuthExpiryUnix() <= now.Unix() {
-		stringToSign, signature := generateSigV4WithTime(creds, daxAddress, client.region, "", now)
+	if t.CompareAndSwapAuthID(creds.AccessKeyID+"/"+creds.SessionToken) || t.AuthExpiryUnix() <= now.Unix() {
+		stringToSign, signature, err := client.sign(creds, now)
+		if err != nil {
+			return err
+		}
 		writer := t.CborWriter()
 
-		if err := encodeAuthInput(creds.AccessKeyID, creds.SessionToken, stringToSign, signature, userAgent, writer); err != nil {
+		if err := encodeAuthInput(creds.AccessKeyID, creds.SessionToken, stringToSign, signature, client.userAgent, writer); err != nil {
 			return err
 		}
 
@@ -638,12 +885,28 @@ func (client *SingleDaxClient) auth(ctx context.Context, t tube) error {
 			return err
 		}
 
-		t.SetAuthExpiryUnix(now.Unix() + client.tubeAuthWindowSecs)
+		expiry := now.Unix() + client.tubeAuthWindowSecs
+		if creds.CanExpire && creds.Expires.Unix() < expiry {
+			expiry = creds.Expires.Unix()
+		}
+		t.SetAuthExpiryUnix(expiry)
 	}
 
 	return nil
 }
 
+func (client *SingleDaxClient) sign(creds aws.Credentials, now time.Time) (string, string, error) {
+	if client.useSigV4A {
+		regionSet := client.regionSet
+		if len(regionSet) == 0 {
+			regionSet = []string{client.region}
+		}
+		return generateSigV4AWithTime(creds, daxAddress, regionSet, "", now)
+	}
+	stringToSign, signature := generateSigV4WithTime(creds, daxAddress, client.region, "", now)
+	return stringToSign, signature, nil
+}
+
 func (client *SingleDaxClient) reapIdleConnections() {
 	client.pool.reapIdleConnections()
 }
@@ -16,9 +16,11 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"reflect"
 	"runtime"
@@ -27,7 +29,10 @@ import (
 	"time"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/lru"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -49,7 +54,7 @@ func TestExecuteErrorHandling(t *testing.T) {
 			&mockConn{we: errors.New("io")},
 			nil,
 			nil,
-			errors.New("io"),
+			&connectError{address: ":9121", err: errors.New("io")},
 			map[string]int{"Write": 1, "Close": 1},
 		},
 		{ // encoding error, discard tube
@@ -572,6 +577,40 @@ func (m *mockConn) SetWriteDeadline(t time.Time) error {
 
 // Test daxRequestFailure where authError() returns true
 // Test daxTransactionCanceledFailure where authError() returns true
+type rotatingCredentialProvider struct {
+	creds aws.Credentials
+}
+
+func (p *rotatingCredentialProvider) Retrieve(_ context.Context) (aws.Credentials, error) {
+	return p.creds, nil
+}
+
+func TestSingleClient_authRotatedCredentials(t *testing.T) {
+	creds := &rotatingCredentialProvider{creds: aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret", SessionToken: "token1"}}
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", creds, 1, nil, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	conn := &mockConn{}
+	tube, err := newTube(conn, 0)
+	require.NoError(t, err)
+	writes := func() int { return conn.cc["Write"] }
+	handshake := writes()
+
+	require.NoError(t, client.auth(context.Background(), tube))
+	require.NoError(t, client.auth(context.Background(), tube))
+	assert.Equal(t, handshake+1, writes())
+
+	creds.creds.SessionToken = "token2"
+	require.NoError(t, client.auth(context.Background(), tube))
+	assert.Equal(t, handshake+2, writes(), "a renewed session token is authenticated on the same connection")
+
+	expires := time.Now().Add(time.Minute)
+	creds.creds = aws.Credentials{AccessKeyID: "id2", SecretAccessKey: "secret", CanExpire: true, Expires: expires}
+	require.NoError(t, client.auth(context.Background(), tube))
+	assert.Equal(t, handshake+3, writes())
+	assert.Equal(t, expires.Unix(), tube.AuthExpiryUnix(), "the connection is authenticated again before the credentials expire")
+}
+
 // Encapsulate both cases when the (mocked) tube session matches and don't match the pool's session,
 // as this impacts the tubepool.put method control flow, not recycleTube's
 func TestRecycleTube(t *testing.T) {
@@ -795,3 +834,316 @@ func TestRecycleTube_NonDaxErrors(t *testing.T) {
 	client.recycleTube(mockedTube, *err)
 	mockedTube.AssertNotCalled(t, "SetAuthExpiryUnix")
 }
+
+func TestSingleClient_drain(t *testing.T) {
+	release := make(chan struct{})
+	dialed := make(chan struct{}, 1)
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		dialed <- struct{}{}
+		<-release
+		return nil, errors.New("dial failed")
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer client.Close()
+
+	if !client.drain(time.Now()) {
+		t.Error("expected idle client to drain immediately")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		opt := RequestOptions{}
+		client.executeWithRetries(context.Background(), OpGetItem, opt, func(writer *cbor.Writer) error { return nil }, func(reader *cbor.Reader) error { return nil })
+	}()
+	<-dialed
+
+	if client.drain(time.Now().Add(20 * time.Millisecond)) {
+		t.Error("expected drain to time out with a request in flight")
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	if !client.drain(time.Now().Add(5 * time.Second)) {
+		t.Error("expected drain to complete once the request finished")
+	}
+	<-done
+}
+
+func TestSingleClient_idleConnectionValidation(t *testing.T) {
+	cfg := unEncryptedConnConfig
+	cfg.idleValidation = time.Millisecond
+	stale := &mockConn{rd: []byte{cbor.Array + 0}}
+	alive := &mockConn{rd: []byte{cbor.Array + 0, cbor.Array + 0, cbor.Array + 0, cbor.Array + 0}}
+	conns := []*mockConn{stale, alive}
+	client, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		c := conns[0]
+		conns = conns[1:]
+		return c, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.pool.closeTubeImmediately = true
+	encoder := func(writer *cbor.Writer) error { return writer.WriteString("abc") }
+	decoder := func(reader *cbor.Reader) error { return nil }
+
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	stale.re = io.EOF
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	assert.Equal(t, 1, stale.cc["Close"], "the stale connection failed the ping")
+	assert.Empty(t, conns)
+
+	// The replacement answers the ping and is reused.
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	assert.Zero(t, alive.cc["Close"])
+	assert.Empty(t, alive.rd)
+}
+
+func TestExecuteInterruptedByContextCancel(t *testing.T) {
+	ours, theirs := net.Pipe()
+	defer theirs.Close()
+	// Consume the request but never respond
+	go io.Copy(io.Discard, theirs)
+
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return ours, nil
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	writer := func(writer *cbor.Writer) error { return nil }
+	reader := func(reader *cbor.Reader) error { return nil }
+	err = client.executeWithContext(ctx, OpGetItem, writer, reader, RequestOptions{})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected cancellation to interrupt the read, took %v", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestExecuteReadLatencyMetrics(t *testing.T) {
+	mp := &testMeterProvider{}
+	om, _ := buildDaxSdkMetrics(mp)
+	writer := func(writer *cbor.Writer) error { return nil }
+	reader := func(reader *cbor.Reader) error { return nil }
+	for _, c := range []struct {
+		op          string
+		passthrough bool
+	}{{OpGetItem, false}, {OpQuery, true}, {OpTransactGetItems, true}, {OpPutItem, false}} {
+		client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+			return &mockConn{rd: []byte{cbor.Array + 0}}, nil
+		}, nil, om)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := client.executeWithContext(context.Background(), c.op, writer, reader, RequestOptions{Passthrough: c.passthrough}); err != nil {
+			t.Fatalf("%s: unexpected error %v", c.op, err)
+		}
+		client.Close()
+	}
+
+	meter := mp.meters[daxMeterScope].(*testMeter)
+	assert.Len(t, meter.i64s[daxReadCacheableLatencyUs].data, 1)
+	assert.Len(t, meter.i64s[daxReadPassthroughLatencyUs].data, 2)
+}
+
+func TestRetryFailsFastWhenDelayExceedsDeadline(t *testing.T) {
+	client, clientErr := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{rd: []byte{cbor.Array + 0}}, nil
+	}, nil, nil)
+	if clientErr != nil {
+		t.Fatalf("unexpected error %v", clientErr)
+	}
+	defer client.Close()
+	client.pool.closeTubeImmediately = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	requestOptions := RequestOptions{RetryDelay: time.Minute}
+	requestOptions.RetryMaxAttempts = 3
+	calls := 0
+	writer := func(writer *cbor.Writer) error { return nil }
+	reader := func(reader *cbor.Reader) error {
+		calls++
+		return newDaxRequestFailure([]int{2}, ErrCodeInternalServerError, "", "", 500, smithy.FaultServer)
+	}
+
+	start := time.Now()
+	err := client.executeWithRetries(ctx, OpGetItem, requestOptions, writer, reader)
+	if _, ok := err.(*daxRequestFailure); !ok {
+		t.Fatalf("expected last request error, got %T %v", err, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to fail fast, took %v", elapsed)
+	}
+}
+
+func TestSingleClient_duplicateKeyHandler(t *testing.T) {
+	mp := &testMeterProvider{}
+	sdkMetrics, err := buildDaxSdkMetrics(mp)
+	require.NoError(t, err)
+
+	for _, strict := range []bool{false, true} {
+		cfg := connConfig{strictResponseDecoding: strict}
+		client, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, defaultDialer.DialContext, nil, sdkMetrics)
+		require.NoError(t, err)
+
+		item := map[string]ddbtypes.AttributeValue{"id": &ddbtypes.AttributeValueMemberS{Value: "old"}}
+		key := map[string]ddbtypes.AttributeValue{"id": &ddbtypes.AttributeValueMemberS{Value: "key"}}
+		r := cbor.NewReader(strings.NewReader(""))
+		r.SetDuplicateKeyHandler(client.onDuplicateKey)
+		err = mergeKey(r, item, key)
+		if strict {
+			var dupErr *cbor.DuplicateKeyError
+			require.ErrorAs(t, err, &dupErr)
+			assert.Equal(t, "id", dupErr.Key)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, key, item)
+		}
+		client.Close()
+	}
+
+	tm := mp.meters[daxMeterScope].(*testMeter)
+	assert.Equal(t, []int64{2}, tm.i64s[daxResponseDuplicateKeys].data)
+}
+
+func TestSingleClient_missingKeyHandler(t *testing.T) {
+	mp := &testMeterProvider{}
+	sdkMetrics, err := buildDaxSdkMetrics(mp)
+	require.NoError(t, err)
+
+	keydef := []ddbtypes.AttributeDefinition{{AttributeName: aws.String("id"), AttributeType: ddbtypes.ScalarAttributeTypeS}}
+	keySchema := &lru.Lru[string, []ddbtypes.AttributeDefinition]{
+		LoadFunc: func(ctx context.Context, table string) ([]ddbtypes.AttributeDefinition, error) {
+			return keydef, nil
+		},
+	}
+	// A Scan page of one item with its key and one whose key decodes as nil.
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	require.NoError(t, w.WriteArrayHeader(2))
+	require.NoError(t, w.WriteArrayHeader(2))
+	require.NoError(t, cbor.EncodeItemKey(map[string]ddbtypes.AttributeValue{"id": &ddbtypes.AttributeValueMemberS{Value: "1"}}, keydef, w))
+	require.NoError(t, w.WriteMapHeader(0))
+	require.NoError(t, w.WriteArrayHeader(2))
+	require.NoError(t, w.WriteNull())
+	require.NoError(t, w.WriteMapHeader(0))
+	require.NoError(t, w.Flush())
+	page := buf.Bytes()
+
+	for _, tc := range []struct {
+		validate, strict bool
+	}{{false, false}, {true, false}, {true, true}} {
+		cfg := connConfig{validateResponseKeys: tc.validate, strictResponseDecoding: tc.strict}
+		client, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, defaultDialer.DialContext, nil, sdkMetrics)
+		require.NoError(t, err)
+
+		r := cbor.NewReader(bytes.NewReader(page))
+		r.SetMissingKeyHandler(client.onMissingKey)
+		items, err := decodeScanQueryItems(context.Background(), r, "table", keySchema, nil, nil, nil)
+		if tc.strict {
+			var keyErr *ResponseKeyError
+			require.ErrorAs(t, err, &keyErr)
+			assert.Equal(t, ResponseKeyError{Table: "table", Attribute: "id"}, *keyErr)
+		} else {
+			require.NoError(t, err)
+			assert.Len(t, items, 2)
+		}
+		client.Close()
+	}
+
+	tm := mp.meters[daxMeterScope].(*testMeter)
+	assert.Equal(t, []int64{2}, tm.i64s[daxResponseKeyMismatches].data)
+}
+
+func TestSingleClient_metadataCacheMaxBytes(t *testing.T) {
+	cc := connConfig{metadataCacheMaxBytes: 1 << 10}
+	client, err := newSingleClientWithOptions(":9121", cc, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{}, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.EqualValues(t, 1<<10, client.keySchema.MaxBytes)
+	assert.EqualValues(t, 1<<10, client.attrNamesListToId.MaxBytes)
+	assert.EqualValues(t, 1<<10, client.attrListIdToNames.MaxBytes)
+
+	keys := []ddbtypes.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: ddbtypes.ScalarAttributeTypeS}}
+	assert.Greater(t, keySchemaSize("table", keys), keySchemaSize("t", keys))
+	assert.Greater(t, attributeNamesSize(1, []string{"a", "b"}), attributeNamesSize(1, []string{"a"}))
+}
+
+func TestSingleClient_metadataCacheTTL(t *testing.T) {
+	cc := connConfig{metadataCacheTTL: time.Minute}
+	client, err := newSingleClientWithOptions(":9121", cc, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{}, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Equal(t, time.Minute, client.keySchema.TTL)
+	assert.Equal(t, 45*time.Second, client.keySchema.SoftTTL)
+	assert.Equal(t, time.Minute, client.attrNamesListToId.TTL)
+	assert.Equal(t, time.Minute, client.attrListIdToNames.TTL)
+
+	var loads int
+	client.keySchema.LoadFunc = func(ctx context.Context, table string) ([]ddbtypes.AttributeDefinition, error) {
+		loads++
+		return []ddbtypes.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: ddbtypes.ScalarAttributeTypeS}}, nil
+	}
+	client.keySchema.TTL = time.Nanosecond
+	client.keySchema.SoftTTL = 0
+	client.keySchema.TTLJitter = 0
+	for i := 0; i < 2; i++ {
+		_, err := client.keySchema.GetWithContext(context.Background(), "t")
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 2, loads, "an expired key schema is fetched again")
+}
+
+func TestSingleClient_cacheMetrics(t *testing.T) {
+	om, err := buildDaxSdkMetrics(&testMeterProvider{})
+	require.NoError(t, err)
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{}, nil
+	}, nil, om)
+	require.NoError(t, err)
+	defer client.Close()
+	client.keySchema.MaxEntries = 1
+	client.keySchema.LoadFunc = func(ctx context.Context, table string) ([]ddbtypes.AttributeDefinition, error) {
+		if table == "missing" {
+			return nil, errors.New("ResourceNotFoundException")
+		}
+		return []ddbtypes.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: ddbtypes.ScalarAttributeTypeS}}, nil
+	}
+
+	ctx := context.Background()
+	for _, table := range []string{"t1", "t1", "t2", "missing"} {
+		client.keySchema.GetWithContext(ctx, table)
+	}
+	expectCounters(t, om, map[string]int{
+		daxCacheHits:       1,
+		daxCacheMisses:     3,
+		daxCacheEvictions:  1,
+		daxCacheLoadErrors: 1,
+	})
+}
@@ -0,0 +1,78 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// reportSlow reports a call of op with input which started at start and
+// took longer than Config.SlowRequestThreshold, to Config.OnSlowRequest or
+// else the logger.
+func (cc *ClusterDaxClient) reportSlow(ctx context.Context, op string, input any, start time.Time, metadata middleware.Metadata, err error) {
+	threshold := cc.config.SlowRequestThreshold
+	if threshold <= 0 {
+		return
+	}
+	d := time.Since(start)
+	if d < threshold {
+		return
+	}
+	countMetricInt64(ctx, cc.cluster.daxSdkMetrics, daxRequestsSlow, 1)
+
+	r := types.SlowRequest{Operation: op, Duration: d, Err: err}
+	r.Tables, _ = requestTables(input)
+	r.Attempts, _ = GetAttempts(metadata)
+	if len(r.Attempts) > 0 {
+		r.Node = r.Attempts[len(r.Attempts)-1].Node
+	}
+	if cc.config.OnSlowRequest != nil {
+		cc.config.OnSlowRequest(r)
+		return
+	}
+	if cc.config.logger != nil {
+		cc.config.logger.Logf(logging.Warn, "Slow request %s on %s took %v: %s", op, strings.Join(r.Tables, ","), d, formatAttempts(r.Attempts))
+	}
+}
+
+// formatAttempts describes attempts for the slow request log.
+func formatAttempts(attempts []types.Attempt) string {
+	var b strings.Builder
+	for i, a := range attempts {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		node := a.Node
+		if node == "" {
+			node = "no node"
+		}
+		b.WriteString(node)
+		b.WriteString(" in ")
+		b.WriteString(a.Duration.String())
+		if a.Err != nil {
+			b.WriteString(" (")
+			b.WriteString(a.Err.Error())
+			b.WriteString(")")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/logging"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterDaxClient_reportSlow(t *testing.T) {
+	cluster, _ := newTestCluster([]string{"127.0.0.1:8111"})
+	var reported []types.SlowRequest
+	cfg := DefaultConfig()
+	cfg.SlowRequestThreshold = time.Second
+	cfg.OnSlowRequest = func(r types.SlowRequest) { reported = append(reported, r) }
+	cc := ClusterDaxClient{config: cfg, cluster: cluster}
+
+	var metadata middleware.Metadata
+	failed := errors.New("failed")
+	metadata.Set(attemptsKey{}, []types.Attempt{{Node: "n1", Err: failed}, {Node: "n2"}})
+	input := &dynamodb.GetItemInput{TableName: aws.String("t")}
+
+	cc.reportSlow(context.Background(), OpGetItem, input, time.Now(), metadata, nil)
+	assert.Empty(t, reported, "fast calls are not reported")
+
+	cc.reportSlow(context.Background(), OpGetItem, input, time.Now().Add(-2*time.Second), metadata, failed)
+	require.Len(t, reported, 1)
+	r := reported[0]
+	assert.Equal(t, OpGetItem, r.Operation)
+	assert.Equal(t, []string{"t"}, r.Tables)
+	assert.GreaterOrEqual(t, r.Duration, 2*time.Second)
+	assert.Equal(t, "n2", r.Node)
+	assert.Len(t, r.Attempts, 2)
+	assert.Equal(t, failed, r.Err)
+
+	var logged []string
+	cc.config.OnSlowRequest = nil
+	cc.config.logger = logging.LoggerFunc(func(_ logging.Classification, format string, v ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, v...))
+	})
+	cc.reportSlow(context.Background(), OpGetItem, input, time.Now().Add(-2*time.Second), metadata, nil)
+	require.Len(t, logged, 1)
+	assert.Contains(t, logged[0], "Slow request GetItem on t took")
+	assert.Contains(t, logged[0], "n1 in 0s (failed), n2 in 0s")
+
+	cc.config.SlowRequestThreshold = 0
+	cc.reportSlow(context.Background(), OpGetItem, input, time.Now().Add(-time.Hour), metadata, nil)
+	assert.Len(t, logged, 1)
+}
@@ -0,0 +1,83 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// standbyCheckInterval is how often standby tubes are topped up and
+	// re-authenticated.
+	standbyCheckInterval = 30 * time.Second
+	standbyCheckTimeout  = 5 * time.Second
+)
+
+// keepStandby keeps n authenticated spare tubes in the pool, see
+// Config.StandbyConnectionsPerNode.
+func (client *SingleDaxClient) keepStandby(n int) {
+	client.executor.startTriggered(standbyCheckInterval, client.pool.standbyTaken, func() error {
+		client.fillStandby(n)
+		return nil
+	})
+}
+
+// fillStandby re-authenticates the standby tubes whose authentication expires
+// before the next check, which also verifies that they are still alive, and
+// connects new ones until there are n.
+func (client *SingleDaxClient) fillStandby(n int) {
+	ctx, cancel := context.WithTimeout(context.Background(), standbyCheckTimeout)
+	defer cancel()
+
+	var ready []tube
+	for _, t := range client.pool.takeStandby() {
+		if t.AuthExpiryUnix() <= time.Now().Add(standbyCheckInterval).Unix() {
+			t.SetAuthExpiryUnix(0)
+		}
+		if err := client.authStandby(ctx, t); err != nil {
+			t.Close()
+			continue
+		}
+		ready = append(ready, t)
+	}
+	for len(ready) < n && ctx.Err() == nil {
+		t, err := client.pool.alloc(client.pool.currentSession(), RequestOptions{})
+		if err != nil {
+			break
+		}
+		if err := client.authStandby(ctx, t); err != nil {
+			t.Close()
+			break
+		}
+		ready = append(ready, t)
+	}
+	for _, t := range ready {
+		if !client.pool.addStandby(t) {
+			t.Close()
+		}
+	}
+}
+
+func (client *SingleDaxClient) authStandby(ctx context.Context, t tube) error {
+	if err := client.pool.setDeadline(ctx, t); err != nil {
+		return err
+	}
+	if err := client.auth(ctx, t); err != nil {
+		return err
+	}
+	return t.SetDeadline(time.Time{})
+}
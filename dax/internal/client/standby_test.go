@@ -0,0 +1,71 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleClient_standbyConnections(t *testing.T) {
+	cfg := unEncryptedConnConfig
+	cfg.standbyConnections = 2
+	var mu sync.Mutex
+	var conns []*mockConn
+	dials := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(conns)
+	}
+	client, err := newSingleClientWithOptions(":9121", cfg, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := &mockConn{rd: []byte{cbor.Array + 0}}
+		conns = append(conns, c)
+		return c, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	standby := func() int { return int(client.poolStats().standby) }
+
+	require.Eventually(t, func() bool { return standby() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, dials())
+	client.pool.mutex.Lock()
+	for _, st := range client.pool.standby {
+		assert.Greater(t, st.AuthExpiryUnix(), time.Now().Unix(), "standby tubes are authenticated")
+	}
+	client.pool.mutex.Unlock()
+
+	encoder := func(writer *cbor.Writer) error { return writer.WriteString("abc") }
+	decoder := func(reader *cbor.Reader) error { return nil }
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	assert.EqualValues(t, 1, client.poolStats().idle, "the request used a standby tube")
+	require.Eventually(t, func() bool { return standby() == 2 }, time.Second, time.Millisecond, "the standby tube is replaced")
+	assert.Equal(t, 3, dials())
+
+	require.NoError(t, client.Close())
+	require.Eventually(t, func() bool { return client.executor.numTasks() == 0 }, time.Second, time.Millisecond)
+	assert.Zero(t, standby())
+	for _, c := range conns {
+		assert.Equal(t, 1, c.cc["Close"])
+	}
+}
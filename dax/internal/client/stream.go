@@ -0,0 +1,61 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// itemStream tracks the items a request passed to RequestOptions.OnItem.
+// Once an item was passed on the request cannot be retried, which would
+// pass the items on again.
+type itemStream struct {
+	onItem  func(map[string]types.AttributeValue) error
+	started bool
+	err     error // returned by onItem
+}
+
+// streamItems wraps o.OnItem, if set and not wrapped already, to track the
+// items passed on.
+func streamItems(o *RequestOptions) {
+	if o.OnItem == nil || o.stream != nil {
+		return
+	}
+	s := &itemStream{onItem: o.OnItem}
+	o.OnItem = s.yield
+	o.stream = s
+}
+
+func (s *itemStream) yield(item map[string]types.AttributeValue) error {
+	s.started = true
+	if err := s.onItem(item); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+// finished reports whether a failed request passed on items and must not be
+// retried. The error to return is the error of OnItem if it failed, err otherwise.
+func (s *itemStream) finished(err error) (bool, error) {
+	if s == nil || !s.started {
+		return false, err
+	}
+	if s.err != nil {
+		return true, s.err
+	}
+	return true, err
+}
@@ -0,0 +1,102 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errIO = errors.New("IO")
+
+func TestItemStream_finished(t *testing.T) {
+	var s *itemStream
+	finished, err := s.finished(errIO)
+	assert.False(t, finished)
+	assert.Equal(t, errIO, err)
+
+	handlerErr := errors.New("handler")
+	o := RequestOptions{OnItem: func(map[string]types.AttributeValue) error { return handlerErr }}
+	streamItems(&o)
+	wrapped := o.stream
+	streamItems(&o)
+	assert.Same(t, wrapped, o.stream, "already wrapped")
+
+	finished, err = o.stream.finished(errIO)
+	assert.False(t, finished)
+	assert.Equal(t, errIO, err)
+
+	assert.Equal(t, handlerErr, o.OnItem(nil))
+	finished, err = o.stream.finished(errIO)
+	assert.True(t, finished)
+	assert.Equal(t, handlerErr, err)
+}
+
+func TestRetryStopsAfterStreamedItems(t *testing.T) {
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return &mockConn{rd: []byte{cbor.Array + 0}}, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+	client.pool.closeTubeImmediately = true
+
+	cases := []struct {
+		name     string
+		yield    bool
+		attempts int
+	}{
+		{"no items passed on", false, 3},
+		{"items passed on", true, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var items int
+			o := RequestOptions{
+				Options: dynamodb.Options{RetryMaxAttempts: 2},
+				OnItem: func(map[string]types.AttributeValue) error {
+					items++
+					return nil
+				},
+			}
+			streamItems(&o)
+			attempts := 0
+			encoder := func(writer *cbor.Writer) error { return nil }
+			decoder := func(reader *cbor.Reader) error {
+				attempts++
+				if c.yield {
+					if err := o.OnItem(map[string]types.AttributeValue{}); err != nil {
+						return err
+					}
+				}
+				return errIO
+			}
+			err := client.executeWithRetries(context.Background(), OpQuery, o, encoder, decoder)
+			assert.Error(t, err)
+			assert.Equal(t, c.attempts, attempts)
+			if c.yield {
+				assert.Equal(t, 1, items)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"syscall"
+	"time"
+)
+
+// tcpRxtConnDropTime is TCP_RXT_CONNDROPTIME of netinet/tcp.h, which package
+// syscall does not define.
+const tcpRxtConnDropTime = 0x80
+
+// userTimeoutControl returns a net.Dialer Control function limiting how long
+// written data may remain unacknowledged before the connection is dropped,
+// nil if timeout is not positive. The limit is rounded up to whole seconds.
+func userTimeoutControl(timeout time.Duration) func(network, address string, c syscall.RawConn) error {
+	if timeout <= 0 {
+		return nil
+	}
+	secs := int((timeout + time.Second - 1) / time.Second)
+	return func(network, address string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) {
+			err = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, tcpRxtConnDropTime, secs)
+		}); cerr != nil {
+			return cerr
+		}
+		return err
+	}
+}
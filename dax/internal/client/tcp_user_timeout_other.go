@@ -0,0 +1,29 @@
+//go:build !linux && !darwin
+
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"syscall"
+	"time"
+)
+
+// userTimeoutControl returns nil, this platform has no equivalent of
+// TCP_USER_TIMEOUT.
+func userTimeoutControl(time.Duration) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/utils"
+)
+
+// telemetryFlushTimeout bounds flushing telemetry on Close.
+const telemetryFlushTimeout = 2 * time.Second
+
+// TelemetryFlusher is implemented by clients which can flush buffered telemetry.
+type TelemetryFlusher interface {
+	FlushTelemetry(ctx context.Context) error
+}
+
+// forceFlusher is implemented by meter providers which buffer measurements,
+// e.g. the OpenTelemetry SDK MeterProvider.
+type forceFlusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// syncer is implemented by loggers which buffer output, e.g. zap loggers.
+type syncer interface {
+	Sync() error
+}
+
+// poolReporter is implemented by clients which can describe their connection pool.
+type poolReporter interface {
+	poolStats() poolStats
+}
+
+type poolStats struct {
+	idle     int64
+	pending  int64
+	inFlight int64
+	standby  int64
+	wire     wireStats
+}
+
+func (client *SingleDaxClient) poolStats() poolStats {
+	s := poolStats{inFlight: atomic.LoadInt64(&client.inFlight), wire: client.wire.load()}
+	if client.pool != nil {
+		s.idle = atomic.LoadInt64(&client.pool.idle)
+		s.pending = atomic.LoadInt64(&client.pool.pending)
+		client.pool.mutex.Lock()
+		s.standby = int64(len(client.pool.standby))
+		client.pool.mutex.Unlock()
+	}
+	return s
+}
+
+// wireStats counts the requests and responses exchanged with a node over all
+// connections of its client, accessed atomically.
+type wireStats struct {
+	bytesSent       int64
+	bytesReceived   int64
+	framesSent      int64
+	framesReceived  int64
+	largestResponse int64 // size in bytes of the largest response frame
+}
+
+func (s *wireStats) load() wireStats {
+	return wireStats{
+		bytesSent:       atomic.LoadInt64(&s.bytesSent),
+		bytesReceived:   atomic.LoadInt64(&s.bytesReceived),
+		framesSent:      atomic.LoadInt64(&s.framesSent),
+		framesReceived:  atomic.LoadInt64(&s.framesReceived),
+		largestResponse: atomic.LoadInt64(&s.largestResponse),
+	}
+}
+
+// wireTally counts the bytes of a request and its response on a tube into
+// the wire stats of its client. The counts must be taken before the tube is
+// returned to the pool, where other requests may use it.
+type wireTally struct {
+	client  *SingleDaxClient
+	counter wireCounter
+	mark    int64 // bytes sent, then received, on the tube before the frame
+}
+
+// tally starts counting the request about to be written on t, nil if t does
+// not count its bytes.
+func (client *SingleDaxClient) tally(t tube) *wireTally {
+	c, ok := t.(wireCounter)
+	if !ok {
+		return nil
+	}
+	w := &wireTally{client: client, counter: c}
+	w.mark, _ = c.wireBytes()
+	return w
+}
+
+// sent counts the bytes written since tally, and the request frame if it was
+// completely written.
+func (w *wireTally) sent(ctx context.Context, complete bool) {
+	if w == nil {
+		return
+	}
+	sent, received := w.counter.wireBytes()
+	n := sent - w.mark
+	w.mark = received
+	atomic.AddInt64(&w.client.wire.bytesSent, n)
+	countMetricInt64(ctx, w.client.daxSdkMetrics, daxConnectionsBytesSent, n)
+	if complete {
+		atomic.AddInt64(&w.client.wire.framesSent, 1)
+	}
+}
+
+// received counts the bytes read since the request was sent, and the
+// response frame if it was completely read.
+func (w *wireTally) received(ctx context.Context, complete bool) {
+	if w == nil {
+		return
+	}
+	_, received := w.counter.wireBytes()
+	n := received - w.mark
+	atomic.AddInt64(&w.client.wire.bytesReceived, n)
+	countMetricInt64(ctx, w.client.daxSdkMetrics, daxConnectionsBytesReceived, n)
+	if !complete {
+		return
+	}
+	atomic.AddInt64(&w.client.wire.framesReceived, 1)
+	for {
+		largest := atomic.LoadInt64(&w.client.wire.largestResponse)
+		if n <= largest || atomic.CompareAndSwapInt64(&w.client.wire.largestResponse, largest, n) {
+			return
+		}
+	}
+}
+
+// FlushTelemetry logs a snapshot of the cluster topology and connection pools
+// and flushes the configured meter provider and logger, for processes which
+// may exit before their telemetry is exported.
+func (cc *ClusterDaxClient) FlushTelemetry(ctx context.Context) error {
+	cc.cluster.logSnapshot()
+	return cc.cluster.flushTelemetry(ctx)
+}
+
+// logSnapshot logs the active nodes and the state of their connection pools.
+func (c *cluster) logSnapshot() {
+	if c.config.logger == nil || !c.config.logLevel.AtLeast(utils.LogDebug) {
+		return
+	}
+	st := c.state()
+	lastRefresh := "never"
+	if !st.LastRefresh.IsZero() {
+		lastRefresh = st.LastRefresh.UTC().Format(time.RFC3339Nano)
+	}
+	c.debugLog("Client snapshot: %d active nodes, last successful refresh %s", len(st.Nodes), lastRefresh)
+	for _, n := range st.Nodes {
+		c.debugLog("Client snapshot: node %s (%s): %d idle connections, %d standby connections, %d pending connection attempts, %d in-flight requests, "+
+			"%d requests of %d bytes sent, %d responses of %d bytes received, largest response %d bytes",
+			n.Address, n.Hostname, n.IdleConnections, n.StandbyConnections, n.PendingConnections, n.InFlightRequests,
+			n.FramesSent, n.BytesSent, n.FramesReceived, n.BytesReceived, n.LargestResponse)
+	}
+}
+
+// flushTelemetry flushes the meter provider, the logger and the configured
+// OnFlushTelemetry hook, reporting all of their errors.
+func (c *cluster) flushTelemetry(ctx context.Context) error {
+	var errs []error
+	if f, ok := c.config.MeterProvider.(forceFlusher); ok {
+		errs = append(errs, f.ForceFlush(ctx))
+	}
+	if f := c.config.OnFlushTelemetry; f != nil {
+		errs = append(errs, f(ctx))
+	}
+	if s, ok := c.config.logger.(syncer); ok {
+		errs = append(errs, s.Sync())
+	}
+	return errors.Join(errs...)
+}
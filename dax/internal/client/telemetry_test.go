@@ -0,0 +1,133 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/utils"
+	"github.com/aws/smithy-go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flushingMeterProvider struct {
+	metrics.NopMeterProvider
+	flushes int
+}
+
+func (p *flushingMeterProvider) ForceFlush(ctx context.Context) error {
+	p.flushes++
+	return nil
+}
+
+type syncingLogger struct {
+	recordingLogger
+	err error
+}
+
+func (l *syncingLogger) Sync() error {
+	return l.err
+}
+
+func TestCluster_logSnapshot(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	logger := &recordingLogger{}
+	cluster.config.SetLogger(logger, utils.LogDebug)
+
+	cluster.logSnapshot()
+	require.Len(t, logger.lines, 3)
+	assert.Contains(t, logger.lines[0], "Client snapshot: 2 active nodes, last successful refresh ")
+	assert.Equal(t, "Client snapshot: node 127.0.0.1:8121 (node1): 0 idle connections, 0 standby connections, 0 pending connection attempts, 0 in-flight requests, "+
+		"0 requests of 0 bytes sent, 0 responses of 0 bytes received, largest response 0 bytes", logger.lines[1])
+	assert.Contains(t, logger.lines[2], "node 127.0.0.2:8121 (node2)")
+
+	logger.lines = nil
+	cluster.config.logLevel = utils.LogOff
+	cluster.logSnapshot()
+	assert.Empty(t, logger.lines)
+}
+
+func TestSingleDaxClient_wireStats(t *testing.T) {
+	newClient := func(conn *mockConn) *SingleDaxClient {
+		client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+			return conn, nil
+		}, nil, nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	encoder := func(writer *cbor.Writer) error { return writer.WriteString("abc") }
+	decoder := func(reader *cbor.Reader) error {
+		_, err := reader.ReadString()
+		return err
+	}
+
+	client := newClient(&mockConn{rd: append([]byte{cbor.Array + 0, cbor.Utf + 6}, "abcdef"...)})
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	assert.Equal(t, wireStats{bytesSent: 4, bytesReceived: 8, framesSent: 1, framesReceived: 1, largestResponse: 8}, client.poolStats().wire)
+
+	client = newClient(&mockConn{re: io.ErrUnexpectedEOF})
+	assert.Error(t, client.executeWithContext(context.Background(), OpGetItem, encoder, decoder, RequestOptions{}))
+	assert.Equal(t, wireStats{bytesSent: 4, framesSent: 1}, client.poolStats().wire, "no response was received")
+}
+
+func TestCluster_flushTelemetry(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	mp := &flushingMeterProvider{}
+	cfg.MeterProvider = mp
+	hookErr := errors.New("hook failed")
+	var hookCalls int
+	cfg.OnFlushTelemetry = func(ctx context.Context) error {
+		hookCalls++
+		return hookErr
+	}
+	syncErr := errors.New("sync failed")
+	cfg.SetLogger(&syncingLogger{err: syncErr}, utils.LogOff)
+	cluster, _ := newTestClusterWithConfig(cfg)
+
+	err := cluster.flushTelemetry(context.Background())
+	assert.ErrorIs(t, err, hookErr)
+	assert.ErrorIs(t, err, syncErr)
+	assert.Equal(t, 1, mp.flushes)
+	assert.Equal(t, 1, hookCalls)
+
+	cluster.config.OnFlushTelemetry = nil
+	cluster.config.logger = &recordingLogger{}
+	assert.NoError(t, cluster.flushTelemetry(context.Background()))
+	assert.Equal(t, 2, mp.flushes)
+}
+
+func TestClusterDaxClient_CloseFlushesTelemetry(t *testing.T) {
+	cluster := newHealthCheckTestCluster(t)
+	mp := &flushingMeterProvider{}
+	cluster.config.MeterProvider = mp
+	logger := &recordingLogger{}
+	cluster.config.SetLogger(logger, utils.LogDebug)
+	cc := &ClusterDaxClient{config: cluster.config, cluster: cluster}
+
+	require.NoError(t, cc.Close())
+	assert.Equal(t, 1, mp.flushes)
+	require.NotEmpty(t, logger.lines)
+	assert.Contains(t, logger.lines[0], "Client snapshot: 2 active nodes")
+}
@@ -0,0 +1,74 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// verifyPeerHostnameFunc decides whether cert, presented by the node at
+// address, may be used in place of a certificate valid for hostname.
+type verifyPeerHostnameFunc func(address, hostname string, cert *x509.Certificate) error
+
+// verifyCertificateFunc decides whether the chain certs, presented by the
+// node at address, authenticates the node in place of the default
+// verification against hostname.
+type verifyCertificateFunc func(address, hostname string, certs []*x509.Certificate) error
+
+// tlsConfig returns the TLS configuration for connections to the node at address.
+func (c connConfig) tlsConfig(address string) *tls.Config {
+	if c.skipHostnameVerification {
+		return &tls.Config{InsecureSkipVerify: true}
+	}
+	serverName := c.hostname
+	if c.tlsServerName != "" {
+		serverName = c.tlsServerName
+	}
+	cfg := &tls.Config{ServerName: serverName, RootCAs: c.rootCAs}
+	if verify := c.verifyCertificate; verify != nil {
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verify(address, serverName, cs.PeerCertificates)
+		}
+	} else if verify := c.verifyPeerHostname; verify != nil {
+		// The default verification is disabled only to replace its hostname
+		// check, the certificate chain is still verified by VerifyConnection.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPeerCertificate(cs.PeerCertificates, c.rootCAs, address, serverName, verify)
+		}
+	}
+	return cfg
+}
+
+// verifyPeerCertificate verifies the chain of certs against roots, or the
+// system roots when nil, and lets verify check the leaf certificate in place
+// of hostname verification.
+func verifyPeerCertificate(certs []*x509.Certificate, roots *x509.CertPool, address, hostname string, verify verifyPeerHostnameFunc) error {
+	if len(certs) == 0 {
+		return errors.New("tls: server presented no certificates")
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return err
+	}
+	return verify(address, hostname, certs[0])
+}
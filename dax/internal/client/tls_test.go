@@ -0,0 +1,188 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate returns a certificate for dnsName signed by a new CA,
+// and a pool holding that CA.
+func newTestCertificate(t *testing.T, dnsName string) (*x509.Certificate, *x509.CertPool) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDer)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     []string{dnsName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return cert, roots
+}
+
+func TestVerifyPeerCertificate(t *testing.T) {
+	cert, roots := newTestCertificate(t, "vpce-123.vpce.amazonaws.com")
+	hostname := "mycluster.frfx8h.clustercfg.dax.usw2.amazonaws.com"
+	assert.Error(t, cert.VerifyHostname(hostname))
+
+	var gotAddress, gotHostname string
+	accept := func(address, hostname string, c *x509.Certificate) error {
+		gotAddress, gotHostname = address, hostname
+		return c.VerifyHostname("vpce-123.vpce.amazonaws.com")
+	}
+	require.NoError(t, verifyPeerCertificate([]*x509.Certificate{cert}, roots, "10.0.0.1:9111", hostname, accept))
+	assert.Equal(t, "10.0.0.1:9111", gotAddress)
+	assert.Equal(t, hostname, gotHostname)
+
+	rejectErr := errors.New("rejected")
+	err := verifyPeerCertificate([]*x509.Certificate{cert}, roots, "10.0.0.1:9111", hostname, func(string, string, *x509.Certificate) error {
+		return rejectErr
+	})
+	assert.ErrorIs(t, err, rejectErr)
+
+	// the chain is verified before the hook is consulted
+	_, otherRoots := newTestCertificate(t, "vpce-123.vpce.amazonaws.com")
+	called := false
+	err = verifyPeerCertificate([]*x509.Certificate{cert}, otherRoots, "10.0.0.1:9111", hostname, func(string, string, *x509.Certificate) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called)
+
+	assert.Error(t, verifyPeerCertificate(nil, roots, "10.0.0.1:9111", hostname, accept))
+}
+
+func TestConnConfig_tlsConfig(t *testing.T) {
+	cc := connConfig{isEncrypted: true, hostname: "cluster.example"}
+	cfg := cc.tlsConfig("10.0.0.1:9111")
+	assert.Equal(t, "cluster.example", cfg.ServerName)
+	assert.False(t, cfg.InsecureSkipVerify)
+	assert.Nil(t, cfg.VerifyConnection)
+
+	cc.tlsServerName = "vpce.example"
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.Equal(t, "vpce.example", cfg.ServerName)
+	assert.False(t, cfg.InsecureSkipVerify)
+
+	cc.verifyPeerHostname = func(string, string, *x509.Certificate) error { return nil }
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.Equal(t, "vpce.example", cfg.ServerName)
+	assert.True(t, cfg.InsecureSkipVerify)
+	assert.NotNil(t, cfg.VerifyConnection)
+
+	var gotCerts []*x509.Certificate
+	cert, roots := newTestCertificate(t, "proxy.example")
+	cc.rootCAs = roots
+	cc.verifyCertificate = func(address, hostname string, certs []*x509.Certificate) error {
+		assert.Equal(t, "10.0.0.1:9111", address)
+		assert.Equal(t, "vpce.example", hostname)
+		gotCerts = certs
+		return nil
+	}
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.True(t, cfg.InsecureSkipVerify)
+	assert.Same(t, roots, cfg.RootCAs)
+	require.NoError(t, cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}))
+	assert.Equal(t, []*x509.Certificate{cert}, gotCerts)
+
+	cc.skipHostnameVerification = true
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.True(t, cfg.InsecureSkipVerify)
+	assert.Nil(t, cfg.VerifyConnection)
+}
+
+func TestConnConfig_tlsConfigRootCAs(t *testing.T) {
+	cert, roots := newTestCertificate(t, "cluster.example")
+	cc := connConfig{isEncrypted: true, hostname: "cluster.example", rootCAs: roots}
+	cfg := cc.tlsConfig("10.0.0.1:9111")
+	assert.False(t, cfg.InsecureSkipVerify)
+	assert.Same(t, roots, cfg.RootCAs)
+
+	// the roots also verify the chain when the hostname check is replaced
+	cc.verifyPeerHostname = func(string, string, *x509.Certificate) error { return nil }
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.NoError(t, cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}))
+	cc.rootCAs = nil
+	cfg = cc.tlsConfig("10.0.0.1:9111")
+	assert.Error(t, cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}))
+}
+
+func TestConfig_validateConnConfigWarnsOnCustomVerification(t *testing.T) {
+	logger := &recordingLogger{}
+	cfg := DefaultConfig()
+	cfg.SetLogger(logger, utils.LogOff)
+	cfg.connConfig = connConfig{isEncrypted: true, hostname: "cluster.example"}
+	cfg.validateConnConfig()
+	assert.Empty(t, logger.lines)
+
+	cfg.TLSServerName = "vpce.example"
+	cfg.VerifyPeerHostname = func(string, string, *x509.Certificate) error { return nil }
+	cfg.validateConnConfig()
+	require.Len(t, logger.lines, 2)
+	assert.Contains(t, logger.lines[0], "against vpce.example instead of the cluster hostname cluster.example")
+	assert.Contains(t, logger.lines[1], "Custom hostname verification")
+
+	logger.lines = nil
+	cfg.VerifyCertificate = func(string, string, []*x509.Certificate) error { return nil }
+	cfg.validateConnConfig()
+	require.Len(t, logger.lines, 2)
+	assert.Contains(t, logger.lines[1], "Custom certificate verification")
+
+	logger.lines = nil
+	cfg.connConfig.isEncrypted = false
+	cfg.validateConnConfig()
+	assert.Empty(t, logger.lines)
+}
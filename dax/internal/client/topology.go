@@ -0,0 +1,140 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+)
+
+// errExternalTopology is returned when refreshing a cluster whose nodes are
+// managed with UpdateTopology.
+var errExternalTopology = NewCustomInvalidParamError("RefreshCluster", "the nodes of this client are managed with UpdateTopology")
+
+// TopologyUpdater is implemented by clients whose nodes are managed by the application.
+type TopologyUpdater interface {
+	UpdateTopology(nodes []types.Node) error
+}
+
+// NewFromTopology creates a client which connects to the given nodes instead
+// of discovering them from HostPorts. HostPorts is optional; when set, its
+// scheme selects whether connections are encrypted and its host is the name
+// node certificates are verified against, but it is never contacted.
+// Health checks, failed node replacement and connection pooling work as for
+// clients created with New, but the nodes only change with UpdateTopology.
+func NewFromTopology(config Config, nodes []types.Node) (*ClusterDaxClient, error) {
+	cluster, err := newTopologyCluster(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.setTopology(nodes); err != nil {
+		return nil, err
+	}
+	if err := cluster.start(); err != nil {
+		return nil, err
+	}
+	return newClusterDaxClient(config, cluster), nil
+}
+
+// UpdateTopology replaces the nodes of a client created with NewFromTopology.
+// Connections to nodes which are kept are reused, requests in flight on
+// removed nodes are drained as after a discovered membership change.
+func (cc *ClusterDaxClient) UpdateTopology(nodes []types.Node) error {
+	if !cc.cluster.external {
+		return NewCustomInvalidParamError("UpdateTopology", "the nodes of this client are discovered from its endpoint")
+	}
+	return cc.cluster.setTopology(nodes)
+}
+
+func newTopologyCluster(cfg Config) (*cluster, error) {
+	if err := cfg.validateSettings(); err != nil {
+		return nil, err
+	}
+	c, err := buildCluster(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.external = true
+	c.seeds = nil
+	return c, nil
+}
+
+// setTopology makes nodes the active nodes of the cluster.
+func (c *cluster) setTopology(nodes []types.Node) error {
+	endpoints, err := topologyEndpoints(nodes)
+	if err != nil {
+		return err
+	}
+	if c.closing.Load() {
+		return os.ErrClosed
+	}
+	c.topologyLock.Lock()
+	defer c.topologyLock.Unlock()
+	if c.hasChanged(endpoints) {
+		if err := c.update(endpoints); err != nil {
+			return err
+		}
+	}
+	c.updateLeader(endpoints)
+	atomic.StoreInt64(&c.lastRefreshSuccessNs, time.Now().UnixNano())
+	c.recordRefresh(nil)
+	return nil
+}
+
+// topologyEndpoints validates nodes and converts them to service endpoints.
+func topologyEndpoints(nodes []types.Node) ([]serviceEndpoint, error) {
+	if len(nodes) == 0 {
+		return nil, NewCustomInvalidParamError("Nodes", "at least one node is required")
+	}
+	endpoints := make([]serviceEndpoint, len(nodes))
+	seen := make(map[hostPort]bool, len(nodes))
+	for i, n := range nodes {
+		ip := net.ParseIP(n.Address)
+		if ip == nil {
+			return nil, NewCustomInvalidParamError("Nodes", fmt.Sprintf("address %q of node %d is not an IP address", n.Address, i))
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		if n.Port <= 0 || n.Port > 65535 {
+			return nil, NewCustomInvalidParamError("Nodes", fmt.Sprintf("port %d of node %d is invalid", n.Port, i))
+		}
+		ep := serviceEndpoint{
+			nodeId:           n.NodeID,
+			hostname:         n.Hostname,
+			address:          ip,
+			port:             n.Port,
+			availabilityZone: n.AvailabilityZone,
+		}
+		switch n.Role {
+		case types.NodeRoleLeader:
+			ep.role = roleLeader
+		case types.NodeRoleReplica:
+			ep.role = roleReplica
+		}
+		if seen[ep.hostPort()] {
+			return nil, NewCustomInvalidParamError("Nodes", fmt.Sprintf("node %d duplicates address %s", i, endpointAddress(ep)))
+		}
+		seen[ep.hostPort()] = true
+		endpoints[i] = ep
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,102 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTopologyCluster(t *testing.T) (*cluster, *testClientBuilder) {
+	cfg := DefaultConfig()
+	cfg.Region = "us-west-2"
+	c, err := newTopologyCluster(cfg)
+	require.NoError(t, err)
+	b := &testClientBuilder{}
+	c.clientBuilder = b
+	return c, b
+}
+
+func TestCluster_setTopology(t *testing.T) {
+	c, b := newTestTopologyCluster(t)
+	defer c.Close()
+
+	require.NoError(t, c.setTopology([]types.Node{
+		{NodeID: 1, Address: "10.0.0.1", Port: 8111, Hostname: "node-1", Role: types.NodeRoleLeader, AvailabilityZone: "us-west-2a"},
+		{NodeID: 2, Address: "10.0.0.2", Port: 8111, Hostname: "node-2", Role: types.NodeRoleReplica},
+	}))
+	assertNumRoutes(c, 2, t)
+	assert.Len(t, b.clients, 2)
+	state := c.state()
+	require.Len(t, state.Nodes, 2)
+	assert.Equal(t, types.NodeState{NodeID: 1, Address: "10.0.0.1:8111", Hostname: "node-1", Role: types.NodeRoleLeader,
+		AvailabilityZone: "us-west-2a", Healthy: true}, state.Nodes[0])
+	assert.False(t, state.LastRefresh.IsZero())
+	assert.Equal(t, hostPort{"10.0.0.1", 8111}, c.leader)
+
+	// the leader moves and node-1 is replaced, node-2 keeps its client
+	require.NoError(t, c.setTopology([]types.Node{
+		{NodeID: 2, Address: "10.0.0.2", Port: 8111, Hostname: "node-2", Role: types.NodeRoleLeader},
+		{NodeID: 3, Address: "10.0.0.3", Port: 8111, Hostname: "node-3", Role: types.NodeRoleReplica},
+	}))
+	assertNumRoutes(c, 2, t)
+	assert.Len(t, b.clients, 3)
+	assert.Equal(t, hostPort{"10.0.0.2", 8111}, c.leader)
+}
+
+func TestCluster_setTopology_invalid(t *testing.T) {
+	c, _ := newTestTopologyCluster(t)
+	defer c.Close()
+
+	for name, nodes := range map[string][]types.Node{
+		"empty":     nil,
+		"hostname":  {{Address: "node-1.example.com", Port: 8111}},
+		"port":      {{Address: "10.0.0.1"}},
+		"duplicate": {{Address: "10.0.0.1", Port: 8111}, {Address: "10.0.0.1", Port: 8111}},
+	} {
+		assert.Error(t, c.setTopology(nodes), name)
+	}
+	assertNumRoutes(c, 0, t)
+}
+
+func TestCluster_externalRefresh(t *testing.T) {
+	c, b := newTestTopologyCluster(t)
+	defer c.Close()
+	require.NoError(t, c.setTopology([]types.Node{{Address: "10.0.0.1", Port: 8111}}))
+
+	assert.ErrorIs(t, c.refreshCluster(context.Background()), errExternalTopology)
+	c.refreshForFailover(context.Background())
+	assertNumRoutes(c, 1, t)
+	assert.NoError(t, c.lastRefreshError())
+	assert.Zero(t, b.clients[0].endpointsCalls)
+}
+
+func TestClusterDaxClient_UpdateTopology(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Region = "us-west-2"
+	_, err := NewFromTopology(cfg, nil)
+	assert.Error(t, err)
+
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cc, err := New(cfg)
+	require.NoError(t, err)
+	defer cc.Close()
+	assert.Error(t, cc.UpdateTopology([]types.Node{{Address: "10.0.0.1", Port: 8111}}))
+}
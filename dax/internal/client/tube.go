@@ -17,6 +17,7 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"net"
 	"strconv"
 	"time"
@@ -27,8 +28,6 @@ import (
 const magic = "J7yne5G"
 const agent = "DaxGoV2Client-1.0.3"
 
-var optional = map[string]string{"UserAgent": agent}
-
 type session = int64
 
 // Interface to represent a data stream connection to the Dax server
@@ -46,22 +45,47 @@ type tube interface {
 	Close() error
 }
 
+// wireRecorder is implemented by tubes which can capture the raw frames exchanged on their connection.
+type wireRecorder interface {
+	startRecording()
+	stopRecording() (sent, received []byte)
+}
+
+// idleTracker is implemented by tubes which remember when they were last
+// returned to the pool.
+type idleTracker interface {
+	markIdle(time.Time)
+	idleSince() time.Time
+}
+
 // A concrete tube implementation based on net.Conn
 type netConnTube struct {
 	sess       session
 	conn       net.Conn
+	rec        *recordingConn
 	cborReader *cbor.Reader
 	cborWriter *cbor.Writer
 	next       tube
 
 	authExpiryUnix int64
 	authID         string
+	idleAt         time.Time // zero until the tube is first returned to the pool
 }
 
 // Creates and initializes a new tube belonging to the given session
 // and using the provided connection.
+// The handshake is one way, the server does not reply to it and does not
+// advertise frame or request size limits, so there are none to enforce
+// before a request is written.
 func newTube(c net.Conn, s session) (tube, error) {
-	w := cbor.NewWriter(bufio.NewWriter(c))
+	return newTubeWithAgent(c, s, agent)
+}
+
+// newTubeWithAgent creates a tube whose handshake identifies the client with
+// the given user agent.
+func newTubeWithAgent(c net.Conn, s session, userAgent string) (tube, error) {
+	rc := &recordingConn{Conn: c}
+	w := cbor.NewWriter(bufio.NewWriter(rc))
 	closeResources := func() {
 		w.Close()
 		c.Close()
@@ -78,7 +102,7 @@ func newTube(c net.Conn, s session) (tube, error) {
 		closeResources()
 		return nil, err
 	}
-	if err := writeHeader(w); err != nil {
+	if err := writeHeader(w, userAgent); err != nil {
 		closeResources()
 		return nil, err
 	}
@@ -95,7 +119,8 @@ func newTube(c net.Conn, s session) (tube, error) {
 	return &netConnTube{
 		sess:       s,
 		conn:       c,
-		cborReader: cbor.NewReader(bufio.NewReader(c)),
+		rec:        rc,
+		cborReader: cbor.NewReader(bufio.NewReader(rc)),
 		cborWriter: w,
 	}, nil
 
@@ -150,6 +175,61 @@ func (t *netConnTube) Close() error {
 	return t.conn.Close()
 }
 
+func (t *netConnTube) markIdle(now time.Time) {
+	t.idleAt = now
+}
+
+func (t *netConnTube) idleSince() time.Time {
+	return t.idleAt
+}
+
+// Starts capturing the bytes sent and received on the connection.
+func (t *netConnTube) startRecording() {
+	t.rec.sent.Reset()
+	t.rec.received.Reset()
+	t.rec.recording = true
+}
+
+// Stops capturing and returns the bytes sent and received since startRecording.
+// The returned slices are only valid until the next recording starts.
+func (t *netConnTube) stopRecording() ([]byte, []byte) {
+	t.rec.recording = false
+	return t.rec.sent.Bytes(), t.rec.received.Bytes()
+}
+
+// Returns the number of bytes sent and received on the connection.
+func (t *netConnTube) wireBytes() (int64, int64) {
+	return t.rec.sentBytes, t.rec.receivedBytes
+}
+
+// recordingConn counts the bytes read from and written to a connection, and
+// copies them while recording is set.
+type recordingConn struct {
+	net.Conn
+	recording      bool
+	sent, received bytes.Buffer
+
+	sentBytes, receivedBytes int64
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.receivedBytes += int64(n)
+	if c.recording {
+		c.received.Write(b[:n])
+	}
+	return n, err
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.sentBytes += int64(n)
+	if c.recording {
+		c.sent.Write(b[:n])
+	}
+	return n, err
+}
+
 func writeMagic(w *cbor.Writer) error {
 	return w.WriteString(magic)
 }
@@ -164,19 +244,14 @@ func writeLayering(w *cbor.Writer) error {
 	return w.WriteInt(0)
 }
 
-func writeHeader(w *cbor.Writer) error {
-	if err := w.WriteMapHeader(len(optional)); err != nil {
+func writeHeader(w *cbor.Writer, userAgent string) error {
+	if err := w.WriteMapHeader(1); err != nil {
 		return err
 	}
-	for k, v := range optional {
-		if err := w.WriteString(k); err != nil {
-			return err
-		}
-		if err := w.WriteString(v); err != nil {
-			return err
-		}
+	if err := w.WriteString("UserAgent"); err != nil {
+		return err
 	}
-	return nil
+	return w.WriteString(userAgent)
 }
 
 func writeClientMode(w *cbor.Writer) error {
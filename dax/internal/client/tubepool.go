@@ -17,7 +17,8 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
 	"os"
 	"sync"
@@ -31,6 +32,13 @@ import (
 
 const network = "tcp"
 
+const (
+	// After reresolveDialFailures consecutive failed dials to a node, its
+	// hostname is resolved again, see tubePool.dialFailed.
+	reresolveDialFailures = 3
+	reresolveTimeout      = 5 * time.Second
+)
+
 type dialContext func(ctx context.Context, network string, address string) (net.Conn, error)
 
 // Acts as the gate to create new tubes
@@ -48,8 +56,18 @@ type tubePool struct {
 	top        tube    // protected by mutex
 	lastActive tube    // protected by mutex
 	session    session // protected by mutex
+	dialAddr   string  // protected by mutex, address if empty
 	waiters    chan tube
 
+	// standby holds authenticated spare tubes, taken when there are no idle
+	// tubes, see Config.StandbyConnectionsPerNode. standbyTaken is signalled
+	// when one is taken.
+	standby      []tube // protected by mutex
+	standbyTaken chan struct{}
+
+	dialFailures int32 // consecutive failed dials, accessed atomically
+	lookupIP     func(ctx context.Context, network, host string) ([]net.IP, error)
+
 	pending int64 // 64 bit for pending gauge convenience
 	idle    int64 // 64 bit for idle gauge convenience
 
@@ -80,19 +98,13 @@ func newTubePoolWithOptions(address string, options tubePoolOptions, connConfigD
 	}
 
 	if options.dialContext == nil {
+		netDialer := &net.Dialer{Control: userTimeoutControl(connConfigData.tcpUserTimeout)}
 		if connConfigData.isEncrypted {
-			dialer := &proxy.Dialer{}
-			var cfg tls.Config
-			if connConfigData.skipHostnameVerification {
-				cfg = tls.Config{InsecureSkipVerify: true}
-			} else {
-				cfg = tls.Config{ServerName: connConfigData.hostname}
-			}
-			dialer.Config = &cfg
+			dialer := &proxy.Dialer{NetDialer: netDialer}
+			dialer.Config = connConfigData.tlsConfig(address)
 			options.dialContext = dialer.DialContext
 		} else {
-			dialer := &net.Dialer{}
-			options.dialContext = dialer.DialContext
+			options.dialContext = netDialer.DialContext
 		}
 	}
 
@@ -103,6 +115,9 @@ func newTubePoolWithOptions(address string, options tubePoolOptions, connConfigD
 		waiters:     make(chan tube),
 		timeout:     options.timeout,
 		dialContext: options.dialContext,
+		lookupIP:    net.DefaultResolver.LookupIP,
+
+		standbyTaken: make(chan struct{}, 1),
 
 		pending: 0,
 		idle:    0,
@@ -148,6 +163,18 @@ func (p *tubePool) getWithContext(ctx context.Context, highPriority bool, opt Re
 			return t, nil
 		}
 
+		// promote a standby tube instead of connecting
+		if n := len(p.standby); n > 0 {
+			t := p.standby[n-1]
+			p.standby = p.standby[:n-1]
+			p.mutex.Unlock()
+			select {
+			case p.standbyTaken <- struct{}{}:
+			default:
+			}
+			return t, nil
+		}
+
 		// no tubes in stack, create wait channel
 		if p.waiters == nil {
 			p.waiters = make(chan tube)
@@ -233,6 +260,10 @@ func (p *tubePool) put(t tube) {
 		return
 	}
 
+	if it, ok := t.(idleTracker); ok {
+		it.markIdle(time.Now())
+	}
+
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
@@ -304,6 +335,11 @@ func (p *tubePool) Close() error {
 		p.closed = true
 		p.sessionBump()
 		head = p.clearIdleConnections()
+		for _, t := range p.standby {
+			t.SetNext(head)
+			head = t
+		}
+		p.standby = nil
 		if p.waiters != nil {
 			close(p.waiters)
 			p.waiters = nil
@@ -316,6 +352,33 @@ func (p *tubePool) Close() error {
 	return nil
 }
 
+// takeStandby removes and returns the standby tubes.
+func (p *tubePool) takeStandby() []tube {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	standby := p.standby
+	p.standby = nil
+	return standby
+}
+
+// addStandby adds t to the standby tubes, false if the pool is closed or t
+// belongs to an old session.
+func (p *tubePool) addStandby(t tube) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed || t.Session() != p.session {
+		return false
+	}
+	p.standby = append(p.standby, t)
+	return true
+}
+
+func (p *tubePool) currentSession() session {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.session
+}
+
 // Resets the idle tube stack by detaching existing tubes from it.
 // p.mutex must be held when calling this method
 func (p *tubePool) clearIdleConnections() tube {
@@ -352,23 +415,119 @@ func (p *tubePool) reapIdleConnections() {
 
 // Allocates a new tube by establishing a new connection and performing initialization.
 func (p *tubePool) alloc(session int64, opt RequestOptions) (tube, error) {
-	conn, err := p.dialContext(context.TODO(), network, p.address)
+	ctx := context.Background()
+	if p.connConfig.connectTimeout > 0 {
+		var cancelFn func()
+		ctx, cancelFn = context.WithTimeout(ctx, p.connConfig.connectTimeout)
+		defer cancelFn()
+	}
+	addr := p.dialAddress()
+	conn, err := p.dialContext(ctx, network, addr)
 	if err != nil {
-		p.debugLog(opt, "Error in establishing connection to address %s : %s", p.address, err)
-		return nil, err
+		p.debugLog(opt, "Error in establishing connection to address %s : %s", addr, err)
+		p.dialFailed(opt)
+		return nil, &connectError{address: addr, err: err}
 	}
+	atomic.StoreInt32(&p.dialFailures, 0)
 
-	t, err := newTube(conn, session)
+	// The connect timeout also covers the handshake of the DAX protocol.
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	t, err := newTubeWithAgent(conn, session, handshakeAgent(p.connConfig.userAgent))
 	if err != nil {
 		p.debugLog(opt, "Error in allocating new tube for %s : %s", conn.RemoteAddr(), err)
-		return nil, err
+		return nil, &connectError{address: p.address, err: err}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.SetDeadline(time.Time{})
 	}
 
 	countMetricInt64(context.Background(), p.daxSdkMetrics, daxConnectionsCreated, 1)
+	p.debugLog(opt, "Established new connection to address %s", p.address)
 
 	return t, nil
 }
 
+// dialAddress returns the address new connections are established to.
+func (p *tubePool) dialAddress() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.dialAddr != "" {
+		return p.dialAddr
+	}
+	return p.address
+}
+
+// dialFailed counts a failed dial. Replaced nodes may keep their hostname
+// but get a new IP, so after reresolveDialFailures consecutive failures the
+// hostname of the node is resolved again, and new connections go to the
+// resolved IP if the current one is no longer among the results.
+func (p *tubePool) dialFailed(opt RequestOptions) {
+	host := p.connConfig.nodeHostname
+	if host == "" || atomic.AddInt32(&p.dialFailures, 1) < reresolveDialFailures {
+		return
+	}
+	atomic.StoreInt32(&p.dialFailures, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reresolveTimeout)
+	defer cancel()
+	ips, err := p.lookupIP(ctx, "ip", host)
+	if err != nil {
+		p.debugLog(opt, "Error in resolving %s : %s", host, err)
+		return
+	}
+	current := p.dialAddress()
+	currentHost, port, err := net.SplitHostPort(current)
+	if err != nil {
+		return
+	}
+	currentIP := net.ParseIP(currentHost)
+	var resolved net.IP
+	for _, ip := range ips {
+		if ip.Equal(currentIP) {
+			return
+		}
+		// Keep the IP version selected by IpDiscovery.
+		if resolved == nil && (ip.To4() == nil) == (currentIP.To4() == nil) {
+			resolved = ip
+		}
+	}
+	if resolved == nil {
+		return
+	}
+	addr := net.JoinHostPort(resolved.String(), port)
+	p.mutex.Lock()
+	p.dialAddr = addr
+	p.mutex.Unlock()
+	p.debugLog(opt, "Resolved %s to %s after %d failed connection attempts to %s", host, addr, reresolveDialFailures, current)
+}
+
+// connectError reports a failure to establish a connection to a node. As no
+// request was sent, it is a recoverable network error: the request can be
+// retried on another node, even if it is a write.
+type connectError struct {
+	address string
+	err     error
+}
+
+func (e *connectError) Error() string {
+	return fmt.Sprintf("connecting to %s: %v", e.address, e.err)
+}
+
+func (e *connectError) Unwrap() error {
+	return e.err
+}
+
+func (e *connectError) Timeout() bool {
+	var ne net.Error
+	return errors.Is(e.err, context.DeadlineExceeded) || (errors.As(e.err, &ne) && ne.Timeout())
+}
+
+func (e *connectError) Temporary() bool {
+	return true
+}
+
 // Traverses the passed stack and closes all tubes in it.
 func (p *tubePool) closeAll(head tube) int64 {
 	var next tube
@@ -17,6 +17,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -380,6 +381,93 @@ func TestTubePoolErrorWithCustomDialContext(t *testing.T) {
 	}
 }
 
+func TestTubePoolConnectTimeout(t *testing.T) {
+	sdkMetrics, _ := buildDaxSdkMetrics(&testMeterProvider{})
+
+	cc := connConfigData
+	cc.connectTimeout = 20 * time.Millisecond
+	pool := newTubePoolWithOptions(":8187", tubePoolOptions{10, time.Second * 5, func(ctx context.Context, network, address string) (net.Conn, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected dial context to have a deadline")
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}, cc, sdkMetrics)
+
+	start := time.Now()
+	_, err := pool.get()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, actual %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected connect timeout to fire before pool timeout, took %v", elapsed)
+	}
+	assert.True(t, DaxRetryer{}.IsErrorRetryable(translateError(err)), "the request can fail over to another node")
+	assert.True(t, IsIOError(err))
+}
+
+func TestTubePoolConnectTimeout_handshake(t *testing.T) {
+	cc := connConfigData
+	cc.connectTimeout = time.Minute
+	conn := &mockConn{}
+	pool := newTubePoolWithOptions(":8187", tubePoolOptions{10, time.Second * 5, func(ctx context.Context, network, address string) (net.Conn, error) {
+		return conn, nil
+	}}, cc, &daxSdkMetrics{})
+	defer pool.Close()
+
+	tube, err := pool.get()
+	require.NoError(t, err)
+	assert.Equal(t, 2, conn.cc["SetDeadline"], "the handshake is bounded by the connect timeout")
+	pool.put(tube)
+}
+
+func TestTubePool_reresolveAfterDialFailures(t *testing.T) {
+	cc := connConfigData
+	cc.nodeHostname = "node1"
+	var dialed []string
+	pool := newTubePoolWithOptions("10.0.0.1:8111", tubePoolOptions{10, time.Second * 5, func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		if address == "10.0.0.1:8111" {
+			return nil, errors.New("connection refused")
+		}
+		return &mockConn{}, nil
+	}}, cc, &daxSdkMetrics{})
+	defer pool.Close()
+	var lookups int
+	pool.lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		lookups++
+		assert.Equal(t, "node1", host)
+		return []net.IP{net.ParseIP("fd00::2"), net.ParseIP("10.0.0.2")}, nil
+	}
+
+	for i := 0; i < reresolveDialFailures; i++ {
+		_, err := pool.get()
+		require.Error(t, err)
+	}
+	assert.Equal(t, 1, lookups)
+	tube, err := pool.get()
+	require.NoError(t, err)
+	pool.put(tube)
+	assert.Equal(t, []string{"10.0.0.1:8111", "10.0.0.1:8111", "10.0.0.1:8111", "10.0.0.2:8111"}, dialed)
+	assert.Equal(t, "10.0.0.1:8111", pool.address, "the node keeps its identity until the next cluster refresh")
+}
+
+func TestTubePool_reresolveKeepsResolvedAddress(t *testing.T) {
+	cc := connConfigData
+	cc.nodeHostname = "node1"
+	pool := newTubePoolWithOptions("10.0.0.1:8111", tubePoolOptions{10, time.Second * 5, func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}}, cc, &daxSdkMetrics{})
+	defer pool.Close()
+	pool.lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("10.0.0.1")}, nil
+	}
+	for i := 0; i < reresolveDialFailures; i++ {
+		pool.get()
+	}
+	assert.Equal(t, "10.0.0.1:8111", pool.dialAddress())
+}
+
 func TestConnectionPriority(t *testing.T) {
 	endpoint := ":8186"
 	listener, err := startServer(endpoint, nil, nil, drainAndCloseConn)
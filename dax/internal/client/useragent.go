@@ -0,0 +1,65 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"sort"
+	"strings"
+)
+
+// buildUserAgent returns the user agent sent when authorizing connections,
+// extended with the application id and additional key/value pairs using the
+// same "app/<id>" and "<key>/<value>" components as the AWS SDK.
+func buildUserAgent(appID string, extras map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(userAgent)
+	if appID != "" {
+		sb.WriteString(" app/")
+		sb.WriteString(sanitizeUserAgentToken(appID))
+	}
+
+	keys := make([]string, 0, len(extras))
+	for k := range extras {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteByte(' ')
+		sb.WriteString(sanitizeUserAgentToken(k))
+		if v := extras[k]; v != "" {
+			sb.WriteByte('/')
+			sb.WriteString(sanitizeUserAgentToken(v))
+		}
+	}
+	return sb.String()
+}
+
+// handshakeAgent returns the user agent sent in the handshake of new
+// connections, which carries the application id and additional components
+// of the user agent sent when authorizing them.
+func handshakeAgent(authAgent string) string {
+	return agent + strings.TrimPrefix(authAgent, userAgent)
+}
+
+// sanitizeUserAgentToken replaces characters which are not allowed in a user agent token with '-'.
+func sanitizeUserAgentToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			return r
+		}
+		return '-'
+	}, s)
+}
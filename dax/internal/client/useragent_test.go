@@ -0,0 +1,81 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUserAgent(t *testing.T) {
+	cases := []struct {
+		name     string
+		appID    string
+		extras   map[string]string
+		expected string
+	}{
+		{name: "default", expected: userAgent},
+		{name: "app id", appID: "billing-app", expected: userAgent + " app/billing-app"},
+		{
+			name:     "extras sorted",
+			appID:    "app1",
+			extras:   map[string]string{"team": "payments", "env": "prod", "flag": ""},
+			expected: userAgent + " app/app1 env/prod flag team/payments",
+		},
+		{name: "sanitized", appID: "my app/v1", extras: map[string]string{"k y": "v(1)"}, expected: userAgent + " app/my-app-v1 k-y/v-1-"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, buildUserAgent(c.appID, c.extras))
+		})
+	}
+}
+
+func TestClusterUserAgent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.AppID = "billing-app"
+	cfg.UserAgentExtras = map[string]string{"team": "payments"}
+	cluster, _ := newTestClusterWithConfig(cfg)
+
+	assert.Equal(t, userAgent+" app/billing-app team/payments", cluster.config.connConfig.userAgent)
+}
+
+func TestHandshakeAgent(t *testing.T) {
+	assert.Equal(t, agent, handshakeAgent(""))
+	assert.Equal(t, agent, handshakeAgent(userAgent))
+	assert.Equal(t, agent+" app/billing-app team/payments", handshakeAgent(buildUserAgent("billing-app", map[string]string{"team": "payments"})))
+
+	cc := connConfigData
+	cc.userAgent = buildUserAgent("billing-app", nil)
+	sent := make([]byte, 256)
+	pool := newTubePoolWithOptions(":8187", tubePoolOptions{1, time.Second, func(ctx context.Context, network, address string) (net.Conn, error) {
+		return &mockConn{wd: sent}, nil
+	}}, cc, &daxSdkMetrics{})
+	defer pool.Close()
+	tube, err := pool.get()
+	require.NoError(t, err)
+	pool.put(tube)
+	assert.True(t, bytes.Contains(sent, []byte(agent+" app/billing-app")), "the handshake identifies the application")
+}
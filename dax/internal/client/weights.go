@@ -0,0 +1,136 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"math/rand"
+	"time"
+)
+
+// minRampUpWeight is the fraction of its weight a node gets when it joins, see Config.NodeRampUp.
+const minRampUpWeight = 0.05
+
+// weighted reports whether routes are picked by node weight.
+func (c *cluster) weighted() bool {
+	return c.config.NodeWeight != nil || c.config.NodeRampUp > 0
+}
+
+// nodeWeight returns the routing weight of n at now, see Config.NodeWeight
+// and Config.NodeRampUp.
+func (c *cluster) nodeWeight(n clientAndConfig, now time.Time) float64 {
+	w := 1.0
+	if c.config.NodeWeight != nil {
+		w = c.config.NodeWeight(endpointState(n.cfg))
+		if !(w > 0) {
+			return 0
+		}
+	}
+	if ramp := c.config.NodeRampUp; ramp > 0 && !n.added.IsZero() {
+		if age := now.Sub(n.added); age < ramp {
+			w *= max(minRampUpWeight, float64(age)/float64(ramp))
+		}
+	}
+	return w
+}
+
+// routeWeights are the node weights of a set of routes. They are computed at
+// most every weightsRefreshInterval rather than for every request, which
+// keeps up with NodeWeight changes and ramp ups.
+type routeWeights struct {
+	routes  []DaxAPI
+	weights []float64
+	expires time.Time
+}
+
+// weightsRefreshInterval bounds the age of the cached route weights.
+const weightsRefreshInterval = time.Second
+
+// cachedWeights returns the weights of routes, computing them if the cached
+// ones are for other routes or expired. It must be called with the lock held.
+func (c *cluster) cachedWeights(routes []DaxAPI) []float64 {
+	now := time.Now()
+	if rw := c.weights.Load(); rw != nil && now.Before(rw.expires) && sameRoutes(rw.routes, routes) {
+		return rw.weights
+	}
+	nodes := make(map[DaxAPI]clientAndConfig, len(c.active))
+	for _, n := range c.active {
+		nodes[n.client] = n
+	}
+	weights := make([]float64, len(routes))
+	for i, r := range routes {
+		if n, ok := nodes[r]; ok {
+			weights[i] = c.nodeWeight(n, now)
+		} else {
+			weights[i] = 1
+		}
+	}
+	c.weights.Store(&routeWeights{
+		routes:  append([]DaxAPI(nil), routes...),
+		weights: weights,
+		expires: now.Add(weightsRefreshInterval),
+	})
+	return weights
+}
+
+func sameRoutes(a, b []DaxAPI) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// weightedRoute returns one of routes other than exclude, picked at random
+// in proportion to the node weights, or nil if none has a positive weight.
+// It must be called with the lock held.
+func (c *cluster) weightedRoute(routes []DaxAPI, exclude ...DaxAPI) DaxAPI {
+	weights := c.cachedWeights(routes)
+	var total float64
+	for i, r := range routes {
+		if !excluded(r, exclude) {
+			total += weights[i]
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+	x := rand.Float64() * total
+	var last DaxAPI
+	for i, w := range weights {
+		if w <= 0 || excluded(routes[i], exclude) {
+			continue
+		}
+		if x < w {
+			return routes[i]
+		}
+		x -= w
+		last = routes[i]
+	}
+	return last
+}
+
+func excluded(r DaxAPI, exclude []DaxAPI) bool {
+	for _, e := range exclude {
+		if r == e {
+			return true
+		}
+	}
+	return false
+}
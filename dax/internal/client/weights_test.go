@@ -0,0 +1,152 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCluster_nodeWeight(t *testing.T) {
+	c := &cluster{config: Config{
+		NodeWeight: func(n types.NodeState) float64 {
+			if n.Hostname == "small" {
+				return 0.5
+			}
+			return 2
+		},
+		NodeRampUp: time.Minute,
+	}}
+	now := time.Now()
+	small := clientAndConfig{cfg: serviceEndpoint{hostname: "small"}}
+	large := clientAndConfig{cfg: serviceEndpoint{hostname: "large"}}
+	assert.Equal(t, 0.5, c.nodeWeight(small, now))
+	assert.Equal(t, 2.0, c.nodeWeight(large, now))
+
+	large.added = now.Add(-30 * time.Second)
+	assert.InDelta(t, 1.0, c.nodeWeight(large, now), 1e-9, "half way through the ramp up")
+	large.added = now
+	assert.InDelta(t, 2*minRampUpWeight, c.nodeWeight(large, now), 1e-9)
+	large.added = now.Add(-time.Hour)
+	assert.Equal(t, 2.0, c.nodeWeight(large, now))
+
+	c.config.NodeWeight = func(types.NodeState) float64 { return -1 }
+	assert.Zero(t, c.nodeWeight(small, now))
+}
+
+func TestCluster_weightedRoutes(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	cfg.NodeWeight = func(n types.NodeState) float64 {
+		if n.Hostname == "node3" {
+			return 0
+		}
+		return 1
+	}
+	cluster, builder := newTestClusterWithConfig(cfg)
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121, role: roleReplica},
+		{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121, role: roleReplica},
+	})
+	require.NoError(t, cluster.refreshNow())
+	defer cluster.Close()
+	nodes := builder.clients[1:]
+	require.Len(t, nodes, 3)
+
+	for i := 0; i < 100; i++ {
+		route, err := cluster.clientFor(nil, OpPutItem, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, DaxAPI(nodes[2]), route, "nodes without weight get no requests")
+
+		route, err = cluster.clientFor(nil, OpGetItem, types.ReadPreferenceReplica)
+		require.NoError(t, err)
+		assert.Equal(t, DaxAPI(nodes[1]), route)
+	}
+
+	// Retries avoid the previous node, preferring the leader to a node without weight.
+	route, err := cluster.clientFor(nodes[1], OpGetItem, types.ReadPreferenceReplica)
+	require.NoError(t, err)
+	assert.Equal(t, DaxAPI(nodes[0]), route)
+
+	// Nodes joining later are ramped up.
+	cluster.config.NodeRampUp = time.Hour
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader},
+		{hostname: "node4", address: net.ParseIP("127.0.0.4"), port: 8121, role: roleReplica},
+	})
+	require.NoError(t, cluster.refreshNow())
+	for _, ns := range cluster.state().Nodes {
+		if ns.Hostname == "node4" {
+			assert.InDelta(t, minRampUpWeight, ns.Weight, 0.01)
+		} else {
+			assert.Equal(t, 1.0, ns.Weight)
+		}
+	}
+
+	cluster.config.NodeWeight = func(types.NodeState) float64 { return 0 }
+	route, err = cluster.clientFor(nil, OpPutItem, "")
+	require.NoError(t, err)
+	assert.NotNil(t, route, "requests are routed when no node has a weight")
+
+	cfg.NodeRampUp = -time.Second
+	assert.Error(t, cfg.validate())
+}
+
+func TestCluster_weightsCached(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	var calls atomic.Int32
+	cfg.NodeWeight = func(types.NodeState) float64 {
+		calls.Add(1)
+		return 1
+	}
+	cluster, _ := newTestClusterWithConfig(cfg)
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader},
+		{hostname: "node2", address: net.ParseIP("127.0.0.2"), port: 8121, role: roleReplica},
+	})
+	require.NoError(t, cluster.refreshNow())
+	defer cluster.Close()
+
+	for i := 0; i < 100; i++ {
+		_, err := cluster.clientFor(nil, OpPutItem, "")
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, calls.Load(), "weights are computed once")
+
+	cluster.weights.Load().expires = time.Now()
+	_, err := cluster.clientFor(nil, OpPutItem, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, calls.Load(), "expired weights are computed again")
+
+	setExpectation(cluster, []serviceEndpoint{
+		{hostname: "node1", address: net.ParseIP("127.0.0.1"), port: 8121, role: roleLeader},
+		{hostname: "node3", address: net.ParseIP("127.0.0.3"), port: 8121, role: roleReplica},
+	})
+	require.NoError(t, cluster.refreshNow())
+	_, err = cluster.clientFor(nil, OpPutItem, "")
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, calls.Load(), "weights are computed again when the routes change")
+}
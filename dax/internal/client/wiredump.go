@@ -0,0 +1,58 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/hex"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/utils"
+	"github.com/aws/smithy-go/logging"
+)
+
+// startWireDump starts recording the frames exchanged on t when wire dumps are
+// enabled and returns a func logging them. The func must be called before the
+// tube is released to the pool, calling it more than once has no effect.
+func startWireDump(t tube, op string, opt RequestOptions) func() {
+	unredacted := opt.LogLevel.Matches(utils.LogDebugWithUnredactedWireDump)
+	if opt.Logger == nil || !(unredacted || opt.LogLevel.Matches(utils.LogDebugWithWireDump)) {
+		return func() {}
+	}
+	rec, ok := t.(wireRecorder)
+	if !ok {
+		return func() {}
+	}
+
+	rec.startRecording()
+	done := false
+	return func() {
+		if done {
+			return
+		}
+		done = true
+		sent, received := rec.stopRecording()
+		opt.Logger.Logf(logging.Debug, "Wire dump %s/%s request (%d bytes): %s", service, op, len(sent), formatWireFrame(sent, !unredacted))
+		opt.Logger.Logf(logging.Debug, "Wire dump %s/%s response (%d bytes): %s", service, op, len(received), formatWireFrame(received, !unredacted))
+	}
+}
+
+func formatWireFrame(b []byte, redact bool) string {
+	s := cbor.Diagnose(b, redact)
+	if !redact && len(b) > 0 {
+		s += "\n" + hex.Dump(b)
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/utils"
+	"github.com/aws/smithy-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Logf(_ logging.Classification, format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func executeWithWireDump(t *testing.T, level utils.LogLevelType) []string {
+	t.Helper()
+	ours, theirs := net.Pipe()
+	defer theirs.Close()
+	go io.Copy(io.Discard, theirs)
+	// no error followed by the response value
+	go theirs.Write([]byte{0x80, 0x63, 'o', 'u', 't'})
+
+	client, err := newSingleClientWithOptions(":9121", unEncryptedConnConfig, "us-west-2", &testCredentialProvider{}, 1, func(ctx context.Context, a, n string) (net.Conn, error) {
+		return ours, nil
+	}, nil, nil)
+	require.NoError(t, err)
+	defer client.Close()
+
+	logger := &recordingLogger{}
+	opt := RequestOptions{LogLevel: level}
+	opt.Logger = logger
+	writer := func(writer *cbor.Writer) error { return writer.WriteString("secret") }
+	reader := func(reader *cbor.Reader) error {
+		_, err := reader.ReadString()
+		return err
+	}
+	require.NoError(t, client.executeWithContext(context.Background(), OpGetItem, writer, reader, opt))
+
+	var dumps []string
+	for _, l := range logger.lines {
+		if strings.HasPrefix(l, "Wire dump") {
+			dumps = append(dumps, l)
+		}
+	}
+	return dumps
+}
+
+func TestWireDump(t *testing.T) {
+	lines := executeWithWireDump(t, utils.LogDebugWithUnredactedWireDump)
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "Wire dump dax/GetItem request (7 bytes): \"secret\"\n"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "Wire dump dax/GetItem response (5 bytes): [], \"out\"\n"), lines[1])
+	// auth frames are not recorded
+	assert.NotContains(t, lines[0], "token")
+}
+
+func TestWireDump_redacted(t *testing.T) {
+	lines := executeWithWireDump(t, utils.LogDebugWithWireDump)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "Wire dump dax/GetItem request (7 bytes): <redacted 6 bytes>", lines[0])
+	assert.Equal(t, "Wire dump dax/GetItem response (5 bytes): [], <redacted 3 bytes>", lines[1])
+}
+
+func TestWireDump_disabled(t *testing.T) {
+	assert.Empty(t, executeWithWireDump(t, utils.LogDebug))
+}
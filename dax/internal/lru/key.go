@@ -0,0 +1,90 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package lru
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// StringsKey is a comparable cache key for an ordered list of strings.
+// The list is encoded once into a single length-prefixed string, so two
+// keys are equal exactly when their lists are element-wise equal.
+type StringsKey struct {
+	enc string
+}
+
+// NewStringsKey returns the key for the list ss.
+func NewStringsKey(ss []string) StringsKey {
+	n := 0
+	for _, s := range ss {
+		n += uvarintLen(uint64(len(s))) + len(s)
+	}
+	var sb strings.Builder
+	sb.Grow(n)
+	var lb [binary.MaxVarintLen64]byte
+	for _, s := range ss {
+		l := binary.PutUvarint(lb[:], uint64(len(s)))
+		sb.Write(lb[:l])
+		sb.WriteString(s)
+	}
+	return StringsKey{enc: sb.String()}
+}
+
+// Size returns the number of bytes of the encoded key.
+func (k StringsKey) Size() int {
+	return len(k.enc)
+}
+
+// Strings decodes the list the key was built from.
+func (k StringsKey) Strings() []string {
+	cnt := 0
+	for rest := k.enc; len(rest) > 0; cnt++ {
+		l, n := uvarintString(rest)
+		rest = rest[n+int(l):]
+	}
+	ss := make([]string, 0, cnt)
+	for rest := k.enc; len(rest) > 0; {
+		l, n := uvarintString(rest)
+		ss = append(ss, rest[n:n+int(l)])
+		rest = rest[n+int(l):]
+	}
+	return ss
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for ; v >= 0x80; v >>= 7 {
+		n++
+	}
+	return n
+}
+
+// uvarintString is binary.Uvarint for a string, which avoids copying the
+// key into a byte slice.
+func uvarintString(s string) (uint64, int) {
+	var v uint64
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b < 0x80 {
+			return v | uint64(b)<<shift, i + 1
+		}
+		v |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+	return 0, len(s)
+}
@@ -17,140 +17,377 @@ package lru
 
 import (
 	"context"
+	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Lru is a cache which is safe for concurrent access.
-type Lru struct {
+//
+// Lookups read the index, a sync.Map, without locking, so they do not
+// contend with each other however many goroutines share the cache. Loads,
+// evictions and removals update the index and the entry list under a lock.
+type Lru[K comparable, V any] struct {
 	// MaxEntries is the maximum number of cache entries
 	// before an item is evicted. Zero means no limit.
 	MaxEntries int
 
+	// MaxBytes, if positive, is the maximum total size of the cache entries
+	// as reported by SizeFunc, which is required with it. Entries are evicted
+	// oldest first until both MaxEntries and MaxBytes are met; an entry
+	// larger than MaxBytes is returned but not kept.
+	MaxBytes int64
+	// SizeFunc returns the approximate memory used by an entry, in bytes.
+	SizeFunc func(key K, value V) int64
+
 	// LoadFunc specifies the function that loads a value
 	// for a specific key when not found in the cache.
-	LoadFunc  func(ctx context.Context, key Key) (interface{}, error)
-	loadGroup loadGroup
+	LoadFunc  func(ctx context.Context, key K) (V, error)
+	loadGroup loadGroup[K, V]
+
+	// TTL, if positive, is how long an entry is served after it was loaded.
+	// An expired entry is loaded again by the next lookup.
+	TTL time.Duration
+	// SoftTTL, if positive and less than TTL, is how long an entry is served
+	// before it is refreshed ahead of its expiry: the first lookup after it
+	// starts a load in the background and, like later lookups, is served the
+	// cached value meanwhile. A failed refresh is retried by the next lookup.
+	SoftTTL time.Duration
+	// TTLJitter randomizes TTL and SoftTTL of every entry by up to this
+	// fraction, in either direction, so entries loaded together, e.g. by
+	// clients started together, do not expire together. It must be in [0, 1).
+	TTLJitter float64
+	// TTLFunc, if set, returns the TTL of an entry in place of TTL, without
+	// SoftTTL. An entry it returns a negative TTL for is returned but not
+	// kept, one it returns zero for does not expire.
+	TTLFunc func(key K, value V) time.Duration
+
+	// OnEvent, if set, is called for every lookup served from the cache or
+	// not, every evicted entry and every failed load. It must not block.
+	OnEvent func(ctx context.Context, e Event)
 
-	// Optional KeyMarshaller. Caller should provide one when using
-	// Key type which is not comparable. eg. slice
-	KeyMarshaller func(key Key) Key
+	now func() time.Time // time.Now unless set by tests
 
-	mu         sync.RWMutex
-	cache      map[Key]*entry
-	head, tail *entry
+	index sync.Map // K to *entry[K, V], changed with mu held
+
+	mu         sync.Mutex // serializes changes to the index and the list
+	head, tail *entry[K, V]
+	count      int   // number of entries in the index
+	bytes      int64 // total size of the entries with MaxBytes
 }
 
-type Key interface{}
+// Event is a cache event reported to Lru.OnEvent.
+type Event int
+
+const (
+	// EventHit is a lookup served from the cache.
+	EventHit Event = iota
+	// EventMiss is a lookup which had to load its value, or wait for a
+	// concurrent lookup loading it.
+	EventMiss
+	// EventEviction is an entry evicted to stay within MaxEntries or MaxBytes.
+	EventEviction
+	// EventLoadError is a failed load, including a failed refresh.
+	EventLoadError
+)
+
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	size       int64
+	prev, next *entry[K, V]
 
-type entry struct {
-	key        Key
-	value      interface{}
-	prev, next *entry
+	expires    time.Time // zero without TTL
+	refreshAt  time.Time // zero without SoftTTL
+	refreshing atomic.Bool
 }
 
-func (c *Lru) contains(key Key) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	_, ok := c.cache[key]
+func (c *Lru[K, V]) contains(key K) bool {
+	_, ok := c.lookup(key)
 	return ok
 }
 
-func (c *Lru) lookup(key Key) (*entry, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	v, ok := c.cache[key]
-	return v, ok
+func (c *Lru[K, V]) lookup(key K) (*entry[K, V], bool) {
+	v, ok := c.index.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*entry[K, V]), true
 }
 
-func (c *Lru) GetWithContext(ctx context.Context, okey Key) (interface{}, error) {
-	ikey := okey
-	if c.KeyMarshaller != nil {
-		ikey = c.KeyMarshaller(okey)
-	}
+func (c *Lru[K, V]) GetWithContext(ctx context.Context, key K) (V, error) {
+	return c.GetOrLoad(ctx, key, c.LoadFunc)
+}
 
-	if en, ok := c.lookup(ikey); ok {
-		return en.value, nil
+// GetOrLoad is GetWithContext loading the value with loadFn instead of
+// LoadFunc, for values whose load depends on the request.
+func (c *Lru[K, V]) GetOrLoad(ctx context.Context, key K, loadFn func(ctx context.Context, key K) (V, error)) (V, error) {
+	if en, ok := c.lookup(key); ok {
+		now := c.clock()
+		if en.expires.IsZero() || now.Before(en.expires) {
+			if !en.refreshAt.IsZero() && !now.Before(en.refreshAt) && en.refreshing.CompareAndSwap(false, true) {
+				go c.refresh(ctx, en, loadFn)
+			}
+			c.report(ctx, EventHit, 1)
+			return en.value, nil
+		}
 	}
 
-	v, err := c.loadGroup.do(ikey, func() (interface{}, error) {
-		if en, ok := c.lookup(ikey); ok {
+	c.report(ctx, EventMiss, 1)
+	return c.loadGroup.do(key, func(l *loader[V]) (V, error) {
+		if en, ok := c.lookup(key); ok && (en.expires.IsZero() || c.clock().Before(en.expires)) {
 			return en.value, nil
 		}
+		return c.load(ctx, key, loadFn, l)
+	})
+}
 
-		val, err := c.LoadFunc(ctx, okey)
-		if err != nil {
-			return nil, err
-		}
+// refresh reloads the entry en ahead of its expiry. The request which found
+// it stale may be done by then, so the load is not canceled with ctx.
+func (c *Lru[K, V]) refresh(ctx context.Context, en *entry[K, V], loadFn func(ctx context.Context, key K) (V, error)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithoutCancel(ctx)
+	_, err := c.loadGroup.do(en.key, func(l *loader[V]) (V, error) {
+		return c.load(ctx, en.key, loadFn, l)
+	})
+	if err != nil {
+		en.refreshing.Store(false)
+	}
+}
 
-		c.mu.Lock()
-		defer c.mu.Unlock()
-		en := &entry{key: ikey, value: val}
-		if c.tail == nil {
-			c.head = en
-			c.tail = en
-		} else {
-			en.prev = c.tail
-			c.tail.next = en
-			c.tail = en
-		}
+// load loads the value for key with loadFn and stores it, replacing an
+// existing entry, unless l was invalidated by Remove or Clear meanwhile: the
+// value may predate the change which caused the invalidation.
+func (c *Lru[K, V]) load(ctx context.Context, key K, loadFn func(ctx context.Context, key K) (V, error), l *loader[V]) (V, error) {
+	val, err := loadFn(ctx, key)
+	if err != nil {
+		c.report(ctx, EventLoadError, 1)
+		var zero V
+		return zero, err
+	}
 
-		if c.cache == nil {
-			c.cache = make(map[Key]*entry)
-		}
-		c.cache[ikey] = en
-
-		// Evict oldest entry if over the max.
-		if c.MaxEntries > 0 && len(c.cache) > c.MaxEntries {
-			evict := c.head
-			if evict != nil {
-				delete(c.cache, evict.key)
-				c.head = evict.next
-				if c.head != nil {
-					c.head.prev = nil
-				}
-				evict.next = nil
+	en := &entry[K, V]{key: key, value: val}
+	if c.MaxBytes > 0 {
+		en.size = c.SizeFunc(key, val)
+		if en.size > c.MaxBytes {
+			// Kept, it would evict every other entry and still not fit. An
+			// existing entry for key is dropped as outdated.
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if !l.invalidated {
+				c.remove(key)
 			}
+			return val, nil
+		}
+	}
+	if c.TTLFunc != nil {
+		ttl := c.TTLFunc(key, val)
+		if ttl < 0 {
+			return val, nil
+		}
+		if ttl > 0 {
+			en.expires = c.clock().Add(Jitter(ttl, c.TTLJitter))
+		}
+	} else if c.TTL > 0 {
+		now := c.clock()
+		en.expires = now.Add(Jitter(c.TTL, c.TTLJitter))
+		if c.SoftTTL > 0 && c.SoftTTL < c.TTL {
+			en.refreshAt = now.Add(Jitter(c.SoftTTL, c.TTLJitter))
 		}
+	}
+
+	evicted := 0
+	defer func() { c.report(ctx, EventEviction, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l.invalidated {
 		return val, nil
-	})
-	return v, err
+	}
+	if old, ok := c.lookup(key); ok {
+		c.unlink(old)
+	} else {
+		c.count++
+	}
+	c.bytes += en.size
+	if c.tail == nil {
+		c.head = en
+		c.tail = en
+	} else {
+		en.prev = c.tail
+		c.tail.next = en
+		c.tail = en
+	}
+
+	c.index.Store(key, en)
+
+	// Evict oldest entries while over the max.
+	for c.head != nil && (c.MaxEntries > 0 && c.count > c.MaxEntries || c.MaxBytes > 0 && c.bytes > c.MaxBytes) {
+		evict := c.head
+		c.index.Delete(evict.key)
+		c.unlink(evict)
+		c.count--
+		evicted++
+	}
+	return val, nil
 }
 
-type loader struct {
+// report calls OnEvent n times for e.
+func (c *Lru[K, V]) report(ctx context.Context, e Event, n int) {
+	if c.OnEvent == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		c.OnEvent(ctx, e)
+	}
+}
+
+func (c *Lru[K, V]) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Jitter randomizes d by up to fraction of it in either direction.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return d + time.Duration((2*rand.Float64()-1)*fraction*float64(d))
+}
+
+// Remove evicts the entry for the given key, if present.
+func (c *Lru[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadGroup.invalidate(key)
+	c.remove(key)
+}
+
+// remove evicts the entry for key, if present.
+// c.mu must be held when calling this method
+func (c *Lru[K, V]) remove(key K) {
+	en, ok := c.lookup(key)
+	if !ok {
+		return
+	}
+	c.index.Delete(key)
+	c.unlink(en)
+	c.count--
+}
+
+// Clear evicts all entries from the cache.
+func (c *Lru[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadGroup.invalidateAll()
+	for en := c.head; en != nil; en = en.next {
+		c.index.Delete(en.key)
+	}
+	c.head = nil
+	c.tail = nil
+	c.count = 0
+	c.bytes = 0
+}
+
+// Len returns the number of entries currently in the cache.
+func (c *Lru[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.count
+}
+
+// Bytes returns the total size of the entries currently in the cache, zero
+// unless MaxBytes is set.
+func (c *Lru[K, V]) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// unlink detaches en from the entry list.
+// c.mu must be held when calling this method
+func (c *Lru[K, V]) unlink(en *entry[K, V]) {
+	c.bytes -= en.size
+	if en.prev != nil {
+		en.prev.next = en.next
+	} else {
+		c.head = en.next
+	}
+	if en.next != nil {
+		en.next.prev = en.prev
+	} else {
+		c.tail = en.prev
+	}
+	en.prev = nil
+	en.next = nil
+}
+
+type loader[V any] struct {
 	wg    sync.WaitGroup
-	value interface{}
+	value V
 	err   error
+
+	// invalidated is set, with the Lru mutex held, when the entry being
+	// loaded is removed or cleared.
+	invalidated bool
 }
 
-type loadGroup struct {
+type loadGroup[K comparable, V any] struct {
 	mu sync.Mutex
-	m  map[Key]*loader
+	m  map[K]*loader[V]
 }
 
-func (g *loadGroup) do(key Key, loadFn func() (interface{}, error)) (interface{}, error) {
+func (g *loadGroup[K, V]) do(key K, loadFn func(l *loader[V]) (V, error)) (V, error) {
 	g.mu.Lock()
 	if g.m == nil {
-		g.m = make(map[Key]*loader)
+		g.m = make(map[K]*loader[V])
 	}
 	if l, ok := g.m[key]; ok {
 		g.mu.Unlock()
 		l.wg.Wait()
 		return l.value, l.err
 	}
-	v := &loader{}
+	v := &loader[V]{}
 	v.wg.Add(1)
 	g.m[key] = v
 	g.mu.Unlock()
 
-	v.value, v.err = loadFn()
+	v.value, v.err = loadFn(v)
 	v.wg.Done()
 
-	// cleanup loader
+	// cleanup loader, unless invalidated and replaced already
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	delete(g.m, key)
+	if g.m[key] == v {
+		delete(g.m, key)
+	}
 	if len(g.m) <= 0 {
 		g.m = nil
 	}
 	return v.value, v.err
 }
+
+// invalidate marks the load of key in progress, if any, as invalidated and
+// lets later lookups start a new one rather than wait for it.
+func (g *loadGroup[K, V]) invalidate(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if l, ok := g.m[key]; ok {
+		l.invalidated = true
+		delete(g.m, key)
+	}
+}
+
+// invalidateAll invalidates every load in progress.
+func (g *loadGroup[K, V]) invalidateAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, l := range g.m {
+		l.invalidated = true
+	}
+	g.m = nil
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -27,8 +28,8 @@ import (
 )
 
 func TestLruGet(t *testing.T) {
-	c := &Lru{
-		LoadFunc: func(ctx context.Context, key Key) (interface{}, error) {
+	c := &Lru[any, any]{
+		LoadFunc: func(ctx context.Context, key any) (interface{}, error) {
 			return key, nil
 		},
 	}
@@ -44,21 +45,18 @@ func TestLruGet(t *testing.T) {
 	}
 }
 
-func TestLruKeyMarshaller(t *testing.T) {
+func TestLruStringsKey(t *testing.T) {
 	loadCount := 0
-	c := &Lru{
-		LoadFunc: func(ctx context.Context, key Key) (interface{}, error) {
+	c := &Lru[StringsKey, []string]{
+		LoadFunc: func(ctx context.Context, key StringsKey) ([]string, error) {
 			loadCount++
-			return key, nil
-		},
-		KeyMarshaller: func(key Key) Key {
-			return fmt.Sprintf("%q", key)
+			return key.Strings(), nil
 		},
 	}
 
 	k := []string{"a", "b", "c"}
 	for i := 0; i < 3; i++ {
-		if v, err := c.GetWithContext(nil, k); err != nil {
+		if v, err := c.GetWithContext(nil, NewStringsKey(k)); err != nil {
 			t.Errorf("unexpected error %v", err)
 		} else if !reflect.DeepEqual(v, k) {
 			t.Errorf("expected %v, got %v", k, v)
@@ -69,14 +67,48 @@ func TestLruKeyMarshaller(t *testing.T) {
 	}
 }
 
+func TestStringsKey(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	lists := [][]string{
+		{},
+		{""},
+		{"", ""},
+		{"a"},
+		{"ab"},
+		{"a", "b"},
+		{"ab", ""},
+		{"", "ab"},
+		{"a\x00b"},
+		{"a", "\x00b"},
+		{long},
+		{long, "b"},
+	}
+	for i, l := range lists {
+		k := NewStringsKey(l)
+		got := k.Strings()
+		if len(l) == 0 {
+			if len(got) != 0 {
+				t.Errorf("Strings() got %q, want empty", got)
+			}
+		} else if !reflect.DeepEqual(got, l) {
+			t.Errorf("Strings() got %q, want %q", got, l)
+		}
+		for j, o := range lists {
+			if eq := k == NewStringsKey(o); eq != (i == j) {
+				t.Errorf("NewStringsKey(%q) == NewStringsKey(%q) got %v", l, o, eq)
+			}
+		}
+	}
+}
+
 func TestLruEvict(t *testing.T) {
 	loads := 0
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		loads++
 		return key, nil
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
@@ -121,7 +153,7 @@ func TestLruEvict(t *testing.T) {
 }
 
 func TestLruTimeout(t *testing.T) {
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -129,7 +161,7 @@ func TestLruTimeout(t *testing.T) {
 		return key, nil
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
@@ -149,13 +181,13 @@ func TestLruTimeout(t *testing.T) {
 func TestLruConcurrentLoad(t *testing.T) {
 	var loads int32
 	loadTime := 10 * time.Millisecond
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		<-time.After(loadTime)
 		atomic.AddInt32(&loads, 1)
 		return key, nil
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 1000,
 		LoadFunc:   loadFn,
 	}
@@ -167,8 +199,8 @@ func TestLruConcurrentLoad(t *testing.T) {
 	st := time.Now()
 	for k := 0; k < keys; k++ {
 		for g := 0; g < gets; g++ {
-			var key Key = k
-			go func(key Key) {
+			var key any = k
+			go func(key any) {
 				v, err := c.GetWithContext(nil, key)
 				if err != nil {
 					t.Errorf("Lru.Get(%v) got error %v", key, err)
@@ -195,11 +227,11 @@ func TestLruConcurrentLoad(t *testing.T) {
 
 func TestLruSingleLoader(t *testing.T) {
 	valueCh := make(chan interface{})
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		return <-valueCh, nil
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
@@ -225,12 +257,12 @@ func TestLruSingleLoader(t *testing.T) {
 
 func TestLoadGroup(t *testing.T) {
 	loadCh := make(chan interface{})
-	loadFn := func() (interface{}, error) {
+	loadFn := func(*loader[any]) (interface{}, error) {
 		return <-loadCh, nil
 	}
 
 	key := "key1"
-	l := &loadGroup{}
+	l := &loadGroup[any, any]{}
 	done := make(chan struct{})
 	go func() {
 		v, err := l.do(key, loadFn)
@@ -251,7 +283,7 @@ func TestLoadGroup(t *testing.T) {
 }
 
 func TestLruTimeoutExceeded(t *testing.T) {
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		// Wait until the context is done
 		select {
 		case <-ctx.Done():
@@ -261,7 +293,7 @@ func TestLruTimeoutExceeded(t *testing.T) {
 		}
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
@@ -285,19 +317,19 @@ func TestLruTimeoutExceeded(t *testing.T) {
 }
 
 func TestLruGetWithNilKey(t *testing.T) {
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		if key == nil {
 			return nil, fmt.Errorf("key cannot be nil")
 		}
 		return key, nil
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
 
-	var key Key = nil // Explicitly assign nil to Key type
+	var key any = nil // Explicitly assign nil interface key
 	v, err := c.GetWithContext(context.Background(), key)
 
 	if err == nil {
@@ -312,9 +344,9 @@ func TestLruGetWithNilKey(t *testing.T) {
 }
 
 func TestLruEvictBeyondCapacity(t *testing.T) {
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 5,
-		LoadFunc: func(ctx context.Context, key Key) (interface{}, error) {
+		LoadFunc: func(ctx context.Context, key any) (interface{}, error) {
 			return key, nil
 		},
 	}
@@ -341,11 +373,11 @@ func TestLruEvictBeyondCapacity(t *testing.T) {
 }
 
 func TestLruConcurrentInvalidKey(t *testing.T) {
-	loadFn := func(ctx context.Context, key Key) (interface{}, error) {
+	loadFn := func(ctx context.Context, key any) (interface{}, error) {
 		return nil, fmt.Errorf("invalid key: %v", key)
 	}
 
-	c := &Lru{
+	c := &Lru[any, any]{
 		MaxEntries: 100,
 		LoadFunc:   loadFn,
 	}
@@ -354,7 +386,7 @@ func TestLruConcurrentInvalidKey(t *testing.T) {
 	wg.Add(10)
 
 	for i := 0; i < 10; i++ {
-		go func(key Key) {
+		go func(key any) {
 			defer wg.Done()
 			_, err := c.GetWithContext(nil, key)
 			if err == nil {
@@ -367,14 +399,416 @@ func TestLruConcurrentInvalidKey(t *testing.T) {
 }
 
 func BenchmarkLruGet(b *testing.B) {
-	c := &Lru{
-		LoadFunc: func(ctx context.Context, key Key) (interface{}, error) {
+	c := &Lru[int64, int64]{
+		LoadFunc: func(ctx context.Context, key int64) (int64, error) {
 			return key, nil
 		},
 	}
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
 		c.GetWithContext(nil, 123)
 	}
 }
+
+func BenchmarkLruGetParallel(b *testing.B) {
+	c := &Lru[int64, int64]{
+		LoadFunc: func(ctx context.Context, key int64) (int64, error) {
+			return key, nil
+		},
+	}
+	for i := int64(0); i < 64; i++ {
+		c.GetWithContext(nil, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var i int64
+		for pb.Next() {
+			c.GetWithContext(nil, i%64)
+			i++
+		}
+	})
+}
+
+func BenchmarkLruGetStringsKey(b *testing.B) {
+	c := &Lru[StringsKey, int64]{
+		LoadFunc: func(ctx context.Context, key StringsKey) (int64, error) {
+			return 1, nil
+		},
+	}
+	names := []string{"attr1", "attr2", "attr3", "attr4", "attr5"}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.GetWithContext(nil, NewStringsKey(names))
+	}
+}
+
+func TestLruRemove(t *testing.T) {
+	loads := 0
+	c := &Lru[any, any]{
+		LoadFunc: func(ctx context.Context, key any) (interface{}, error) {
+			loads++
+			return key, nil
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetWithContext(nil, i); err != nil {
+			t.Fatalf("Lru.Get(%v) got error %v", i, err)
+		}
+	}
+
+	c.Remove(1)
+	c.Remove(42) // absent keys are ignored
+	if c.contains(1) {
+		t.Fatalf("Lru.contains(%v) want false", 1)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Lru.Len() got %v want %v", c.Len(), 2)
+	}
+	if c.head.key != 0 || c.tail.key != 2 || c.head.next != c.tail || c.tail.prev != c.head {
+		t.Fatalf("unexpected entry list after remove")
+	}
+
+	if _, err := c.GetWithContext(nil, 1); err != nil {
+		t.Fatalf("Lru.Get(%v) got error %v", 1, err)
+	}
+	if loads != 4 {
+		t.Fatalf("load calls got %v want %v", loads, 4)
+	}
+}
+
+func TestLruRemoveStringsKey(t *testing.T) {
+	c := &Lru[StringsKey, []string]{
+		LoadFunc: func(ctx context.Context, key StringsKey) ([]string, error) {
+			return key.Strings(), nil
+		},
+	}
+
+	k := []string{"a", "b"}
+	if _, err := c.GetWithContext(nil, NewStringsKey(k)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c.Remove(NewStringsKey(k))
+	if c.Len() != 0 {
+		t.Fatalf("Lru.Len() got %v want %v", c.Len(), 0)
+	}
+}
+
+func TestLruClear(t *testing.T) {
+	loads := 0
+	c := &Lru[any, any]{
+		MaxEntries: 10,
+		LoadFunc: func(ctx context.Context, key any) (interface{}, error) {
+			loads++
+			return key, nil
+		},
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetWithContext(nil, i); err != nil {
+			t.Fatalf("Lru.Get(%v) got error %v", i, err)
+		}
+	}
+	c.Clear()
+	if c.Len() != 0 || c.head != nil || c.tail != nil {
+		t.Fatalf("expected empty cache after Clear")
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := c.GetWithContext(nil, i); err != nil {
+			t.Fatalf("Lru.Get(%v) got error %v", i, err)
+		}
+	}
+	if loads != 10 {
+		t.Fatalf("load calls got %v want %v", loads, 10)
+	}
+}
+
+func TestLruInvalidateDuringLoad(t *testing.T) {
+	for name, invalidate := range map[string]func(c *Lru[string, int64]){
+		"Remove": func(c *Lru[string, int64]) { c.Remove("k") },
+		"Clear":  func(c *Lru[string, int64]) { c.Clear() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var loads atomic.Int64
+			started, release := make(chan struct{}), make(chan struct{})
+			c := &Lru[string, int64]{
+				LoadFunc: func(ctx context.Context, key string) (int64, error) {
+					n := loads.Add(1)
+					if n == 1 {
+						close(started)
+						<-release
+					}
+					return n, nil
+				},
+			}
+			ctx := context.Background()
+
+			done := make(chan int64)
+			go func() {
+				v, _ := c.GetWithContext(ctx, "k")
+				done <- v
+			}()
+			<-started
+			invalidate(c)
+
+			// a lookup after the invalidation does not wait for the load
+			// which started before it
+			if v, _ := c.GetWithContext(ctx, "k"); v != 2 {
+				t.Errorf("expected a new load, got %v", v)
+			}
+			close(release)
+			if v := <-done; v != 1 {
+				t.Errorf("expected the first load, got %v", v)
+			}
+
+			// and the outdated value is not stored over the new one
+			if v, _ := c.GetWithContext(ctx, "k"); v != 2 {
+				t.Errorf("expected the value of the second load, got %v", v)
+			}
+			if n := loads.Load(); n != 2 {
+				t.Errorf("expected 2 loads, got %d", n)
+			}
+		})
+	}
+}
+
+// fakeClock is a settable time source for TTL tests.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (f *fakeClock) now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.t
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.t = f.t.Add(d)
+}
+
+func TestLruTTL(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var loads atomic.Int32
+	c := &Lru[string, int32]{
+		TTL: time.Minute,
+		LoadFunc: func(ctx context.Context, key string) (int32, error) {
+			return loads.Add(1), nil
+		},
+		now: clock.now,
+	}
+
+	if v, _ := c.GetWithContext(context.Background(), "k"); v != 1 {
+		t.Fatalf("expected first load, got %d", v)
+	}
+	clock.advance(59 * time.Second)
+	if v, _ := c.GetWithContext(context.Background(), "k"); v != 1 {
+		t.Errorf("expected cached value before TTL, got %d", v)
+	}
+	clock.advance(time.Second)
+	if v, _ := c.GetWithContext(context.Background(), "k"); v != 2 {
+		t.Errorf("expected reload after TTL, got %d", v)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected the expired entry to be replaced, got %d entries", c.Len())
+	}
+}
+
+func TestLruTTLFunc(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var loads atomic.Int32
+	c := &Lru[string, int32]{
+		TTL: time.Hour,
+		TTLFunc: func(key string, _ int32) time.Duration {
+			switch key {
+			case "short":
+				return time.Minute
+			case "uncached":
+				return -1
+			}
+			return 0
+		},
+		now: clock.now,
+	}
+	load := func(ctx context.Context, key string) (int32, error) {
+		return loads.Add(1), nil
+	}
+
+	c.GetOrLoad(context.Background(), "short", load)
+	c.GetOrLoad(context.Background(), "forever", load)
+	if v, _ := c.GetOrLoad(context.Background(), "uncached", load); v != 3 {
+		t.Fatalf("expected the loaded value, got %d", v)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected the negative TTL entry not to be kept, got %d entries", c.Len())
+	}
+	clock.advance(2 * time.Hour)
+	if v, _ := c.GetOrLoad(context.Background(), "forever", load); v != 2 {
+		t.Errorf("expected the zero TTL entry not to expire, got %d", v)
+	}
+	if v, _ := c.GetOrLoad(context.Background(), "short", load); v != 4 {
+		t.Errorf("expected reload after the entry TTL, got %d", v)
+	}
+}
+
+func TestLruTTLJitter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	c := &Lru[int, int]{
+		TTL:       time.Minute,
+		TTLJitter: 0.5,
+		LoadFunc: func(ctx context.Context, key int) (int, error) {
+			return key, nil
+		},
+		now: clock.now,
+	}
+
+	expiries := map[time.Time]bool{}
+	for i := 0; i < 100; i++ {
+		if _, err := c.GetWithContext(context.Background(), i); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		en, _ := c.lookup(i)
+		ttl := en.expires.Sub(clock.now())
+		if ttl < 30*time.Second || ttl > 90*time.Second {
+			t.Errorf("TTL %v out of jitter range", ttl)
+		}
+		expiries[en.expires] = true
+	}
+	if len(expiries) < 50 {
+		t.Errorf("expected spread expiries, got %d distinct of 100", len(expiries))
+	}
+}
+
+func TestLruSoftTTL(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	var loads atomic.Int32
+	var fail atomic.Bool
+	loaded := make(chan struct{}, 10)
+	c := &Lru[string, int32]{
+		TTL:     time.Minute,
+		SoftTTL: 30 * time.Second,
+		LoadFunc: func(ctx context.Context, key string) (int32, error) {
+			defer func() { loaded <- struct{}{} }()
+			if fail.Load() {
+				return 0, errors.New("load failed")
+			}
+			return loads.Add(1), nil
+		},
+		now: clock.now,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.GetWithContext(ctx, "k")
+	<-loaded
+	clock.advance(30 * time.Second)
+
+	// a failed refresh keeps the value and is retried by the next lookup
+	fail.Store(true)
+	if v, _ := c.GetWithContext(ctx, "k"); v != 1 {
+		t.Errorf("expected the stale value while refreshing, got %d", v)
+	}
+	<-loaded
+	for en, _ := c.lookup("k"); en.refreshing.Load(); {
+		time.Sleep(time.Millisecond)
+	}
+	fail.Store(false)
+	cancel() // the refresh outlives the request
+	if v, _ := c.GetWithContext(ctx, "k"); v != 1 {
+		t.Errorf("expected the stale value while refreshing, got %d", v)
+	}
+	<-loaded
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		en, _ := c.lookup("k")
+		if en.value == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("refresh was not stored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if v, _ := c.GetWithContext(ctx, "k"); v != 2 {
+		t.Errorf("expected the refreshed value, got %d", v)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("expected 2 successful loads, got %d", n)
+	}
+}
+
+func TestLruMaxBytes(t *testing.T) {
+	c := &Lru[string, string]{
+		MaxEntries: 10,
+		MaxBytes:   10,
+		SizeFunc:   func(key, value string) int64 { return int64(len(value)) },
+		LoadFunc: func(ctx context.Context, key string) (string, error) {
+			return strings.Repeat("x", len(key)), nil
+		},
+	}
+	ctx := context.Background()
+
+	for _, k := range []string{"aaaa", "bbbb"} {
+		c.GetWithContext(ctx, k)
+	}
+	if c.Len() != 2 || c.Bytes() != 8 {
+		t.Fatalf("expected 2 entries of 8 bytes, got %d of %d", c.Len(), c.Bytes())
+	}
+
+	c.GetWithContext(ctx, "ccc")
+	if c.contains("aaaa") || !c.contains("bbbb") || !c.contains("ccc") {
+		t.Errorf("expected the oldest entry to be evicted")
+	}
+	if c.Bytes() != 7 {
+		t.Errorf("expected 7 bytes, got %d", c.Bytes())
+	}
+
+	// an entry over the budget is returned but not kept, nor evicts others
+	if v, _ := c.GetWithContext(ctx, "ddddddddddd"); len(v) != 11 {
+		t.Errorf("expected the loaded value, got %q", v)
+	}
+	if c.contains("ddddddddddd") || !c.contains("bbbb") || !c.contains("ccc") || c.Bytes() != 7 {
+		t.Errorf("expected the cache unchanged, got %d entries of %d bytes", c.Len(), c.Bytes())
+	}
+
+	c.GetWithContext(ctx, "ee")
+	c.Remove("ee")
+	if c.Bytes() != 7 {
+		t.Errorf("expected 7 bytes after Remove, got %d", c.Bytes())
+	}
+}
+
+func TestLruOnEvent(t *testing.T) {
+	events := map[Event]int{}
+	c := &Lru[string, string]{
+		MaxEntries: 1,
+		LoadFunc: func(ctx context.Context, key string) (string, error) {
+			if key == "bad" {
+				return "", errors.New("load failed")
+			}
+			return key, nil
+		},
+		OnEvent: func(ctx context.Context, e Event) { events[e]++ },
+	}
+	ctx := context.Background()
+
+	c.GetWithContext(ctx, "a")
+	c.GetWithContext(ctx, "a")
+	c.GetWithContext(ctx, "b")
+	c.GetWithContext(ctx, "bad")
+
+	expected := map[Event]int{EventHit: 1, EventMiss: 3, EventEviction: 1, EventLoadError: 1}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
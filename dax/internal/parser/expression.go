@@ -57,13 +57,12 @@ type ExpressionEncoder struct {
 	nestingLevel      int
 	err               error
 
-	// temporary buffer/writer
+	// temporary buffer/writer, set while parsing
 	cborWriter *cbor.Writer
 	buf        *bytes.Buffer
 }
 
 func NewExpressionEncoder(expr map[int]string, subs map[string]string, vars map[string]types.AttributeValue) *ExpressionEncoder {
-	var b bytes.Buffer
 	us := make(stringSet, len(subs))
 	us.addKeysStrVal(subs)
 	uv := make(stringSet, len(vars))
@@ -78,9 +77,6 @@ func NewExpressionEncoder(expr map[int]string, subs map[string]string, vars map[
 		variableValues:    make([]types.AttributeValue, 0, len(vars)),
 		unusedSubstitutes: us,
 		unusedVariables:   uv,
-
-		cborWriter: cbor.NewWriter(&b),
-		buf:        &b,
 	}
 }
 
@@ -88,6 +84,15 @@ func (e *ExpressionEncoder) Parse() (map[int][]byte, error) {
 	if len(e.expressions) == 0 || len(e.encoded) == len(e.expressions) {
 		return e.encoded, nil
 	}
+	// The encoded expressions are copied out of buf, which goes back to the pool.
+	e.buf = cbor.GetBuffer()
+	e.cborWriter = cbor.NewWriter(e.buf)
+	defer func() {
+		e.cborWriter.Close()
+		cbor.PutBuffer(e.buf)
+		e.cborWriter, e.buf = nil, nil
+	}()
+
 	var err error
 	for k, v := range e.expressions {
 		e.reset(k)
@@ -0,0 +1,168 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TestExpressionEncoderConformance encodes expressions written by the
+// feature/dynamodb/expression builder. Encodings are compared in cbor
+// diagnostic notation, where the first element of an array is the operation,
+// e.g. 18 for a document path and 17 for a value.
+func TestExpressionEncoderConformance(t *testing.T) {
+	ss := &types.AttributeValueMemberSS{Value: []string{"x"}}
+	ns := &types.AttributeValueMemberNS{Value: []string{"1", "2.5"}}
+	bs := &types.AttributeValueMemberBS{Value: [][]byte{{1}}}
+	const setValues = `[3321(["x"]), 3322([1, 4([-1, 25])]), 3323([h'01'])]`
+	a, b := expression.Name("a"), expression.Name("b")
+
+	cases := []struct {
+		name    string
+		typ     int
+		builder expression.Builder
+		out     string
+	}{
+		{
+			name:    "nested path",
+			typ:     ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(expression.Name("a.b[2].c").Equal(expression.Value(3))),
+			out:     `[1, [0, [18, "a", "b", 3324(2), "c"], [17, 0]], [3]]`,
+		},
+		{
+			name:    "size",
+			typ:     ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(expression.Name("a.b[0]").Size().GreaterThan(expression.Value(3))),
+			out:     `[1, [4, [16, [18, "a", "b", 3324(0)]], [17, 0]], [3]]`,
+		},
+		{
+			name:    "size compared to size",
+			typ:     ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(a.Size().LessThanEqual(b.Size())),
+			out:     `[1, [5, [16, [18, "a"]], [16, [18, "b"]]], []]`,
+		},
+		{
+			name: "size between",
+			typ:  ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(
+				a.Size().Between(expression.Value(ss), expression.Value(ns)).And(b.Equal(expression.Value(bs)))),
+			out: `[1, [6, [9, [16, [18, "a"]], [17, 0], [17, 1]], [0, [18, "b"], [17, 2]]], ` + setValues + `]`,
+		},
+		{
+			name: "contains on sets",
+			typ:  ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(expression.And(
+				a.Contains(ss), expression.Name("b.c[1]").Contains(ns), expression.Not(a.Contains(bs)))),
+			out: `[1, [6, [6, [15, [18, "a"], [17, 0]], [15, [18, "b", "c", 3324(1)], [17, 1]]], [8, [15, [18, "a"], [17, 2]]]], ` + setValues + `]`,
+		},
+		{
+			name: "in list",
+			typ:  ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(
+				expression.Name("a.b[2].c").In(expression.Value(ss), expression.Value(ns), expression.Value(bs))),
+			out: `[1, [10, [18, "a", "b", 3324(2), "c"], [[17, 0], [17, 1], [17, 2]]], ` + setValues + `]`,
+		},
+		{
+			name: "in list with size",
+			typ:  ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(
+				a.In(b.Size(), expression.Value(3)).Or(a.In(expression.Value(3)))),
+			out: `[1, [7, [10, [18, "a"], [[16, [18, "b"]], [17, 0]]], [10, [18, "a"], [[17, 1]]]], [3, 3]]`,
+		},
+		{
+			name:    "attribute type",
+			typ:     ConditionExpr,
+			builder: expression.NewBuilder().WithCondition(a.AttributeType(expression.StringSet)),
+			out:     `[1, [13, [18, "a"], [17, 0]], ["SS"]]`,
+		},
+		{
+			name: "key condition",
+			typ:  KeyConditionExpr,
+			builder: expression.NewBuilder().WithKeyCondition(
+				expression.Key("a").Equal(expression.Value(3)).And(expression.Key("b").BeginsWith("x"))),
+			out: `[1, [6, [0, [18, "a"], [17, 0]], [14, [18, "b"], [17, 1]]], [3, "x"]]`,
+		},
+		{
+			name: "add sets",
+			typ:  UpdateExpr,
+			builder: expression.NewBuilder().WithUpdate(expression.
+				Add(a, expression.Value(ss)).
+				Add(b, expression.Value(ns)).
+				Add(expression.Name("c"), expression.Value(bs))),
+			out: `[1, [[20, [18, "a"], [17, 0]], [20, [18, "b"], [17, 1]], [20, [18, "c"], [17, 2]]], ` + setValues + `]`,
+		},
+		{
+			name: "delete sets",
+			typ:  UpdateExpr,
+			builder: expression.NewBuilder().WithUpdate(expression.
+				Delete(a, expression.Value(ss)).
+				Delete(b, expression.Value(ns)).
+				Delete(expression.Name("c"), expression.Value(bs))),
+			out: `[1, [[21, [18, "a"], [17, 0]], [21, [18, "b"], [17, 1]], [21, [18, "c"], [17, 2]]], ` + setValues + `]`,
+		},
+		{
+			name: "all update clauses",
+			typ:  UpdateExpr,
+			builder: expression.NewBuilder().WithUpdate(expression.
+				Add(expression.Name("a.b[1]"), expression.Value(ss)).
+				Delete(expression.Name("b.c"), expression.Value(ns)).
+				Remove(expression.Name("a[0]")).
+				Set(expression.Name("c"), expression.IfNotExists(expression.Name("c"), expression.Value(bs)))),
+			out: `[1, [[20, [18, "a", "b", 3324(1)], [17, 0]], [21, [18, "b", "c"], [17, 1]], [22, [18, "a", 3324(0)]], ` +
+				`[19, [18, "c"], [23, [18, "c"], [17, 2]]]], ` + setValues + `]`,
+		},
+		{
+			name: "set operations",
+			typ:  UpdateExpr,
+			builder: expression.NewBuilder().WithUpdate(expression.
+				Set(a, expression.Plus(expression.IfNotExists(a, expression.Value(3)), expression.Value(3))).
+				Set(b, expression.ListAppend(b, expression.Value([]string{"x"})))),
+			out: `[1, [[19, [18, "a"], [25, [23, [18, "a"], [17, 0]], [17, 1]]], [19, [18, "b"], [24, [18, "b"], [17, 2]]]], [3, 3, ["x"]]]`,
+		},
+		{
+			name:    "projection",
+			typ:     ProjectionExpr,
+			builder: expression.NewBuilder().WithProjection(expression.NamesList(a, expression.Name("b.c[3]"))),
+			out:     `[1, [[18, "a"], [18, "b", "c", 3324(3)]]]`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expr, err := c.builder.Build()
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			in := *map[int]*string{
+				ConditionExpr:    expr.Condition(),
+				KeyConditionExpr: expr.KeyCondition(),
+				UpdateExpr:       expr.Update(),
+				ProjectionExpr:   expr.Projection(),
+			}[c.typ]
+			encoded, err := NewExpressionEncoder(map[int]string{c.typ: in}, expr.Names(), expr.Values()).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error %v for %q", err, in)
+			}
+			if actual := cbor.Diagnose(encoded[c.typ], false); actual != c.out {
+				t.Errorf("expected %s, actual %s for %q", c.out, actual, in)
+			}
+		})
+	}
+}
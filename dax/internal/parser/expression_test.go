@@ -113,6 +113,11 @@ func TestExpressionEncoder(t *testing.T) {
 			in:  "attribute_exists(a)",
 			out: fromHex("0x8301820B8212616180"),
 		},
+		{
+			typ: ConditionExpr,
+			in:  "attribute_exists(a[1][2].b)",
+			out: fromHex("0x8301820B85126161D90CFC01D90CFC02616280"),
+		},
 		{
 			typ:  ConditionExpr,
 			in:   "attribute_not_exists(#a.k1)",
@@ -0,0 +1,139 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyInit holds the state of a client created with NewLazy.
+type lazyInit struct {
+	provider func(ctx context.Context) (Config, error)
+	done     atomic.Bool
+
+	mu      sync.Mutex
+	closed  bool      // protected by mu
+	pending *lazyCall // initialization in progress, protected by mu
+}
+
+// lazyCall is an initialization shared by the requests waiting for it.
+type lazyCall struct {
+	done chan struct{} // closed once err is set
+	err  error
+}
+
+// NewLazy creates a DAX client which defers loading its configuration and
+// connecting to the cluster until the first request, so it can be constructed
+// without blocking on DAX availability.
+//
+// Concurrent first requests share a single initialization. If configProvider
+// or the client creation fails the error is returned to the waiting requests
+// and the next request tries again. A request whose context is done stops
+// waiting without canceling the initialization.
+func NewLazy(configProvider func(ctx context.Context) (Config, error)) *Dax {
+	return &Dax{lazy: &lazyInit{provider: configProvider}}
+}
+
+// init creates the client of a lazily constructed Dax if that has not happened
+// yet. The initialization is shared with concurrent requests, so it runs with
+// the values and deadline of ctx but is not canceled with it; init returns
+// early if ctx is done first.
+func (d *Dax) init(ctx context.Context) error {
+	l := d.lazy
+	if l == nil || l.done.Load() {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	l.mu.Lock()
+	if l.done.Load() {
+		l.mu.Unlock()
+		return nil
+	}
+	if l.closed {
+		l.mu.Unlock()
+		return os.ErrClosed
+	}
+	call := l.pending
+	if call == nil {
+		call = &lazyCall{done: make(chan struct{})}
+		l.pending = call
+		ictx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if deadline, ok := ctx.Deadline(); ok {
+			ictx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline)
+		}
+		go func() {
+			defer cancel()
+			d.initLazy(ictx, call)
+		}()
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// initLazy runs the initialization call and delivers its error to the
+// requests waiting for it. A client created after Close is closed again.
+func (d *Dax) initLazy(ctx context.Context, call *lazyCall) {
+	l := d.lazy
+	cfg, err := l.provider(ctx)
+	var c *Dax
+	if err == nil {
+		c, err = New(cfg)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = nil
+	if err == nil && l.closed {
+		c.Close()
+		err = os.ErrClosed
+	}
+	if err == nil {
+		d.client, d.config, d.scans = c.client, c.config, c.scans
+		l.done.Store(true)
+	}
+	call.err = err
+	close(call.done)
+}
+
+// initialized reports whether the client is ready to use, which is always the case unless created with NewLazy.
+func (d *Dax) initialized() bool {
+	return d.lazy == nil || d.lazy.done.Load()
+}
+
+// closeLazy prevents a lazily constructed Dax from initializing after Close
+// and reports whether there is an underlying client to close.
+func (d *Dax) closeLazy() bool {
+	l := d.lazy
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return l.done.Load()
+}
@@ -0,0 +1,137 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lazyTestConfig() Config {
+	cfg := DefaultConfig()
+	cfg.HostPorts = []string{"127.0.0.1:8111"}
+	cfg.Region = "us-west-2"
+	return cfg
+}
+
+func TestNewLazy_concurrentInit(t *testing.T) {
+	var calls int32
+	d := NewLazy(func(ctx context.Context) (Config, error) {
+		atomic.AddInt32(&calls, 1)
+		return lazyTestConfig(), nil
+	})
+	defer d.Close()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "expected provider not to be called on construction")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, d.init(context.Background()))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.NotNil(t, d.client)
+	assert.Equal(t, "us-west-2", d.config.Region)
+}
+
+func TestNewLazy_retriesFailedInit(t *testing.T) {
+	providerErr := errors.New("config unavailable")
+	var calls int32
+	d := NewLazy(func(ctx context.Context) (Config, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return Config{}, providerErr
+		}
+		return lazyTestConfig(), nil
+	})
+	defer d.Close()
+
+	_, err := d.GetItem(context.Background(), &dynamodb.GetItemInput{})
+	assert.ErrorIs(t, err, providerErr)
+	assert.NoError(t, d.InvalidateCaches(types.AllCaches()))
+
+	require.NoError(t, d.init(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestNewLazy_sharesFailedInit(t *testing.T) {
+	providerErr := errors.New("config unavailable")
+	release := make(chan struct{})
+	var calls int32
+	d := NewLazy(func(ctx context.Context) (Config, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return Config{}, providerErr
+	})
+	defer d.Close()
+
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func() { errs <- d.init(context.Background()) }()
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	close(release)
+	for i := 0; i < cap(errs); i++ {
+		assert.ErrorIs(t, <-errs, providerErr)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestNewLazy_waiterCanceled(t *testing.T) {
+	release := make(chan struct{})
+	d := NewLazy(func(ctx context.Context) (Config, error) {
+		<-release
+		return lazyTestConfig(), ctx.Err()
+	})
+	defer d.Close()
+
+	first := make(chan error, 1)
+	go func() { first <- d.init(context.Background()) }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, d.init(ctx), context.Canceled)
+
+	close(release)
+	assert.NoError(t, <-first)
+	assert.True(t, d.initialized())
+}
+
+func TestNewLazy_closeBeforeInit(t *testing.T) {
+	var calls int32
+	d := NewLazy(func(ctx context.Context) (Config, error) {
+		atomic.AddInt32(&calls, 1)
+		return lazyTestConfig(), nil
+	})
+
+	assert.NoError(t, d.Close())
+	_, err := d.PutItem(context.Background(), &dynamodb.PutItemInput{})
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,127 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ErrLegacyParameter is matched by errors.Is for a LegacyParameterError.
+var ErrLegacyParameter = errors.New("legacy parameter")
+
+// LegacyParameterError is returned with Config.StrictParameters for a
+// request using a legacy parameter instead of its expression replacement.
+type LegacyParameterError struct {
+	Operation string
+	// Parameter is the legacy parameter, prefixed with the table for the
+	// RequestItems of BatchGetItem.
+	Parameter string
+	// Replacement is the expression parameter to use instead.
+	Replacement string
+}
+
+func (e *LegacyParameterError) Error() string {
+	return fmt.Sprintf("%s: legacy parameter %s is not allowed, use %s", e.Operation, e.Parameter, e.Replacement)
+}
+
+// Is reports whether target is ErrLegacyParameter.
+func (e *LegacyParameterError) Is(target error) bool {
+	return target == ErrLegacyParameter
+}
+
+type legacyParameter struct {
+	name, replacement string
+	set               bool
+}
+
+// checkLegacyParameters rejects the legacy parameters of input when
+// Config.StrictParameters is set.
+func (d *Dax) checkLegacyParameters(op string, input any) error {
+	if !d.config.StrictParameters {
+		return nil
+	}
+	var params []legacyParameter
+	switch in := input.(type) {
+	case *dynamodb.GetItemInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"AttributesToGet", "ProjectionExpression", len(in.AttributesToGet) > 0},
+			}
+		}
+	case *dynamodb.PutItemInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"Expected", "ConditionExpression", len(in.Expected) > 0},
+				{"ConditionalOperator", "ConditionExpression", in.ConditionalOperator != ""},
+			}
+		}
+	case *dynamodb.DeleteItemInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"Expected", "ConditionExpression", len(in.Expected) > 0},
+				{"ConditionalOperator", "ConditionExpression", in.ConditionalOperator != ""},
+			}
+		}
+	case *dynamodb.UpdateItemInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"AttributeUpdates", "UpdateExpression", len(in.AttributeUpdates) > 0},
+				{"Expected", "ConditionExpression", len(in.Expected) > 0},
+				{"ConditionalOperator", "ConditionExpression", in.ConditionalOperator != ""},
+			}
+		}
+	case *dynamodb.QueryInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"KeyConditions", "KeyConditionExpression", len(in.KeyConditions) > 0},
+				{"QueryFilter", "FilterExpression", len(in.QueryFilter) > 0},
+				{"ConditionalOperator", "FilterExpression", in.ConditionalOperator != ""},
+				{"AttributesToGet", "ProjectionExpression", len(in.AttributesToGet) > 0},
+			}
+		}
+	case *dynamodb.ScanInput:
+		if in != nil {
+			params = []legacyParameter{
+				{"ScanFilter", "FilterExpression", len(in.ScanFilter) > 0},
+				{"ConditionalOperator", "FilterExpression", in.ConditionalOperator != ""},
+				{"AttributesToGet", "ProjectionExpression", len(in.AttributesToGet) > 0},
+			}
+		}
+	case *dynamodb.BatchGetItemInput:
+		if in != nil {
+			tables := make([]string, 0, len(in.RequestItems))
+			for table := range in.RequestItems {
+				tables = append(tables, table)
+			}
+			sort.Strings(tables) // report the same table every time
+			for _, table := range tables {
+				params = append(params, legacyParameter{
+					"RequestItems." + table + ".AttributesToGet", "ProjectionExpression", len(in.RequestItems[table].AttributesToGet) > 0,
+				})
+			}
+		}
+	}
+	for _, p := range params {
+		if p.set {
+			return &LegacyParameterError{Operation: op, Parameter: p.name, Replacement: p.replacement}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLegacyParameters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StrictParameters = true
+	d := &Dax{config: cfg}
+
+	cases := []struct {
+		op          string
+		input       any
+		parameter   string
+		replacement string
+	}{
+		{"GetItem", &dynamodb.GetItemInput{AttributesToGet: []string{"a"}}, "AttributesToGet", "ProjectionExpression"},
+		{"PutItem", &dynamodb.PutItemInput{Expected: map[string]types.ExpectedAttributeValue{"a": {}}}, "Expected", "ConditionExpression"},
+		{"DeleteItem", &dynamodb.DeleteItemInput{ConditionalOperator: types.ConditionalOperatorOr}, "ConditionalOperator", "ConditionExpression"},
+		{"UpdateItem", &dynamodb.UpdateItemInput{AttributeUpdates: map[string]types.AttributeValueUpdate{"a": {}}}, "AttributeUpdates", "UpdateExpression"},
+		{"Query", &dynamodb.QueryInput{KeyConditions: map[string]types.Condition{"pk": {}}}, "KeyConditions", "KeyConditionExpression"},
+		{"Query", &dynamodb.QueryInput{QueryFilter: map[string]types.Condition{"a": {}}}, "QueryFilter", "FilterExpression"},
+		{"Scan", &dynamodb.ScanInput{ScanFilter: map[string]types.Condition{"a": {}}}, "ScanFilter", "FilterExpression"},
+		{"BatchGetItem", &dynamodb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{
+			"t1": {ProjectionExpression: aws.String("a")},
+			"t2": {AttributesToGet: []string{"a"}},
+		}}, "RequestItems.t2.AttributesToGet", "ProjectionExpression"},
+	}
+	for _, c := range cases {
+		err := d.checkLegacyParameters(c.op, c.input)
+		var lpe *LegacyParameterError
+		if assert.ErrorAs(t, err, &lpe, c.parameter) {
+			assert.Equal(t, LegacyParameterError{Operation: c.op, Parameter: c.parameter, Replacement: c.replacement}, *lpe)
+			assert.True(t, errors.Is(err, ErrLegacyParameter))
+		}
+	}
+
+	assert.NoError(t, d.checkLegacyParameters("Query", &dynamodb.QueryInput{KeyConditionExpression: aws.String("pk = :pk")}))
+	assert.NoError(t, d.checkLegacyParameters("GetItem", (*dynamodb.GetItemInput)(nil)))
+
+	d.config.StrictParameters = false
+	assert.NoError(t, d.checkLegacyParameters("GetItem", cases[0].input))
+}
+
+func TestStrictParameters_rejectsBeforeSending(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StrictParameters = true
+	d := &Dax{config: cfg} // no client, the request must not reach it
+
+	_, err := d.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:     aws.String("t"),
+		KeyConditions: map[string]types.Condition{"pk": {}},
+	})
+	assert.ErrorIs(t, err, ErrLegacyParameter)
+}
+
+func TestStrictParameters_lazyClient(t *testing.T) {
+	d := NewLazy(func(context.Context) (Config, error) {
+		cfg := lazyTestConfig()
+		cfg.StrictParameters = true
+		return cfg, nil
+	})
+	defer d.Close()
+
+	_, err := d.Query(context.Background(), &dynamodb.QueryInput{
+		TableName:     aws.String("t"),
+		KeyConditions: map[string]types.Condition{"pk": {}},
+	})
+	assert.ErrorIs(t, err, ErrLegacyParameter)
+}
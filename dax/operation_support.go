@@ -0,0 +1,95 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+var nativeOperations = []string{
+	client.OpGetItem,
+	client.OpPutItem,
+	client.OpUpdateItem,
+	client.OpDeleteItem,
+	client.OpBatchGetItem,
+	client.OpBatchWriteItem,
+	client.OpTransactWriteItems,
+	client.OpQuery,
+	client.OpScan,
+}
+
+// passthroughOperations are sent to the cluster, which forwards them to
+// DynamoDB without serving them from its cache, see alwaysPassthrough.
+var passthroughOperations = []string{
+	client.OpTransactGetItems,
+}
+
+// operationSupport is built once from the operations of the DynamoDB client,
+// so operations added to the SDK are reported as unsupported until DAX
+// implements them.
+var operationSupport = sync.OnceValue(func() map[string]types.SupportLevel {
+	support := make(map[string]types.SupportLevel)
+	ctxType := reflect.TypeOf((*context.Context)(nil)).Elem()
+	errType := reflect.TypeOf((*error)(nil)).Elem()
+	t := reflect.TypeOf(&dynamodb.Client{})
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		// Operations look like Op(context.Context, *OpInput, ...func(*Options)) (*OpOutput, error).
+		mt := m.Type
+		if mt.NumIn() != 4 || mt.NumOut() != 2 || !mt.IsVariadic() ||
+			mt.In(1) != ctxType || mt.Out(1) != errType ||
+			!strings.HasSuffix(mt.In(2).String(), "."+m.Name+"Input") {
+			continue
+		}
+		support[m.Name] = types.SupportLevelUnsupported
+	}
+	for _, op := range nativeOperations {
+		support[op] = types.SupportLevelNative
+	}
+	for _, op := range passthroughOperations {
+		support[op] = types.SupportLevelPassthrough
+	}
+	return support
+})
+
+// SupportedOperations returns the support level of every DynamoDB operation,
+// keyed by operation name, for tooling to check before taking a code path
+// rather than finding out from a NotImplemented error. The returned map is
+// a copy the caller may modify.
+func (d *Dax) SupportedOperations() map[string]types.SupportLevel {
+	support := operationSupport()
+	res := make(map[string]types.SupportLevel, len(support))
+	for op, level := range support {
+		res[op] = level
+	}
+	return res
+}
+
+// OperationSupport returns the support level of the DynamoDB operation op,
+// SupportLevelUnsupported for unknown operations.
+func (d *Dax) OperationSupport(op string) types.SupportLevel {
+	if level, ok := operationSupport()[op]; ok {
+		return level
+	}
+	return types.SupportLevelUnsupported
+}
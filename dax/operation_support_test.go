@@ -0,0 +1,71 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDax_SupportedOperations(t *testing.T) {
+	d := &Dax{}
+	support := d.SupportedOperations()
+
+	assert.Equal(t, types.SupportLevelNative, support["GetItem"])
+	assert.Equal(t, types.SupportLevelPassthrough, support["TransactGetItems"])
+	assert.True(t, support["TransactGetItems"].IsSupported())
+	assert.Equal(t, types.SupportLevelUnsupported, support["ExecuteStatement"])
+	assert.NotContains(t, support, "Options")
+	assert.Equal(t, types.SupportLevelUnsupported, d.OperationSupport("NoSuchOperation"))
+
+	native, passthrough := 0, 0
+	dt := reflect.ValueOf(d)
+	for op, level := range support {
+		m := dt.MethodByName(op)
+		switch level {
+		case types.SupportLevelNative:
+			native++
+			assert.True(t, m.IsValid(), "native operation %s has no method", op)
+		case types.SupportLevelPassthrough:
+			passthrough++
+			assert.True(t, m.IsValid(), "passthrough operation %s has no method", op)
+		case types.SupportLevelUnsupported:
+			if !m.IsValid() {
+				continue
+			}
+			// Unsupported operations fail without looking at their input.
+			in := []reflect.Value{reflect.ValueOf(context.Background()), reflect.Zero(m.Type().In(1))}
+			out := m.Call(in)
+			err, _ := out[1].Interface().(error)
+			require.Error(t, err, op)
+			assert.Equal(t, client.ErrCodeNotImplemented, err.Error(), op)
+		default:
+			t.Errorf("unexpected support level %q for %s", level, op)
+		}
+	}
+	assert.Equal(t, len(nativeOperations), native)
+	assert.Equal(t, len(passthroughOperations), passthrough)
+
+	// The returned map is a copy.
+	support["GetItem"] = types.SupportLevelUnsupported
+	assert.Equal(t, types.SupportLevelNative, d.OperationSupport("GetItem"))
+}
@@ -0,0 +1,231 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// callOptionsID is the middleware ID of callOptions.
+const callOptionsID = "DaxCallOptions"
+
+// callOptions carries DAX specific per-call overrides through the
+// func(*dynamodb.Options) parameters of the client methods. They are added to
+// dynamodb.Options.APIOptions as a middleware doing nothing, so the other
+// options of the call are left alone and a dynamodb.Client ignores them.
+type callOptions struct {
+	requestTimeout *time.Duration
+	consistentRead *bool
+	scanApproved   bool
+	onItem         func(map[string]types.AttributeValue) error
+}
+
+func (*callOptions) ID() string {
+	return callOptionsID
+}
+
+func (*callOptions) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	return next.HandleInitialize(ctx, in)
+}
+
+// withCallOptions returns the per-call option applying f to the callOptions
+// of the call.
+func withCallOptions(f func(*callOptions)) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		o.APIOptions = append(o.APIOptions, func(s *middleware.Stack) error {
+			m, ok := s.Initialize.Get(callOptionsID)
+			if !ok {
+				m = &callOptions{}
+				if err := s.Initialize.Add(m, middleware.Before); err != nil {
+					return err
+				}
+			}
+			f(m.(*callOptions))
+			return nil
+		})
+	}
+}
+
+// checkCallOptions rejects APIOptions other than those of callOptions, DAX
+// does not run custom middleware. Each must add only callOptions to a new stack.
+func checkCallOptions(o *dynamodb.Options) error {
+	for _, fn := range o.APIOptions {
+		s := middleware.NewStack("DAX", nil)
+		if err := fn(s); err != nil {
+			return err
+		}
+		ids := s.Initialize.List()
+		if len(ids) != 1 || ids[0] != callOptionsID || len(s.Serialize.List())+len(s.Build.List())+len(s.Finalize.List())+len(s.Deserialize.List()) > 0 {
+			return client.RejectCustomMiddleware(o.APIOptions)
+		}
+	}
+	return nil
+}
+
+// callOptionsFrom returns the callOptions of a call, nil if it has none.
+func callOptionsFrom(o *dynamodb.Options) *callOptions {
+	if len(o.APIOptions) == 0 {
+		return nil
+	}
+	s := middleware.NewStack("DAX", nil)
+	for _, fn := range o.APIOptions {
+		if err := fn(s); err != nil {
+			return nil
+		}
+	}
+	if m, ok := s.Initialize.Get(callOptionsID); ok {
+		return m.(*callOptions)
+	}
+	return nil
+}
+
+// WithRequestTimeout overrides Config.RequestTimeout for a single call.
+//
+//	out, err := svc.GetItem(ctx, input, dax.WithRequestTimeout(100*time.Millisecond))
+func WithRequestTimeout(timeout time.Duration) func(*dynamodb.Options) {
+	return withCallOptions(func(c *callOptions) {
+		c.requestTimeout = &timeout
+	})
+}
+
+// WithConsistentRead overrides the ConsistentRead parameter of a single
+// GetItem, Query, Scan or BatchGetItem call without modifying the input.
+func WithConsistentRead(consistent bool) func(*dynamodb.Options) {
+	return withCallOptions(func(c *callOptions) {
+		c.consistentRead = &consistent
+	})
+}
+
+// WithRetryMaxAttempts overrides Config.ReadRetries or Config.WriteRetries for a single call.
+// It is equivalent to setting dynamodb.Options.RetryMaxAttempts.
+func WithRetryMaxAttempts(attempts int) func(*dynamodb.Options) {
+	return func(o *dynamodb.Options) {
+		o.RetryMaxAttempts = attempts
+	}
+}
+
+// WithoutRetries disables the retries of a single call, whatever the
+// client-wide retry settings, e.g. for a health probe which must fail fast.
+// It is equivalent to WithRetryMaxAttempts(0).
+//
+//	_, err := svc.GetItem(ctx, probe, dax.WithoutRetries())
+func WithoutRetries() func(*dynamodb.Options) {
+	return WithRetryMaxAttempts(0)
+}
+
+func consistentReadOverride(o *dynamodb.Options) *bool {
+	if c := callOptionsFrom(o); c != nil {
+		return c.consistentRead
+	}
+	return nil
+}
+
+// consistentRead reports whether a read with the ConsistentRead parameter
+// consistent is strongly consistent, once the per-call options are merged.
+func consistentRead(consistent *bool) func(*dynamodb.Options) bool {
+	return func(o *dynamodb.Options) bool {
+		if cr := consistentReadOverride(o); cr != nil {
+			return *cr
+		}
+		return consistent != nil && *consistent
+	}
+}
+
+func getItemConsistentRead(input *dynamodb.GetItemInput) *bool {
+	if input == nil {
+		return nil
+	}
+	return input.ConsistentRead
+}
+
+func queryConsistentRead(input *dynamodb.QueryInput) *bool {
+	if input == nil {
+		return nil
+	}
+	return input.ConsistentRead
+}
+
+func scanConsistentRead(input *dynamodb.ScanInput) *bool {
+	if input == nil {
+		return nil
+	}
+	return input.ConsistentRead
+}
+
+// batchGetItemConsistentRead reports a BatchGetItem as consistent when any
+// of its tables is read consistently, DAX forwards these to DynamoDB.
+func batchGetItemConsistentRead(input *dynamodb.BatchGetItemInput) *bool {
+	if input == nil {
+		return nil
+	}
+	for _, kaa := range input.RequestItems {
+		if kaa.ConsistentRead != nil && *kaa.ConsistentRead {
+			return kaa.ConsistentRead
+		}
+	}
+	return nil
+}
+
+// alwaysPassthrough is the passthrough of reads DAX never serves from its cache.
+func alwaysPassthrough(*dynamodb.Options) bool {
+	return true
+}
+
+func getItemWithConsistentRead(input *dynamodb.GetItemInput, o *dynamodb.Options) *dynamodb.GetItemInput {
+	if cr := consistentReadOverride(o); cr != nil && input != nil {
+		in := *input
+		in.ConsistentRead = cr
+		return &in
+	}
+	return input
+}
+
+func queryWithConsistentRead(input *dynamodb.QueryInput, o *dynamodb.Options) *dynamodb.QueryInput {
+	if cr := consistentReadOverride(o); cr != nil && input != nil {
+		in := *input
+		in.ConsistentRead = cr
+		return &in
+	}
+	return input
+}
+
+func scanWithConsistentRead(input *dynamodb.ScanInput, o *dynamodb.Options) *dynamodb.ScanInput {
+	if cr := consistentReadOverride(o); cr != nil && input != nil {
+		in := *input
+		in.ConsistentRead = cr
+		return &in
+	}
+	return input
+}
+
+func batchGetItemWithConsistentRead(input *dynamodb.BatchGetItemInput, o *dynamodb.Options) *dynamodb.BatchGetItemInput {
+	if cr := consistentReadOverride(o); cr != nil && input != nil {
+		in := *input
+		in.RequestItems = make(map[string]types.KeysAndAttributes, len(input.RequestItems))
+		for table, kaas := range input.RequestItems {
+			kaas.ConsistentRead = cr
+			in.RequestItems[table] = kaas
+		}
+		return &in
+	}
+	return input
+}
@@ -0,0 +1,128 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithConsistentRead(t *testing.T) {
+	o := dynamodb.Options{}
+	WithConsistentRead(true)(&o)
+
+	get := &dynamodb.GetItemInput{TableName: aws.String("t")}
+	out := getItemWithConsistentRead(get, &o)
+	assert.True(t, aws.ToBool(out.ConsistentRead))
+	assert.Nil(t, get.ConsistentRead, "input must not be modified")
+
+	batch := &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]types.KeysAndAttributes{
+			"t1": {ConsistentRead: aws.Bool(false)},
+			"t2": {},
+		},
+	}
+	bout := batchGetItemWithConsistentRead(batch, &o)
+	for table, kaas := range bout.RequestItems {
+		assert.True(t, aws.ToBool(kaas.ConsistentRead), table)
+	}
+	assert.False(t, aws.ToBool(batch.RequestItems["t1"].ConsistentRead), "input must not be modified")
+	assert.Nil(t, batch.RequestItems["t2"].ConsistentRead, "input must not be modified")
+
+	assert.True(t, aws.ToBool(queryWithConsistentRead(&dynamodb.QueryInput{}, &o).ConsistentRead))
+	assert.True(t, aws.ToBool(scanWithConsistentRead(&dynamodb.ScanInput{}, &o).ConsistentRead))
+	assert.Nil(t, getItemWithConsistentRead(nil, &o))
+}
+
+func TestWithConsistentRead_noOverride(t *testing.T) {
+	o := dynamodb.Options{}
+	WithRetryMaxAttempts(1)(&o)
+
+	get := &dynamodb.GetItemInput{TableName: aws.String("t")}
+	assert.Same(t, get, getItemWithConsistentRead(get, &o))
+	scan := &dynamodb.ScanInput{}
+	assert.Same(t, scan, scanWithConsistentRead(scan, &o))
+}
+
+func TestCallOptions_combine(t *testing.T) {
+	o := dynamodb.Options{}
+	WithConsistentRead(false)(&o)
+	WithRequestTimeout(5)(&o)
+
+	c := callOptionsFrom(&o)
+	if assert.NotNil(t, c) {
+		assert.False(t, aws.ToBool(c.consistentRead))
+		assert.EqualValues(t, 5, *c.requestTimeout)
+	}
+}
+
+// httpClientFunc is a dynamodb.HTTPClient for options tests.
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCallOptions_keepOtherOptions(t *testing.T) {
+	var hc dynamodb.HTTPClient = httpClientFunc(func(*http.Request) (*http.Response, error) {
+		return nil, nil
+	})
+	o := dynamodb.Options{HTTPClient: hc}
+	WithRequestTimeout(5)(&o)
+	WithScanApproval()(&o)
+	assert.Equal(t, fmt.Sprintf("%p", hc), fmt.Sprintf("%p", o.HTTPClient), "the HTTP client of the call is kept")
+	require.NoError(t, checkCallOptions(&o))
+
+	// A dynamodb.Client given the options runs their middleware, a no-op.
+	s := middleware.NewStack("test", func() interface{} { return &http.Request{} })
+	for _, fn := range o.APIOptions {
+		require.NoError(t, fn(s))
+	}
+	sent := false
+	h := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, in interface{}) (interface{}, middleware.Metadata, error) {
+		sent = true
+		return nil, middleware.Metadata{}, nil
+	}), s)
+	_, _, err := h.Handle(context.Background(), "input")
+	require.NoError(t, err)
+	assert.True(t, sent)
+}
+
+func TestReadOptionsItemHandler(t *testing.T) {
+	cfg := &Config{}
+	var n int
+	opts, _, err := cfg.readOptions(consistentRead(nil), context.Background(), withItemHandler(func(map[string]types.AttributeValue) error {
+		n++
+		return nil
+	}))
+	require.NoError(t, err)
+	require.NotNil(t, opts.OnItem)
+	assert.NoError(t, opts.OnItem(nil))
+	assert.Equal(t, 1, n)
+
+	opts, _, err = cfg.readOptions(consistentRead(nil), context.Background(), WithConsistentRead(true))
+	require.NoError(t, err)
+	assert.Nil(t, opts.OnItem)
+}
@@ -0,0 +1,122 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrPaginationLoop is matched by errors.Is for a PaginationLoopError.
+var ErrPaginationLoop = errors.New("pagination loop")
+
+// PaginationLoopError is returned by a paginator which stopped because a
+// page returned a LastEvaluatedKey seen within the last pageKeyWindow pages,
+// which would repeat the same pages forever, or because it reached its
+// MaxPages ceiling.
+type PaginationLoopError struct {
+	Operation string
+	// Page is the number of pages retrieved so far.
+	Page int
+	// MaxPages is set when the ceiling was reached.
+	MaxPages int
+	// Key is the repeated LastEvaluatedKey.
+	Key map[string]types.AttributeValue
+}
+
+func (e *PaginationLoopError) Error() string {
+	if e.MaxPages > 0 {
+		return fmt.Sprintf("%s pagination reached the ceiling of %d pages", e.Operation, e.MaxPages)
+	}
+	return fmt.Sprintf("%s pagination loop: page %d returned a LastEvaluatedKey seen before", e.Operation, e.Page)
+}
+
+// Is reports whether target is ErrPaginationLoop.
+func (e *PaginationLoopError) Is(target error) bool {
+	return target == ErrPaginationLoop
+}
+
+// pageKeyWindow is the number of recent page keys a pageGuard remembers, so
+// that a paginator going through many pages does not keep all their keys.
+const pageKeyWindow = 16
+
+// pageGuard detects repeated page keys and enforces the page ceiling of a paginator.
+type pageGuard struct {
+	op       string
+	maxPages int
+	pages    int
+	seen     map[string]struct{}
+	recent   []string // keys of seen, oldest first
+}
+
+// before is called before requesting a page.
+func (g *pageGuard) before() error {
+	if g.maxPages > 0 && g.pages >= g.maxPages {
+		return &PaginationLoopError{Operation: g.op, Page: g.pages, MaxPages: g.maxPages}
+	}
+	return nil
+}
+
+// after records a retrieved page and the LastEvaluatedKey it returned.
+func (g *pageGuard) after(key map[string]types.AttributeValue) error {
+	g.pages++
+	return g.record(key)
+}
+
+func (g *pageGuard) record(key map[string]types.AttributeValue) error {
+	if key == nil {
+		return nil
+	}
+	k := encodePageKey(key)
+	if _, ok := g.seen[k]; ok {
+		return &PaginationLoopError{Operation: g.op, Page: g.pages, Key: key}
+	}
+	if g.seen == nil {
+		g.seen = make(map[string]struct{}, pageKeyWindow)
+	}
+	if len(g.recent) == pageKeyWindow {
+		delete(g.seen, g.recent[0])
+		g.recent = append(g.recent[:0], g.recent[1:]...)
+	}
+	g.seen[k] = struct{}{}
+	g.recent = append(g.recent, k)
+	return nil
+}
+
+// encodePageKey encodes the attributes of key in name order.
+func encodePageKey(key map[string]types.AttributeValue) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	w := cbor.NewWriter(&buf)
+	for _, name := range names {
+		w.WriteString(name)
+		// A key which cannot be encoded is still told apart by its names.
+		cbor.EncodeAttributeValue(key[name], w)
+	}
+	w.Flush()
+	w.Close()
+	return buf.String()
+}
@@ -17,6 +17,8 @@ package dax
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -1067,3 +1069,109 @@ func TestScanPaginator_MultiplePages(t *testing.T) {
 		t.Errorf("Expected 2 pages, got %d", pageNum)
 	}
 }
+
+func idKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func TestQueryPaginator_RepeatedKey(t *testing.T) {
+	mockClient := &MockDaxAPI{
+		queryResults: []dynamodb.QueryOutput{
+			{LastEvaluatedKey: idKey("1")},
+			{LastEvaluatedKey: idKey("2")},
+			{LastEvaluatedKey: idKey("1")},
+		},
+	}
+	paginator := NewQueryPaginator(mockClient, &dynamodb.QueryInput{TableName: aws.String("TestTable")})
+
+	for i := 0; i < 2; i++ {
+		if _, err := paginator.NextPage(context.TODO()); err != nil {
+			t.Fatalf("Unexpected error on page %d: %v", i+1, err)
+		}
+	}
+	_, err := paginator.NextPage(context.TODO())
+	if !errors.Is(err, ErrPaginationLoop) {
+		t.Fatalf("Expected ErrPaginationLoop, got %v", err)
+	}
+	var loopErr *PaginationLoopError
+	if !errors.As(err, &loopErr) || loopErr.Page != 3 || !reflect.DeepEqual(loopErr.Key, idKey("1")) {
+		t.Errorf("Unexpected error %#v", err)
+	}
+	if paginator.HasMorePages() {
+		t.Error("Expected no more pages after a loop")
+	}
+}
+
+func TestPageGuard_window(t *testing.T) {
+	g := pageGuard{op: "Query"}
+	for i := 0; i < 10*pageKeyWindow; i++ {
+		if err := g.after(idKey(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Unexpected error on page %d: %v", i+1, err)
+		}
+	}
+	if len(g.seen) != pageKeyWindow || len(g.recent) != pageKeyWindow {
+		t.Errorf("Expected %d remembered keys, got %d", pageKeyWindow, len(g.seen))
+	}
+	if err := g.after(idKey(fmt.Sprint(10*pageKeyWindow - 2))); !errors.Is(err, ErrPaginationLoop) {
+		t.Errorf("Expected ErrPaginationLoop for a recent key, got %v", err)
+	}
+	if err := g.after(idKey("0")); err != nil {
+		t.Errorf("Unexpected error for a key out of the window: %v", err)
+	}
+}
+
+func TestScanPaginator_RepeatedStartKey(t *testing.T) {
+	mockClient := &MockDaxAPI{
+		scanResults: []dynamodb.ScanOutput{{LastEvaluatedKey: idKey("1")}},
+	}
+	paginator := NewScanPaginator(mockClient, &dynamodb.ScanInput{ExclusiveStartKey: idKey("1")})
+
+	if _, err := paginator.NextPage(context.TODO()); !errors.Is(err, ErrPaginationLoop) {
+		t.Fatalf("Expected ErrPaginationLoop, got %v", err)
+	}
+}
+
+func TestPaginators_MaxPages(t *testing.T) {
+	query := NewQueryPaginator(&MockDaxAPI{
+		queryResults: []dynamodb.QueryOutput{{LastEvaluatedKey: idKey("1")}, {LastEvaluatedKey: idKey("2")}},
+	}, nil)
+	scan := NewScanPaginator(&MockDaxAPI{
+		scanResults: []dynamodb.ScanOutput{{LastEvaluatedKey: idKey("1")}, {LastEvaluatedKey: idKey("2")}},
+	}, nil)
+	unprocessed := map[string]types.KeysAndAttributes{"TestTable": {Keys: []map[string]types.AttributeValue{idKey("1")}}}
+	batch := NewBatchGetItemPaginator(&MockDaxAPI{
+		batchResults: []dynamodb.BatchGetItemOutput{{UnprocessedKeys: unprocessed}, {UnprocessedKeys: unprocessed}},
+	}, &dynamodb.BatchGetItemInput{RequestItems: unprocessed})
+
+	pages := map[string]func() error{
+		"Query": func() error {
+			_, err := query.NextPage(context.TODO())
+			return err
+		},
+		"Scan": func() error {
+			_, err := scan.NextPage(context.TODO())
+			return err
+		},
+		"BatchGetItem": func() error {
+			_, err := batch.NextPage(context.TODO())
+			return err
+		},
+	}
+	query.SetMaxPages(1)
+	scan.SetMaxPages(1)
+	batch.SetMaxPages(1)
+
+	for op, next := range pages {
+		if err := next(); err != nil {
+			t.Fatalf("%s: unexpected error on first page: %v", op, err)
+		}
+		err := next()
+		var loopErr *PaginationLoopError
+		if !errors.As(err, &loopErr) || loopErr.MaxPages != 1 || loopErr.Operation != op {
+			t.Errorf("%s: expected a MaxPages PaginationLoopError, got %v", op, err)
+		}
+		if !errors.Is(err, ErrPaginationLoop) {
+			t.Errorf("%s: expected ErrPaginationLoop, got %v", op, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrUnsupportedParameter is matched by errors.Is for an
+// UnsupportedParameterError.
+var ErrUnsupportedParameter = errors.New("unsupported parameter")
+
+// UnsupportedParameterError is returned with strict Config.ParameterValidation
+// for a request using a parameter DAX ignores, or a combination of
+// parameters DynamoDB rejects. It is a smithy.APIError with the code
+// ValidationException.
+type UnsupportedParameterError struct {
+	Operation string
+	Parameter string
+	Reason    string
+}
+
+func (e *UnsupportedParameterError) Error() string {
+	return fmt.Sprintf("%s: parameter %s %s", e.Operation, e.Parameter, e.Reason)
+}
+
+// Is reports whether target is ErrUnsupportedParameter.
+func (e *UnsupportedParameterError) Is(target error) bool {
+	return target == ErrUnsupportedParameter
+}
+
+func (e *UnsupportedParameterError) ErrorCode() string {
+	return "ValidationException"
+}
+
+func (e *UnsupportedParameterError) ErrorMessage() string {
+	return e.Parameter + " " + e.Reason
+}
+
+func (e *UnsupportedParameterError) ErrorFault() smithy.ErrorFault {
+	return smithy.FaultClient
+}
+
+// checkParameters rejects the unsupported parameters of input when
+// Config.ParameterValidation is strict.
+func (d *Dax) checkParameters(op string, input any) error {
+	if !d.config.ParameterValidation.IsStrict() {
+		return nil
+	}
+	unsupported := func(param, reason string) error {
+		return &UnsupportedParameterError{Operation: op, Parameter: param, Reason: reason}
+	}
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		if in != nil && returnsValuesOnConditionCheckFailure(in.ReturnValuesOnConditionCheckFailure) {
+			return unsupported("ReturnValuesOnConditionCheckFailure", "is not supported by DAX outside of transactions")
+		}
+	case *dynamodb.DeleteItemInput:
+		if in != nil && returnsValuesOnConditionCheckFailure(in.ReturnValuesOnConditionCheckFailure) {
+			return unsupported("ReturnValuesOnConditionCheckFailure", "is not supported by DAX outside of transactions")
+		}
+	case *dynamodb.UpdateItemInput:
+		if in != nil && returnsValuesOnConditionCheckFailure(in.ReturnValuesOnConditionCheckFailure) {
+			return unsupported("ReturnValuesOnConditionCheckFailure", "is not supported by DAX outside of transactions")
+		}
+	case *dynamodb.QueryInput:
+		if in != nil {
+			if param, reason := checkSelect(in.Select, in.IndexName, in.ProjectionExpression, in.AttributesToGet); param != "" {
+				return unsupported(param, reason)
+			}
+			if in.Limit != nil && *in.Limit < 1 {
+				return unsupported("Limit", "must be at least 1")
+			}
+		}
+	case *dynamodb.ScanInput:
+		if in != nil {
+			if param, reason := checkSelect(in.Select, in.IndexName, in.ProjectionExpression, in.AttributesToGet); param != "" {
+				return unsupported(param, reason)
+			}
+			if in.Limit != nil && *in.Limit < 1 {
+				return unsupported("Limit", "must be at least 1")
+			}
+			if param, reason := checkSegments(in.Segment, in.TotalSegments); param != "" {
+				return unsupported(param, reason)
+			}
+		}
+	}
+	return nil
+}
+
+func returnsValuesOnConditionCheckFailure(v types.ReturnValuesOnConditionCheckFailure) bool {
+	return v != "" && v != types.ReturnValuesOnConditionCheckFailureNone
+}
+
+// checkSelect returns the parameter and the reason a Query or Scan is
+// rejected for its Select, empty if it is not.
+func checkSelect(sel types.Select, index, projection *string, attributesToGet []string) (string, string) {
+	projects := aws.ToString(projection) != "" || len(attributesToGet) > 0
+	switch sel {
+	case "":
+	case types.SelectAllAttributes:
+		if projects {
+			return "Select", "ALL_ATTRIBUTES cannot be combined with a ProjectionExpression"
+		}
+	case types.SelectAllProjectedAttributes:
+		if aws.ToString(index) == "" {
+			return "Select", "ALL_PROJECTED_ATTRIBUTES requires an IndexName"
+		}
+		if projects {
+			return "Select", "ALL_PROJECTED_ATTRIBUTES cannot be combined with a ProjectionExpression"
+		}
+	case types.SelectCount:
+		if projects {
+			return "Select", "COUNT cannot be combined with a ProjectionExpression"
+		}
+	case types.SelectSpecificAttributes:
+		if !projects {
+			return "Select", "SPECIFIC_ATTRIBUTES requires a ProjectionExpression"
+		}
+	default:
+		return "Select", fmt.Sprintf("value %q is not supported by DAX", sel)
+	}
+	return "", ""
+}
+
+// checkSegments returns the parameter and the reason a parallel Scan is
+// rejected for its segments, empty if it is not.
+func checkSegments(segment, total *int32) (string, string) {
+	switch {
+	case segment == nil && total == nil:
+		return "", ""
+	case segment == nil:
+		return "Segment", "is required with TotalSegments"
+	case total == nil:
+		return "TotalSegments", "is required with Segment"
+	case *total < 1:
+		return "TotalSegments", "must be at least 1"
+	case *segment < 0 || *segment >= *total:
+		return "Segment", fmt.Sprintf("must be between 0 and %d", *total-1)
+	}
+	return "", ""
+}
@@ -0,0 +1,102 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckParameters(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ParameterValidation = types.ParameterValidationStrict
+	d := &Dax{config: cfg}
+
+	cases := []struct {
+		op        string
+		input     any
+		parameter string
+	}{
+		{"PutItem", &dynamodb.PutItemInput{ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureAllOld}, "ReturnValuesOnConditionCheckFailure"},
+		{"DeleteItem", &dynamodb.DeleteItemInput{ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureAllOld}, "ReturnValuesOnConditionCheckFailure"},
+		{"UpdateItem", &dynamodb.UpdateItemInput{ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureAllOld}, "ReturnValuesOnConditionCheckFailure"},
+		{"Query", &dynamodb.QueryInput{Select: ddbtypes.SelectAllProjectedAttributes}, "Select"},
+		{"Query", &dynamodb.QueryInput{Select: ddbtypes.SelectCount, ProjectionExpression: aws.String("a")}, "Select"},
+		{"Query", &dynamodb.QueryInput{Limit: aws.Int32(0)}, "Limit"},
+		{"Scan", &dynamodb.ScanInput{Select: ddbtypes.SelectSpecificAttributes}, "Select"},
+		{"Scan", &dynamodb.ScanInput{Select: ddbtypes.SelectAllAttributes, ProjectionExpression: aws.String("a")}, "Select"},
+		{"Scan", &dynamodb.ScanInput{Select: "SOME_ATTRIBUTES"}, "Select"},
+		{"Scan", &dynamodb.ScanInput{Segment: aws.Int32(0)}, "TotalSegments"},
+		{"Scan", &dynamodb.ScanInput{TotalSegments: aws.Int32(2)}, "Segment"},
+		{"Scan", &dynamodb.ScanInput{Segment: aws.Int32(2), TotalSegments: aws.Int32(2)}, "Segment"},
+	}
+	for _, c := range cases {
+		err := d.checkParameters(c.op, c.input)
+		var upe *UnsupportedParameterError
+		if assert.ErrorAs(t, err, &upe, c.parameter) {
+			assert.Equal(t, c.op, upe.Operation)
+			assert.Equal(t, c.parameter, upe.Parameter)
+			assert.True(t, errors.Is(err, ErrUnsupportedParameter))
+		}
+	}
+
+	valid := []struct {
+		op    string
+		input any
+	}{
+		{"PutItem", &dynamodb.PutItemInput{ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureNone}},
+		{"Query", &dynamodb.QueryInput{Select: ddbtypes.SelectAllProjectedAttributes, IndexName: aws.String("i")}},
+		{"Query", &dynamodb.QueryInput{Select: ddbtypes.SelectSpecificAttributes, ProjectionExpression: aws.String("a")}},
+		{"Scan", &dynamodb.ScanInput{ProjectionExpression: aws.String("a"), Segment: aws.Int32(1), TotalSegments: aws.Int32(2)}},
+		{"Scan", (*dynamodb.ScanInput)(nil)},
+	}
+	for _, c := range valid {
+		assert.NoError(t, d.checkParameters(c.op, c.input))
+	}
+
+	d.config.ParameterValidation = types.ParameterValidationPermissive
+	assert.NoError(t, d.checkParameters("Scan", &dynamodb.ScanInput{Select: ddbtypes.SelectSpecificAttributes}))
+}
+
+func TestCheckParameters_lazyClient(t *testing.T) {
+	d := NewLazy(func(context.Context) (Config, error) {
+		cfg := lazyTestConfig()
+		cfg.ParameterValidation = types.ParameterValidationStrict
+		return cfg, nil
+	})
+	defer d.Close()
+
+	for _, call := range []func() error{
+		func() error {
+			_, err := d.PutItem(context.Background(), &dynamodb.PutItemInput{ReturnValuesOnConditionCheckFailure: ddbtypes.ReturnValuesOnConditionCheckFailureAllOld})
+			return err
+		},
+		func() error {
+			_, err := d.Query(context.Background(), &dynamodb.QueryInput{Limit: aws.Int32(0)})
+			return err
+		},
+	} {
+		var upe *UnsupportedParameterError
+		assert.ErrorAs(t, call(), &upe)
+	}
+}
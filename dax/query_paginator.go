@@ -31,6 +31,7 @@ type QueryPaginator struct {
 	params    *dynamodb.QueryInput
 	nextToken map[string]types.AttributeValue
 	firstPage bool
+	guard     pageGuard
 }
 
 // NewQueryPaginator returns a new QueryPaginator
@@ -48,13 +49,22 @@ func NewQueryPaginator(client dynamodb.QueryAPIClient, params *dynamodb.QueryInp
 		fn(&options)
 	}
 
-	return &QueryPaginator{
+	p := &QueryPaginator{
 		options:   options,
 		client:    client,
 		params:    params,
 		firstPage: true,
 		nextToken: params.ExclusiveStartKey,
+		guard:     pageGuard{op: "Query"},
 	}
+	p.guard.record(params.ExclusiveStartKey)
+	return p
+}
+
+// SetMaxPages makes NextPage fail with a PaginationLoopError instead of
+// retrieving more than n pages. Zero, the default, sets no ceiling.
+func (p *QueryPaginator) SetMaxPages(n int) {
+	p.guard.maxPages = n
 }
 
 // HasMorePages returns a boolean indicating whether more pages are available
@@ -62,11 +72,15 @@ func (p *QueryPaginator) HasMorePages() bool {
 	return p.firstPage || p.nextToken != nil
 }
 
-// NextPage retrieves the next Query page.
+// NextPage retrieves the next Query page. A page returning a LastEvaluatedKey
+// returned before ends the pagination with a PaginationLoopError.
 func (p *QueryPaginator) NextPage(ctx context.Context, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
 	if !p.HasMorePages() {
 		return nil, fmt.Errorf("no more pages available")
 	}
+	if err := p.guard.before(); err != nil {
+		return nil, err
+	}
 
 	params := *p.params
 	params.ExclusiveStartKey = p.nextToken
@@ -82,10 +96,11 @@ func (p *QueryPaginator) NextPage(ctx context.Context, optFns ...func(*dynamodb.
 	}
 	p.firstPage = false
 
-	prevToken := p.nextToken
 	p.nextToken = result.LastEvaluatedKey
-
-	_ = prevToken
+	if err := p.guard.after(p.nextToken); err != nil {
+		p.nextToken = nil
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -0,0 +1,278 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// maxItemSize is the size DynamoDB allows an item to have.
+	maxItemSize = 400 * 1024
+	// maxTransactItems is the number of actions a transaction may contain.
+	maxTransactItems = 100
+	// maxTransactSize is the size the items of a transaction may add up to.
+	maxTransactSize = 4 * 1024 * 1024
+)
+
+// ErrRequestLimit is matched by errors.Is for a RequestLimitError.
+var ErrRequestLimit = errors.New("request limit exceeded")
+
+// RequestLimitError is returned for a request DynamoDB would reject because
+// of its size or the shape of its keys, before it is sent. It is a
+// smithy.APIError with the code ValidationException, like the error the
+// cluster would have returned, and is not retried.
+type RequestLimitError struct {
+	Operation string
+	// Parameter is the invalid parameter, such as "Item" or
+	// "TransactItems[3].Put.Item", prefixed with the table for the
+	// RequestItems of batches.
+	Parameter string
+	Reason    string
+}
+
+func (e *RequestLimitError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Operation, e.Parameter, e.Reason)
+}
+
+// Is reports whether target is ErrRequestLimit.
+func (e *RequestLimitError) Is(target error) bool {
+	return target == ErrRequestLimit
+}
+
+func (e *RequestLimitError) ErrorCode() string {
+	return "ValidationException"
+}
+
+func (e *RequestLimitError) ErrorMessage() string {
+	return e.Parameter + ": " + e.Reason
+}
+
+func (e *RequestLimitError) ErrorFault() smithy.ErrorFault {
+	return smithy.FaultClient
+}
+
+// checkRequestLimits rejects the items exceeding 400KB, the transactions
+// exceeding 100 actions or 4MB and the keys which cannot match a key schema
+// in input. Batches beyond 25 write requests or 100 keys are split rather
+// than rejected. Missing required parameters are left to the client.
+func checkRequestLimits(op string, input any) error {
+	c := limitChecker{op: op}
+	switch in := input.(type) {
+	case *dynamodb.PutItemInput:
+		if in != nil {
+			c.item("Item", in.Item)
+		}
+	case *dynamodb.DeleteItemInput:
+		if in != nil {
+			c.key("Key", in.Key)
+		}
+	case *dynamodb.UpdateItemInput:
+		if in != nil {
+			c.key("Key", in.Key)
+		}
+	case *dynamodb.GetItemInput:
+		if in != nil {
+			c.key("Key", in.Key)
+		}
+	case *dynamodb.BatchWriteItemInput:
+		if in == nil {
+			return nil
+		}
+		for _, table := range sortedNames(in.RequestItems) {
+			for i, wr := range in.RequestItems[table] {
+				if wr.PutRequest != nil {
+					c.item(fmt.Sprintf("RequestItems.%s[%d].PutRequest.Item", table, i), wr.PutRequest.Item)
+				}
+				if wr.DeleteRequest != nil {
+					c.key(fmt.Sprintf("RequestItems.%s[%d].DeleteRequest.Key", table, i), wr.DeleteRequest.Key)
+				}
+			}
+		}
+	case *dynamodb.BatchGetItemInput:
+		if in == nil {
+			return nil
+		}
+		for _, table := range sortedNames(in.RequestItems) {
+			for i, key := range in.RequestItems[table].Keys {
+				c.key(fmt.Sprintf("RequestItems.%s.Keys[%d]", table, i), key)
+			}
+		}
+	case *dynamodb.TransactWriteItemsInput:
+		if in == nil {
+			return nil
+		}
+		if len(in.TransactItems) > maxTransactItems {
+			c.fail("TransactItems", fmt.Sprintf("%d actions exceed the limit of %d", len(in.TransactItems), maxTransactItems))
+		}
+		var total int
+		for i, ti := range in.TransactItems {
+			p := fmt.Sprintf("TransactItems[%d]", i)
+			switch {
+			case ti.Put != nil:
+				total += c.item(p+".Put.Item", ti.Put.Item)
+			case ti.Update != nil:
+				c.key(p+".Update.Key", ti.Update.Key)
+			case ti.Delete != nil:
+				c.key(p+".Delete.Key", ti.Delete.Key)
+			case ti.ConditionCheck != nil:
+				c.key(p+".ConditionCheck.Key", ti.ConditionCheck.Key)
+			}
+		}
+		if total > maxTransactSize {
+			c.fail("TransactItems", fmt.Sprintf("items of %d bytes exceed the limit of %d bytes", total, maxTransactSize))
+		}
+	case *dynamodb.TransactGetItemsInput:
+		if in == nil {
+			return nil
+		}
+		if len(in.TransactItems) > maxTransactItems {
+			c.fail("TransactItems", fmt.Sprintf("%d actions exceed the limit of %d", len(in.TransactItems), maxTransactItems))
+		}
+		for i, ti := range in.TransactItems {
+			if ti.Get != nil {
+				c.key(fmt.Sprintf("TransactItems[%d].Get.Key", i), ti.Get.Key)
+			}
+		}
+	}
+	return c.err
+}
+
+// limitChecker keeps the first limit a request exceeds.
+type limitChecker struct {
+	op  string
+	err error
+}
+
+func (c *limitChecker) fail(param, reason string) {
+	if c.err == nil {
+		c.err = &RequestLimitError{Operation: c.op, Parameter: param, Reason: reason}
+	}
+}
+
+// item checks the size of item and returns it.
+func (c *limitChecker) item(param string, item map[string]types.AttributeValue) int {
+	n := itemSize(item)
+	if n > maxItemSize {
+		c.fail(param, fmt.Sprintf("item size of %d bytes exceeds the limit of %d bytes", n, maxItemSize))
+	}
+	return n
+}
+
+// key checks that key holds a partition key and at most a sort key, of
+// scalar types. A nil key is reported by the client as missing.
+func (c *limitChecker) key(param string, key map[string]types.AttributeValue) {
+	if key == nil {
+		return
+	}
+	if len(key) == 0 || len(key) > 2 {
+		c.fail(param, fmt.Sprintf("key has %d attributes, a key has a partition key and an optional sort key", len(key)))
+		return
+	}
+	for _, name := range sortedNames(key) {
+		switch key[name].(type) {
+		case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		default:
+			c.fail(param, fmt.Sprintf("key attribute %s is not a string, number or binary", name))
+			return
+		}
+	}
+}
+
+// itemSize returns the size DynamoDB accounts for item: the UTF-8 length
+// of the attribute names plus the size of their values.
+func itemSize(item map[string]types.AttributeValue) int {
+	var n int
+	for name, av := range item {
+		n += len(name) + attributeValueSize(av)
+	}
+	return n
+}
+
+func attributeValueSize(av types.AttributeValue) int {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return numberSize(v.Value)
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		var n int
+		for _, s := range v.Value {
+			n += len(s)
+		}
+		return n
+	case *types.AttributeValueMemberNS:
+		var n int
+		for _, s := range v.Value {
+			n += numberSize(s)
+		}
+		return n
+	case *types.AttributeValueMemberBS:
+		var n int
+		for _, b := range v.Value {
+			n += len(b)
+		}
+		return n
+	case *types.AttributeValueMemberL:
+		n := 3
+		for _, e := range v.Value {
+			n += 1 + attributeValueSize(e)
+		}
+		return n
+	case *types.AttributeValueMemberM:
+		n := 3
+		for name, e := range v.Value {
+			n += 1 + len(name) + attributeValueSize(e)
+		}
+		return n
+	}
+	return 0
+}
+
+// numberSize returns the size of a number: a byte per two significant
+// digits plus one.
+func numberSize(s string) int {
+	mantissa, _, _ := strings.Cut(strings.ToLower(s), "e")
+	digits := strings.Trim(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, mantissa), "0")
+	return (len(digits)+1)/2 + 1
+}
+
+// sortedNames returns the keys of m in order, so that the first of several
+// invalid parameters is reported.
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
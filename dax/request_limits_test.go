@@ -0,0 +1,111 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRequestLimits(t *testing.T) {
+	key := map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "a"}}
+	large := map[string]types.AttributeValue{
+		"pk":   &types.AttributeValueMemberS{Value: "a"},
+		"data": &types.AttributeValueMemberS{Value: strings.Repeat("x", maxItemSize)},
+	}
+	cases := []struct {
+		op        string
+		input     any
+		parameter string
+	}{
+		{"PutItem", &dynamodb.PutItemInput{Item: large}, "Item"},
+		{"GetItem", &dynamodb.GetItemInput{Key: map[string]types.AttributeValue{}}, "Key"},
+		{"DeleteItem", &dynamodb.DeleteItemInput{Key: map[string]types.AttributeValue{
+			"a": &types.AttributeValueMemberS{}, "b": &types.AttributeValueMemberS{}, "c": &types.AttributeValueMemberS{},
+		}}, "Key"},
+		{"UpdateItem", &dynamodb.UpdateItemInput{Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberBOOL{Value: true},
+		}}, "Key"},
+		{"BatchWriteItem", &dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+			"t1": {{PutRequest: &types.PutRequest{Item: key}}},
+			"t2": {{DeleteRequest: &types.DeleteRequest{Key: key}}, {PutRequest: &types.PutRequest{Item: large}}},
+		}}, "RequestItems.t2[1].PutRequest.Item"},
+		{"BatchGetItem", &dynamodb.BatchGetItemInput{RequestItems: map[string]types.KeysAndAttributes{
+			"t": {Keys: []map[string]types.AttributeValue{key, {"pk": nil}}},
+		}}, "RequestItems.t.Keys[1]"},
+		{"TransactWriteItems", &dynamodb.TransactWriteItemsInput{TransactItems: make([]types.TransactWriteItem, maxTransactItems+1)}, "TransactItems"},
+		{"TransactWriteItems", &dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{
+			{Update: &types.Update{Key: key}},
+			{Put: &types.Put{Item: large}},
+		}}, "TransactItems[1].Put.Item"},
+		{"TransactGetItems", &dynamodb.TransactGetItemsInput{TransactItems: []types.TransactGetItem{
+			{Get: &types.Get{Key: map[string]types.AttributeValue{}}},
+		}}, "TransactItems[0].Get.Key"},
+	}
+	for _, c := range cases {
+		err := checkRequestLimits(c.op, c.input)
+		var rle *RequestLimitError
+		if assert.ErrorAs(t, err, &rle, c.parameter) {
+			assert.Equal(t, c.op, rle.Operation)
+			assert.Equal(t, c.parameter, rle.Parameter)
+			assert.True(t, errors.Is(err, ErrRequestLimit))
+			var apiErr smithy.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, "ValidationException", apiErr.ErrorCode())
+		}
+	}
+
+	valid := []any{
+		&dynamodb.PutItemInput{Item: key},
+		&dynamodb.GetItemInput{Key: key},
+		&dynamodb.PutItemInput{},
+		(*dynamodb.BatchWriteItemInput)(nil),
+		&dynamodb.TransactWriteItemsInput{TransactItems: []types.TransactWriteItem{{Put: &types.Put{Item: key}}}},
+	}
+	for _, input := range valid {
+		assert.NoError(t, checkRequestLimits("Op", input))
+	}
+}
+
+func TestItemSize(t *testing.T) {
+	assert.Equal(t, 0, itemSize(nil))
+	assert.Equal(t, 2+5, itemSize(map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: "hello"}}))
+	assert.Equal(t, 1+1+1, itemSize(map[string]types.AttributeValue{"n": &types.AttributeValueMemberN{Value: "1000"}}))
+	assert.Equal(t, 1+4, itemSize(map[string]types.AttributeValue{"n": &types.AttributeValueMemberN{Value: "-12.345e10"}}))
+	assert.Equal(t, 1+3+(1+1+1)+(1+1+1), itemSize(map[string]types.AttributeValue{"m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"a": &types.AttributeValueMemberBOOL{},
+		"b": &types.AttributeValueMemberB{Value: []byte{1}},
+	}}}))
+}
+
+func TestDax_requestLimits(t *testing.T) {
+	// The request is rejected before the client connects to the cluster.
+	d := &Dax{config: DefaultConfig()}
+	_, err := d.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String("t"),
+		Item:      map[string]types.AttributeValue{"data": &types.AttributeValueMemberB{Value: make([]byte, maxItemSize+1)}},
+	})
+	assert.ErrorIs(t, err, ErrRequestLimit)
+}
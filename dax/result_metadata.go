@@ -0,0 +1,46 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// GetAttempts returns the attempts of the operation whose output has the
+// given ResultMetadata, in order, the last one being the attempt which
+// succeeded:
+//
+//	out, err := svc.GetItem(ctx, input)
+//	attempts, _ := dax.GetAttempts(out.ResultMetadata)
+//
+// The ResultMetadata of every output also has a request ID, as returned by
+// middleware.GetRequestIDMetadata of the SDK. DAX nodes only report request
+// IDs for failed requests, which are listed in the attempts, so it is an ID
+// the client generates for each operation.
+func GetAttempts(metadata middleware.Metadata) ([]types.Attempt, bool) {
+	return client.GetAttempts(metadata)
+}
+
+// GetServingNode returns the address of the node which served the operation
+// whose output has the given ResultMetadata, to attribute latency anomalies
+// to a node; the number of attempts it took is the length of GetAttempts.
+// Outputs merged from several requests, such as split batches, report the
+// node of the last request.
+func GetServingNode(metadata middleware.Metadata) (string, bool) {
+	return client.GetServingNode(metadata)
+}
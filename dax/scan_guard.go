@@ -0,0 +1,70 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ErrScanNotApproved may be returned by Config.ApproveScan to reject Scan
+// calls which were not made with WithScanApproval.
+var ErrScanNotApproved = errors.New("dax: scan not approved, use dax.WithScanApproval")
+
+// WithScanApproval marks a single Scan call as approved, Config.ApproveScan
+// is not called for it. It still counts towards Config.MaxConcurrentScans.
+//
+//	out, err := svc.Scan(ctx, input, dax.WithScanApproval())
+func WithScanApproval() func(*dynamodb.Options) {
+	return withCallOptions(func(c *callOptions) {
+		c.scanApproved = true
+	})
+}
+
+// newScanSlots returns the semaphore bounding concurrent Scan calls to max,
+// nil for no bound.
+func newScanSlots(max int) chan struct{} {
+	if max <= 0 {
+		return nil
+	}
+	return make(chan struct{}, max)
+}
+
+// beginScan approves a Scan call and waits for one of the MaxConcurrentScans
+// slots until ctx is done. The returned func releases the slot.
+func (d *Dax) beginScan(ctx context.Context, input *dynamodb.ScanInput, o *dynamodb.Options) (func(), error) {
+	approved := false
+	if co := callOptionsFrom(o); co != nil {
+		approved = co.scanApproved
+	}
+	if !approved && d.config.ApproveScan != nil {
+		if err := d.config.ApproveScan(ctx, input); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.scans == nil {
+		return func() {}, nil
+	}
+	select {
+	case d.scans <- struct{}{}:
+		return func() { <-d.scans }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
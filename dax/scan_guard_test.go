@@ -0,0 +1,103 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanClient blocks Scan calls until release is closed and records the
+// highest number of calls in flight.
+type scanClient struct {
+	client.DaxAPI
+	release  chan struct{}
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (c *scanClient) ScanWithOptions(ctx context.Context, input *dynamodb.ScanInput, output *dynamodb.ScanOutput, opt client.RequestOptions) (*dynamodb.ScanOutput, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		m := c.maxSeen.Load()
+		if n <= m || c.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	<-c.release
+	return output, nil
+}
+
+func TestScan_maxConcurrentScans(t *testing.T) {
+	fake := &scanClient{release: make(chan struct{})}
+	cfg := DefaultConfig()
+	cfg.MaxConcurrentScans = 2
+	d := &Dax{client: fake, config: cfg, scans: newScanSlots(cfg.MaxConcurrentScans)}
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := d.Scan(context.Background(), &dynamodb.ScanInput{TableName: aws.String("t")})
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return fake.inFlight.Load() == 2 }, time.Second, time.Millisecond)
+
+	// a scan waiting for a slot gives up with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := d.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("t")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(fake.release)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-errs)
+	}
+	assert.Equal(t, int32(2), fake.maxSeen.Load())
+}
+
+func TestScan_approveScan(t *testing.T) {
+	fake := &scanClient{release: make(chan struct{})}
+	close(fake.release)
+	var approved []string
+	cfg := DefaultConfig()
+	cfg.ApproveScan = func(ctx context.Context, input *dynamodb.ScanInput) error {
+		if aws.ToString(input.TableName) == "small" {
+			approved = append(approved, "small")
+			return nil
+		}
+		return ErrScanNotApproved
+	}
+	d := &Dax{client: fake, config: cfg}
+
+	_, err := d.Scan(context.Background(), &dynamodb.ScanInput{TableName: aws.String("small")})
+	assert.NoError(t, err)
+	_, err = d.Scan(context.Background(), &dynamodb.ScanInput{TableName: aws.String("large")})
+	assert.True(t, errors.Is(err, ErrScanNotApproved))
+	_, err = d.Scan(context.Background(), &dynamodb.ScanInput{TableName: aws.String("large")}, WithScanApproval())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"small"}, approved)
+}
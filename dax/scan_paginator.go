@@ -31,6 +31,7 @@ type ScanPaginator struct {
 	params    *dynamodb.ScanInput
 	nextToken map[string]types.AttributeValue
 	firstPage bool
+	guard     pageGuard
 }
 
 // NewScanPaginator returns a new ScanPaginator
@@ -48,13 +49,22 @@ func NewScanPaginator(client dynamodb.ScanAPIClient, params *dynamodb.ScanInput,
 		fn(&options)
 	}
 
-	return &ScanPaginator{
+	p := &ScanPaginator{
 		options:   options,
 		client:    client,
 		params:    params,
 		firstPage: true,
 		nextToken: params.ExclusiveStartKey,
+		guard:     pageGuard{op: "Scan"},
 	}
+	p.guard.record(params.ExclusiveStartKey)
+	return p
+}
+
+// SetMaxPages makes NextPage fail with a PaginationLoopError instead of
+// retrieving more than n pages. Zero, the default, sets no ceiling.
+func (p *ScanPaginator) SetMaxPages(n int) {
+	p.guard.maxPages = n
 }
 
 // HasMorePages returns a boolean indicating whether more pages are available
@@ -62,11 +72,15 @@ func (p *ScanPaginator) HasMorePages() bool {
 	return p.firstPage || p.nextToken != nil
 }
 
-// NextPage retrieves the next Scan page.
+// NextPage retrieves the next Scan page. A page returning a LastEvaluatedKey
+// returned before ends the pagination with a PaginationLoopError.
 func (p *ScanPaginator) NextPage(ctx context.Context, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
 	if !p.HasMorePages() {
 		return nil, fmt.Errorf("no more pages available")
 	}
+	if err := p.guard.before(); err != nil {
+		return nil, err
+	}
 
 	params := *p.params
 	params.ExclusiveStartKey = p.nextToken
@@ -83,10 +97,11 @@ func (p *ScanPaginator) NextPage(ctx context.Context, optFns ...func(*dynamodb.O
 	}
 	p.firstPage = false
 
-	prevToken := p.nextToken
 	p.nextToken = result.LastEvaluatedKey
-
-	_ = prevToken
+	if err := p.guard.after(p.nextToken); err != nil {
+		p.nextToken = nil
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -0,0 +1,88 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+// ScanAllSegments runs the parallel scan of input over its TotalSegments
+// segments, scanning up to parallelism segments at once, or all of them if
+// parallelism is not positive. The Segment of input is ignored.
+//
+// Every page retrieved is passed to fn together with its segment. Calls to fn
+// do not overlap, so fn needs no locking; the pages of a segment are passed
+// in order, those of different segments interleave. The first error returned
+// by a Scan call or by fn stops the scan of every segment and is returned.
+func (d *Dax) ScanAllSegments(ctx context.Context, input *dynamodb.ScanInput, parallelism int, fn func(segment int32, page *dynamodb.ScanOutput) error, optFns ...func(*dynamodb.Options)) error {
+	if input == nil || input.TotalSegments == nil {
+		return smithy.NewErrParamRequired("TotalSegments")
+	}
+	total := *input.TotalSegments
+	if total < 1 {
+		return client.NewCustomInvalidParamError("TotalSegments", "must be at least 1")
+	}
+	if parallelism <= 0 {
+		parallelism = int(total)
+	}
+
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var failed error
+	fail := func(err error) {
+		if failed == nil {
+			failed = err
+			cancel()
+		}
+	}
+	forEachChunk(sctx, int(total), parallelism, func(i int) {
+		segment := int32(i)
+		in := *input
+		in.Segment = &segment
+		p := NewScanPaginator(d, &in)
+		for p.HasMorePages() {
+			page, err := p.NextPage(sctx, optFns...)
+			mu.Lock()
+			if failed != nil {
+				mu.Unlock()
+				return
+			}
+			if err == nil {
+				err = fn(segment, page)
+			}
+			if err != nil {
+				fail(err)
+			}
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if failed == nil {
+		failed = ctx.Err()
+	}
+	return failed
+}
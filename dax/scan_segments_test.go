@@ -0,0 +1,112 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// segmentClient returns two pages per segment, each holding an item naming
+// its segment and page, and fails the scans of segment fail.
+type segmentClient struct {
+	client.DaxAPI
+	fail     int32
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (c *segmentClient) ScanWithOptions(ctx context.Context, input *dynamodb.ScanInput, output *dynamodb.ScanOutput, opt client.RequestOptions) (*dynamodb.ScanOutput, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		m := c.maxSeen.Load()
+		if n <= m || c.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	segment := aws.ToInt32(input.Segment)
+	if segment == c.fail {
+		return nil, errors.New("segment failed")
+	}
+	page := "1"
+	if input.ExclusiveStartKey != nil {
+		page = "2"
+	} else {
+		output.LastEvaluatedKey = map[string]types.AttributeValue{"id": &types.AttributeValueMemberN{Value: strconv.Itoa(int(segment))}}
+	}
+	output.Items = []map[string]types.AttributeValue{{
+		"page": &types.AttributeValueMemberS{Value: strconv.Itoa(int(segment)) + "/" + page},
+	}}
+	return output, nil
+}
+
+func TestScanAllSegments(t *testing.T) {
+	fake := &segmentClient{fail: -1}
+	d := &Dax{client: fake, config: DefaultConfig()}
+	input := &dynamodb.ScanInput{TableName: aws.String("t"), TotalSegments: aws.Int32(4)}
+
+	pages := make(map[int32][]string)
+	err := d.ScanAllSegments(context.Background(), input, 2, func(segment int32, page *dynamodb.ScanOutput) error {
+		pages[segment] = append(pages[segment], page.Items[0]["page"].(*types.AttributeValueMemberS).Value)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[int32][]string{
+		0: {"0/1", "0/2"},
+		1: {"1/1", "1/2"},
+		2: {"2/1", "2/2"},
+		3: {"3/1", "3/2"},
+	}, pages)
+	assert.LessOrEqual(t, fake.maxSeen.Load(), int32(2))
+	assert.Nil(t, input.Segment, "the input is not modified")
+}
+
+func TestScanAllSegments_errors(t *testing.T) {
+	d := &Dax{client: &segmentClient{fail: 2}, config: DefaultConfig()}
+	input := &dynamodb.ScanInput{TableName: aws.String("t"), TotalSegments: aws.Int32(4)}
+	ignore := func(int32, *dynamodb.ScanOutput) error { return nil }
+
+	err := d.ScanAllSegments(context.Background(), input, 0, ignore)
+	assert.EqualError(t, err, "segment failed")
+
+	d.client = &segmentClient{fail: -1}
+	stop := errors.New("stop")
+	var calls int
+	err = d.ScanAllSegments(context.Background(), input, 1, func(int32, *dynamodb.ScanOutput) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls, "no page is passed on after an error")
+
+	assert.Error(t, d.ScanAllSegments(context.Background(), &dynamodb.ScanInput{TableName: aws.String("t")}, 1, ignore))
+	assert.Error(t, d.ScanAllSegments(context.Background(), &dynamodb.ScanInput{TotalSegments: aws.Int32(0)}, 1, ignore))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, d.ScanAllSegments(ctx, input, 1, ignore), context.Canceled)
+}
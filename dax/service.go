@@ -24,6 +24,7 @@ import (
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/client"
 	"github.com/aws/aws-dax-go-v2/dax/internal/proxy"
+	"github.com/aws/aws-dax-go-v2/dax/types"
 	"github.com/aws/aws-dax-go-v2/dax/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -36,6 +37,8 @@ import (
 type Dax struct {
 	client client.DaxAPI
 	config Config
+	lazy   *lazyInit     // set by NewLazy
+	scans  chan struct{} // bounds concurrent Scan calls, nil if unbounded
 }
 
 const ServiceName = "dax"
@@ -49,8 +52,83 @@ type Config struct {
 	ReadRetries    int
 	RetryDelay     time.Duration
 
+	// ReadRequestTimeout and WriteRequestTimeout override RequestTimeout for
+	// read and write operations respectively when set.
+	ReadRequestTimeout  time.Duration
+	WriteRequestTimeout time.Duration
+
+	// PassthroughTimeoutMultiplier scales the read timeout of the reads DAX
+	// always forwards to DynamoDB, which are as slow as cache misses:
+	// strongly consistent GetItem, Query, Scan and BatchGetItem calls, and
+	// TransactGetItems. This keeps ReadRequestTimeout tight for reads which
+	// may be served from the cache. Zero leaves them at the read timeout.
+	PassthroughTimeoutMultiplier float64
+
+	// ReadMaxConsecutiveThrottles and WriteMaxConsecutiveThrottles stop
+	// retrying read and write operations respectively once that many
+	// consecutive attempts were throttled with the same error code.
+	// Zero retries up to ReadRetries or WriteRetries.
+	ReadMaxConsecutiveThrottles  int
+	WriteMaxConsecutiveThrottles int
+
+	// ThrottleBackoff is the backoff of retries after a throttle, which
+	// needs time to pass: 70ms doubling up to 20s if zero. IOBackoff is the
+	// backoff of retries after a network error, which recovers best when
+	// retried at once on another node: zero retries after RetryDelay.
+	// Latency-sensitive callers may choose tighter bounds, and the jitter
+	// strategy of each.
+	ThrottleBackoff types.Backoff
+	IOBackoff       types.Backoff
+
+	// BatchGetItemConcurrency bounds the requests in flight when BatchGetItem
+	// splits more than 100 keys into several requests. Zero or one executes
+	// them one at a time.
+	BatchGetItemConcurrency int
+	// BatchWriteItemConcurrency does the same for BatchWriteItem, which
+	// splits more than 25 write requests.
+	BatchWriteItemConcurrency int
+
+	// MaxConcurrentScans bounds the Scan calls in flight on this client, to
+	// keep a parallel scan job from overloading a shared cluster. Calls
+	// beyond it wait for a slot until their context is done. Zero leaves
+	// Scan unbounded.
+	MaxConcurrentScans int
+	// ApproveScan, when set, is called before every Scan not made with
+	// WithScanApproval and fails it with the error it returns, e.g. to only
+	// allow scans of some tables, or ErrScanNotApproved to require the
+	// per-call option.
+	ApproveScan func(ctx context.Context, input *dynamodb.ScanInput) error
+
+	// ControlPlaneEndpoint is the URL of the DAX control plane
+	// NewFromClusterName calls DescribeClusters on,
+	// https://dax.<Region>.amazonaws.com by default.
+	ControlPlaneEndpoint string
+	// ControlPlaneHTTPClient sends the DescribeClusters request of
+	// NewFromClusterName, and ControlPlaneRetryer retries it, like the
+	// HTTPClient and Retryer of aws.Config, which NewConfig copies them from.
+	// The SDK defaults are used if nil.
+	ControlPlaneHTTPClient aws.HTTPClient
+	ControlPlaneRetryer    func() aws.Retryer
+
+	// StrictParameters rejects requests using the legacy parameters
+	// AttributesToGet, KeyConditions, QueryFilter, ScanFilter, Expected,
+	// ConditionalOperator and AttributeUpdates with a LegacyParameterError
+	// naming the expression parameter to use instead. By default they are
+	// translated to expressions before the request is sent.
+	StrictParameters bool
+
+	// ItemCodec converts between application values and items for the
+	// generic GetItem and PutItem functions, with attributevalue by default.
+	ItemCodec ItemCodec
+
 	Logger   logging.Logger
 	LogLevel utils.LogLevelType
+
+	// ClientLogMode enables logging through Logger the same way as
+	// aws.Config.ClientLogMode does for the SDK clients. aws.LogRetries logs
+	// request retries, aws.LogRequest and aws.LogResponse log cluster
+	// refreshes and connection churn. It is combined with LogLevel.
+	ClientLogMode aws.ClientLogMode
 }
 
 // DefaultConfig returns the default DAX configuration.
@@ -70,15 +148,36 @@ func DefaultConfig() Config {
 }
 
 // NewConfig creates a new instance of the DAX config with an aws.Config.
-func NewConfig(config aws.Config, endpoint string) Config {
+// The optFns are applied after the aws.Config settings, e.g. to override the
+// region or credentials for this cluster.
+func NewConfig(config aws.Config, endpoint string, optFns ...func(*Config)) Config {
 	dc := DefaultConfig()
 	dc.mergeFrom(config, endpoint)
+	for _, fn := range optFns {
+		fn(&dc)
+	}
 	return dc
 }
 
+// validate validates the settings of the config beyond client.Config.
+func (c *Config) validate() error {
+	for _, b := range []types.Backoff{c.ThrottleBackoff, c.IOBackoff} {
+		if b.BaseDelay < 0 || b.MaxDelay < 0 {
+			return client.NewCustomInvalidParamError("ConfigValidation", "Backoff delays cannot be negative")
+		}
+		if !b.Jitter.IsValid() {
+			return client.NewCustomInvalidParamError("ConfigValidation", "Backoff Jitter must be 'equal', 'full' or 'none'")
+		}
+	}
+	return nil
+}
+
 // New creates a new instance of the DAX client with a DAX configuration.
 func New(cfg Config) (*Dax, error) {
-	cfg.Config.SetLogger(cfg.Logger, cfg.LogLevel)
+	cfg.Config.SetLogger(cfg.Logger, cfg.logLevel())
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	c, err := client.New(cfg.Config)
 	if err != nil {
 		if cfg.Logger != nil {
@@ -86,7 +185,30 @@ func New(cfg Config) (*Dax, error) {
 		}
 		return nil, err
 	}
-	return &Dax{client: c, config: cfg}, nil
+	return &Dax{client: c, config: cfg, scans: newScanSlots(cfg.MaxConcurrentScans)}, nil
+}
+
+// NewFromTopology creates a new instance of the DAX client which connects to
+// the given nodes, e.g. as returned by DescribeClusters, instead of
+// discovering them. The nodes only change with UpdateTopology; health checks
+// and connection pooling work as for clients created with New.
+//
+// Config.HostPorts is optional. When set, its scheme selects whether
+// connections are encrypted and its host is the name node certificates are
+// verified against, but it is not contacted.
+func NewFromTopology(nodes []types.Node, cfg Config) (*Dax, error) {
+	cfg.Config.SetLogger(cfg.Logger, cfg.logLevel())
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	c, err := client.NewFromTopology(cfg.Config, nodes)
+	if err != nil {
+		if cfg.Logger != nil {
+			cfg.Logger.Logf("ERROR", "Exception in initialisation of DAX Client : %s", err)
+		}
+		return nil, err
+	}
+	return &Dax{client: c, config: cfg, scans: newScanSlots(cfg.MaxConcurrentScans)}, nil
 }
 
 // SecureDialContext creates a secure DialContext for connecting to encrypted cluster
@@ -116,10 +238,17 @@ func SecureDialContext(endpoint string, skipHostnameVerification bool) (func(ctx
 //
 //	// Create a DAX client.
 //	svc := dax.NewFromConfig(config, "dax://mycluster.frfx8h.clustercfg.dax.usw2.amazonaws.com:8111")
-func NewFromConfig(config aws.Config, endpoint string) (*Dax, error) {
-	dc := DefaultConfig()
-	dc.mergeFrom(config, endpoint)
-	return New(dc)
+//
+// The optFns are applied after the aws.Config settings, so one aws.Config can
+// be shared by clients of clusters in different regions:
+//
+//	euw1 := dax.NewFromConfig(config, "dax://othercluster.x1y2z3.dax-clusters.eu-west-1.amazonaws.com",
+//		func(c *dax.Config) {
+//			c.Region = "eu-west-1"
+//			c.Credentials = euCredentials
+//		})
+func NewFromConfig(config aws.Config, endpoint string, optFns ...func(*Config)) (*Dax, error) {
+	return New(NewConfig(config, endpoint, optFns...))
 }
 
 func (c *Config) mergeFrom(ac aws.Config, endpoint string) {
@@ -136,36 +265,107 @@ func (c *Config) mergeFrom(ac aws.Config, endpoint string) {
 	if ac.Region != "" {
 		c.Region = ac.Region
 	}
+	if ac.AppID != "" {
+		c.AppID = ac.AppID
+	}
+	if ac.Logger != nil {
+		c.Logger = ac.Logger
+	}
+	if ac.HTTPClient != nil {
+		c.ControlPlaneHTTPClient = ac.HTTPClient
+	}
+	if ac.Retryer != nil {
+		c.ControlPlaneRetryer = ac.Retryer
+	}
+	c.ClientLogMode |= ac.ClientLogMode
+}
+
+// logLevel returns LogLevel extended with the levels enabled by ClientLogMode.
+func (c *Config) logLevel() utils.LogLevelType {
+	l := c.LogLevel
+	if c.ClientLogMode.IsRequest() || c.ClientLogMode.IsResponse() {
+		l |= utils.LogDebug
+	}
+	if c.ClientLogMode.IsRetries() {
+		l |= utils.LogDebug | utils.LogDebugWithRequestRetries
+	}
+	return l
 }
 
 func (c *Config) requestOptions(read bool, ctx context.Context, optFns ...func(*dynamodb.Options)) (client.RequestOptions, context.CancelFunc, error) {
-	r := c.WriteRetries
+	return c.requestOptionsFor(read, nil, ctx, optFns...)
+}
+
+// readOptions returns the options of a read, which passthrough reports,
+// once the per-call options are merged, as forwarded to DynamoDB.
+func (c *Config) readOptions(passthrough func(*dynamodb.Options) bool, ctx context.Context, optFns ...func(*dynamodb.Options)) (client.RequestOptions, context.CancelFunc, error) {
+	return c.requestOptionsFor(true, passthrough, ctx, optFns...)
+}
+
+func (c *Config) requestOptionsFor(read bool, passthrough func(*dynamodb.Options) bool, ctx context.Context, optFns ...func(*dynamodb.Options)) (client.RequestOptions, context.CancelFunc, error) {
+	r, timeout, throttles := c.WriteRetries, c.WriteRequestTimeout, c.WriteMaxConsecutiveThrottles
 	if read {
-		r = c.ReadRetries
+		r, timeout, throttles = c.ReadRetries, c.ReadRequestTimeout, c.ReadMaxConsecutiveThrottles
+	}
+	if timeout <= 0 {
+		timeout = c.RequestTimeout
 	}
 	var cfn context.CancelFunc
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if _, hasDeadline := ctx.Deadline(); !hasDeadline && c.RequestTimeout > 0 {
-		ctx, cfn = context.WithTimeout(ctx, c.RequestTimeout)
-	}
 	opt := client.RequestOptions{}
 	opt.Logger = c.Logger
-	opt.LogLevel = c.LogLevel
+	opt.LogLevel = c.logLevel()
 	opt.RetryMaxAttempts = r
 	opt.RetryDelay = c.RetryDelay
-	opt.Context = ctx
+	opt.Retryer.MaxConsecutiveThrottles = throttles
+	opt.Retryer.BaseThrottleDelay = c.ThrottleBackoff.BaseDelay
+	opt.Retryer.MaxBackoffDelay = c.ThrottleBackoff.MaxDelay
+	opt.Retryer.BaseIODelay = c.IOBackoff.BaseDelay
+	opt.Retryer.MaxIOBackoffDelay = c.IOBackoff.MaxDelay
+	opt.Retryer.ThrottleJitter = c.ThrottleBackoff.Jitter
+	opt.Retryer.IOJitter = c.IOBackoff.Jitter
 
 	// merge from request options
 	for _, o := range optFns {
 		o(&opt.Options)
 	}
 
-	if err := client.RejectCustomMiddleware(opt.APIOptions); err != nil {
+	if err := checkCallOptions(&opt.Options); err != nil {
 		return client.RequestOptions{}, cfn, err
 	}
 
+	// An SDK retryer set for the call, such as aws.NopRetryer, bounds its
+	// attempts; the client still retries with its own policy.
+	if opt.Options.Retryer != nil {
+		if n := opt.Options.Retryer.MaxAttempts(); n > 0 && n-1 < opt.RetryMaxAttempts {
+			opt.RetryMaxAttempts = n - 1
+		}
+	}
+
+	if passthrough != nil && passthrough(&opt.Options) {
+		opt.Passthrough = true
+		if c.PassthroughTimeoutMultiplier > 0 {
+			timeout = time.Duration(float64(timeout) * c.PassthroughTimeoutMultiplier)
+		}
+	}
+
+	co := callOptionsFrom(&opt.Options)
+	if co != nil {
+		opt.OnItem = co.onItem
+	}
+	if co != nil && co.requestTimeout != nil {
+		// an explicit per-call timeout applies even if the context already has a deadline,
+		// the earlier of both wins
+		if *co.requestTimeout > 0 {
+			ctx, cfn = context.WithTimeout(ctx, *co.requestTimeout)
+		}
+	} else if _, hasDeadline := ctx.Deadline(); !hasDeadline && timeout > 0 {
+		ctx, cfn = context.WithTimeout(ctx, timeout)
+	}
+	opt.Context = ctx
+
 	return opt, cfn, nil
 }
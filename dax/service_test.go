@@ -21,10 +21,15 @@ import (
 	"time"
 
 	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-dax-go-v2/dax/types"
+	"github.com/aws/aws-dax-go-v2/dax/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/smithy-go/logging"
 	"github.com/aws/smithy-go/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfigMergeFrom(t *testing.T) {
@@ -72,6 +77,70 @@ func TestConfigMergeFrom(t *testing.T) {
 	}
 }
 
+func TestConfigMergeFromAppID(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.mergeFrom(aws.Config{}, "")
+	assert.Empty(t, cfg.AppID)
+
+	cfg.mergeFrom(aws.Config{AppID: "billing-app"}, "")
+	assert.Equal(t, "billing-app", cfg.AppID)
+}
+
+func TestNewConfigOverrides(t *testing.T) {
+	shared := aws.Config{Region: "us-west-2", Credentials: aws.AnonymousCredentials{}}
+	endpoint := "dax://mycluster.frfx8h.clustercfg.dax.usw2.amazonaws.com"
+
+	cfg := NewConfig(shared, endpoint)
+	assert.Equal(t, "us-west-2", cfg.Region)
+	assert.Equal(t, aws.AnonymousCredentials{}, cfg.Credentials)
+
+	euCredentials := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "eu"}, nil
+	})
+	cfg = NewConfig(shared, endpoint, func(c *Config) {
+		c.Region = "eu-west-1"
+		c.Credentials = euCredentials
+	}, func(c *Config) {
+		c.SigningRegionSet = []string{"eu-*"}
+	})
+	assert.Equal(t, "eu-west-1", cfg.Region)
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", creds.AccessKeyID)
+	assert.Equal(t, []string{"eu-*"}, cfg.SigningRegionSet)
+	assert.Equal(t, []string{endpoint}, cfg.HostPorts)
+	assert.Equal(t, "us-west-2", shared.Region, "the shared aws.Config is not modified")
+}
+
+func TestConfigClientLogMode(t *testing.T) {
+	logger := logging.Nop{}
+	cfg := DefaultConfig()
+	cfg.mergeFrom(aws.Config{Logger: logger, ClientLogMode: aws.LogRetries}, "")
+	assert.Equal(t, logger, cfg.Logger)
+	assert.Equal(t, aws.LogRetries, cfg.ClientLogMode)
+
+	cases := []struct {
+		mode     aws.ClientLogMode
+		level    utils.LogLevelType
+		expected utils.LogLevelType
+	}{
+		{mode: 0, level: utils.LogOff, expected: utils.LogOff},
+		{mode: 0, level: utils.LogDebug, expected: utils.LogDebug},
+		{mode: aws.LogRequest, level: utils.LogOff, expected: utils.LogDebug},
+		{mode: aws.LogResponse, level: utils.LogOff, expected: utils.LogDebug},
+		{mode: aws.LogRetries, level: utils.LogOff, expected: utils.LogDebug | utils.LogDebugWithRequestRetries},
+		{mode: aws.LogSigning, level: utils.LogOff, expected: utils.LogOff},
+	}
+	for _, c := range cases {
+		cfg := Config{ClientLogMode: c.mode, LogLevel: c.level}
+		assert.Equal(t, c.expected, cfg.logLevel(), "mode %v level %v", c.mode, c.level)
+
+		opts, _, err := cfg.requestOptions(true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, opts.LogLevel)
+	}
+}
+
 func TestRequestOptions(t *testing.T) {
 	t.Run("read operation with default config", func(t *testing.T) {
 		cfg := &Config{
@@ -170,6 +239,154 @@ func TestRequestOptions(t *testing.T) {
 		})
 	})
 
+	t.Run("with separate read and write timeouts", func(t *testing.T) {
+		cfg := &Config{
+			ReadRetries:         3,
+			WriteRetries:        5,
+			RequestTimeout:      time.Minute,
+			ReadRequestTimeout:  time.Second,
+			WriteRequestTimeout: time.Second * 10,
+		}
+
+		for _, tc := range []struct {
+			read     bool
+			expected time.Duration
+		}{{true, time.Second}, {false, time.Second * 10}} {
+			start := time.Now()
+			opts, cfn, err := cfg.requestOptions(tc.read, nil)
+			assert.NoError(t, err)
+			assert.NotNil(t, cfn)
+			deadline, ok := opts.Context.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, start.Add(tc.expected), deadline, time.Second/2)
+			cfn()
+		}
+
+		cfg.WriteRequestTimeout = 0
+		start := time.Now()
+		opts, cfn, err := cfg.requestOptions(false, nil)
+		assert.NoError(t, err)
+		defer cfn()
+		deadline, _ := opts.Context.Deadline()
+		assert.WithinDuration(t, start.Add(time.Minute), deadline, time.Second/2)
+	})
+
+	t.Run("with consecutive throttle limits", func(t *testing.T) {
+		cfg := &Config{
+			ReadRetries:                  3,
+			WriteRetries:                 5,
+			ReadMaxConsecutiveThrottles:  2,
+			WriteMaxConsecutiveThrottles: 4,
+		}
+
+		opts, _, err := cfg.requestOptions(true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, opts.Retryer.MaxConsecutiveThrottles)
+
+		opts, _, err = cfg.requestOptions(false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, opts.Retryer.MaxConsecutiveThrottles)
+	})
+
+	t.Run("with backoffs", func(t *testing.T) {
+		cfg := &Config{
+			ThrottleBackoff: types.Backoff{BaseDelay: time.Second, MaxDelay: time.Minute},
+			IOBackoff:       types.Backoff{BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond},
+		}
+
+		opts, _, err := cfg.requestOptions(true, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second, opts.Retryer.BaseThrottleDelay)
+		assert.Equal(t, time.Minute, opts.Retryer.MaxBackoffDelay)
+		assert.Equal(t, time.Millisecond, opts.Retryer.BaseIODelay)
+		assert.Equal(t, 10*time.Millisecond, opts.Retryer.MaxIOBackoffDelay)
+	})
+
+	t.Run("with jitter", func(t *testing.T) {
+		cfg := &Config{
+			ThrottleBackoff: types.Backoff{Jitter: types.BackoffJitterFull},
+			IOBackoff:       types.Backoff{Jitter: types.BackoffJitterNone},
+		}
+
+		opts, _, err := cfg.requestOptions(false, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, types.BackoffJitterFull, opts.Retryer.ThrottleJitter)
+		assert.Equal(t, types.BackoffJitterNone, opts.Retryer.IOJitter)
+	})
+
+	t.Run("with per-call overrides", func(t *testing.T) {
+		cfg := &Config{
+			ReadRetries:    3,
+			WriteRetries:   5,
+			RequestTimeout: time.Minute,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		opts, cfn, err := cfg.requestOptions(true, ctx, WithRequestTimeout(time.Second), WithRetryMaxAttempts(7))
+		defer func() {
+			if cfn != nil {
+				cfn()
+			}
+		}()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 7, opts.RetryMaxAttempts)
+		assert.NotNil(t, cfn)
+		deadline, ok := opts.Context.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 500*time.Millisecond)
+	})
+
+	t.Run("with per-call retries set through dynamodb options", func(t *testing.T) {
+		cfg := &Config{
+			ReadRetries:  3,
+			WriteRetries: 5,
+		}
+
+		opts, cfn, err := cfg.requestOptions(false, nil, func(o *dynamodb.Options) {
+			o.RetryMaxAttempts = 0
+		})
+		defer func() {
+			if cfn != nil {
+				cfn()
+			}
+		}()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, opts.RetryMaxAttempts)
+	})
+
+	t.Run("without retries", func(t *testing.T) {
+		cfg := &Config{ReadRetries: 3, WriteRetries: 5}
+
+		opts, _, err := cfg.requestOptions(true, nil, WithoutRetries())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, opts.RetryMaxAttempts)
+	})
+
+	t.Run("with a per-call SDK retryer", func(t *testing.T) {
+		cfg := &Config{ReadRetries: 3, WriteRetries: 5}
+
+		opts, _, err := cfg.requestOptions(false, nil, func(o *dynamodb.Options) {
+			o.Retryer = aws.NopRetryer{}
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, opts.RetryMaxAttempts)
+
+		opts, _, err = cfg.requestOptions(false, nil, func(o *dynamodb.Options) {
+			o.Retryer = retry.AddWithMaxAttempts(retry.NewStandard(), 3)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, opts.RetryMaxAttempts)
+
+		opts, _, err = cfg.requestOptions(false, nil, func(o *dynamodb.Options) {
+			o.Retryer = retry.AddWithMaxAttempts(retry.NewStandard(), 10)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, opts.RetryMaxAttempts, "the retryer only lowers the attempts")
+	})
+
 	t.Run("with custom middleware should return error", func(t *testing.T) {
 		cfg := &Config{
 			ReadRetries:  3,
@@ -194,3 +411,75 @@ func TestRequestOptions(t *testing.T) {
 		assert.Equal(t, client.RequestOptions{}, opts)
 	})
 }
+
+func TestConfigValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.NoError(t, cfg.validate())
+	cfg.IOBackoff = types.Backoff{BaseDelay: time.Millisecond, Jitter: "FULL"}
+	assert.NoError(t, cfg.validate())
+
+	cfg.ThrottleBackoff.MaxDelay = -time.Second
+	assert.ErrorContains(t, cfg.validate(), "Backoff delays cannot be negative")
+	cfg.ThrottleBackoff.MaxDelay = 0
+	cfg.IOBackoff.Jitter = "decorrelated"
+	assert.ErrorContains(t, cfg.validate(), "Backoff Jitter must be")
+
+	_, err := New(cfg)
+	assert.ErrorContains(t, err, "Backoff Jitter must be")
+}
+
+func TestReadOptionsPassthroughTimeout(t *testing.T) {
+	cfg := &Config{ReadRequestTimeout: time.Second, PassthroughTimeoutMultiplier: 10}
+	cases := []struct {
+		name        string
+		passthrough func(*dynamodb.Options) bool
+		optFns      []func(*dynamodb.Options)
+		expected    time.Duration
+		isPassthru  bool
+	}{
+		{"eventually consistent", consistentRead(nil), nil, time.Second, false},
+		{"consistent", consistentRead(aws.Bool(true)), nil, 10 * time.Second, true},
+		{"consistent per call", consistentRead(aws.Bool(false)), []func(*dynamodb.Options){WithConsistentRead(true)}, 10 * time.Second, true},
+		{"eventually consistent per call", consistentRead(aws.Bool(true)), []func(*dynamodb.Options){WithConsistentRead(false)}, time.Second, false},
+		{"transaction", alwaysPassthrough, nil, 10 * time.Second, true},
+		{"per call timeout", alwaysPassthrough, []func(*dynamodb.Options){WithRequestTimeout(2 * time.Second)}, 2 * time.Second, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			start := time.Now()
+			opts, cfn, err := cfg.readOptions(c.passthrough, context.Background(), c.optFns...)
+			if cfn != nil {
+				defer cfn()
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.isPassthru, opts.Passthrough)
+			deadline, ok := opts.Context.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, start.Add(c.expected), deadline, 100*time.Millisecond)
+		})
+	}
+}
+
+func TestNewFromTopology(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Region = "us-west-2"
+	d, err := NewFromTopology([]types.Node{{NodeID: 1, Address: "127.0.0.1", Port: 8111, Role: types.NodeRoleLeader}}, cfg)
+	require.NoError(t, err)
+	defer d.Close()
+
+	state, err := d.ClusterState(context.Background())
+	require.NoError(t, err)
+	require.Len(t, state.Nodes, 1)
+	assert.Equal(t, "127.0.0.1:8111", state.Nodes[0].Address)
+
+	require.NoError(t, d.UpdateTopology([]types.Node{{NodeID: 2, Address: "127.0.0.2", Port: 8111}}))
+	state, err = d.ClusterState(context.Background())
+	require.NoError(t, err)
+	require.Len(t, state.Nodes, 1)
+	assert.Equal(t, int64(2), state.Nodes[0].NodeID)
+	assert.Error(t, d.RefreshCluster(context.Background()))
+
+	status, err := d.DiscoveryStatus(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, status.Source, "the nodes are not discovered")
+}
@@ -0,0 +1,83 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ItemCodec converts between application values and items. Marshal and
+// Unmarshal default to MarshalMap and UnmarshalMap of
+// feature/dynamodb/attributevalue when nil.
+type ItemCodec struct {
+	Marshal   func(in interface{}) (map[string]types.AttributeValue, error)
+	Unmarshal func(item map[string]types.AttributeValue, out interface{}) error
+}
+
+// withDefaults returns c with its nil functions set to the attributevalue ones.
+func (c ItemCodec) withDefaults() ItemCodec {
+	if c.Marshal == nil {
+		c.Marshal = attributevalue.MarshalMap
+	}
+	if c.Unmarshal == nil {
+		c.Unmarshal = attributevalue.UnmarshalMap
+	}
+	return c
+}
+
+// GetItem reads the item of table with the given key and unmarshals it into
+// a T with Config.ItemCodec. The key is an item key, or a value the codec
+// marshals to one. A nil T is returned if there is no such item.
+func GetItem[T any](ctx context.Context, client *Dax, table string, key any, optFns ...func(*dynamodb.Options)) (*T, error) {
+	if err := client.init(ctx); err != nil {
+		return nil, err
+	}
+	codec := client.config.ItemCodec.withDefaults()
+	k, ok := key.(map[string]types.AttributeValue)
+	if !ok {
+		var err error
+		if k, err = codec.Marshal(key); err != nil {
+			return nil, err
+		}
+	}
+	out, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String(table), Key: k}, optFns...)
+	if err != nil || out.Item == nil {
+		return nil, err
+	}
+	v := new(T)
+	if err := codec.Unmarshal(out.Item, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PutItem marshals item with Config.ItemCodec and writes it to table.
+func PutItem[T any](ctx context.Context, client *Dax, table string, item T, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := client.init(ctx); err != nil {
+		return nil, err
+	}
+	codec := client.config.ItemCodec.withDefaults()
+	av, err := codec.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	return client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(table), Item: av}, optFns...)
+}
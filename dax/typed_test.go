@@ -0,0 +1,149 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package dax
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// itemClient keeps the items put, by their id attribute.
+type itemClient struct {
+	client.DaxAPI
+	items map[string]map[string]types.AttributeValue
+}
+
+func (c *itemClient) GetItemWithOptions(ctx context.Context, input *dynamodb.GetItemInput, output *dynamodb.GetItemOutput, opt client.RequestOptions) (*dynamodb.GetItemOutput, error) {
+	output.Item = c.items[input.Key["id"].(*types.AttributeValueMemberS).Value]
+	return output, nil
+}
+
+func (c *itemClient) PutItemWithOptions(ctx context.Context, input *dynamodb.PutItemInput, output *dynamodb.PutItemOutput, opt client.RequestOptions) (*dynamodb.PutItemOutput, error) {
+	c.items[input.Item["id"].(*types.AttributeValueMemberS).Value] = input.Item
+	return output, nil
+}
+
+type order struct {
+	ID    string
+	Total string
+}
+
+type orderKey struct {
+	ID string
+}
+
+// orderCodec converts orders and their keys, as attributevalue would.
+var orderCodec = ItemCodec{
+	Marshal: func(in interface{}) (map[string]types.AttributeValue, error) {
+		switch v := in.(type) {
+		case order:
+			return map[string]types.AttributeValue{
+				"id":    &types.AttributeValueMemberS{Value: v.ID},
+				"total": &types.AttributeValueMemberN{Value: v.Total},
+			}, nil
+		case orderKey:
+			return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: v.ID}}, nil
+		}
+		return nil, errors.New("unsupported type")
+	},
+	Unmarshal: func(item map[string]types.AttributeValue, out interface{}) error {
+		o, ok := out.(*order)
+		if !ok {
+			return errors.New("unsupported type")
+		}
+		o.ID = item["id"].(*types.AttributeValueMemberS).Value
+		o.Total = item["total"].(*types.AttributeValueMemberN).Value
+		return nil
+	},
+}
+
+func TestGetItemPutItem(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ItemCodec = orderCodec
+	d := &Dax{client: &itemClient{items: map[string]map[string]types.AttributeValue{}}, config: cfg}
+	ctx := context.Background()
+
+	_, err := PutItem(ctx, d, "orders", order{ID: "a", Total: "12"})
+	require.NoError(t, err)
+
+	o, err := GetItem[order](ctx, d, "orders", orderKey{ID: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, &order{ID: "a", Total: "12"}, o)
+
+	o, err = GetItem[order](ctx, d, "orders", map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "a"}})
+	require.NoError(t, err)
+	assert.Equal(t, "12", o.Total)
+
+	o, err = GetItem[order](ctx, d, "orders", orderKey{ID: "missing"})
+	require.NoError(t, err)
+	assert.Nil(t, o)
+
+	_, err = GetItem[order](ctx, d, "orders", aws.String("a"))
+	assert.EqualError(t, err, "unsupported type")
+	_, err = PutItem(ctx, d, "orders", orderKey{ID: "b"})
+	assert.NoError(t, err, "keys are items too")
+	_, err = GetItem[string](ctx, d, "orders", orderKey{ID: "b"})
+	assert.EqualError(t, err, "unsupported type")
+}
+
+func TestGetItemPutItem_defaultCodec(t *testing.T) {
+	type item struct {
+		ID    string `dynamodbav:"id"`
+		Total int    `dynamodbav:"total"`
+	}
+	d := &Dax{client: &itemClient{items: map[string]map[string]types.AttributeValue{}}, config: DefaultConfig()}
+	ctx := context.Background()
+
+	_, err := PutItem(ctx, d, "orders", item{ID: "a", Total: 12})
+	require.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "12"}, d.client.(*itemClient).items["a"]["total"])
+
+	o, err := GetItem[item](ctx, d, "orders", struct {
+		ID string `dynamodbav:"id"`
+	}{ID: "a"})
+	require.NoError(t, err)
+	assert.Equal(t, &item{ID: "a", Total: 12}, o)
+}
+
+func TestGetItemPutItem_lazyClient(t *testing.T) {
+	var marshals int
+	codec := orderCodec
+	codec.Marshal = func(in interface{}) (map[string]types.AttributeValue, error) {
+		marshals++
+		return orderCodec.Marshal(in)
+	}
+	d := NewLazy(func(context.Context) (Config, error) {
+		cfg := lazyTestConfig()
+		cfg.ItemCodec = codec
+		return cfg, nil
+	})
+	defer d.Close()
+
+	// No node is listening, the calls fail after using the codec.
+	_, err := GetItem[order](context.Background(), d, "orders", orderKey{ID: "a"})
+	assert.Error(t, err)
+	_, err = PutItem(context.Background(), d, "orders", order{ID: "a"})
+	assert.Error(t, err)
+	assert.Equal(t, 2, marshals)
+}
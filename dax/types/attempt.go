@@ -0,0 +1,33 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// Attempt describes an attempt of an operation, as listed in the
+// ResultMetadata of its output.
+type Attempt struct {
+	// Node is the address of the node the attempt was sent to, empty if
+	// no node was available.
+	Node string
+	// Err is the error the attempt failed with, nil for the attempt which
+	// succeeded.
+	Err error
+	// RequestID is the ID the node reported for the failed request, if any.
+	RequestID string
+	// Duration is how long the attempt took, without the retry delay.
+	Duration time.Duration
+}
@@ -0,0 +1,73 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// Backoff is an exponential backoff: the delay before retry n is up to
+// BaseDelay * 2^n, capped by MaxDelay, randomized as Jitter selects.
+type Backoff struct {
+	// BaseDelay is the delay of the first retry, before jitter. Zero retries
+	// at once.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay of a retry, 20 seconds if zero.
+	MaxDelay time.Duration
+	// Jitter selects the random part of the delays, equal jitter by default.
+	Jitter BackoffJitter
+}
+
+// BackoffJitter selects how the delays of a Backoff are randomized, so that
+// clients failing together do not retry together.
+type BackoffJitter string
+
+const (
+	// BackoffJitterEqual randomizes the upper half of every delay. This is
+	// the default.
+	BackoffJitterEqual BackoffJitter = "equal"
+	// BackoffJitterFull randomizes the whole delay, spreading retries the
+	// most at the cost of some retries being immediate.
+	BackoffJitterFull BackoffJitter = "full"
+	// BackoffJitterNone uses the exponential delays as they are.
+	BackoffJitterNone BackoffJitter = "none"
+)
+
+// String implements fmt.Stringer interface
+func (j BackoffJitter) String() string {
+	return string(j)
+}
+
+// IsFull returns true if the value matches "full" regardless the capitalization.
+func (j BackoffJitter) IsFull() bool {
+	return strings.EqualFold(BackoffJitterFull.String(), j.String())
+}
+
+// IsNone returns true if the value matches "none" regardless the capitalization.
+func (j BackoffJitter) IsNone() bool {
+	return strings.EqualFold(BackoffJitterNone.String(), j.String())
+}
+
+// IsValid represents a validation function on the user-inserted value for BackoffJitter
+// Returns bool true if the value matches "equal", "full", "none" or empty string regardless the capitalization. False, otherwise.
+func (j BackoffJitter) IsValid() bool {
+	v := j.String()
+	return strings.EqualFold(BackoffJitterEqual.String(), v) ||
+		j.IsFull() ||
+		j.IsNone() ||
+		v == ""
+}
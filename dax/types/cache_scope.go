@@ -0,0 +1,62 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// CacheScopeLevel defines how much client-local cached state is invalidated.
+type CacheScopeLevel string
+
+const (
+	// CacheScopeAll drops every client-local cache entry.
+	CacheScopeAll CacheScopeLevel = "all"
+	// CacheScopeTable drops cache entries that belong to a single table.
+	CacheScopeTable CacheScopeLevel = "table"
+	// CacheScopeKey drops cache entries that belong to a single item of a table.
+	CacheScopeKey CacheScopeLevel = "key"
+)
+
+// String implements fmt.Stringer interface
+func (l CacheScopeLevel) String() string {
+	return string(l)
+}
+
+// CacheScope selects the client-local cache entries to invalidate.
+//
+// TableName is required for CacheScopeTable and CacheScopeKey,
+// Key is required for CacheScopeKey.
+type CacheScope struct {
+	Level     CacheScopeLevel
+	TableName string
+	Key       map[string]ddbtypes.AttributeValue
+}
+
+// AllCaches returns a scope invalidating every client-local cache entry.
+func AllCaches() CacheScope {
+	return CacheScope{Level: CacheScopeAll}
+}
+
+// TableCaches returns a scope invalidating cache entries of the given table.
+func TableCaches(table string) CacheScope {
+	return CacheScope{Level: CacheScopeTable, TableName: table}
+}
+
+// KeyCaches returns a scope invalidating cache entries of a single item.
+func KeyCaches(table string, key map[string]ddbtypes.AttributeValue) CacheScope {
+	return CacheScope{Level: CacheScopeKey, TableName: table, Key: key}
+}
@@ -0,0 +1,60 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	"time"
+)
+
+// ClusterEventType identifies a change observed in a DAX cluster.
+type ClusterEventType string
+
+const (
+	// ClusterEventNodeAdded is reported when a node joins the cluster.
+	ClusterEventNodeAdded ClusterEventType = "NodeAdded"
+	// ClusterEventNodeRemoved is reported when a node leaves the cluster.
+	ClusterEventNodeRemoved ClusterEventType = "NodeRemoved"
+	// ClusterEventLeaderChanged is reported when a different node, or no
+	// node, is the cluster leader.
+	ClusterEventLeaderChanged ClusterEventType = "LeaderChanged"
+	// ClusterEventRefreshFailed is reported when the cluster membership
+	// could not be refreshed.
+	ClusterEventRefreshFailed ClusterEventType = "RefreshFailed"
+	// ClusterEventAttributeListsExceeded is reported when more distinct
+	// sets of attribute names were written to a table than the configured
+	// attribute list threshold.
+	ClusterEventAttributeListsExceeded ClusterEventType = "AttributeListsExceeded"
+)
+
+// ClusterEvent describes a change in the cluster membership, or a warning
+// about how the application uses the cluster.
+type ClusterEvent struct {
+	Type ClusterEventType
+	Time time.Time
+
+	// Node is the node added or removed, or the new leader. Its Address is
+	// empty when the cluster has no leader. Only the node identity, role and
+	// availability zone are set.
+	Node NodeState
+
+	// Err is the refresh error of a ClusterEventRefreshFailed event.
+	Err error
+
+	// Table and AttributeLists are the table and its number of distinct
+	// attribute lists of a ClusterEventAttributeListsExceeded event.
+	Table          string
+	AttributeLists int
+}
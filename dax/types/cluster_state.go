@@ -0,0 +1,112 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	"time"
+)
+
+// NodeRole is the role of a node within a DAX cluster.
+type NodeRole string
+
+const (
+	NodeRoleLeader  NodeRole = "leader"
+	NodeRoleReplica NodeRole = "replica"
+)
+
+// Node identifies a DAX node of a client whose nodes are managed by the
+// application, e.g. from the nodes returned by DescribeClusters.
+type Node struct {
+	NodeID int64
+	// Address is the IP address the client connects to.
+	Address string
+	Port    int
+	// Hostname is the DNS name of the node, used in logs and cluster events.
+	Hostname         string
+	Role             NodeRole
+	AvailabilityZone string
+}
+
+// ClusterState describes the cluster nodes as currently known to a DAX client.
+type ClusterState struct {
+	// LastRefresh is the time of the last successful membership refresh.
+	LastRefresh time.Time
+	// RefreshError is the error of the last membership refresh, if it failed.
+	RefreshError error
+
+	// Nodes holds the known nodes, ordered by Address.
+	Nodes []NodeState
+
+	// AttributeLists holds the number of distinct sets of non-key attribute
+	// names written to each table, see Config.AttributeListThreshold.
+	AttributeLists map[string]int
+}
+
+// DiscoveryStatus describes the cluster membership refreshes of a DAX
+// client, which tells a stale view of the cluster apart from nodes which are
+// down.
+type DiscoveryStatus struct {
+	// LastRefresh is the time of the last successful membership refresh,
+	// LastAttempt the time of the last one.
+	LastRefresh time.Time
+	LastAttempt time.Time
+	// RefreshError is the error of the last membership refresh, if it failed,
+	// and ConsecutiveFailures the number of refreshes which failed since the
+	// last successful one.
+	RefreshError        error
+	ConsecutiveFailures int
+
+	// Seed is the configured host:port the membership was last discovered
+	// through, and Source the address of the node it resolved to which
+	// returned the membership.
+	Seed   string
+	Source string
+}
+
+// NodeState describes a single DAX node and the client's connections to it.
+type NodeState struct {
+	NodeID int64
+	// Address is the host:port the client connects to.
+	Address          string
+	Hostname         string
+	Role             NodeRole
+	AvailabilityZone string
+
+	// Healthy is set while the client routes requests to the node. Nodes are
+	// only taken out of rotation when the route manager or health probes are
+	// enabled.
+	Healthy bool
+	// Weight is the routing weight of the node relative to the others when
+	// Config.NodeWeight or Config.NodeRampUp is set, zero otherwise.
+	Weight float64
+
+	IdleConnections    int
+	PendingConnections int
+	InFlightRequests   int
+	// StandbyConnections counts the spare connections kept for the node,
+	// see Config.StandbyConnectionsPerNode.
+	StandbyConnections int
+
+	// BytesSent and BytesReceived count the bytes of the requests sent to the
+	// node and of its responses, over all connections of the client.
+	// FramesSent and FramesReceived count the requests and responses, and
+	// LargestResponse is the size in bytes of the largest response.
+	BytesSent       int64
+	BytesReceived   int64
+	FramesSent      int64
+	FramesReceived  int64
+	LargestResponse int64
+}
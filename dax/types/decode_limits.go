@@ -0,0 +1,32 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+// DecodeLimits bound what the client accepts from DAX responses, so a
+// corrupted or malicious frame fails with a deserialization error instead of
+// an attempt to allocate the sizes its headers announce. Zero fields select
+// the defaults.
+type DecodeLimits struct {
+	// MaxArrayLength is the maximum number of elements of an array or map,
+	// such as the items of a page or the members of a set. 1048576 by default.
+	MaxArrayLength int
+	// MaxStringLength is the maximum number of bytes of a string or binary
+	// value, including the encoded items of a page. 16 MiB by default.
+	MaxStringLength int
+	// MaxDepth is the maximum number of nested list and map levels of an
+	// attribute value. 32 by default, the DynamoDB limit.
+	MaxDepth int
+}
@@ -0,0 +1,51 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	"time"
+)
+
+// HealthCheckResult reports the health of a DAX client.
+type HealthCheckResult struct {
+	// Healthy is set when cluster discovery is fresh and at least one node
+	// could be reached and accepted the client's credentials.
+	Healthy bool
+
+	// DiscoveryFresh is set when the cluster membership was refreshed
+	// successfully within the last few refresh intervals. It is always set
+	// for clients whose nodes are managed with UpdateTopology.
+	DiscoveryFresh bool
+	// LastRefresh is the time of the last successful membership refresh.
+	LastRefresh time.Time
+	// RefreshError is the error of the last membership refresh, if it failed.
+	RefreshError error
+
+	// Nodes holds the result of checking each node of the cluster.
+	Nodes []NodeHealth
+}
+
+// NodeHealth reports the health of a single DAX node.
+type NodeHealth struct {
+	// Address is the host:port the client connects to.
+	Address  string
+	Hostname string
+	// Healthy is set when a request to the node succeeded, which requires
+	// both connectivity and valid credentials.
+	Healthy bool
+	Latency time.Duration
+	Err     error
+}
@@ -0,0 +1,30 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import (
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ItemKey is the primary key of an item, as the client sends it to DAX.
+type ItemKey struct {
+	// Attributes holds the key attributes of the item.
+	Attributes map[string]ddbtypes.AttributeValue
+	// Encoded is the canonical encoding of the key. Items with equal keys
+	// have equal encodings, whatever their other attributes, so
+	// string(Encoded) can serve as a cache key, shard key or dedup key.
+	Encoded []byte
+}
@@ -0,0 +1,48 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "strings"
+
+// ParameterValidation selects how requests using parameters DAX does not
+// support, or parameter combinations DynamoDB rejects, are handled.
+type ParameterValidation string
+
+const (
+	// ParameterValidationPermissive sends requests as they are and lets the
+	// cluster decide. This is the default.
+	ParameterValidationPermissive ParameterValidation = "permissive"
+	// ParameterValidationStrict fails such requests before they are sent.
+	ParameterValidationStrict ParameterValidation = "strict"
+)
+
+// String implements fmt.Stringer interface
+func (v ParameterValidation) String() string {
+	return string(v)
+}
+
+// IsStrict returns true if the value matches "strict" regardless the capitalization.
+func (v ParameterValidation) IsStrict() bool {
+	return strings.EqualFold(ParameterValidationStrict.String(), v.String())
+}
+
+// IsValid returns true if the value matches "permissive", "strict" or the
+// empty string regardless the capitalization.
+func (v ParameterValidation) IsValid() bool {
+	return strings.EqualFold(ParameterValidationPermissive.String(), v.String()) ||
+		v.IsStrict() ||
+		v == ""
+}
@@ -0,0 +1,30 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+// RateLimit bounds the requests a client sends for a table, see
+// Config.TableRateLimits. Reads are GetItem, Query, Scan, BatchGetItem and
+// TransactGetItems; the other operations are writes. Zero rates do not
+// limit.
+type RateLimit struct {
+	// ReadsPerSecond is the sustained rate of read requests.
+	ReadsPerSecond float64
+	// WritesPerSecond is the sustained rate of write requests.
+	WritesPerSecond float64
+	// Burst is the number of requests of either kind which may be sent at
+	// once after a quiet period. The rate rounded up, at least 1, if zero.
+	Burst int
+}
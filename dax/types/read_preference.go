@@ -0,0 +1,55 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "strings"
+
+// ReadPreference selects which cluster nodes serve read operations.
+type ReadPreference string
+
+const (
+	// ReadPreferenceAny spreads reads across all nodes. This is the default.
+	ReadPreferenceAny ReadPreference = "any"
+	// ReadPreferenceLeader sends reads to the leader node for lower staleness.
+	ReadPreferenceLeader ReadPreference = "leader"
+	// ReadPreferenceReplica sends reads to replica nodes, saving leader capacity.
+	ReadPreferenceReplica ReadPreference = "replica"
+)
+
+// String implements fmt.Stringer interface
+func (p ReadPreference) String() string {
+	return string(p)
+}
+
+// IsLeader returns true if the value matches "leader" regardless the capitalization.
+func (p ReadPreference) IsLeader() bool {
+	return strings.EqualFold(ReadPreferenceLeader.String(), p.String())
+}
+
+// IsReplica returns true if the value matches "replica" regardless the capitalization.
+func (p ReadPreference) IsReplica() bool {
+	return strings.EqualFold(ReadPreferenceReplica.String(), p.String())
+}
+
+// IsValid represents a validation function on the user-inserted value for ReadPreference
+// Returns bool true if the value matches "any", "leader", "replica" or empty string regardless the capitalization. False, otherwise.
+func (p ReadPreference) IsValid() bool {
+	v := p.String()
+	return strings.EqualFold(ReadPreferenceAny.String(), v) ||
+		p.IsLeader() ||
+		p.IsReplica() ||
+		v == ""
+}
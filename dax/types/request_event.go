@@ -0,0 +1,37 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// RequestEvent describes a request sent to a node, as passed to the
+// OnRequest, OnResponse and OnError interceptors of the client.
+type RequestEvent struct {
+	// Operation is the name of the operation, such as "GetItem".
+	Operation string
+	// Node is the address of the node the request was sent to.
+	Node string
+	// RequestSize is the serialized size of the request in bytes, zero if
+	// it was not sent.
+	RequestSize int64
+	// ResponseSize is the serialized size of the response in bytes, zero
+	// until it is read.
+	ResponseSize int64
+	// Duration is how long the request took so far, connecting included.
+	Duration time.Duration
+	// Err is the error the request failed with, for OnError.
+	Err error
+}
@@ -0,0 +1,45 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "strings"
+
+// Define constant string values for possible inputs of the user provided SigningAlgorithm
+type SigningAlgorithm string
+
+const (
+	SigningAlgorithmSigV4  SigningAlgorithm = "sigv4"
+	SigningAlgorithmSigV4A SigningAlgorithm = "sigv4a"
+)
+
+// String implements fmt.Stringer interface
+func (s SigningAlgorithm) String() string {
+	return string(s)
+}
+
+// IsSigV4A returns true if the value matches "sigv4a" regardless the capitalization.
+func (s SigningAlgorithm) IsSigV4A() bool {
+	return strings.EqualFold(SigningAlgorithmSigV4A.String(), s.String())
+}
+
+// IsValid represents a validation function on the user-inserted value for SigningAlgorithm
+// Returns bool true if the value matches "sigv4", "sigv4a" or empty string regardless the capitalization. False, otherwise.
+func (s SigningAlgorithm) IsValid() bool {
+	v := s.String()
+	return strings.EqualFold(SigningAlgorithmSigV4.String(), v) ||
+		strings.EqualFold(SigningAlgorithmSigV4A.String(), v) ||
+		v == ""
+}
@@ -0,0 +1,36 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// SlowRequest describes a call which took longer than the
+// SlowRequestThreshold of the client, retries included.
+type SlowRequest struct {
+	// Operation is the name of the operation, such as "GetItem".
+	Operation string
+	// Tables are the tables the call accessed, in order.
+	Tables []string
+	// Duration is how long the call took, retries included.
+	Duration time.Duration
+	// Node is the address of the node of the last attempt, empty if no node
+	// was available.
+	Node string
+	// Attempts are the attempts of the call, in order.
+	Attempts []Attempt
+	// Err is the error the call failed with, nil if it succeeded.
+	Err error
+}
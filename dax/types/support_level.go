@@ -0,0 +1,39 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package types
+
+// SupportLevel tells how a client handles a DynamoDB operation.
+type SupportLevel string
+
+const (
+	// SupportLevelNative operations are served by the DAX cluster.
+	SupportLevelNative SupportLevel = "native"
+	// SupportLevelPassthrough operations are forwarded to DynamoDB unchanged,
+	// without caching.
+	SupportLevelPassthrough SupportLevel = "passthrough"
+	// SupportLevelUnsupported operations fail with a NotImplemented error.
+	SupportLevelUnsupported SupportLevel = "unsupported"
+)
+
+// String implements fmt.Stringer interface
+func (l SupportLevel) String() string {
+	return string(l)
+}
+
+// IsSupported returns true for operations which can be called, natively or passed through.
+func (l SupportLevel) IsSupported() bool {
+	return l == SupportLevelNative || l == SupportLevelPassthrough
+}
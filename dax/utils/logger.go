@@ -64,6 +64,17 @@ const (
 	// be retried. This should be used to log when you want to log when service
 	// requests are being retried. Will also enable LogDebug.
 	LogDebugWithRequestRetries LogLevelType = 2
+
+	// LogDebugWithWireDump states the SDK should log the cbor frames of every
+	// request and response in diagnostic notation, with the content of strings
+	// and binary values redacted. This should be used to diagnose protocol
+	// level issues.
+	LogDebugWithWireDump LogLevelType = 4
+
+	// LogDebugWithUnredactedWireDump is like LogDebugWithWireDump but logs the
+	// frames including attribute values along with a hex dump. Only use it with
+	// data which is safe to appear in logs.
+	LogDebugWithUnredactedWireDump LogLevelType = 8
 )
 
 // Create a default logger implementing smithy-go logging.Logger interface
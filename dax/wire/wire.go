@@ -0,0 +1,140 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+// Package wire decodes captured DAX protocol frames, such as the wire dumps
+// logged with utils.LogDebugWithUnredactedWireDump, into values which
+// marshal to readable JSON for support escalations.
+//
+// The cbor items of a frame are decoded to integers, float64, bool, nil,
+// strings and []any for arrays. Maps marshal to JSON objects in encoding
+// order, tags to {"tag": 3321, "name": "StringSet", "value": ...} and byte
+// strings to {"bytes": "<hex>", "cbor": [...]}, with the items they hold if
+// any, as DAX embeds encoded keys and attributes in byte strings.
+package wire
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/aws/aws-dax-go-v2/dax/internal/client"
+)
+
+// Request is a request sent to a DAX node, as decoded by DecodeRequest.
+type Request struct {
+	Service  int64  `json:"service"`
+	MethodID int64  `json:"methodId"`
+	Method   string `json:"method,omitempty"`
+	// Arguments holds the items following the method ID.
+	Arguments []any `json:"arguments"`
+}
+
+// Response is a response of a DAX node, as decoded by DecodeResponse.
+type Response struct {
+	Error *Error `json:"error,omitempty"`
+	// Result holds the items of a successful response.
+	Result []any `json:"result,omitempty"`
+}
+
+// Error is the error of a failed request.
+type Error struct {
+	Codes []int64 `json:"codes"`
+	// Exception is the error code of the exception the client returns for
+	// this failure, e.g. ConditionalCheckFailedException.
+	Exception string `json:"exception,omitempty"`
+	Message   string `json:"message"`
+	// Info holds the request ID, the error code, the status code and, for
+	// canceled transactions, the cancellation reasons.
+	Info []any `json:"info,omitempty"`
+}
+
+// DecodeRequest decodes a captured request frame, such as a wire dump, to
+// report the method called and its arguments. Frames captured on a new
+// connection may start with the connection preamble, which is skipped.
+// With redact set, strings and byte strings are replaced by their length.
+func DecodeRequest(frame []byte, redact bool) (*Request, error) {
+	values, err := cbor.Values(frame, redact)
+	if err != nil {
+		return nil, err
+	}
+	if client.HasPreamble(frame) {
+		values = values[min(client.PreambleItems, len(values)):]
+	}
+	if len(values) < 2 {
+		return nil, fmt.Errorf("expected a service and a method ID, got %d items", len(values))
+	}
+	service, ok1 := asInt64(values[0])
+	method, ok2 := asInt64(values[1])
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("expected a service and a method ID, got %v and %v", values[0], values[1])
+	}
+	return &Request{Service: service, MethodID: method, Method: client.MethodName(method), Arguments: values[2:]}, nil
+}
+
+// DecodeResponse decodes a captured response frame, such as a wire dump,
+// splitting the error, if any, from the result.
+func DecodeResponse(frame []byte, redact bool) (*Response, error) {
+	values, err := cbor.Values(frame, redact)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	codes, ok := values[0].([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of error codes, got %v", values[0])
+	}
+	if len(codes) == 0 {
+		return &Response{Result: values[1:]}, nil
+	}
+
+	e := &Error{}
+	for _, c := range codes {
+		code, ok := asInt64(c)
+		if !ok {
+			return nil, fmt.Errorf("expected an error code, got %v", c)
+		}
+		e.Codes = append(e.Codes, code)
+	}
+	if len(values) > 1 {
+		e.Message = fmt.Sprint(values[1])
+	}
+	var errorCode string
+	if len(values) > 2 {
+		e.Info, _ = values[2].([]any)
+		if len(e.Info) > 1 {
+			errorCode, _ = e.Info[1].(string)
+		}
+	}
+	codeSeq := make([]int, len(e.Codes))
+	for i, c := range e.Codes {
+		codeSeq[i] = int(c)
+	}
+	e.Exception = client.ExceptionName(codeSeq, errorCode)
+	return &Response{Error: e}, nil
+}
+
+func asInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case uint64:
+		if n > 1<<63-1 {
+			return 0, false
+		}
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
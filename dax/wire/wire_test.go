@@ -0,0 +1,94 @@
+/*
+  Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+
+  Licensed under the Apache License, Version 2.0 (the "License").
+  You may not use this file except in compliance with the License.
+  A copy of the License is located at
+
+      http://www.apache.org/licenses/LICENSE-2.0
+
+  or in the "license" file accompanying this file. This file is distributed
+  on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+  express or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package wire
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-dax-go-v2/dax/internal/cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getItem is a GetItem request for table "t" with an encoded key.
+var getItem = []byte{0x01, 0x1a, 0x0f, 0xb0, 0xcc, 0x6a, 0x61, 't', 0x42, 0x61, 'k', 0xa0}
+
+func TestDecodeRequest(t *testing.T) {
+	req, err := DecodeRequest(getItem, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), req.Service)
+	assert.Equal(t, "GetItem", req.Method)
+
+	b, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"service":1,"methodId":263244906,"method":"GetItem","arguments":["t",{"bytes":"616b","cbor":["k"]},{}]}`, string(b))
+}
+
+func TestDecodeRequest_preamble(t *testing.T) {
+	// magic, layering, session, header and client mode
+	preamble := []byte{0x67, 'J', '7', 'y', 'n', 'e', '5', 'G', 0x01, 0x41, 0x00, 0xf6, 0x00}
+	req, err := DecodeRequest(append(preamble, getItem...), true)
+	require.NoError(t, err)
+	assert.Equal(t, "GetItem", req.Method)
+	assert.Equal(t, []any{"<redacted 1 bytes>", "<redacted 2 bytes>", cbor.MapValue{}}, req.Arguments)
+}
+
+func TestDecodeRequest_invalid(t *testing.T) {
+	_, err := DecodeRequest([]byte{0x01}, false)
+	assert.Error(t, err)
+	_, err = DecodeRequest([]byte{0x61, 'a', 0x01}, false)
+	assert.Error(t, err)
+	_, err = DecodeRequest([]byte{0x01, 0x82}, false)
+	assert.Error(t, err)
+}
+
+func TestDecodeResponse(t *testing.T) {
+	res, err := DecodeResponse([]byte{0x80, 0x61, 'a'}, false)
+	require.NoError(t, err)
+	assert.Nil(t, res.Error)
+	assert.Equal(t, []any{"a"}, res.Result)
+
+	// codes [4 37 38 39 43], a message and the request ID, error code and status code
+	frame := []byte{0x85, 0x04, 0x18, 0x25, 0x18, 0x26, 0x18, 0x27, 0x18, 0x2b, 0x61, 'm',
+		0x83, 0x61, 'r', 0xf6, 0x19, 0x01, 0x90}
+	res, err = DecodeResponse(frame, false)
+	require.NoError(t, err)
+	require.NotNil(t, res.Error)
+	assert.Equal(t, []int64{4, 37, 38, 39, 43}, res.Error.Codes)
+	assert.Equal(t, "ConditionalCheckFailedException", res.Error.Exception)
+	assert.Equal(t, "m", res.Error.Message)
+	assert.Equal(t, []any{"r", nil, uint64(400)}, res.Error.Info)
+	assert.Nil(t, res.Result)
+}
+
+func TestDecodeResponse_errorCode(t *testing.T) {
+	frame := []byte{0x82, 0x04, 0x01, 0x61, 'm', 0x83, 0xf6, 0x78, 0x1c}
+	frame = append(frame, "TransactionConflictException"...)
+	frame = append(frame, 0xf6)
+	res, err := DecodeResponse(frame, false)
+	require.NoError(t, err)
+	assert.Equal(t, "TransactionConflictException", res.Error.Exception)
+}
+
+func TestDecodeResponse_invalid(t *testing.T) {
+	_, err := DecodeResponse(nil, false)
+	assert.Error(t, err)
+	_, err = DecodeResponse([]byte{0x01}, false)
+	assert.Error(t, err)
+	_, err = DecodeResponse([]byte{0x81, 0x61, 'a'}, false)
+	assert.Error(t, err)
+}